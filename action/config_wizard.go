@@ -18,6 +18,7 @@ import (
 // ConfigWizard reads configuration from a glide.yaml file and attempts to suggest
 // improvements. The wizard is interactive.
 func ConfigWizard(base string) {
+	EnsureConfigWritable()
 	cache.SystemLock()
 	_, err := gpath.Glide()
 	glidefile := gpath.GlideFile
@@ -263,7 +264,7 @@ func wizardFindVersions(d *cfg.Dependency) {
 	}
 
 	local := filepath.Join(l, "src", key)
-	repo, err := vcs.NewRepo(remote, local)
+	repo, err := d.GetRepo(local)
 	if err != nil {
 		msg.Debug("Problem getting repo instance: %s", err)
 		return
@@ -26,6 +26,7 @@ import (
 // GPM, Godep, or GB project if one should exist. However, it will still attempt
 // to read the local source to determine required packages.
 func Create(base string, skipImport, nonInteractive bool) {
+	EnsureConfigWritable()
 	glidefile := gpath.GlideFile
 	// Guard against overwrites.
 	guardYAML(glidefile)
@@ -14,6 +14,19 @@ func Quiet(on bool) {
 	msg.Default.Quiet = on
 }
 
+// LogLevel sets the level of the messages shown by name. An empty name leaves
+// it as it is.
+func LogLevel(name string) {
+	if name == "" {
+		return
+	}
+	l, err := msg.ParseLevel(name)
+	if err != nil {
+		msg.Die("%s", err)
+	}
+	msg.Default.Level = l
+}
+
 // NoColor sets the color flags.
 func NoColor(on bool) {
 	msg.Default.NoColor = on
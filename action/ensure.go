@@ -1,7 +1,6 @@
 package action
 
 import (
-	"io/ioutil"
 	"os"
 	"os/exec"
 	"path"
@@ -15,6 +14,10 @@ import (
 	"github.com/Ownercz/glide/util"
 )
 
+// GlideVersion is the version of the running Glide. It is checked against the
+// minimum version a config requires.
+var GlideVersion string
+
 // EnsureConfig loads and returns a config file.
 //
 // Any error will cause an immediate exit, with an error printed to Stderr.
@@ -25,18 +28,23 @@ func EnsureConfig() *cfg.Config {
 		msg.Die("Failed to find %s file in directory tree: %s", gpath.GlideFile, err)
 	}
 
-	yml, err := ioutil.ReadFile(yamlpath)
-	if err != nil {
+	conf, err := cfg.ReadConfigFile(yamlpath)
+	if _, ok := err.(*os.PathError); ok {
 		msg.ExitCode(2)
 		msg.Die("Failed to load %s: %s", yamlpath, err)
-	}
-	conf, err := cfg.ConfigFromYaml(yml)
-	if err != nil {
+	} else if err != nil {
 		msg.ExitCode(3)
 		msg.Die("Failed to parse %s: %s", yamlpath, err)
 	}
+	if err := conf.CheckGlideVersion(GlideVersion); err != nil {
+		msg.ExitCode(3)
+		msg.Die("%s: %s", yamlpath, err)
+	}
 
 	b := filepath.Dir(yamlpath)
+	if yamlpath == gpath.StdinFile {
+		b = gpath.Basepath()
+	}
 	buildContext, err := util.GetBuildContext()
 	if err != nil {
 		msg.Die("Failed to build an import context while ensuring config: %s", err)
@@ -65,6 +73,15 @@ func EnsureConfig() *cfg.Config {
 	return conf
 }
 
+// EnsureConfigWritable exits when the config is read from standard input as
+// there is no file to write it back to. Commands writing the config call it
+// before changing anything.
+func EnsureConfigWritable() {
+	if gpath.GlideFile == gpath.StdinFile {
+		msg.Die("The config is read from standard input so it can't be written. Use a %s file for this command", gpath.DefaultGlideFile)
+	}
+}
+
 // EnsureGoVendor ensures that the Go version is correct.
 func EnsureGoVendor() {
 	// 6l was removed in 1.5, when vendoring was introduced.
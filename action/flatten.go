@@ -0,0 +1,34 @@
+package action
+
+import (
+	"github.com/Ownercz/glide/msg"
+	gpath "github.com/Ownercz/glide/path"
+)
+
+// Flatten removes the vendor and Godeps/_workspace directories nested in the
+// dependencies in the vendor directory. When hoist is set their packages are
+// copied to the vendor directory first where it doesn't have them.
+func Flatten(hoist bool) {
+	if err := stripNested(hoist); err != nil {
+		msg.Die("Unable to flatten the vendor directory: %s", err)
+	}
+}
+
+// stripNested removes the nested vendor and Godeps/_workspace directories,
+// hoisting their packages first when hoist is set. When hoisting fails
+// nothing is removed so no packages are lost.
+func stripNested(hoist bool) error {
+	if hoist {
+		msg.Info("Hoisting packages from nested vendor directories...")
+		vendor, err := gpath.Vendor()
+		if err != nil {
+			return err
+		}
+		if err := gpath.HoistVendor(vendor); err != nil {
+			return err
+		}
+	}
+
+	msg.Info("Removing nested vendor and Godeps/_workspace directories...")
+	return gpath.StripVendor()
+}
@@ -0,0 +1,75 @@
+package action
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+
+	"github.com/Ownercz/glide/cfg"
+	"github.com/Ownercz/glide/msg"
+	gpath "github.com/Ownercz/glide/path"
+)
+
+// Format rewrites the glide.yaml file with the imports, test imports and
+// subpackages sorted and the duplicates merged so hand edits don't leave it
+// in an order glide would change the next time it writes it.
+func Format() {
+	EnsureConfigWritable()
+	yamlpath, err := gpath.Glide()
+	if err != nil {
+		msg.ExitCode(2)
+		msg.Die("Failed to find %s file in directory tree: %s", gpath.GlideFile, err)
+	}
+
+	changed, err := formatConfigFile(yamlpath)
+	if err != nil {
+		msg.Die("Unable to format %s: %s", yamlpath, err)
+	}
+	if changed {
+		msg.Info("Formatted %s", yamlpath)
+	} else {
+		msg.Info("%s is already formatted", yamlpath)
+	}
+}
+
+// formatConfigFile formats a glide.yaml file and returns if it was changed.
+// The file is only written when its content changes. Comments can't be kept
+// when the file is written so a warning is printed when it has any.
+func formatConfigFile(yamlpath string) (bool, error) {
+	yml, err := ioutil.ReadFile(yamlpath)
+	if err != nil {
+		return false, err
+	}
+	conf, err := cfg.ReadConfigFile(yamlpath)
+	if err != nil {
+		return false, err
+	}
+	if err := conf.DeDupe(); err != nil {
+		return false, err
+	}
+	conf.Sort()
+
+	o, err := conf.Marshal()
+	if err != nil {
+		return false, err
+	}
+	if bytes.Equal(o, yml) {
+		return false, nil
+	}
+
+	if hasYamlComments(yml) {
+		msg.Warn("The comments in %s are removed when it is formatted", yamlpath)
+	}
+	return true, ioutil.WriteFile(yamlpath, o, 0666)
+}
+
+// hasYamlComments returns if a YAML document has comments, either on a line of
+// their own or following a value.
+func hasYamlComments(yml []byte) bool {
+	for _, l := range strings.Split(string(yml), "\n") {
+		if strings.HasPrefix(strings.TrimSpace(l), "#") || strings.Contains(l, " #") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,88 @@
+package action
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFormatConfigFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "glide-format")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	yamlpath := filepath.Join(dir, "glide.yaml")
+	yml := `import:
+  - package: github.com/example/zed
+    subpackages:
+      - z
+      - a
+  - version: ^1.0.0
+    package: github.com/example/alpha
+  - package: github.com/example/zed
+    subpackages:
+      - m
+testImport:
+  - package: github.com/example/test-b
+  - package: github.com/example/test-a
+package: example.com/project
+`
+	if err := ioutil.WriteFile(yamlpath, []byte(yml), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	changed, err := formatConfigFile(yamlpath)
+	if err != nil {
+		t.Fatalf("Unexpected error formatting: %s", err)
+	}
+	if !changed {
+		t.Error("Expected the scrambled file to be changed")
+	}
+	first, err := ioutil.ReadFile(yamlpath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `package: example.com/project
+import:
+- package: github.com/example/alpha
+  version: ^1.0.0
+- package: github.com/example/zed
+  subpackages:
+  - a
+  - m
+  - z
+testImport:
+- package: github.com/example/test-a
+- package: github.com/example/test-b
+`
+	if string(first) != expected {
+		t.Errorf("Expected the formatted file to be\n%s\ngot\n%s", expected, first)
+	}
+
+	changed, err = formatConfigFile(yamlpath)
+	if err != nil {
+		t.Fatalf("Unexpected error formatting again: %s", err)
+	}
+	second, err := ioutil.ReadFile(yamlpath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed || string(second) != string(first) {
+		t.Errorf("Expected formatting to be idempotent, got\n%s", second)
+	}
+}
+
+func TestHasYamlComments(t *testing.T) {
+	if hasYamlComments([]byte("package: example.com/project\n")) {
+		t.Error("Expected no comments to be found")
+	}
+	for _, yml := range []string{"# A comment\npackage: a\n", "package: a # A comment\n"} {
+		if !hasYamlComments([]byte(yml)) {
+			t.Errorf("Expected a comment to be found in %q", strings.TrimSpace(yml))
+		}
+	}
+}
@@ -2,6 +2,7 @@ package action
 
 import (
 	"fmt"
+	"path"
 	"path/filepath"
 	"strings"
 
@@ -18,18 +19,39 @@ import (
 // Get fetches one or more dependencies and installs.
 //
 // This includes resolving dependency resolution and re-generating the lock file.
+//
+// The Include and Exclude patterns of the installer limit the names added. A
+// dry run resolves the dependencies and reports what would change without
+// writing the glide.yaml or glide.lock files or the vendor directory. With
+// VerifyAdded each package is resolved before anything is written so a
+// package that does not exist is never added to the glide.yaml file.
 func Get(names []string, installer *repo.Installer, insecure, skipRecursive, stripVendor, nonInteract, testDeps bool) {
+	dryRun := installer.DryRun
+	if !dryRun {
+		EnsureConfigWritable()
+	}
 	cache.SystemLock()
 
 	base := gpath.Basepath()
 	EnsureGopath()
-	EnsureVendorDir()
+	if !dryRun {
+		EnsureVendorDir()
+	}
 	conf := EnsureConfig()
+	orig := conf
+	if dryRun {
+		conf = conf.Clone()
+	}
 	glidefile, err := gpath.Glide()
 	if err != nil {
 		msg.Die("Could not find Glide file: %s", err)
 	}
 
+	names, err = filterPkgNames(names, installer.Include, installer.Exclude)
+	if err != nil {
+		msg.Die("Invalid package pattern: %s", err)
+	}
+
 	// Add the packages to the config.
 	if count, err2 := addPkgsToConfig(conf, names, insecure, nonInteract, testDeps); err2 != nil {
 		msg.Die("Failed to get new packages: %s", err2)
@@ -38,6 +60,12 @@ func Get(names []string, installer *repo.Installer, insecure, skipRecursive, str
 		return
 	}
 
+	if installer.VerifyAdded {
+		if err := verifyPkgs(installer, conf, names); err != nil {
+			msg.Die("%s. Nothing was written, use --no-verify to add it anyway", err)
+		}
+	}
+
 	// Fetch the new packages. Can't resolve versions via installer.Update if
 	// get is called while the vendor/ directory is empty so we checkout
 	// everything.
@@ -66,39 +94,109 @@ func Get(names []string, installer *repo.Installer, insecure, skipRecursive, str
 	}
 
 	// Set Reference
-	if err := repo.SetReference(confcopy, installer.ResolveTest); err != nil {
+	if err := installer.SetReference(confcopy); err != nil {
 		msg.Err("Failed to set references: %s", err)
 	}
 
+	if dryRun {
+		reportDryRun(orig, conf, confcopy, base)
+		return
+	}
+
 	err = installer.Export(confcopy)
 	if err != nil {
 		msg.Die("Unable to export dependencies to vendor directory: %s", err)
 	}
 
-	// Write YAML
-	if err := conf.WriteFile(glidefile); err != nil {
-		msg.Die("Failed to write glide YAML file: %s", err)
-	}
+	var lock *cfg.Lockfile
 	if !skipRecursive {
-		// Write lock
 		if stripVendor {
 			confcopy = godep.RemoveGodepSubpackages(confcopy)
 		}
-		writeLock(conf, confcopy, base)
+		lock = newLock(conf, confcopy)
+		lock.Metadata = installer.LockMetadata()
+		addDigests(installer, lock)
+	}
+	beforeWrite(installer, conf, lock, true)
+
+	// Write YAML
+	if err := conf.WriteFile(glidefile); err != nil {
+		msg.Die("Failed to write glide YAML file: %s", err)
+	}
+	if lock != nil {
+		writeLock(lock, base)
 	} else {
 		msg.Warn("Skipping lockfile generation because full dependency tree is not being calculated")
 	}
 
 	if stripVendor {
-		msg.Info("Removing nested vendor and Godeps/_workspace directories...")
-		err := gpath.StripVendor()
-		if err != nil {
+		if err := stripNested(installer.HoistVendor); err != nil {
 			msg.Err("Unable to strip vendor directories: %s", err)
 		}
 	}
+
+	if installer.VerifyBuild {
+		if err := installer.CheckBuild(confcopy); err != nil {
+			msg.Die("%s", err)
+		}
+	}
+}
+
+// verifyPkgs resolves each of the packages being added, using the versions
+// set for them in the config, and returns an error for the first that can't
+// be fetched or does not exist.
+func verifyPkgs(installer *repo.Installer, conf *cfg.Config, names []string) error {
+	for _, name := range names {
+		name = strings.Split(name, "#")[0]
+		if conf.HasIgnore(name) {
+			continue
+		}
+		msg.Info("Verifying %s", name)
+		if _, err := installer.Subtree(conf, name); err != nil {
+			return fmt.Errorf("Unable to resolve %s: %s", name, err)
+		}
+	}
+	return nil
+}
+
+// reportDryRun displays the dependencies a get would add to the config and
+// those it would vendor, compared to the current lock file.
+func reportDryRun(orig, conf, resolved *cfg.Config, base string) {
+	for _, d := range append(conf.Imports, conf.DevImports...) {
+		if !orig.HasDependency(d.Name) {
+			if d.Reference != "" {
+				msg.Info("Would add %s with the version %s to the glide.yaml file", d.Name, d.Reference)
+			} else {
+				msg.Info("Would add %s to the glide.yaml file", d.Name)
+			}
+		}
+	}
+
+	locked := make(map[string]string)
+	if lock, err := cfg.ReadLockFile(filepath.Join(base, gpath.LockFile)); err == nil {
+		for _, l := range append(lock.Imports, lock.DevImports...) {
+			locked[l.Name] = l.Version
+		}
+	}
+
+	deps := append(resolved.Imports, resolved.DevImports...)
+	msg.Info("Would vendor %d dependencies:", len(deps))
+	for _, d := range deps {
+		old, found := locked[d.Name]
+		switch {
+		case !found:
+			msg.Info("--> %s %s (new)", d.Name, d.Pin)
+		case old != d.Pin:
+			msg.Info("--> %s %s (was %s)", d.Name, d.Pin, old)
+		default:
+			msg.Info("--> %s %s", d.Name, d.Pin)
+		}
+	}
+	msg.Info("Dry run. Nothing was written")
 }
 
-func writeLock(conf, confcopy *cfg.Config, base string) {
+// newLock generates the lock file for the resolved dependencies in confcopy.
+func newLock(conf, confcopy *cfg.Config) *cfg.Lockfile {
 	hash, err := conf.Hash()
 	if err != nil {
 		msg.Die("Failed to generate config hash. Unable to generate lock file.")
@@ -107,11 +205,52 @@ func writeLock(conf, confcopy *cfg.Config, base string) {
 	if err != nil {
 		msg.Die("Failed to generate lock file: %s", err)
 	}
+	return lock
+}
+
+// addDigests records the digest of each dependency in the vendor directory in
+// the lock file.
+func addDigests(installer *repo.Installer, lock *cfg.Lockfile) {
+	if err := installer.AddDigests(lock); err != nil {
+		msg.Die("Failed to generate the digests of the vendor directory: %s", err)
+	}
+}
+
+func writeLock(lock *cfg.Lockfile, base string) {
 	if err := lock.WriteFile(filepath.Join(base, gpath.LockFile)); err != nil {
 		msg.Die("Failed to write glide lock file: %s", err)
 	}
 }
 
+// beforeWrite calls the BeforeWrite hook of the installer, if there is one.
+// The hash in the lock is not the hook's to change. When the config is
+// written too it is taken from the config the hook returns, otherwise the
+// hash from before the hook is kept as it matches the glide.yaml file.
+func beforeWrite(installer *repo.Installer, conf *cfg.Config, lock *cfg.Lockfile, confWritten bool) {
+	if installer.BeforeWrite == nil {
+		return
+	}
+	var hash string
+	if lock != nil {
+		hash = lock.Hash
+	}
+	if err := installer.BeforeWrite(conf, lock); err != nil {
+		msg.Die("Configuration not written: %s", err)
+	}
+	if lock == nil {
+		return
+	}
+
+	if confWritten {
+		var err error
+		hash, err = conf.Hash()
+		if err != nil {
+			msg.Die("Failed to generate config hash. Unable to generate lock file.")
+		}
+	}
+	lock.Hash = hash
+}
+
 // addPkgsToConfig adds the given packages to the config file.
 //
 // Along the way it:
@@ -224,6 +363,71 @@ func addPkgsToConfig(conf *cfg.Config, names []string, insecure, nonInteract, te
 	return numAdded, nil
 }
 
+// filterPkgNames returns the names matching the include patterns, or all of
+// them when there are none, and none of the exclude patterns.
+func filterPkgNames(names, include, exclude []string) ([]string, error) {
+	if len(include) == 0 && len(exclude) == 0 {
+		return names, nil
+	}
+
+	res := []string{}
+	for _, name := range names {
+		// Versions are appended to the name after a #.
+		n := strings.Split(name, "#")[0]
+
+		in := len(include) == 0
+		for _, p := range include {
+			m, err := matchPkgPattern(p, n)
+			if err != nil {
+				return nil, err
+			}
+			if m {
+				in = true
+				break
+			}
+		}
+		for _, p := range exclude {
+			m, err := matchPkgPattern(p, n)
+			if err != nil {
+				return nil, err
+			}
+			if m {
+				in = false
+				break
+			}
+		}
+
+		if in {
+			res = append(res, name)
+		} else {
+			msg.Info("--> Skipping %s as it does not match the package patterns", n)
+		}
+	}
+
+	return res, nil
+}
+
+// matchPkgPattern matches an import path against a pattern segment by
+// segment. Each segment of the pattern is a path.Match pattern and the
+// pattern matches every import path below it. For example, the pattern
+// github.com/*/foo matches github.com/example/foo/bar.
+func matchPkgPattern(pattern, name string) (bool, error) {
+	ps := strings.Split(strings.Trim(pattern, "/"), "/")
+	ns := strings.Split(name, "/")
+	if len(ns) < len(ps) {
+		return false, nil
+	}
+
+	for k, p := range ps {
+		m, err := path.Match(p, ns[k])
+		if err != nil || !m {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
 func getWizard(dep *cfg.Dependency) {
 	remote := dep.Remote()
 
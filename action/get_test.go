@@ -1,11 +1,18 @@
 package action
 
 import (
+	"bytes"
 	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/Ownercz/glide/cfg"
 	"github.com/Ownercz/glide/msg"
+	gpath "github.com/Ownercz/glide/path"
+	"github.com/Ownercz/glide/repo"
 )
 
 func TestAddPkgsToConfig(t *testing.T) {
@@ -44,3 +51,160 @@ func TestAddPkgsToConfig(t *testing.T) {
 	// Restore messaging to original location
 	msg.Default.Stderr = o
 }
+
+func TestFilterPkgNames(t *testing.T) {
+	o := msg.Default.Stderr
+	msg.Default.Stderr = ioutil.Discard
+	defer func() { msg.Default.Stderr = o }()
+
+	names := []string{
+		"github.com/Ownercz/semver#^1.0.0",
+		"github.com/Ownercz/vcs/sub",
+		"github.com/example/foo",
+		"golang.org/x/net",
+	}
+
+	res, err := filterPkgNames(names, []string{"github.com/Ownercz"}, []string{"github.com/*/vcs"})
+	if err != nil {
+		t.Fatalf("Unexpected error filtering names: %s", err)
+	}
+	if len(res) != 1 || res[0] != "github.com/Ownercz/semver#^1.0.0" {
+		t.Errorf("Unexpected filtered names %v", res)
+	}
+
+	// Patterns match whole segments rather than string prefixes.
+	res, _ = filterPkgNames(names, []string{"github.com/Owner"}, nil)
+	if len(res) != 0 {
+		t.Errorf("Expected no names to match a partial segment, got %v", res)
+	}
+
+	res, _ = filterPkgNames(names, nil, nil)
+	if len(res) != len(names) {
+		t.Errorf("Expected names to be unchanged without patterns, got %v", res)
+	}
+}
+
+func TestReportDryRun(t *testing.T) {
+	var buf bytes.Buffer
+	o := msg.Default.Stderr
+	msg.Default.Stderr = &buf
+	defer func() {
+		msg.Default.Stderr = o
+	}()
+
+	base, err := ioutil.TempDir("", "glide-dryrun")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(base)
+	lock := &cfg.Lockfile{Imports: cfg.Locks{
+		{Name: "github.com/example/same", Version: "a"},
+		{Name: "github.com/example/changed", Version: "b"},
+	}}
+	if err := lock.WriteFile(filepath.Join(base, gpath.LockFile)); err != nil {
+		t.Fatal(err)
+	}
+
+	orig := &cfg.Config{Imports: cfg.Dependencies{{Name: "github.com/example/same"}}}
+	conf := orig.Clone()
+	conf.Imports = append(conf.Imports, &cfg.Dependency{Name: "github.com/example/added", Reference: "^1.0.0"})
+	resolved := conf.Clone()
+	resolved.Imports[0].Pin = "a"
+	resolved.Imports[1].Pin = "c"
+	resolved.Imports = append(resolved.Imports, &cfg.Dependency{Name: "github.com/example/changed", Pin: "d"})
+
+	reportDryRun(orig, conf, resolved, base)
+
+	out := buf.String()
+	for _, e := range []string{
+		"Would add github.com/example/added with the version ^1.0.0",
+		"github.com/example/same a\n",
+		"github.com/example/added c (new)",
+		"github.com/example/changed d (was b)",
+	} {
+		if !strings.Contains(out, e) {
+			t.Errorf("Expected the report to contain %q, got:\n%s", e, out)
+		}
+	}
+	if strings.Contains(out, "Would add github.com/example/same") {
+		t.Error("Expected existing dependencies not to be reported as added")
+	}
+}
+
+func TestVerifyPkgs(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir, err := ioutil.TempDir("", "glide-verify")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	remote := filepath.Join(dir, "remote")
+	if err := os.MkdirAll(filepath.Join(remote, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(remote, "sub", "sub.go"), []byte("package sub\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{
+		{"init", "-q", remote},
+		{"-C", remote, "add", "."},
+		{"-C", remote, "-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "commit"},
+	} {
+		if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			t.Fatalf("Unable to setup the test repo: %s", out)
+		}
+	}
+
+	base := filepath.Join(dir, "app")
+	if err := os.MkdirAll(base, 0755); err != nil {
+		t.Fatal(err)
+	}
+	installer := repo.NewInstaller()
+	installer.Base = base
+	installer.Home = filepath.Join(dir, "home")
+	conf := &cfg.Config{
+		Name:    "example.com/app",
+		Imports: cfg.Dependencies{{Name: "github.com/example/lib", Repository: remote, VcsType: "git"}},
+	}
+
+	if err := verifyPkgs(installer, conf, []string{"github.com/example/lib/sub#master"}); err != nil {
+		t.Errorf("Unexpected error verifying an existing package: %s", err)
+	}
+	err = verifyPkgs(installer, conf, []string{"github.com/example/lib/typo"})
+	if err == nil || !strings.Contains(err.Error(), "github.com/example/lib/typo") {
+		t.Errorf("Expected an error naming the missing package, got %v", err)
+	}
+}
+
+func TestBeforeWriteHash(t *testing.T) {
+	installer := repo.NewInstaller()
+	installer.BeforeWrite = func(conf *cfg.Config, lock *cfg.Lockfile) error {
+		conf.Description = "changed by the hook"
+		lock.Hash = "changed by the hook"
+		return nil
+	}
+
+	for _, written := range []bool{false, true} {
+		conf := &cfg.Config{Name: "example.com/project"}
+		before, err := conf.Hash()
+		if err != nil {
+			t.Fatal(err)
+		}
+		lock := &cfg.Lockfile{Hash: before}
+		beforeWrite(installer, conf, lock, written)
+
+		expected := before
+		if written {
+			if expected, err = conf.Hash(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if lock.Hash != expected {
+			t.Errorf("Expected the hash %s with the config written %t, got %s", expected, written, lock.Hash)
+		}
+	}
+}
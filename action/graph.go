@@ -0,0 +1,59 @@
+package action
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/Ownercz/glide/msg"
+	"github.com/Ownercz/glide/repo"
+)
+
+// dotFormat is the Graphviz DOT format written by Graph.
+const dotFormat = "dot"
+
+// Graph prints the import graph of the dependencies in the vendor directory,
+// with the subpackages of each grouped under its root package. The dot
+// format is for Graphviz, with the project highlighted. The json format maps
+// each root package to the root packages it imports.
+func Graph(installer *repo.Installer, format string) {
+	if format != dotFormat && format != jsonFormat {
+		msg.Die("invalid output format: must be one of: dot|json")
+	}
+
+	conf := EnsureConfig()
+	g, err := installer.Graph(conf)
+	if err != nil {
+		msg.Die("Unable to resolve the import graph: %s", err)
+	}
+
+	if format == jsonFormat {
+		json.NewEncoder(msg.Default.Stdout).Encode(g)
+		return
+	}
+	writeDot(msg.Default.Stdout, conf.Name, g)
+}
+
+// writeDot writes an import graph in the Graphviz DOT format with the root
+// package of the project filled in. The nodes and edges are sorted so the
+// output only changes along with the graph.
+func writeDot(w io.Writer, root string, g map[string][]string) {
+	from := []string{}
+	for n := range g {
+		if n != root {
+			from = append(from, n)
+		}
+	}
+	sort.Strings(from)
+
+	fmt.Fprintf(w, "digraph %s {\n", strconv.Quote(root))
+	fmt.Fprintf(w, "\t%s [style=filled, fillcolor=lightblue];\n", strconv.Quote(root))
+	for _, n := range append([]string{root}, from...) {
+		for _, t := range g[n] {
+			fmt.Fprintf(w, "\t%s -> %s;\n", strconv.Quote(n), strconv.Quote(t))
+		}
+	}
+	fmt.Fprintln(w, "}")
+}
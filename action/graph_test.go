@@ -0,0 +1,27 @@
+package action
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWriteDot(t *testing.T) {
+	var buf bytes.Buffer
+	writeDot(&buf, "example.com/app", map[string][]string{
+		"github.com/example/b": {"github.com/example/c"},
+		"example.com/app":      {"github.com/example/a", "github.com/example/b"},
+		"github.com/example/a": {"github.com/example/b"},
+	})
+
+	expected := `digraph "example.com/app" {
+	"example.com/app" [style=filled, fillcolor=lightblue];
+	"example.com/app" -> "github.com/example/a";
+	"example.com/app" -> "github.com/example/b";
+	"github.com/example/a" -> "github.com/example/b";
+	"github.com/example/b" -> "github.com/example/c";
+}
+`
+	if buf.String() != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, buf.String())
+	}
+}
@@ -2,6 +2,7 @@ package action
 
 import (
 	"path/filepath"
+	"strings"
 
 	"github.com/Ownercz/glide/cache"
 	"github.com/Ownercz/glide/cfg"
@@ -11,7 +12,8 @@ import (
 )
 
 // Install installs a vendor directory based on an existing Glide configuration.
-func Install(installer *repo.Installer, stripVendor bool) {
+// The test dependencies in the lock file are only installed with useDev.
+func Install(installer *repo.Installer, stripVendor, useDev bool) {
 	cache.SystemLock()
 
 	base := "."
@@ -32,15 +34,12 @@ func Install(installer *repo.Installer, stripVendor bool) {
 		msg.Die("Could not load lockfile.")
 	}
 	// Verify lockfile hasn't changed
-	hash, err := conf.Hash()
-	if err != nil {
-		msg.Die("Could not load lockfile.")
-	} else if hash != lock.Hash {
+	if !lock.IsCurrent(conf) {
 		msg.Warn("Lock file may be out of date. Hash check of YAML failed. You may need to run 'update'")
 	}
 
 	// Install
-	newConf, err := installer.Install(lock, conf)
+	newConf, err := installer.Install(lock, conf, useDev)
 	if err != nil {
 		msg.Die("Failed to install: %s", err)
 	}
@@ -48,20 +47,94 @@ func Install(installer *repo.Installer, stripVendor bool) {
 	msg.Info("Setting references.")
 
 	// Set reference
-	if err := repo.SetReference(newConf, installer.ResolveTest); err != nil {
+	if err := installer.SetReference(newConf); err != nil {
 		msg.Die("Failed to set references: %s (Skip to cleanup)", err)
 	}
 
+	if installer.DryRun {
+		listUnusedVendored(installer, newConf)
+		return
+	}
+
 	err = installer.Export(newConf)
 	if err != nil {
 		msg.Die("Unable to export dependencies to vendor directory: %s", err)
 	}
 
 	if stripVendor {
-		msg.Info("Removing nested vendor and Godeps/_workspace directories...")
-		err := gpath.StripVendor()
-		if err != nil {
+		if err := stripNested(installer.HoistVendor); err != nil {
 			msg.Err("Unable to strip vendor directories: %s", err)
 		}
 	}
+
+	if installer.VerifyBuild {
+		if err := installer.CheckBuild(newConf); err != nil {
+			msg.Die("%s", err)
+		}
+	}
+
+	installer.LogQuarantined()
+	installer.LogMetrics()
+	if err := installer.CheckUnexpected(); err != nil {
+		msg.Die("%s", err)
+	}
+}
+
+// Check verifies the lock file was generated for the config and the vendor
+// directory matches the lock file without changing anything. It exits with an
+// error listing the problems when they don't.
+func Check(installer *repo.Installer) {
+	base := "."
+	EnsureGopath()
+	conf := EnsureConfig()
+
+	if !gpath.HasLock(base) {
+		msg.Die("Lock file (glide.lock) does not exist. Run 'glide update' to create it.")
+	}
+	lock, err := cfg.ReadLockFile(filepath.Join(base, gpath.LockFile))
+	if err != nil {
+		msg.Die("Could not load lockfile.")
+	}
+
+	current := true
+	if !lock.IsCurrent(conf) {
+		msg.Err("glide.lock is out of date with glide.yaml. Run 'glide update' to update it.")
+		current = false
+	}
+
+	modified, err := installer.VerifyVendor(lock, conf)
+	if err != nil {
+		msg.Die("Unable to check the vendor directory: %s", err)
+	}
+	for _, m := range modified {
+		if m.Missing {
+			msg.Err("%s is missing from the vendor directory", m.Name)
+		} else {
+			msg.Err("%s in the vendor directory does not match glide.lock: %s", m.Name, strings.Join(m.Files, ", "))
+		}
+		current = false
+	}
+
+	if !current {
+		msg.Die("The dependencies are out of date")
+	}
+	msg.Info("glide.lock and the vendor directory are up to date")
+}
+
+// listUnusedVendored displays the packages in the vendor directory exporting
+// would delete, for a dry run.
+func listUnusedVendored(installer *repo.Installer, conf *cfg.Config) {
+	unused, err := installer.UnusedVendored(conf)
+	if err != nil {
+		msg.Die("Unable to list the unused packages in the vendor directory: %s", err)
+	}
+	if len(unused) == 0 {
+		msg.Info("Dry run: no packages would be removed from the vendor directory")
+		return
+	}
+
+	msg.Info("Dry run: %d packages would be removed from the vendor directory:", len(unused))
+	for _, pkg := range unused {
+		msg.Info("--> %s", pkg)
+	}
 }
@@ -2,15 +2,22 @@ package action
 
 import (
 	"encoding/json"
+	"os"
 	"path/filepath"
 	"sort"
 
+	"github.com/Ownercz/glide/cfg"
 	"github.com/Ownercz/glide/dependency"
 	"github.com/Ownercz/glide/msg"
+	gpath "github.com/Ownercz/glide/path"
 )
 
 // List lists all of the dependencies of the current project.
 //
+// The JSON formats also list the dependencies of the project along with the
+// version each resolved to. Only the list is written to Stdout so it can be
+// read by other tools. Messages are written to Stderr.
+//
 // Params:
 //  - dir (string): basedir
 //  - deep (bool): whether to do a deep scan or a shallow scan
@@ -47,15 +54,82 @@ func List(basedir string, deep bool, format string) {
 		Missing:   h.Missing,
 		Gopath:    h.Gopath,
 	}
+	if format != textFormat {
+		l.Dependencies = listDependencies(basedir)
+	}
 
 	outputList(l, format)
 }
 
 // PackageList contains the packages being used by their location
 type PackageList struct {
-	Installed []string `json:"installed"`
-	Missing   []string `json:"missing"`
-	Gopath    []string `json:"gopath"`
+	Installed    []string           `json:"installed"`
+	Missing      []string           `json:"missing"`
+	Gopath       []string           `json:"gopath"`
+	Dependencies []ListedDependency `json:"dependencies"`
+}
+
+// ListedDependency is a dependency of the project in the JSON output of List.
+// Every field is always present so scripts can rely on the format.
+type ListedDependency struct {
+	// Name is the root package of the dependency.
+	Name string `json:"name"`
+
+	// Version is the commit from the lock file or, when there is no lock
+	// file, the version in the config. It is empty when neither has one.
+	Version string `json:"version"`
+
+	// Repository is the location the dependency is fetched from when it is
+	// not the one of its name.
+	Repository string `json:"repository"`
+
+	// Subpackages are the packages of the dependency used by the project.
+	Subpackages []string `json:"subpackages"`
+}
+
+// listDependencies returns the dependencies of the project in a directory.
+// They are read from the lock file as it has those resolved from the imports
+// of the dependencies, falling back to the config. The list is empty when
+// the directory has neither.
+func listDependencies(basedir string) []ListedDependency {
+	deps := []ListedDependency{}
+
+	if gpath.HasLock(basedir) {
+		lock, err := cfg.ReadLockFile(filepath.Join(basedir, gpath.LockFile))
+		if err != nil {
+			msg.Die("Could not load lockfile: %s", err)
+		}
+		for _, l := range lock.Imports {
+			deps = append(deps, listedDependency(l.Name, l.Version, l.Repository, l.Subpackages))
+		}
+		return deps
+	}
+
+	yamlpath := filepath.Join(basedir, gpath.GlideFile)
+	if gpath.GlideFile == gpath.StdinFile {
+		yamlpath = gpath.StdinFile
+	}
+	conf, err := cfg.ReadConfigFile(yamlpath)
+	if os.IsNotExist(err) {
+		return deps
+	} else if err != nil {
+		msg.Die("Could not read %s: %s", gpath.GlideFile, err)
+	}
+	for _, d := range conf.Imports {
+		deps = append(deps, listedDependency(d.Name, d.Reference, d.Repository, d.Subpackages))
+	}
+	return deps
+}
+
+func listedDependency(name, version, repository string, subpackages []string) ListedDependency {
+	d := ListedDependency{
+		Name:        name,
+		Version:     version,
+		Repository:  repository,
+		Subpackages: []string{},
+	}
+	d.Subpackages = append(d.Subpackages, subpackages...)
+	return d
 }
 
 const (
@@ -3,6 +3,10 @@ package action
 import (
 	"bytes"
 	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/Ownercz/glide/msg"
@@ -34,6 +38,9 @@ func TestList(t *testing.T) {
 	if len(o.Installed) == 0 {
 		t.Error("No packages found on json list")
 	}
+	if len(o.Dependencies) == 0 || o.Dependencies[0].Name != "github.com/urfave/cli" || o.Dependencies[0].Version == "" {
+		t.Errorf("Expected the locked dependencies on json list, got %+v", o.Dependencies)
+	}
 
 	var buf3 bytes.Buffer
 	msg.Default.Stdout = &buf3
@@ -48,3 +55,33 @@ func TestList(t *testing.T) {
 		t.Error("No packages found on json-pretty list")
 	}
 }
+
+func TestListDependencies(t *testing.T) {
+	msg.Default.PanicOnDie = true
+	dir, err := ioutil.TempDir("", "glide-list")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	old := msg.Default.Stdout
+	defer func() {
+		msg.Default.Stdout = old
+	}()
+	var buf bytes.Buffer
+	msg.Default.Stdout = &buf
+	List(dir, false, "json")
+	if !strings.Contains(buf.String(), `"dependencies":[]`) {
+		t.Errorf("Expected an empty list of dependencies, got %s", buf.String())
+	}
+
+	yml := "package: example.com/app\nimport:\n- package: example.com/a\n  version: ^1.2.0\n  repo: https://example.com/fork/a.git\n  subpackages:\n  - sub\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "glide.yaml"), []byte(yml), 0644); err != nil {
+		t.Fatal(err)
+	}
+	deps := listDependencies(dir)
+	expected := ListedDependency{Name: "example.com/a", Version: "^1.2.0", Repository: "https://example.com/fork/a.git", Subpackages: []string{"sub"}}
+	if len(deps) != 1 || deps[0].Name != expected.Name || deps[0].Version != expected.Version || deps[0].Repository != expected.Repository || len(deps[0].Subpackages) != 1 {
+		t.Errorf("Expected %+v from the config, got %+v", expected, deps)
+	}
+}
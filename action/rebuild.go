@@ -10,6 +10,7 @@ import (
 	"github.com/Ownercz/glide/cfg"
 	"github.com/Ownercz/glide/msg"
 	gpath "github.com/Ownercz/glide/path"
+	"github.com/Ownercz/glide/util"
 )
 
 // Rebuild rebuilds '.a' files for a project.
@@ -62,12 +63,16 @@ func buildDep(dep *cfg.Dependency, vpath string) error {
 }
 
 func resolvePackages(vpath, pkg, subpkg string) ([]string, error) {
+	dir, err := util.VendorPath(vpath, path.Join(pkg, subpkg), nil)
+	if err != nil {
+		return []string{}, err
+	}
 	sdir, _ := os.Getwd()
-	if err := os.Chdir(filepath.Join(vpath, pkg, subpkg)); err != nil {
+	if err := os.Chdir(dir); err != nil {
 		return []string{}, err
 	}
 	defer os.Chdir(sdir)
-	p, err := filepath.Glob(path.Join(vpath, pkg, subpkg))
+	p, err := filepath.Glob(dir)
 	if err != nil {
 		return []string{}, err
 	}
@@ -10,6 +10,7 @@ import (
 
 // Remove removes a dependncy from the configuration.
 func Remove(packages []string, inst *repo.Installer) {
+	EnsureConfigWritable()
 	cache.SystemLock()
 	base := gpath.Basepath()
 	EnsureGopath()
@@ -29,7 +30,7 @@ func Remove(packages []string, inst *repo.Installer) {
 
 	//confcopy.Imports = inst.List(confcopy)
 
-	if err := repo.SetReference(confcopy, inst.ResolveTest); err != nil {
+	if err := inst.SetReference(confcopy); err != nil {
 		msg.Err("Failed to set references: %s", err)
 	}
 
@@ -38,13 +39,17 @@ func Remove(packages []string, inst *repo.Installer) {
 		msg.Die("Unable to export dependencies to vendor directory: %s", err)
 	}
 
+	lock := newLock(conf, confcopy)
+	addDigests(inst, lock)
+	beforeWrite(inst, conf, lock, true)
+
 	// Write glide.yaml
 	if err := conf.WriteFile(glidefile); err != nil {
 		msg.Die("Failed to write glide YAML file: %s", err)
 	}
 
 	// Write glide lock
-	writeLock(conf, confcopy, base)
+	writeLock(lock, base)
 }
 
 // rmDeps returns a list of dependencies that do not contain the given pkgs.
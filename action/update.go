@@ -1,8 +1,11 @@
 package action
 
 import (
+	"fmt"
 	"io/ioutil"
+	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/Ownercz/glide/cache"
 	"github.com/Ownercz/glide/cfg"
@@ -12,7 +15,17 @@ import (
 )
 
 // Update updates repos and the lock file from the main glide yaml.
+//
+// The lock file is written to the LockFile of the installer when set. When
+// the installer resolves from specific roots the lock file only covers what
+// they need, so it is only written when LockFile is set.
+//
+// When the installer has Only names just they, and what they bring in, are
+// updated. The rest stay at their version in the existing lock file. The
+// installer set to OnlyChanged does the same for the dependencies changed in
+// the config.
 func Update(installer *repo.Installer, skipRecursive, stripVendor bool) {
+	lockFile, only := installer.LockFile, installer.Only
 	cache.SystemLock()
 
 	base := "."
@@ -27,7 +40,7 @@ func Update(installer *repo.Installer, skipRecursive, stripVendor bool) {
 
 	// Set the versions for the initial dependencies so that resolved dependencies
 	// are rooted in the correct version of the base.
-	if err := repo.SetReference(conf, installer.ResolveTest); err != nil {
+	if err := installer.SetReference(conf); err != nil {
 		msg.Die("Failed to set initial config references: %s", err)
 	}
 
@@ -35,9 +48,21 @@ func Update(installer *repo.Installer, skipRecursive, stripVendor bool) {
 	// of the conf because we'll be making real changes to it.
 	confcopy := conf.Clone()
 
+	lockPath := filepath.Join(base, gpath.LockFile)
+	if lockFile != "" {
+		lockPath = lockFile
+	}
+
 	if !skipRecursive {
 		// Get all repos and update them.
-		err := installer.Update(confcopy)
+		var err error
+		if len(only) > 0 {
+			err = updateOnly(installer, confcopy, lockPath, only)
+		} else if installer.OnlyChanged {
+			err = updateChanged(installer, confcopy, lockPath)
+		} else {
+			err = installer.Update(confcopy)
+		}
 		if err != nil {
 			msg.Die("Could not update packages: %s", err)
 		}
@@ -46,9 +71,24 @@ func Update(installer *repo.Installer, skipRecursive, stripVendor bool) {
 		// installer set them as it went to make sure it parsed the right imports
 		// from the right version of the package.
 		msg.Info("Setting references for remaining imports")
-		if err := repo.SetReference(confcopy, installer.ResolveTest); err != nil {
+		if err := installer.SetReference(confcopy); err != nil {
 			msg.Err("Failed to set references: %s (Skip to cleanup)", err)
 		}
+
+		// Compare to the versions being replaced before anything is changed.
+		if _, err := os.Stat(lockPath); err == nil {
+			current, err := cfg.ReadLockFile(lockPath)
+			if err != nil {
+				msg.Warn("Unable to read %s to check for downgrades: %s", lockPath, err)
+			} else if err := installer.CheckDowngrades(confcopy, current); err != nil {
+				msg.Die("%s", err)
+			}
+		}
+	}
+
+	if installer.DryRun {
+		listUnusedVendored(installer, confcopy)
+		return
 	}
 
 	err := installer.Export(confcopy)
@@ -65,7 +105,11 @@ func Update(installer *repo.Installer, skipRecursive, stripVendor bool) {
 	// from the project. A removed dependency should warn and an added dependency
 	// should be added to the glide.yaml file. See issue #193.
 
-	if !skipRecursive {
+	if !skipRecursive && len(installer.Quarantined()) > 0 {
+		msg.Warn("Skipping lockfile generation because some dependencies were quarantined")
+	} else if !skipRecursive && len(installer.Roots) > 0 && lockFile == "" {
+		msg.Warn("Skipping lockfile generation because only the dependencies of %s were resolved", strings.Join(installer.Roots, ", "))
+	} else if !skipRecursive {
 		// Write lock
 		hash, err := conf.Hash()
 		if err != nil {
@@ -75,9 +119,12 @@ func Update(installer *repo.Installer, skipRecursive, stripVendor bool) {
 		if err != nil {
 			msg.Die("Failed to generate lock file: %s", err)
 		}
+		lock.Metadata = installer.LockMetadata()
+		addDigests(installer, lock)
+		beforeWrite(installer, conf, lock, false)
 		wl := true
-		if gpath.HasLock(base) {
-			yml, err := ioutil.ReadFile(filepath.Join(base, gpath.LockFile))
+		if _, err := os.Stat(lockPath); err == nil {
+			yml, err := ioutil.ReadFile(lockPath)
 			if err == nil {
 				l2, err := cfg.LockfileFromYaml(yml)
 				if err == nil {
@@ -90,8 +137,8 @@ func Update(installer *repo.Installer, skipRecursive, stripVendor bool) {
 			}
 		}
 		if wl {
-			if err := lock.WriteFile(filepath.Join(base, gpath.LockFile)); err != nil {
-				msg.Err("Could not write lock file to %s: %s", base, err)
+			if err := lock.WriteFile(lockPath); err != nil {
+				msg.Err("Could not write lock file to %s: %s", lockPath, err)
 				return
 			}
 		} else {
@@ -104,10 +151,48 @@ func Update(installer *repo.Installer, skipRecursive, stripVendor bool) {
 	}
 
 	if stripVendor {
-		msg.Info("Removing nested vendor and Godeps/_workspace directories...")
-		err := gpath.StripVendor()
-		if err != nil {
+		if err := stripNested(installer.HoistVendor); err != nil {
 			msg.Err("Unable to strip vendor directories: %s", err)
 		}
 	}
+
+	if installer.VerifyBuild {
+		if err := installer.CheckBuild(confcopy); err != nil {
+			msg.Die("%s", err)
+		}
+	}
+
+	installer.LogQuarantined()
+	installer.LogUnused()
+	installer.LogMetrics()
+	if err := installer.CheckUnexpected(); err != nil {
+		msg.Die("%s", err)
+	}
+}
+
+// updateOnly updates some of the dependencies leaving the others at their
+// version in the lock file.
+func updateOnly(installer *repo.Installer, conf *cfg.Config, lockPath string, only []string) error {
+	if _, err := os.Stat(lockPath); os.IsNotExist(err) {
+		return fmt.Errorf("Updating only %s needs an existing %s", strings.Join(only, ", "), lockPath)
+	}
+	lock, err := cfg.ReadLockFile(lockPath)
+	if err != nil {
+		return err
+	}
+	return installer.UpdateOnly(conf, lock, only)
+}
+
+// updateChanged updates the dependencies changed in the config since the
+// lock file was written. Without a lock file everything is updated.
+func updateChanged(installer *repo.Installer, conf *cfg.Config, lockPath string) error {
+	if _, err := os.Stat(lockPath); os.IsNotExist(err) {
+		msg.Info("No %s to compare with, updating all dependencies", lockPath)
+		return installer.Update(conf)
+	}
+	lock, err := cfg.ReadLockFile(lockPath)
+	if err != nil {
+		return err
+	}
+	return installer.UpdateChanged(conf, lock)
 }
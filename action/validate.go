@@ -0,0 +1,38 @@
+package action
+
+import (
+	"github.com/Ownercz/glide/cfg"
+	"github.com/Ownercz/glide/msg"
+	gpath "github.com/Ownercz/glide/path"
+)
+
+// Validate checks the glide.yaml file for problems and prints each of them.
+// It exits with an error when any are errors rather than warnings.
+func Validate() {
+	yamlpath, err := gpath.Glide()
+	if err != nil {
+		msg.ExitCode(2)
+		msg.Die("Failed to find %s file in directory tree: %s", gpath.GlideFile, err)
+	}
+	yml, err := cfg.ReadConfigYaml(yamlpath)
+	if err != nil {
+		msg.ExitCode(2)
+		msg.Die("Failed to load %s: %s", yamlpath, err)
+	}
+
+	failed := false
+	for _, err := range cfg.ValidateYaml(yml) {
+		if cfg.IsWarning(err) {
+			msg.Warn("%s", err)
+		} else {
+			msg.Err("%s", err)
+			failed = true
+		}
+	}
+
+	if failed {
+		msg.ExitCode(3)
+		msg.Die("%s is not valid", yamlpath)
+	}
+	msg.Info("%s is valid", yamlpath)
+}
@@ -0,0 +1,47 @@
+package action
+
+import (
+	"path/filepath"
+
+	"github.com/Ownercz/glide/cfg"
+	"github.com/Ownercz/glide/msg"
+	gpath "github.com/Ownercz/glide/path"
+	"github.com/Ownercz/glide/repo"
+)
+
+// Verify checks the dependencies in the vendor directory are the ones in the
+// lock file, using the digests recorded in it or the VCS metadata kept with
+// them. Unlike Check nothing is fetched or read from the cache.
+func Verify(installer *repo.Installer) {
+	base := "."
+	EnsureGopath()
+	EnsureConfig()
+
+	if !gpath.HasLock(base) {
+		msg.Die("Lock file (glide.lock) does not exist. Run 'glide update' to create it.")
+	}
+	lock, err := cfg.ReadLockFile(filepath.Join(base, gpath.LockFile))
+	if err != nil {
+		msg.Die("Could not load lockfile.")
+	}
+
+	modified, err := installer.VerifyDigests(lock)
+	if err != nil {
+		msg.Die("Unable to verify the vendor directory: %s", err)
+	}
+	for _, m := range modified {
+		switch {
+		case m.Missing:
+			msg.Err("%s is missing from the vendor directory", m.Name)
+		case m.Digest:
+			msg.Err("%s in the vendor directory does not match its digest in glide.lock", m.Name)
+		default:
+			msg.Err("%s in the vendor directory is at %s, not the version in glide.lock", m.Name, m.Version)
+		}
+	}
+
+	if len(modified) > 0 {
+		msg.Die("The vendor directory does not match glide.lock")
+	}
+	msg.Info("The vendor directory matches glide.lock")
+}
@@ -34,13 +34,13 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
-	"regexp"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/Ownercz/glide/msg"
 	gpath "github.com/Ownercz/glide/path"
+	"github.com/Ownercz/glide/util"
 )
 
 // Enabled sets if the cache is globally enabled. Defaults to true.
@@ -92,17 +92,21 @@ func Location() string {
 	return p
 }
 
-// scpSyntaxRe matches the SCP-like addresses used to access repos over SSH.
-var scpSyntaxRe = regexp.MustCompile(`^([a-zA-Z0-9_]+)@([a-zA-Z0-9._-]+):(.*)$`)
+// CanonicalKey generates a cache key like Key from the canonical location of a
+// repository so the ways of referring to it share a key. See
+// util.CanonicalRepo.
+func CanonicalKey(repo string) string {
+	key := strings.Replace(strings.Trim(util.CanonicalRepo(repo), "/"), "/", "-", -1)
+	return strings.Replace(key, ":", "-", -1)
+}
 
 // Key generates a cache key based on a url or scp string. The key is file
 // system safe.
 func Key(repo string) (string, error) {
-
 	var u *url.URL
 	var err error
 	var strip bool
-	if m := scpSyntaxRe.FindStringSubmatch(repo); m != nil {
+	if m := util.ScpSyntaxRe.FindStringSubmatch(repo); m != nil {
 		// Match SCP-like syntax and convert it to a URL.
 		// Eg, "git@github.com:user/repo" becomes
 		// "ssh://git@github.com/user/repo".
@@ -152,7 +156,15 @@ func SaveRepoData(key string, data RepoInfo) error {
 	if !Enabled {
 		return ErrCacheDisabled
 	}
-	location := Location()
+	return SaveRepoDataAt(Location(), key, data)
+}
+
+// SaveRepoDataAt stores data about a repo in the cache at a location, such as
+// one in another home directory.
+func SaveRepoDataAt(location, key string, data RepoInfo) error {
+	if !Enabled {
+		return ErrCacheDisabled
+	}
 	data.LastUpdate = time.Now().String()
 	d, err := json.Marshal(data)
 	if err != nil {
@@ -181,7 +193,15 @@ func RepoData(key string) (*RepoInfo, error) {
 	if !Enabled {
 		return &RepoInfo{}, ErrCacheDisabled
 	}
-	location := Location()
+	return RepoDataAt(Location(), key)
+}
+
+// RepoDataAt retrieves cached information about a repo from the cache at a
+// location.
+func RepoDataAt(location, key string) (*RepoInfo, error) {
+	if !Enabled {
+		return &RepoInfo{}, ErrCacheDisabled
+	}
 	c := &RepoInfo{}
 	p := filepath.Join(location, "info", key+".json")
 	f, err := ioutil.ReadFile(p)
@@ -1,6 +1,8 @@
 package cache
 
-import "testing"
+import (
+	"testing"
+)
 
 func TestKey(t *testing.T) {
 	tests := map[string]string{
@@ -20,3 +22,11 @@ func TestKey(t *testing.T) {
 		}
 	}
 }
+
+func TestCanonicalKey(t *testing.T) {
+	for _, k := range []string{"https://github.com/foo/bar", "git@GitHub.com:foo/bar.git"} {
+		if key := CanonicalKey(k); key != "github.com-foo-bar" {
+			t.Errorf("Expected cache key github.com-foo-bar for %s but got %s", k, key)
+		}
+	}
+}
@@ -7,9 +7,11 @@ import (
 	"reflect"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/Ownercz/glide/mirrors"
 	"github.com/Ownercz/glide/util"
+	"github.com/Ownercz/semver"
 	"github.com/Ownercz/vcs"
 	"gopkg.in/yaml.v2"
 )
@@ -47,6 +49,35 @@ type Config struct {
 	// exclude from scanning for dependencies.
 	Exclude []string `yaml:"excludeDirs,omitempty"`
 
+	// MinGlideVersion is the oldest version of Glide able to work with the
+	// config. Older versions may not understand all of its settings.
+	MinGlideVersion string `yaml:"minGlideVersion,omitempty"`
+
+	// Priority lists imports in order of precedence. When the configurations
+	// of several dependencies set a version for the same package the one from
+	// the dependency listed first is used.
+	Priority []string `yaml:"priority,omitempty"`
+
+	// Allow lists the import path prefixes packages may be fetched from. When
+	// it is set fetching any other package is an error, even when it is only
+	// imported by a dependency. This is the opposite of Ignore.
+	Allow []string `yaml:"allow,omitempty"`
+
+	// Rewrite fetches the packages under import path prefixes from other
+	// repositories, such as forks, while keeping their import paths. See the
+	// Rewrite type.
+	Rewrite Rewrites `yaml:"rewrite,omitempty"`
+
+	// URLRewrite changes the start of the URLs repositories are fetched from,
+	// such as to go through an internal mirror, like git's insteadOf. See the
+	// URLRewrite type.
+	URLRewrite URLRewrites `yaml:"urlRewrite,omitempty"`
+
+	// Include lists other glide.yaml files, by a path absolute or relative to
+	// the including file, whose imports are merged into the config by
+	// ReadConfigFile. See the included field.
+	Include []string `yaml:"include,omitempty"`
+
 	// Imports contains a list of all non-development imports for a project. For
 	// more detail on how these are captured see the Dependency type.
 	Imports Dependencies `yaml:"import"`
@@ -54,6 +85,10 @@ type Config struct {
 	// DevImports contains the test or other development imports for a project.
 	// See the Dependency type for more details on how this is recorded.
 	DevImports Dependencies `yaml:"testImport,omitempty"`
+
+	// included holds the names of the imports merged from the Include files.
+	// They are left out when the config is written.
+	included map[string]bool
 }
 
 // A transitive representation of a dependency for importing and exporting to yaml.
@@ -65,6 +100,12 @@ type cf struct {
 	Owners      Owners       `yaml:"owners,omitempty"`
 	Ignore      []string     `yaml:"ignore,omitempty"`
 	Exclude     []string     `yaml:"excludeDirs,omitempty"`
+	MinGlide    string       `yaml:"minGlideVersion,omitempty"`
+	Priority    []string     `yaml:"priority,omitempty"`
+	Allow       []string     `yaml:"allow,omitempty"`
+	Rewrite     Rewrites     `yaml:"rewrite,omitempty"`
+	URLRewrite  URLRewrites  `yaml:"urlRewrite,omitempty"`
+	Include     []string     `yaml:"include,omitempty"`
 	Imports     Dependencies `yaml:"import"`
 	DevImports  Dependencies `yaml:"testImport,omitempty"`
 }
@@ -91,15 +132,7 @@ func (c *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	if err := unmarshal(&newConfig); err != nil {
 		return err
 	}
-	c.Name = newConfig.Name
-	c.Description = newConfig.Description
-	c.Home = newConfig.Home
-	c.License = newConfig.License
-	c.Owners = newConfig.Owners
-	c.Ignore = newConfig.Ignore
-	c.Exclude = newConfig.Exclude
-	c.Imports = newConfig.Imports
-	c.DevImports = newConfig.DevImports
+	*c = *newConfig.config()
 
 	// Cleanup the Config object now that we have it.
 	err := c.DeDupe()
@@ -107,6 +140,27 @@ func (c *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	return err
 }
 
+// config returns the Config as it is in the yaml, before DeDupe.
+func (n *cf) config() *Config {
+	return &Config{
+		Name:            n.Name,
+		Description:     n.Description,
+		Home:            n.Home,
+		License:         n.License,
+		Owners:          n.Owners,
+		Ignore:          n.Ignore,
+		Exclude:         n.Exclude,
+		MinGlideVersion: n.MinGlide,
+		Priority:        n.Priority,
+		Allow:           n.Allow,
+		Rewrite:         n.Rewrite,
+		URLRewrite:      n.URLRewrite,
+		Include:         n.Include,
+		Imports:         n.Imports,
+		DevImports:      n.DevImports,
+	}
+}
+
 // MarshalYAML is a hook for gopkg.in/yaml.v2 in the marshaling process
 func (c *Config) MarshalYAML() (interface{}, error) {
 	newConfig := &cf{
@@ -117,8 +171,20 @@ func (c *Config) MarshalYAML() (interface{}, error) {
 		Owners:      c.Owners,
 		Ignore:      c.Ignore,
 		Exclude:     c.Exclude,
+		MinGlide:    c.MinGlideVersion,
+		Priority:    c.Priority,
+		Allow:       c.Allow,
+		Rewrite:     c.Rewrite,
+		URLRewrite:  c.URLRewrite,
+		Include:     c.Include,
+	}
+	imports := Dependencies{}
+	for _, d := range c.Imports {
+		if !c.included[d.Name] {
+			imports = append(imports, d)
+		}
 	}
-	i, err := c.Imports.Clone().DeDupe()
+	i, err := imports.Clone().DeDupe()
 	if err != nil {
 		return newConfig, err
 	}
@@ -163,6 +229,34 @@ func (c *Config) HasIgnore(name string) bool {
 	return false
 }
 
+// PriorityRank returns the position of a package in the Priority list. Those
+// not listed rank after all of the listed ones.
+func (c *Config) PriorityRank(name string) int {
+	for i, v := range c.Priority {
+		if v == name {
+			return i
+		}
+	}
+
+	return len(c.Priority)
+}
+
+// IsAllowed returns true if packages may be fetched from the given name. When
+// there is no allow list everything is allowed.
+func (c *Config) IsAllowed(name string) bool {
+	if len(c.Allow) == 0 {
+		return true
+	}
+	for _, v := range c.Allow {
+		v = strings.TrimSuffix(v, "/")
+		if v == name || strings.HasPrefix(name, v+"/") {
+			return true
+		}
+	}
+
+	return false
+}
+
 // HasExclude returns true if the given name is listed on the exclude list.
 func (c *Config) HasExclude(ex string) bool {
 	ep := normalizeSlash(ex)
@@ -185,11 +279,48 @@ func (c *Config) Clone() *Config {
 	n.Owners = c.Owners.Clone()
 	n.Ignore = c.Ignore
 	n.Exclude = c.Exclude
+	n.MinGlideVersion = c.MinGlideVersion
+	n.Priority = c.Priority
+	n.Allow = c.Allow
+	n.Rewrite = c.Rewrite.Clone()
+	n.URLRewrite = c.URLRewrite.Clone()
+	n.Include = c.Include
 	n.Imports = c.Imports.Clone()
 	n.DevImports = c.DevImports.Clone()
+	n.included = c.included
 	return n
 }
 
+// CheckGlideVersion returns an error when the given version of Glide is older
+// than the MinGlideVersion of the config. Pre-release versions, such as
+// development builds, count as the release they lead up to. The check is
+// skipped when the given version is not a semantic version.
+func (c *Config) CheckGlideVersion(current string) error {
+	if c.MinGlideVersion == "" {
+		return nil
+	}
+
+	min, err := semver.NewVersion(c.MinGlideVersion)
+	if err != nil {
+		return fmt.Errorf("Invalid minGlideVersion %q: %s", c.MinGlideVersion, err)
+	}
+
+	cur, err := semver.NewVersion(current)
+	if err != nil {
+		return nil
+	}
+	rel, err := cur.SetPrerelease("")
+	if err != nil {
+		return nil
+	}
+
+	if rel.LessThan(min) {
+		return fmt.Errorf("This config requires Glide %s or newer but the running version is %s. Please upgrade Glide", c.MinGlideVersion, current)
+	}
+
+	return nil
+}
+
 // WriteFile writes a Glide YAML file.
 //
 // This is a convenience function that marshals the YAML and then writes it to
@@ -263,6 +394,17 @@ func (c *Config) DeDupe() error {
 	return nil
 }
 
+// Sort sorts the imports and test imports by name and the subpackages of
+// each of them so the config is always written in the same order.
+func (c *Config) Sort() {
+	for _, deps := range []Dependencies{c.Imports, c.DevImports} {
+		sort.Stable(deps)
+		for _, d := range deps {
+			sort.Strings(d.Subpackages)
+		}
+	}
+}
+
 // AddImport appends dependencies to the import list, deduplicating as we go.
 func (c *Config) AddImport(deps ...*Dependency) error {
 	t := c.Imports
@@ -275,9 +417,22 @@ func (c *Config) AddImport(deps ...*Dependency) error {
 	return nil
 }
 
-// Hash generates a sha256 hash for a given Config
+// Hash generates a sha256 hash for a given Config. The imports are sorted by
+// name first so the order they are listed in doesn't change it.
 func (c *Config) Hash() (string, error) {
-	yml, err := c.Marshal()
+	sorted := *c
+	sorted.Imports = sortedDependencies(c.Imports)
+	sorted.DevImports = sortedDependencies(c.DevImports)
+	return sorted.rawHash()
+}
+
+// rawHash returns a hash of the config with the imports in the order they
+// are listed. The imports merged from included files are part of it so the
+// hash changes along with them.
+func (c *Config) rawHash() (string, error) {
+	flat := *c
+	flat.included = nil
+	yml, err := flat.Marshal()
 	if err != nil {
 		return "", err
 	}
@@ -300,6 +455,35 @@ func (d Dependencies) Get(name string) *Dependency {
 	return nil
 }
 
+// Len returns the length of the Dependencies. This is needed for sorting with
+// the sort package.
+func (d Dependencies) Len() int {
+	return len(d)
+}
+
+// Less is needed for the sort interface. It compares two dependencies based
+// on their name.
+func (d Dependencies) Less(i, j int) bool {
+	return d[i].Name < d[j].Name
+}
+
+// Swap is needed for the sort interface. It swaps the position of two
+// dependencies.
+func (d Dependencies) Swap(i, j int) {
+	d[i], d[j] = d[j], d[i]
+}
+
+// sortedDependencies returns a copy of dependencies sorted by name.
+func sortedDependencies(d Dependencies) Dependencies {
+	if d == nil {
+		return nil
+	}
+	s := make(Dependencies, len(d))
+	copy(s, d)
+	sort.Stable(s)
+	return s
+}
+
 // Has checks if a dependency is on a list of dependencies such as import or testImport
 func (d Dependencies) Has(name string) bool {
 	for _, dep := range d {
@@ -367,6 +551,17 @@ func (d Dependencies) DeDupe() (Dependencies, error) {
 	return imports, nil
 }
 
+// SameRepository returns if two dependencies use the same repository. With
+// canonical set their canonical locations are compared, so the ways of
+// referring to a repository are the same. See util.CanonicalRepo.
+func SameRepository(a, b *Dependency, canonical bool) bool {
+	if !canonical {
+		return a.Repository == b.Repository
+	}
+
+	return util.CanonicalRepo(a.Location()) == util.CanonicalRepo(b.Location())
+}
+
 // Dependency describes a package that the present package depends upon.
 type Dependency struct {
 	Name        string   `yaml:"package"`
@@ -377,8 +572,65 @@ type Dependency struct {
 	Subpackages []string `yaml:"subpackages,omitempty"`
 	Arch        []string `yaml:"arch,omitempty"`
 	Os          []string `yaml:"os,omitempty"`
+
+	// Checkout is an optional command template used to fetch the dependency
+	// in place of the VCS. It is only honored when custom checkouts are
+	// explicitly allowed.
+	Checkout string `yaml:"checkout,omitempty"`
+
+	// Prerelease controls if pre-release tags are candidates when resolving
+	// a semantic version range. It is one of PrereleaseInclude or
+	// PrereleaseExclude. When empty the installer wide setting is used.
+	Prerelease string `yaml:"prerelease,omitempty"`
+
+	// Float controls what a dependency without a version resolves to. It is
+	// one of FloatTag or FloatBranch. When empty the installer wide setting
+	// is used.
+	Float string `yaml:"float,omitempty"`
+
+	// CacheTTL is how long a cached copy is used before fetching updates for
+	// it again. When zero the installer wide setting is used. It is written
+	// to the glide.yaml file as a duration such as 1h30m.
+	CacheTTL time.Duration `yaml:"-"`
+
+	// Fallbacks are other repositories with the same history, such as
+	// mirrors, tried in order when the dependency can't be fetched from its
+	// own repository.
+	Fallbacks []string `yaml:"fallbacks,omitempty"`
+
+	// Mirror, when set, is where the dependency is fetched from in place of
+	// its location and the mirrors configured by the user. An installer sets
+	// it for the run, such as for the result of discovery, so it is never
+	// written to the glide.yaml or lock file.
+	Mirror *Mirror `yaml:"-"`
 }
 
+// Mirror is a repository, and its VCS type when it differs from that of the
+// dependency, a dependency is fetched from for the run.
+type Mirror struct {
+	Repo, Vcs string
+}
+
+const (
+	// PrereleaseInclude considers pre-release tags when resolving a
+	// semantic version range.
+	PrereleaseInclude = "include"
+
+	// PrereleaseExclude skips pre-release tags when resolving a semantic
+	// version range unless the range itself names a pre-release.
+	PrereleaseExclude = "exclude"
+)
+
+const (
+	// FloatTag resolves a dependency without a version to its highest
+	// semantic version tag.
+	FloatTag = "tag"
+
+	// FloatBranch resolves a dependency without a version to the newest
+	// commit on the branch being followed.
+	FloatBranch = "branch"
+)
+
 // A transitive representation of a dependency for importing and exploting to yaml.
 type dep struct {
 	Name        string   `yaml:"package"`
@@ -389,6 +641,11 @@ type dep struct {
 	Subpackages []string `yaml:"subpackages,omitempty"`
 	Arch        []string `yaml:"arch,omitempty"`
 	Os          []string `yaml:"os,omitempty"`
+	Checkout    string   `yaml:"checkout,omitempty"`
+	Prerelease  string   `yaml:"prerelease,omitempty"`
+	Float       string   `yaml:"float,omitempty"`
+	CacheTTL    string   `yaml:"cacheTTL,omitempty"`
+	Fallbacks   []string `yaml:"fallbacks,omitempty"`
 }
 
 // DependencyFromLock converts a Lock to a Dependency
@@ -401,6 +658,7 @@ func DependencyFromLock(lock *Lock) *Dependency {
 		Subpackages: lock.Subpackages,
 		Arch:        lock.Arch,
 		Os:          lock.Os,
+		Checkout:    lock.Checkout,
 	}
 }
 
@@ -418,6 +676,25 @@ func (d *Dependency) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	d.Subpackages = newDep.Subpackages
 	d.Arch = newDep.Arch
 	d.Os = newDep.Os
+	d.Checkout = newDep.Checkout
+	d.Prerelease = newDep.Prerelease
+	d.Float = newDep.Float
+	d.Fallbacks = newDep.Fallbacks
+
+	if d.Prerelease != "" && d.Prerelease != PrereleaseInclude && d.Prerelease != PrereleaseExclude {
+		return fmt.Errorf("Invalid prerelease setting %q for %s, expected %q or %q", d.Prerelease, d.Name, PrereleaseInclude, PrereleaseExclude)
+	}
+
+	if d.Float != "" && d.Float != FloatTag && d.Float != FloatBranch {
+		return fmt.Errorf("Invalid float setting %q for %s, expected %q or %q", d.Float, d.Name, FloatTag, FloatBranch)
+	}
+
+	if newDep.CacheTTL != "" {
+		d.CacheTTL, err = time.ParseDuration(newDep.CacheTTL)
+		if err != nil || d.CacheTTL < 0 {
+			return fmt.Errorf("Invalid cacheTTL %q for %s, expected a duration such as 1h30m", newDep.CacheTTL, d.Name)
+		}
+	}
 
 	if d.Reference == "" && newDep.Ref != "" {
 		d.Reference = newDep.Ref
@@ -455,21 +732,37 @@ func (d *Dependency) MarshalYAML() (interface{}, error) {
 		Subpackages: d.Subpackages,
 		Arch:        d.Arch,
 		Os:          d.Os,
+		Checkout:    d.Checkout,
+		Prerelease:  d.Prerelease,
+		Float:       d.Float,
+		Fallbacks:   d.Fallbacks,
+	}
+	if d.CacheTTL != 0 {
+		newDep.CacheTTL = d.CacheTTL.String()
 	}
 
 	return newDep, nil
 }
 
+// Location returns the configured repository or the default location for
+// the package name, before mirrors are applied. A major version at the end of
+// the name, such as /v2, is part of the import path rather than the
+// repository so it is not included.
+func (d *Dependency) Location() string {
+	if d.Repository != "" {
+		return d.Repository
+	}
+	name, _ := util.SplitMajorVersion(d.Name)
+	return "https://" + name
+}
+
 // Remote returns the remote location to fetch source from. This location is
 // the central place where mirrors can alter the location.
 func (d *Dependency) Remote() string {
-	var r string
-
-	if d.Repository != "" {
-		r = d.Repository
-	} else {
-		r = "https://" + d.Name
+	if d.Mirror != nil {
+		return d.Mirror.Repo
 	}
+	r := d.Location()
 
 	f, nr, _ := mirrors.Get(r)
 	if f {
@@ -479,18 +772,19 @@ func (d *Dependency) Remote() string {
 	return r
 }
 
-// Vcs returns the VCS type to fetch source from.
+// Vcs returns the VCS type to fetch source from. A mirror that doesn't set
+// one keeps the type of the dependency.
 func (d *Dependency) Vcs() string {
-	var r string
-
-	if d.Repository != "" {
-		r = d.Repository
-	} else {
-		r = "https://" + d.Name
+	if d.Mirror != nil {
+		if d.Mirror.Vcs != "" {
+			return d.Mirror.Vcs
+		}
+		return d.VcsType
 	}
+	r := d.Location()
 
 	f, _, nv := mirrors.Get(r)
-	if f {
+	if f && nv != "" {
 		return nv
 	}
 
@@ -538,6 +832,12 @@ func (d *Dependency) Clone() *Dependency {
 		Subpackages: d.Subpackages,
 		Arch:        d.Arch,
 		Os:          d.Os,
+		Checkout:    d.Checkout,
+		Prerelease:  d.Prerelease,
+		Float:       d.Float,
+		CacheTTL:    d.CacheTTL,
+		Fallbacks:   d.Fallbacks,
+		Mirror:      d.Mirror,
 	}
 }
 
@@ -589,6 +889,109 @@ func (o *Owner) Clone() *Owner {
 	}
 }
 
+// Rewrites is a list of rewrite rules.
+type Rewrites []*Rewrite
+
+// Clone performs a deep clone of Rewrites
+func (r Rewrites) Clone() Rewrites {
+	if r == nil {
+		return nil
+	}
+	n := make(Rewrites, 0, len(r))
+	for _, v := range r {
+		n = append(n, &Rewrite{Prefix: v.Prefix, Repo: v.Repo})
+	}
+	return n
+}
+
+// Repo returns the repository the rule with the longest prefix matching the
+// name of a package gives it, or an empty string when none match. The part of
+// the name after the prefix is appended to the repository so a prefix can
+// cover many repositories. The major version of a Go module is not part of
+// the repository.
+func (r Rewrites) Repo(name string) string {
+	name, _ = util.SplitMajorVersion(name)
+	var found *Rewrite
+	for _, v := range r {
+		p := strings.TrimSuffix(v.Prefix, "/")
+		if p != name && !strings.HasPrefix(name, p+"/") {
+			continue
+		}
+		if found == nil || len(p) > len(strings.TrimSuffix(found.Prefix, "/")) {
+			found = v
+		}
+	}
+	if found == nil {
+		return ""
+	}
+
+	rest := strings.TrimPrefix(name, strings.TrimSuffix(found.Prefix, "/"))
+	return strings.TrimSuffix(found.Repo, "/") + rest
+}
+
+// Rewrite fetches the packages under an import path prefix from another
+// repository. For example, a prefix of github.com/foo/bar and a repo of
+// https://github.com/myorg/bar fetches a fork in place of the original.
+type Rewrite struct {
+
+	// Prefix is the import path prefix the rule applies to.
+	Prefix string `yaml:"prefix"`
+
+	// Repo is the repository, or the location repositories are under, the
+	// packages are fetched from.
+	Repo string `yaml:"repo"`
+}
+
+// URLRewrites is a list of URL rewrite rules.
+type URLRewrites []*URLRewrite
+
+// Clone performs a deep clone of URLRewrites
+func (r URLRewrites) Clone() URLRewrites {
+	if r == nil {
+		return nil
+	}
+	n := make(URLRewrites, 0, len(r))
+	for _, v := range r {
+		n = append(n, &URLRewrite{Prefix: v.Prefix, URL: v.URL})
+	}
+	return n
+}
+
+// Apply returns a URL with the start matching the longest prefix of the rules
+// replaced by its URL. An empty string is returned when no rule matches or
+// the URL was already rewritten by one, so a rule is not applied twice.
+func (r URLRewrites) Apply(url string) string {
+	var found *URLRewrite
+	for _, v := range r {
+		if v.Prefix == "" || v.URL == "" {
+			continue
+		}
+		if strings.HasPrefix(url, v.URL) {
+			return ""
+		}
+		if strings.HasPrefix(url, v.Prefix) && (found == nil || len(v.Prefix) > len(found.Prefix)) {
+			found = v
+		}
+	}
+	if found == nil {
+		return ""
+	}
+
+	return found.URL + strings.TrimPrefix(url, found.Prefix)
+}
+
+// URLRewrite replaces the start of the URL of a repository. For example, a
+// prefix of https://github.com/ and a url of https://git.example.com/github/
+// fetches https://github.com/foo/bar from https://git.example.com/github/foo/bar.
+type URLRewrite struct {
+
+	// Prefix is the start of the URLs the rule applies to.
+	Prefix string `yaml:"prefix"`
+
+	// URL replaces the prefix.
+	URL string `yaml:"url"`
+}
+
 func stringArrayDeDupe(s []string, items ...string) []string {
 	for _, item := range items {
 		exists := false
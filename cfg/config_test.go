@@ -1,7 +1,12 @@
 package cfg
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"gopkg.in/yaml.v2"
 )
@@ -176,3 +181,359 @@ func TestOwners(t *testing.T) {
 		t.Error("Unable to parse owners from yaml")
 	}
 }
+
+func TestCheckGlideVersion(t *testing.T) {
+	c := &Config{}
+	if err := c.CheckGlideVersion("0.1.0"); err != nil {
+		t.Errorf("Unexpected error without a minimum version: %s", err)
+	}
+
+	c.MinGlideVersion = "0.13.4"
+	tests := map[string]bool{
+		"0.13.4":     true,
+		"0.13.4-dev": true,
+		"0.14.0":     true,
+		"0.13.3":     false,
+		"0.12.0-dev": false,
+		"dev":        true,
+	}
+	for v, ok := range tests {
+		err := c.CheckGlideVersion(v)
+		if ok && err != nil {
+			t.Errorf("Unexpected error for version %s: %s", v, err)
+		} else if !ok && err == nil {
+			t.Errorf("Expected an error for version %s", v)
+		}
+	}
+}
+
+func TestPriorityRank(t *testing.T) {
+	c := &Config{}
+	err := yaml.Unmarshal([]byte("package: fake/testing\npriority:\n- github.com/example/b\n- github.com/example/a\n"), &c)
+	if err != nil {
+		t.Fatalf("Unable to Unmarshal config yaml: %s", err)
+	}
+
+	tests := map[string]int{
+		"github.com/example/b": 0,
+		"github.com/example/a": 1,
+		"github.com/example/c": 2,
+	}
+	for name, rank := range tests {
+		if r := c.PriorityRank(name); r != rank {
+			t.Errorf("Expected %s to rank %d, got %d", name, rank, r)
+		}
+	}
+
+	if n := c.Clone(); len(n.Priority) != 2 {
+		t.Error("Expected the priority list to be cloned")
+	}
+}
+
+func TestRewritesRepo(t *testing.T) {
+	c := &Config{}
+	err := yaml.Unmarshal([]byte("package: fake/testing\nrewrite:\n- prefix: github.com/foo\n  repo: https://git.example.com/foo/\n- prefix: github.com/foo/bar\n  repo: https://github.com/myorg/bar\n"), &c)
+	if err != nil {
+		t.Fatalf("Unable to Unmarshal config yaml: %s", err)
+	}
+
+	tests := map[string]string{
+		"github.com/foo/bar":    "https://github.com/myorg/bar",
+		"github.com/foo/bar/v2": "https://github.com/myorg/bar",
+		"github.com/foo/baz":    "https://git.example.com/foo/baz",
+		"github.com/foobar/baz": "",
+		"github.com/other/baz":  "",
+	}
+	for name, repo := range tests {
+		if r := c.Rewrite.Repo(name); r != repo {
+			t.Errorf("Expected %s to be rewritten to %q, got %q", name, repo, r)
+		}
+	}
+
+	out, err := c.Marshal()
+	if err != nil {
+		t.Fatalf("Unable to Marshal config: %s", err)
+	}
+	if !strings.Contains(string(out), "prefix: github.com/foo/bar") {
+		t.Errorf("Expected the rewrite rules to be written, got %s", out)
+	}
+}
+
+func TestURLRewritesApply(t *testing.T) {
+	c := &Config{}
+	err := yaml.Unmarshal([]byte("package: fake/testing\nurlRewrite:\n- prefix: https://github.com/\n  url: https://git.example.com/github/\n- prefix: https://github.com/example/\n  url: ssh://git@git.example.com/example/\n"), &c)
+	if err != nil {
+		t.Fatalf("Unable to Unmarshal config yaml: %s", err)
+	}
+
+	tests := map[string]string{
+		"https://github.com/foo/bar":             "https://git.example.com/github/foo/bar",
+		"https://github.com/example/bar":         "ssh://git@git.example.com/example/bar",
+		"https://git.example.com/github/foo/bar": "",
+		"https://bitbucket.org/foo/bar":          "",
+		"git@github.com:foo/bar.git":             "",
+	}
+	for url, expected := range tests {
+		if u := c.URLRewrite.Apply(url); u != expected {
+			t.Errorf("Expected %s to be rewritten to %q, got %q", url, expected, u)
+		}
+	}
+
+	if n := c.Clone(); len(n.URLRewrite) != 2 || n.URLRewrite[0] == c.URLRewrite[0] {
+		t.Error("Expected the URL rewrite rules to be cloned")
+	}
+	out, err := c.Marshal()
+	if err != nil {
+		t.Fatalf("Unable to Marshal config: %s", err)
+	}
+	if !strings.Contains(string(out), "url: https://git.example.com/github/") {
+		t.Errorf("Expected the URL rewrite rules to be written, got %s", out)
+	}
+}
+
+func TestIsAllowed(t *testing.T) {
+	c := &Config{}
+	if !c.IsAllowed("github.com/example/a") {
+		t.Error("Expected everything to be allowed without an allow list")
+	}
+
+	err := yaml.Unmarshal([]byte("package: fake/testing\nallow:\n- github.com/example\n- golang.org/x/net/\n"), &c)
+	if err != nil {
+		t.Fatalf("Unable to Unmarshal config yaml: %s", err)
+	}
+
+	tests := map[string]bool{
+		"github.com/example":          true,
+		"github.com/example/a":        true,
+		"golang.org/x/net":            true,
+		"golang.org/x/net/context":    true,
+		"github.com/example-fork/a":   false,
+		"golang.org/x/text":           false,
+		"bitbucket.org/example/other": false,
+	}
+	for name, allowed := range tests {
+		if a := c.IsAllowed(name); a != allowed {
+			t.Errorf("Expected %s allowed to be %t, got %t", name, allowed, a)
+		}
+	}
+
+	if n := c.Clone(); len(n.Allow) != 2 {
+		t.Error("Expected the allow list to be cloned")
+	}
+}
+
+func TestCacheTTL(t *testing.T) {
+	c := &Config{}
+	err := yaml.Unmarshal([]byte("package: fake/testing\nimport:\n- package: github.com/example/a\n  cacheTTL: 1h30m\n"), &c)
+	if err != nil {
+		t.Fatalf("Unable to Unmarshal config yaml: %s", err)
+	}
+	if ttl := c.Imports[0].CacheTTL; ttl != 90*time.Minute {
+		t.Errorf("Expected a cache TTL of 1h30m, got %s", ttl)
+	}
+	if c.Imports[0].Clone().CacheTTL != c.Imports[0].CacheTTL {
+		t.Error("Expected the cache TTL to be cloned")
+	}
+
+	out, err := yaml.Marshal(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(out), "cacheTTL: 1h30m0s") {
+		t.Errorf("Expected the cache TTL to be written, got:\n%s", out)
+	}
+
+	err = yaml.Unmarshal([]byte("package: fake/testing\nimport:\n- package: github.com/example/a\n  cacheTTL: daily\n"), &c)
+	if err == nil {
+		t.Error("Expected an error for an invalid cache TTL")
+	}
+}
+
+func TestMajorVersionLocation(t *testing.T) {
+	d := &Dependency{Name: "github.com/example/lib/v2"}
+	if l := d.Location(); l != "https://github.com/example/lib" {
+		t.Errorf("Expected the major version to not be part of the location, got %s", l)
+	}
+
+	d.Repository = "git@github.com:example/lib.git"
+	if l := d.Location(); l != d.Repository {
+		t.Errorf("Expected the repository to be the location, got %s", l)
+	}
+}
+
+func TestValidateYaml(t *testing.T) {
+	yml := `package: example.com/app
+import:
+- package: github.com/example/a
+  version: ^1.2.0
+- package: github.com/example/a
+- package: github.com/example/b
+  version: https://github.com/example/b.git
+- package: ""
+- package: github.com/example/c
+  version: develop
+  subpackages:
+  - github.com/example/c/sub
+  - .
+  - util
+  - util
+testImport:
+- package: github.com/example/d
+  version: 9b33ce2
+  versoin: ^1.0.0
+`
+	var errs, warns []string
+	for _, err := range ValidateYaml([]byte(yml)) {
+		if IsWarning(err) {
+			warns = append(warns, err.Error())
+		} else {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	expectErrs := []string{"versoin", "github.com/example/a is listed more than once", "version of github.com/example/b is a URL", "Entry 4 of import", "subpackage github.com/example/c/sub", "is the package itself"}
+	if len(errs) != len(expectErrs) {
+		t.Fatalf("Expected %d errors, got %d: %v", len(expectErrs), len(errs), errs)
+	}
+	for ii, e := range expectErrs {
+		if !strings.Contains(errs[ii], e) {
+			t.Errorf("Expected error %d to mention %q, got %q", ii, e, errs[ii])
+		}
+	}
+
+	expectWarns := []string{"version develop of github.com/example/c", "subpackage util of github.com/example/c is listed more than once"}
+	if len(warns) != len(expectWarns) {
+		t.Fatalf("Expected %d warnings, got %d: %v", len(expectWarns), len(warns), warns)
+	}
+	for ii, w := range expectWarns {
+		if !strings.Contains(warns[ii], w) {
+			t.Errorf("Expected warning %d to mention %q, got %q", ii, w, warns[ii])
+		}
+	}
+
+	valid := "package: example.com/app\nimport:\n- package: github.com/example/a\n  version: ^1.2.0\n  subpackages:\n  - util\n"
+	if errs := ValidateYaml([]byte(valid)); len(errs) != 0 {
+		t.Errorf("Expected no problems with a valid config, got %v", errs)
+	}
+}
+
+func TestReadConfigFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "glide-include")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"glide.yaml": `package: example.com/app
+include:
+- shared/base.yaml
+- other.yaml
+import:
+- package: example.com/a
+  version: ^2.0.0
+`,
+		"shared/base.yaml": `package: example.com/base
+include:
+- ../glide.yaml
+import:
+- package: example.com/a
+  version: ^1.0.0
+- package: example.com/b
+  version: ^1.0.0
+`,
+		"other.yaml": `package: example.com/other
+import:
+- package: example.com/b
+  version: ^3.0.0
+- package: example.com/c
+`,
+	}
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	conf, err := ReadConfigFile(filepath.Join(dir, "glide.yaml"))
+	if err != nil {
+		t.Fatalf("Unexpected error reading the config: %s", err)
+	}
+	expected := map[string]string{
+		"example.com/a": "^2.0.0",
+		"example.com/b": "^1.0.0",
+		"example.com/c": "",
+	}
+	if len(conf.Imports) != len(expected) {
+		t.Fatalf("Expected %d imports, got %d", len(expected), len(conf.Imports))
+	}
+	for name, ver := range expected {
+		if d := conf.Imports.Get(name); d == nil || d.Reference != ver {
+			t.Errorf("Expected %s at %q, got %+v", name, ver, d)
+		}
+	}
+
+	out, err := conf.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(out), "example.com/b") || !strings.Contains(string(out), "shared/base.yaml") {
+		t.Errorf("Expected the includes, and not what they import, to be written, got %s", out)
+	}
+	local, err := ConfigFromYaml([]byte(files["glide.yaml"]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h1, _ := conf.Hash()
+	h2, _ := local.Hash()
+	if h1 == h2 {
+		t.Error("Expected the imports of the included files to change the hash")
+	}
+
+	if err := os.Remove(filepath.Join(dir, "other.yaml")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadConfigFile(filepath.Join(dir, "glide.yaml")); err == nil || !strings.Contains(err.Error(), "other.yaml") {
+		t.Errorf("Expected an error naming the missing include, got %v", err)
+	}
+}
+
+func TestReadConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "glide-stdin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, "shared.yaml"), []byte("package: shared\nimport:\n- package: github.com/example/shared\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	// Includes are relative to the working directory.
+	piped := "package: example.com/piped\ninclude:\n- shared.yaml\nimport:\n- package: github.com/example/dep\n  version: ^1.0.0\n"
+	conf, err := ReadConfig(strings.NewReader(piped))
+	if err != nil {
+		t.Fatalf("Unexpected error reading the config: %s", err)
+	}
+	if conf.Name != "example.com/piped" || len(conf.Imports) != 2 {
+		t.Fatalf("Expected the piped config with its include, got %s with %d imports", conf.Name, len(conf.Imports))
+	}
+	if d := conf.Imports.Get("github.com/example/dep"); d == nil || d.Reference != "^1.0.0" {
+		t.Errorf("Expected the piped import, got %+v", d)
+	}
+	if !conf.Imports.Has("github.com/example/shared") {
+		t.Error("Expected the included import")
+	}
+
+	if _, err := ReadConfig(strings.NewReader("import: [")); err == nil {
+		t.Error("Expected an error for invalid YAML")
+	}
+}
@@ -0,0 +1,117 @@
+package cfg
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	gpath "github.com/Ownercz/glide/path"
+)
+
+// stdin holds the config read from standard input. It can only be read once
+// so it is kept for the config to be loaded again during the run.
+var stdin struct {
+	sync.Once
+	yml []byte
+	err error
+}
+
+// ReadConfigYaml returns the content of a glide.yaml file or, for
+// gpath.StdinFile, of standard input.
+func ReadConfigYaml(glidepath string) ([]byte, error) {
+	if glidepath != gpath.StdinFile {
+		return ioutil.ReadFile(glidepath)
+	}
+	stdin.Do(func() {
+		stdin.yml, stdin.err = ioutil.ReadAll(os.Stdin)
+	})
+	return stdin.yml, stdin.err
+}
+
+// ReadConfigFile loads a glide.yaml file and merges into it the imports of
+// the files it includes, and of those they include in turn. The config
+// including a dependency takes precedence over the files it includes, and a
+// file listed earlier over one listed later. A file including one of those
+// that included it is not read again so include cycles end there. A missing
+// include is an error. For gpath.StdinFile the config is read from standard
+// input.
+func ReadConfigFile(glidepath string) (*Config, error) {
+	if glidepath == gpath.StdinFile {
+		yml, err := ReadConfigYaml(glidepath)
+		if err != nil {
+			return nil, err
+		}
+		return readConfig(yml, "", map[string]bool{})
+	}
+	return readConfigFile(glidepath, map[string]bool{})
+}
+
+// ReadConfig loads a config from a reader, such as standard input, like
+// ReadConfigFile. Included files are relative to the working directory.
+func ReadConfig(r io.Reader) (*Config, error) {
+	yml, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return readConfig(yml, "", map[string]bool{})
+}
+
+// readConfigFile is ReadConfigFile skipping the includes of the files being
+// read.
+func readConfigFile(glidepath string, reading map[string]bool) (*Config, error) {
+	abs, err := filepath.Abs(glidepath)
+	if err != nil {
+		return nil, err
+	}
+	yml, err := ioutil.ReadFile(abs)
+	if err != nil {
+		return nil, err
+	}
+
+	reading[abs] = true
+	defer delete(reading, abs)
+	return readConfig(yml, filepath.Dir(abs), reading)
+}
+
+// readConfig parses a config and merges in its includes, which are relative
+// to a directory.
+func readConfig(yml []byte, dir string, reading map[string]bool) (*Config, error) {
+	conf, err := ConfigFromYaml(yml)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, inc := range conf.Include {
+		p := inc
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(dir, p)
+		}
+		if reading[filepath.Clean(p)] {
+			continue
+		}
+		ic, err := readConfigFile(p, reading)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to include %s: %s", inc, err)
+		}
+		conf.merge(ic)
+	}
+
+	return conf, nil
+}
+
+// merge adds the imports of an included config not already in this one.
+func (c *Config) merge(inc *Config) {
+	for _, dep := range inc.Imports {
+		if c.Imports.Has(dep.Name) {
+			continue
+		}
+		if c.included == nil {
+			c.included = make(map[string]bool)
+		}
+		c.included[dep.Name] = true
+		c.Imports = append(c.Imports, dep)
+	}
+}
@@ -17,6 +17,17 @@ type Lockfile struct {
 	Updated    time.Time `yaml:"updated"`
 	Imports    Locks     `yaml:"imports"`
 	DevImports Locks     `yaml:"testImports"`
+
+	// Metadata is informational and optional. It is not used to install
+	// dependencies so readers can ignore it.
+	Metadata *LockMetadata `yaml:"metadata,omitempty"`
+}
+
+// LockMetadata records information about how a lock file was generated.
+type LockMetadata struct {
+	// Warnings are the problems reported while resolving, such as version
+	// conflicts and how they were resolved.
+	Warnings []string `yaml:"warnings,omitempty"`
 }
 
 // LockfileFromYaml returns an instance of Lockfile from YAML
@@ -87,15 +98,32 @@ func (lf *Lockfile) Clone() *Lockfile {
 	n.Updated = lf.Updated
 	n.Imports = lf.Imports.Clone()
 	n.DevImports = lf.DevImports.Clone()
+	if lf.Metadata != nil {
+		n.Metadata = &LockMetadata{
+			Warnings: append([]string(nil), lf.Metadata.Warnings...),
+		}
+	}
 
 	return n
 }
 
-// Fingerprint returns a hash of the contents minus the date. This allows for
-// two lockfiles to be compared irrespective of their updated times.
+// IsCurrent returns if the lock file was generated for a config by comparing
+// Hash with the hash of the config. Lock files generated before the imports
+// were sorted for the hash are compared with the imports in the order listed.
+func (lf *Lockfile) IsCurrent(conf *Config) bool {
+	if h, err := conf.Hash(); err == nil && h == lf.Hash {
+		return true
+	}
+	h, err := conf.rawHash()
+	return err == nil && h == lf.Hash
+}
+
+// Fingerprint returns a hash of the contents minus the date and metadata. This
+// allows for two lockfiles to be compared irrespective of their updated times.
 func (lf *Lockfile) Fingerprint() ([32]byte, error) {
 	c := lf.Clone()
 	c.Updated = time.Time{} // Set the time to be the nil equivalent
+	c.Metadata = nil
 	sort.Sort(c.Imports)
 	sort.Sort(c.DevImports)
 	yml, err := c.Marshal()
@@ -131,6 +159,16 @@ func (l Locks) Clone() Locks {
 	return n
 }
 
+// Get a lock by name.
+func (l Locks) Get(name string) *Lock {
+	for _, lk := range l {
+		if lk.Name == name {
+			return lk
+		}
+	}
+	return nil
+}
+
 // Len returns the length of the Locks. This is needed for sorting with
 // the sort package.
 func (l Locks) Len() int {
@@ -162,6 +200,12 @@ type Lock struct {
 	Subpackages []string `yaml:"subpackages,omitempty"`
 	Arch        []string `yaml:"arch,omitempty"`
 	Os          []string `yaml:"os,omitempty"`
+	Checkout    string   `yaml:"checkout,omitempty"`
+
+	// Digest is a hash of the content of the dependency in the vendor
+	// directory when the lock file was written. It is used to check the
+	// vendor directory wasn't changed since.
+	Digest string `yaml:"digest,omitempty"`
 }
 
 // Clone creates a clone of a Lock.
@@ -174,6 +218,8 @@ func (l *Lock) Clone() *Lock {
 		Subpackages: l.Subpackages,
 		Arch:        l.Arch,
 		Os:          l.Os,
+		Checkout:    l.Checkout,
+		Digest:      l.Digest,
 	}
 }
 
@@ -187,6 +233,7 @@ func LockFromDependency(dep *Dependency) *Lock {
 		Subpackages: dep.Subpackages,
 		Arch:        dep.Arch,
 		Os:          dep.Os,
+		Checkout:    dep.Checkout,
 	}
 }
 
@@ -110,3 +110,78 @@ func TestSortSubpackages(t *testing.T) {
 		t.Errorf("Expected %q\n to contain\n%q", string(out), expectSubpkgYaml)
 	}
 }
+
+func TestLockMetadata(t *testing.T) {
+	lf := &Lockfile{
+		Imports:  Locks{{Name: "github.com/example/a", Version: "abc"}},
+		Metadata: &LockMetadata{Warnings: []string{"a warning"}},
+	}
+	out, err := lf.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	read, err := LockfileFromYaml(out)
+	if err != nil {
+		t.Fatalf("Unable to read the lock file: %s", err)
+	}
+	if read.Metadata == nil || len(read.Metadata.Warnings) != 1 || read.Metadata.Warnings[0] != "a warning" {
+		t.Errorf("Expected the metadata to be read back, got:\n%s", out)
+	}
+
+	plain := lf.Clone()
+	plain.Metadata = nil
+	f1, err := lf.Fingerprint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	f2, err := plain.Fingerprint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f1 != f2 {
+		t.Error("Expected the metadata not to change the fingerprint")
+	}
+}
+
+func TestIsCurrent(t *testing.T) {
+	conf := &Config{
+		Name: "example.com/app",
+		Imports: Dependencies{
+			{Name: "github.com/example/b", Reference: "^1.0.0"},
+			{Name: "github.com/example/a"},
+		},
+	}
+	hash, err := conf.Hash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	lf := &Lockfile{Hash: hash}
+	if !lf.IsCurrent(conf) {
+		t.Error("Expected the lock file to be current")
+	}
+
+	reordered := conf.Clone()
+	reordered.Imports[0], reordered.Imports[1] = reordered.Imports[1], reordered.Imports[0]
+	if !lf.IsCurrent(reordered) {
+		t.Error("Expected the order of the imports not to matter")
+	}
+	if reordered.Imports[0].Name != "github.com/example/a" || conf.Imports[0].Name != "github.com/example/b" {
+		t.Error("Expected hashing not to reorder the imports")
+	}
+
+	changed := conf.Clone()
+	changed.Imports[0].Reference = "^2.0.0"
+	if lf.IsCurrent(changed) {
+		t.Error("Expected a changed version to make the lock file out of date")
+	}
+
+	// Lock files from before the imports were sorted for the hash.
+	raw, err := conf.rawHash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if raw == hash || !(&Lockfile{Hash: raw}).IsCurrent(conf) {
+		t.Error("Expected the hash of the imports as listed to be accepted")
+	}
+}
@@ -0,0 +1,114 @@
+package cfg
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Ownercz/semver"
+	"gopkg.in/yaml.v2"
+)
+
+// ValidationWarning is a problem found by Validate that does not stop the
+// config from being used, unlike the errors it finds. Use IsWarning to tell
+// them apart.
+type ValidationWarning string
+
+func (w ValidationWarning) Error() string {
+	return string(w)
+}
+
+// IsWarning returns if an error found by Validate is only a warning.
+func IsWarning(err error) bool {
+	_, ok := err.(ValidationWarning)
+	return ok
+}
+
+// commitID matches what looks like a commit ID, possibly abbreviated.
+var commitID = regexp.MustCompile(`^[a-f0-9]{7,40}$`)
+
+// ValidateYaml validates glide.yaml content. Unlike ConfigFromYaml unknown
+// keys and values of the wrong type are errors, and repeated dependencies
+// are kept so Validate reports them.
+func ValidateYaml(yml []byte) []error {
+	errs := []error{}
+	n := &cf{}
+	if err := yaml.UnmarshalStrict(yml, n); err != nil {
+		errs = append(errs, err)
+		n = &cf{}
+		if err := yaml.Unmarshal(yml, n); err != nil {
+			return errs
+		}
+	}
+	return append(errs, n.config().Validate()...)
+}
+
+// Validate returns the problems found in the config, such as repeated or
+// unnamed dependencies, URLs where a package name or version belongs, and
+// subpackages naming their package. A version that may be a branch, which
+// moves with each update, is a ValidationWarning. The config is not changed.
+func (c *Config) Validate() []error {
+	errs := []error{}
+	if c.Name == "" {
+		errs = append(errs, ValidationWarning("No package name is set"))
+	}
+	errs = append(errs, c.Imports.validate("import")...)
+	return append(errs, c.DevImports.validate("testImport")...)
+}
+
+// validate returns the problems with the dependencies in a list of the
+// config.
+func (d Dependencies) validate(list string) []error {
+	errs := []error{}
+	seen := map[string]bool{}
+	for ii, dep := range d {
+		if dep.Name == "" {
+			errs = append(errs, fmt.Errorf("Entry %d of %s has no package name", ii+1, list))
+			continue
+		}
+		if seen[dep.Name] {
+			errs = append(errs, fmt.Errorf("%s is listed more than once in %s", dep.Name, list))
+		}
+		seen[dep.Name] = true
+		errs = append(errs, dep.validate()...)
+	}
+	return errs
+}
+
+// validate returns the problems with a dependency.
+func (d *Dependency) validate() []error {
+	errs := []error{}
+	if isURL(d.Name) {
+		errs = append(errs, fmt.Errorf("The package name %s is a URL, use the import path and set the URL as the repo", d.Name))
+	}
+
+	switch {
+	case d.Reference == "":
+	case isURL(d.Reference):
+		errs = append(errs, fmt.Errorf("The version of %s is a URL, set the URL as the repo instead", d.Name))
+	case !commitID.MatchString(d.Reference):
+		if _, err := semver.NewConstraint(d.Reference); err != nil {
+			errs = append(errs, ValidationWarning(fmt.Sprintf("The version %s of %s is not a commit or semantic version. If it is a branch %s moves with each update", d.Reference, d.Name, d.Name)))
+		}
+	}
+
+	subs := map[string]bool{}
+	for _, sp := range d.Subpackages {
+		switch {
+		case sp == "" || sp == "." || sp == d.Name:
+			errs = append(errs, fmt.Errorf("A subpackage of %s is the package itself, which is always included", d.Name))
+		case strings.HasPrefix(sp, d.Name+"/"):
+			errs = append(errs, fmt.Errorf("The subpackage %s of %s repeats the package name, subpackages are relative to it", sp, d.Name))
+		case subs[sp]:
+			errs = append(errs, ValidationWarning(fmt.Sprintf("The subpackage %s of %s is listed more than once", sp, d.Name)))
+		}
+		subs[sp] = true
+	}
+	return errs
+}
+
+// isURL returns if a string looks like a URL or scp like Git location rather
+// than an import path or version.
+func isURL(s string) bool {
+	return strings.Contains(s, "://") || strings.HasPrefix(s, "git@")
+}
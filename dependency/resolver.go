@@ -3,6 +3,7 @@ package dependency
 import (
 	"container/list"
 	"errors"
+	"fmt"
 	"runtime"
 	"sort"
 
@@ -158,12 +159,48 @@ type Resolver struct {
 	// ResolveTest sets if test dependencies should be resolved.
 	ResolveTest bool
 
+	// SkipTestImports skips the imports of the test files of dependencies.
+	// When a deep ResolveLocal resolves the test imports of the project the
+	// dependencies they reach are scanned for their imports rather than
+	// those of their tests, which are only needed to test the dependency.
+	SkipTestImports bool
+
+	// StrictSubpackages makes an imported package that is missing from the
+	// checkout of its dependency an error naming the package importing it.
+	// Otherwise packages without Go source are skipped.
+	StrictSubpackages bool
+
+	// VendorName, when set, maps the import path of a package to its
+	// directory within the vendor directory. See util.VendorPath.
+	VendorName func(string) string
+
+	// OnIgnore, when set, is called once for each imported package left out
+	// by the ignore rules of the config.
+	OnIgnore func(string)
+
+	// ignored records the packages passed to OnIgnore.
+	ignored map[string]bool
+
+	// vendorNames records the import path of each path in the vendor
+	// directory handed out so Stripv can map it back.
+	vendorNames map[string]string
+
 	// Items already in the queue.
 	alreadyQ map[string]bool
 
 	// Attempts to scan that had unrecoverable error.
 	hadError map[string]bool
 
+	// importedBy records the first package found importing each package.
+	importedBy map[string]string
+
+	// graph records the root packages imported by each root package.
+	graph map[string]map[string]bool
+
+	// cycles records the import cycles found between root packages, each as
+	// the path from a root package back to itself.
+	cycles [][]string
+
 	basedir string
 	seen    map[string]bool
 
@@ -208,7 +245,10 @@ func NewResolver(basedir string) (*Resolver, error) {
 		seen:           map[string]bool{},
 		alreadyQ:       map[string]bool{},
 		hadError:       map[string]bool{},
+		importedBy:     map[string]string{},
+		graph:          map[string]map[string]bool{},
 		findCache:      map[string]*PkgInfo{},
+		vendorNames:    map[string]string{},
 
 		// The config instance here should really be replaced with a real one.
 		Config: &cfg.Config{},
@@ -284,51 +324,122 @@ func (r *Resolver) ResolveLocal(deep bool) ([]string, []string, error) {
 			return filepath.SkipDir
 		}
 
-		// Scan for dependencies, and anything that's not part of the local
-		// package gets added to the scan list.
-		var imps []string
-		var testImps []string
-		p, err := r.BuildContext.ImportDir(path, 0)
+		return r.queueLocal(path, l, tl, alreadySeen, talreadySeen)
+	})
+
+	if err != nil {
+		msg.Err("Failed to build an initial list of packages to scan: %s", err)
+		return []string{}, []string{}, err
+	}
+
+	if deep {
+		if r.ResolveAllFiles {
+			re, err := r.resolveList(l, false, false)
+			if err != nil {
+				return []string{}, []string{}, err
+			}
+			tre, err := r.resolveList(l, false, true)
+			return re, tre, err
+		}
+		re, err := r.resolveImports(l, false, false)
 		if err != nil {
-			if strings.HasPrefix(err.Error(), "no buildable Go source") {
-				return nil
-			} else if strings.HasPrefix(err.Error(), "found packages ") {
-				// If we got here it's because a package and multiple packages
-				// declared. This is often because of an example with a package
-				// or main but +build ignore as a build tag. In that case we
-				// try to brute force the packages with a slower scan.
-				imps, testImps, err = IterativeScan(path)
-				if err != nil {
-					return err
-				}
-			} else {
+			return []string{}, []string{}, err
+		}
+		tre, err := r.resolveImports(tl, true, true)
+		return re, tre, err
+	}
+
+	// If we're not doing a deep scan, we just convert the list into an
+	// array and return.
+	res := make([]string, 0, l.Len())
+	for e := l.Front(); e != nil; e = e.Next() {
+		res = append(res, e.Value.(string))
+	}
+	tres := make([]string, 0, l.Len())
+	if r.ResolveTest {
+		for e := tl.Front(); e != nil; e = e.Next() {
+			tres = append(tres, e.Value.(string))
+		}
+	}
+
+	return res, tres, nil
+}
+
+// queueLocal scans a local directory and queues the packages it imports that
+// are not part of the project. Test imports are queued on tl when ResolveTest
+// is set. The seen maps keep a package from being queued twice.
+func (r *Resolver) queueLocal(path string, l, tl *list.List, alreadySeen, talreadySeen map[string]bool) error {
+	// Scan for dependencies, and anything that's not part of the local
+	// package gets added to the scan list.
+	var imps []string
+	var testImps []string
+	p, err := r.BuildContext.ImportDir(path, 0)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "no buildable Go source") {
+			return nil
+		} else if strings.HasPrefix(err.Error(), "found packages ") {
+			// If we got here it's because a package and multiple packages
+			// declared. This is often because of an example with a package
+			// or main but +build ignore as a build tag. In that case we
+			// try to brute force the packages with a slower scan.
+			imps, testImps, err = IterativeScan(path)
+			if err != nil {
 				return err
 			}
 		} else {
-			imps = p.Imports
-			testImps = dedupeStrings(p.TestImports, p.XTestImports)
+			return err
 		}
+	} else {
+		imps = p.Imports
+		testImps = dedupeStrings(p.TestImports, p.XTestImports)
+	}
 
-		// We are only looking for dependencies in vendor. No root, cgo, etc.
-		for _, imp := range imps {
-			if r.Config.HasIgnore(imp) {
-				continue
+	// We are only looking for dependencies in vendor. No root, cgo, etc.
+	for _, imp := range imps {
+		if r.Config.HasIgnore(imp) {
+			r.ignore(imp)
+			continue
+		}
+		if alreadySeen[imp] {
+			continue
+		}
+		alreadySeen[imp] = true
+		info := r.FindPkg(imp)
+		switch info.Loc {
+		case LocUnknown, LocVendor:
+			l.PushBack(r.vendorPath(r.VendorDir, imp)) // Do we need a path on this?
+		case LocGopath:
+			if !dirHasPrefix(info.Path, r.basedir) {
+				// FIXME: This is a package outside of the project we're
+				// scanning. It should really be on vendor. But we don't
+				// want it to reference GOPATH. We want it to be detected
+				// and moved.
+				l.PushBack(r.vendorPath(r.VendorDir, imp))
+			}
+		case LocRelative:
+			if strings.HasPrefix(imp, "./"+gpath.VendorDir) {
+				msg.Warn("Go package resolving will resolve %s without the ./%s/ prefix", imp, gpath.VendorDir)
 			}
-			if alreadySeen[imp] {
+		}
+	}
+
+	if r.ResolveTest {
+		for _, imp := range testImps {
+			if talreadySeen[imp] {
 				continue
 			}
-			alreadySeen[imp] = true
+			talreadySeen[imp] = true
 			info := r.FindPkg(imp)
 			switch info.Loc {
 			case LocUnknown, LocVendor:
-				l.PushBack(filepath.Join(r.VendorDir, filepath.FromSlash(imp))) // Do we need a path on this?
+				tl.PushBack(r.vendorPath(r.VendorDir, imp)) // Do we need a path on this?
 			case LocGopath:
 				if !dirHasPrefix(info.Path, r.basedir) {
 					// FIXME: This is a package outside of the project we're
 					// scanning. It should really be on vendor. But we don't
 					// want it to reference GOPATH. We want it to be detected
 					// and moved.
-					l.PushBack(filepath.Join(r.VendorDir, filepath.FromSlash(imp)))
+					tl.PushBack(r.vendorPath(r.VendorDir, imp))
 				}
 			case LocRelative:
 				if strings.HasPrefix(imp, "./"+gpath.VendorDir) {
@@ -336,65 +447,46 @@ func (r *Resolver) ResolveLocal(deep bool) ([]string, []string, error) {
 				}
 			}
 		}
-
-		if r.ResolveTest {
-			for _, imp := range testImps {
-				if talreadySeen[imp] {
-					continue
-				}
-				talreadySeen[imp] = true
-				info := r.FindPkg(imp)
-				switch info.Loc {
-				case LocUnknown, LocVendor:
-					tl.PushBack(filepath.Join(r.VendorDir, filepath.FromSlash(imp))) // Do we need a path on this?
-				case LocGopath:
-					if !dirHasPrefix(info.Path, r.basedir) {
-						// FIXME: This is a package outside of the project we're
-						// scanning. It should really be on vendor. But we don't
-						// want it to reference GOPATH. We want it to be detected
-						// and moved.
-						tl.PushBack(filepath.Join(r.VendorDir, filepath.FromSlash(imp)))
-					}
-				case LocRelative:
-					if strings.HasPrefix(imp, "./"+gpath.VendorDir) {
-						msg.Warn("Go package resolving will resolve %s without the ./%s/ prefix", imp, gpath.VendorDir)
-					}
-				}
-			}
-		}
-
-		return nil
-	})
-
-	if err != nil {
-		msg.Err("Failed to build an initial list of packages to scan: %s", err)
-		return []string{}, []string{}, err
 	}
 
-	if deep {
-		if r.ResolveAllFiles {
-			re, err := r.resolveList(l, false, false)
-			if err != nil {
-				return []string{}, []string{}, err
-			}
-			tre, err := r.resolveList(l, false, true)
-			return re, tre, err
+	return nil
+}
+
+// ResolveRoots resolves the dependencies of specific local packages rather
+// than the whole project. Each root is a directory relative to the project
+// or an import path within it. Only the given packages are scanned, not the
+// directories below them.
+//
+// Like ResolveLocal without the deep flag, the packages the roots rely upon
+// and their test imports are returned.
+func (r *Resolver) ResolveRoots(roots []string) ([]string, []string, error) {
+	msg.Debug("Resolving dependencies of %s", strings.Join(roots, ", "))
+	l := list.New()
+	tl := list.New()
+	alreadySeen := map[string]bool{}
+	talreadySeen := map[string]bool{}
+	for _, root := range roots {
+		if r.Config != nil && strings.HasPrefix(root, r.Config.Name+"/") {
+			root = strings.TrimPrefix(root, r.Config.Name+"/")
 		}
-		re, err := r.resolveImports(l, false, false)
+		path := filepath.Join(r.basedir, filepath.FromSlash(root))
+		fi, err := os.Stat(path)
 		if err != nil {
 			return []string{}, []string{}, err
 		}
-		tre, err := r.resolveImports(tl, true, true)
-		return re, tre, err
+		if !fi.IsDir() {
+			return []string{}, []string{}, fmt.Errorf("%s is not a package directory", root)
+		}
+		if err := r.queueLocal(path, l, tl, alreadySeen, talreadySeen); err != nil {
+			return []string{}, []string{}, err
+		}
 	}
 
-	// If we're not doing a deep scan, we just convert the list into an
-	// array and return.
 	res := make([]string, 0, l.Len())
 	for e := l.Front(); e != nil; e = e.Next() {
 		res = append(res, e.Value.(string))
 	}
-	tres := make([]string, 0, l.Len())
+	tres := make([]string, 0, tl.Len())
 	if r.ResolveTest {
 		for e := tl.Front(); e != nil; e = e.Next() {
 			tres = append(tres, e.Value.(string))
@@ -418,7 +510,7 @@ func (r *Resolver) ResolveLocal(deep bool) ([]string, []string, error) {
 // an error is returned.
 func (r *Resolver) ResolveAll(deps []*cfg.Dependency, addTest bool) ([]string, error) {
 
-	queue := sliceToQueue(deps, r.VendorDir)
+	queue := r.sliceToQueue(deps)
 
 	if r.ResolveAllFiles {
 		return r.resolveList(queue, false, addTest)
@@ -426,14 +518,123 @@ func (r *Resolver) ResolveAll(deps []*cfg.Dependency, addTest bool) ([]string, e
 	return r.resolveImports(queue, false, addTest)
 }
 
+// addEdge records that a package imports another in the graph of their root
+// packages. Packages of the project, which are scanned by their path, and
+// imports within the same root package are not recorded.
+//
+// An edge closing a cycle is warned about with the path of the cycle. Go
+// allows root packages to import each other so resolving carries on. Each
+// edge is only added once so each cycle is only warned about once.
+func (r *Resolver) addEdge(pkg, imp string) {
+	if filepath.IsAbs(pkg) {
+		return
+	}
+	from, _ := util.NormalizeName(pkg)
+	to, _ := util.NormalizeName(imp)
+	if from == to || from == r.Config.Name || r.graph[from][to] {
+		return
+	}
+	if r.graph[from] == nil {
+		r.graph[from] = map[string]bool{}
+	}
+	r.graph[from][to] = true
+
+	if p := r.graphPath(to, from); p != nil {
+		cycle := append([]string{from}, p...)
+		r.cycles = append(r.cycles, cycle)
+		msg.Warn("Import cycle: %s", strings.Join(cycle, " -> "))
+	}
+}
+
+// graphPath returns the shortest path of root packages in the graph from one
+// to another, including both, or nil when there is none.
+func (r *Resolver) graphPath(from, to string) []string {
+	prev := map[string]string{from: ""}
+	queue := []string{from}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		if n == to {
+			p := []string{}
+			for ; n != ""; n = prev[n] {
+				p = append([]string{n}, p...)
+			}
+			return p
+		}
+
+		next := make([]string, 0, len(r.graph[n]))
+		for t := range r.graph[n] {
+			next = append(next, t)
+		}
+		sort.Strings(next)
+		for _, t := range next {
+			if _, ok := prev[t]; !ok {
+				prev[t] = n
+				queue = append(queue, t)
+			}
+		}
+	}
+	return nil
+}
+
+// Graph returns the import graph of the root packages resolved so far. It
+// maps each root package to the sorted root packages it imports. Root
+// packages importing no others are not listed. Packages in the GOROOT are not
+// included. Import cycles between root packages are possible so this is not
+// necessarily acyclic.
+func (r *Resolver) Graph() map[string][]string {
+	g := make(map[string][]string, len(r.graph))
+	for from, to := range r.graph {
+		l := make([]string, 0, len(to))
+		for t := range to {
+			l = append(l, t)
+		}
+		sort.Strings(l)
+		g[from] = l
+	}
+	return g
+}
+
+// ignore reports an imported package left out by the ignore rules to
+// OnIgnore the first time it is seen.
+func (r *Resolver) ignore(pkg string) {
+	if r.OnIgnore == nil || r.ignored[pkg] {
+		return
+	}
+	if r.ignored == nil {
+		r.ignored = make(map[string]bool)
+	}
+	r.ignored[pkg] = true
+	r.OnIgnore(pkg)
+}
+
 // Stripv strips the vendor/ prefix from vendored packages.
 func (r *Resolver) Stripv(str string) string {
+	if imp, ok := r.vendorNames[str]; ok {
+		return filepath.FromSlash(imp)
+	}
 	return strings.TrimPrefix(str, r.VendorDir+string(os.PathSeparator))
 }
 
 // vpath adds an absolute vendor path.
 func (r *Resolver) vpath(str string) string {
-	return filepath.Join(r.basedir, "vendor", str)
+	return r.vendorPath(filepath.Join(r.basedir, "vendor"), str)
+}
+
+// vendorPath returns the location of a package within a vendor directory
+// following VendorName. A package VendorName puts outside of the vendor
+// directory keeps the import path layout here as exporting it reports the
+// error.
+func (r *Resolver) vendorPath(vendor, imp string) string {
+	p, err := util.VendorPath(vendor, imp, r.VendorName)
+	if err != nil {
+		p = filepath.Join(vendor, filepath.FromSlash(imp))
+	}
+	if r.vendorNames == nil {
+		r.vendorNames = make(map[string]string)
+	}
+	r.vendorNames[p] = imp
+	return p
 }
 
 // resolveImports takes a list of existing packages and resolves their imports.
@@ -500,7 +701,7 @@ func (r *Resolver) resolveImports(queue *list.List, testDeps, addTest bool) ([]s
 			// or main but +build ignore as a build tag. In that case we
 			// try to brute force the packages with a slower scan.
 			msg.Debug("Using Iterative Scanning for %s", dep)
-			if testDeps {
+			if testDeps && !r.SkipTestImports {
 				_, imps, err = IterativeScan(r.Handler.PkgPath(dep))
 			} else {
 				imps, _, err = IterativeScan(r.Handler.PkgPath(dep))
@@ -513,7 +714,16 @@ func (r *Resolver) resolveImports(queue *list.List, testDeps, addTest bool) ([]s
 		} else if err != nil {
 			errStr := err.Error()
 			msg.Debug("ImportDir error on %s: %s", r.Handler.PkgPath(dep), err)
-			if strings.HasPrefix(errStr, "no buildable Go source") {
+			if r.StrictSubpackages && r.missingSubpackage(dep, err, foundQ) {
+				r.hadError[dep] = true
+				root, _ := util.NormalizeName(dep)
+				if by, ok := r.importedBy[dep]; ok {
+					msg.Err("%s imports %s which is not in the version of %s in use", by, dep, root)
+				} else {
+					msg.Err("%s is not in the version of %s in use", dep, root)
+				}
+				continue
+			} else if strings.HasPrefix(errStr, "no buildable Go source") {
 				msg.Debug("No subpackages declared. Skipping %s.", dep)
 				continue
 			} else if osDirNotFound(err, r.Handler.PkgPath(dep)) && !foundErr && !foundQ {
@@ -534,7 +744,11 @@ func (r *Resolver) resolveImports(queue *list.List, testDeps, addTest bool) ([]s
 					r.VersionHandler.SetVersion(dep, addTest)
 				} else if err2 != nil {
 					r.hadError[dep] = true
-					msg.Err("Error looking for %s: %s", dep, err2)
+					if by, ok := r.importedBy[dep]; ok {
+						msg.Err("Error looking for %s, imported by %s: %s", dep, by, err2)
+					} else {
+						msg.Err("Error looking for %s: %s", dep, err2)
+					}
 				} else {
 					r.hadError[dep] = true
 					// TODO (mpb): Should we toss this into a Handler to
@@ -557,7 +771,7 @@ func (r *Resolver) resolveImports(queue *list.List, testDeps, addTest bool) ([]s
 			}
 			continue
 		} else {
-			if testDeps {
+			if testDeps && !r.SkipTestImports {
 				imps = dedupeStrings(pkg.TestImports, pkg.XTestImports)
 			} else {
 				imps = pkg.Imports
@@ -570,11 +784,16 @@ func (r *Resolver) resolveImports(queue *list.List, testDeps, addTest bool) ([]s
 		for _, imp := range imps {
 			if r.Config.HasIgnore(imp) {
 				msg.Debug("Ignoring: %s", imp)
+				r.ignore(imp)
 				continue
 			}
 			pi := r.FindPkg(imp)
 			if pi.Loc != LocCgo && pi.Loc != LocGoroot && pi.Loc != LocAppengine {
 				msg.Debug("Package %s imports %s", dep, imp)
+				if _, ok := r.importedBy[imp]; !ok {
+					r.importedBy[imp] = dep
+				}
+				r.addEdge(dep, imp)
 			}
 			switch pi.Loc {
 			case LocVendor:
@@ -597,7 +816,7 @@ func (r *Resolver) resolveImports(queue *list.List, testDeps, addTest bool) ([]s
 					r.VersionHandler.SetVersion(imp, addTest)
 				} else if err != nil {
 					r.hadError[imp] = true
-					msg.Err("Error looking for %s: %s", imp, err)
+					msg.Err("Error looking for %s, imported by %s: %s", imp, dep, err)
 				} else {
 					r.hadError[imp] = true
 					msg.Err("Not found: %s (2)", imp)
@@ -606,10 +825,13 @@ func (r *Resolver) resolveImports(queue *list.List, testDeps, addTest bool) ([]s
 				msg.Debug("Found on GOPATH, not vendor: %s", imp)
 				if _, ok := r.alreadyQ[imp]; !ok {
 					// Only scan it if it gets moved into vendor/
-					if ok, _ := r.Handler.OnGopath(imp, addTest); ok {
+					if ok, err := r.Handler.OnGopath(imp, addTest); ok {
 						r.alreadyQ[imp] = true
 						queue.PushBack(r.vpath(imp))
 						r.VersionHandler.SetVersion(imp, addTest)
+					} else if err != nil {
+						r.hadError[imp] = true
+						msg.Err("Error handling %s, imported by %s: %s", imp, dep, err)
 					}
 				}
 			}
@@ -622,17 +844,11 @@ func (r *Resolver) resolveImports(queue *list.List, testDeps, addTest bool) ([]s
 		return []string{}, errors.New("Error resolving imports")
 	}
 
-	// FIXME: From here to the end is a straight copy of the resolveList() func.
 	res := make([]string, 0, queue.Len())
 
 	// In addition to generating a list
 	for e := queue.Front(); e != nil; e = e.Next() {
 		t := r.Stripv(e.Value.(string))
-		root, sp := util.NormalizeName(t)
-
-		if root == r.Config.Name {
-			continue
-		}
 
 		// Skip ignored packages
 		if r.Config.HasIgnore(e.Value.(string)) {
@@ -640,33 +856,53 @@ func (r *Resolver) resolveImports(queue *list.List, testDeps, addTest bool) ([]s
 			continue
 		}
 
-		// TODO(mattfarina): Need to eventually support devImport
-		existing := r.Config.Imports.Get(root)
-		if existing == nil && addTest {
-			existing = r.Config.DevImports.Get(root)
+		if r.depFromPackage(t, addTest) {
+			res = append(res, t)
 		}
-		if existing != nil {
-			if sp != "" && !existing.HasSubpackage(sp) {
-				existing.Subpackages = append(existing.Subpackages, sp)
-			}
-		} else {
-			newDep := &cfg.Dependency{
-				Name: root,
-			}
-			if sp != "" {
-				newDep.Subpackages = []string{sp}
-			}
+	}
 
-			if addTest {
-				r.Config.DevImports = append(r.Config.DevImports, newDep)
-			} else {
-				r.Config.Imports = append(r.Config.Imports, newDep)
-			}
+	return res, nil
+}
+
+// depFromPackage records the root package of a resolved package in the
+// Imports of the Config, or the DevImports when addTest is set, adding the
+// subpackage to one already there. It returns false for packages that are
+// not part of a dependency, those of the project itself and of the standard
+// library. See IsStdlib.
+func (r *Resolver) depFromPackage(pkg string, addTest bool) bool {
+	root, sp := util.NormalizeName(pkg)
+	if root == r.Config.Name {
+		return false
+	}
+	if r.isStdlib(root) {
+		msg.Debug("Not adding %s as a dependency, it is part of the standard library", pkg)
+		return false
+	}
+
+	// TODO(mattfarina): Need to eventually support devImport
+	existing := r.Config.Imports.Get(root)
+	if existing == nil && addTest {
+		existing = r.Config.DevImports.Get(root)
+	}
+	if existing != nil {
+		if sp != "" && !existing.HasSubpackage(sp) {
+			existing.Subpackages = append(existing.Subpackages, sp)
 		}
-		res = append(res, t)
+		return true
 	}
 
-	return res, nil
+	newDep := &cfg.Dependency{
+		Name: root,
+	}
+	if sp != "" {
+		newDep.Subpackages = []string{sp}
+	}
+	if addTest {
+		r.Config.DevImports = append(r.Config.DevImports, newDep)
+	} else {
+		r.Config.Imports = append(r.Config.Imports, newDep)
+	}
+	return true
 }
 
 // resolveList takes a list and resolves it.
@@ -685,7 +921,7 @@ func (r *Resolver) resolveList(queue *list.List, testDeps, addTest bool) ([]stri
 	var pkgPath string
 	for e := queue.Front(); e != nil; e = e.Next() {
 		dep := e.Value.(string)
-		t := strings.TrimPrefix(dep, r.VendorDir+string(os.PathSeparator))
+		t := r.Stripv(dep)
 		if r.Config.HasIgnore(t) {
 			msg.Debug("Ignoring: %s", t)
 			continue
@@ -732,37 +968,10 @@ func (r *Resolver) resolveList(queue *list.List, testDeps, addTest bool) ([]stri
 
 	// In addition to generating a list
 	for e := queue.Front(); e != nil; e = e.Next() {
-		t := strings.TrimPrefix(e.Value.(string), r.VendorDir+string(os.PathSeparator))
-		root, sp := util.NormalizeName(t)
-
-		if root == r.Config.Name {
-			continue
-		}
-
-		existing := r.Config.Imports.Get(root)
-		if existing == nil && addTest {
-			existing = r.Config.DevImports.Get(root)
-		}
-
-		if existing != nil {
-			if sp != "" && !existing.HasSubpackage(sp) {
-				existing.Subpackages = append(existing.Subpackages, sp)
-			}
-		} else {
-			newDep := &cfg.Dependency{
-				Name: root,
-			}
-			if sp != "" {
-				newDep.Subpackages = []string{sp}
-			}
-
-			if addTest {
-				r.Config.DevImports = append(r.Config.DevImports, newDep)
-			} else {
-				r.Config.Imports = append(r.Config.Imports, newDep)
-			}
+		t := r.Stripv(e.Value.(string))
+		if r.depFromPackage(t, addTest) {
+			res = append(res, e.Value.(string))
 		}
-		res = append(res, e.Value.(string))
 	}
 
 	return res, nil
@@ -825,7 +1034,7 @@ func (r *Resolver) imports(pkg string, testDeps, addTest bool) ([]string, error)
 		// declared. This is often because of an example with a package
 		// or main but +build ignore as a build tag. In that case we
 		// try to brute force the packages with a slower scan.
-		if testDeps {
+		if testDeps && !r.SkipTestImports {
 			_, imps, err = IterativeScan(r.Handler.PkgPath(pkg))
 		} else {
 			imps, _, err = IterativeScan(r.Handler.PkgPath(pkg))
@@ -837,7 +1046,7 @@ func (r *Resolver) imports(pkg string, testDeps, addTest bool) ([]string, error)
 	} else if err != nil {
 		return []string{}, err
 	} else {
-		if testDeps {
+		if testDeps && !r.SkipTestImports {
 			imps = dedupeStrings(p.TestImports, p.XTestImports)
 		} else {
 			imps = p.Imports
@@ -860,18 +1069,22 @@ func (r *Resolver) imports(pkg string, testDeps, addTest bool) ([]string, error)
 	for _, imp := range imps {
 		if r.Config.HasIgnore(imp) {
 			msg.Debug("Ignoring %s", imp)
+			r.ignore(imp)
 			continue
 		}
 		info := r.FindPkg(imp)
+		if info.Loc != LocCgo && info.Loc != LocGoroot && info.Loc != LocAppengine && strings.HasPrefix(pkg, r.VendorDir+string(os.PathSeparator)) {
+			r.addEdge(filepath.ToSlash(r.Stripv(pkg)), imp)
+		}
 		switch info.Loc {
 		case LocUnknown:
 			// Do we resolve here?
 			found, err := r.Handler.NotFound(imp, addTest)
 			if err != nil {
-				msg.Err("Failed to fetch %s: %s", imp, err)
+				msg.Err("Failed to fetch %s, imported by %s: %s", imp, p.ImportPath, err)
 			}
 			if found {
-				buf = append(buf, filepath.Join(r.VendorDir, filepath.FromSlash(imp)))
+				buf = append(buf, r.vendorPath(r.VendorDir, imp))
 				r.VersionHandler.SetVersion(imp, addTest)
 				continue
 			}
@@ -893,7 +1106,7 @@ func (r *Resolver) imports(pkg string, testDeps, addTest bool) ([]string, error)
 			// for subsequent processing. Otherwise, we assume that we're
 			// in a less-than-perfect, but functional, situation.
 			if found {
-				buf = append(buf, filepath.Join(r.VendorDir, filepath.FromSlash(imp)))
+				buf = append(buf, r.vendorPath(r.VendorDir, imp))
 				r.VersionHandler.SetVersion(imp, addTest)
 				continue
 			}
@@ -910,7 +1123,7 @@ func (r *Resolver) imports(pkg string, testDeps, addTest bool) ([]string, error)
 
 // sliceToQueue is a special-purpose function for unwrapping a slice of
 // dependencies into a queue of fully qualified paths.
-func sliceToQueue(deps []*cfg.Dependency, basepath string) *list.List {
+func (r *Resolver) sliceToQueue(deps []*cfg.Dependency) *list.List {
 	l := list.New()
 	for _, e := range deps {
 		if len(e.Subpackages) > 0 {
@@ -920,11 +1133,11 @@ func sliceToQueue(deps []*cfg.Dependency, basepath string) *list.List {
 					ip = ip + "/" + v
 				}
 				msg.Debug("Adding local Import %s to queue", ip)
-				l.PushBack(filepath.Join(basepath, filepath.FromSlash(ip)))
+				l.PushBack(r.vendorPath(r.VendorDir, ip))
 			}
 		} else {
 			msg.Debug("Adding local Import %s to queue", e.Name)
-			l.PushBack(filepath.Join(basepath, filepath.FromSlash(e.Name)))
+			l.PushBack(r.vendorPath(r.VendorDir, e.Name))
 		}
 
 	}
@@ -1016,7 +1229,7 @@ func (r *Resolver) FindPkg(name string) *PkgInfo {
 	}
 
 	// Check _only_ if this dep is in the current vendor directory.
-	p = filepath.Join(r.VendorDir, filepath.FromSlash(name))
+	p = r.vendorPath(r.VendorDir, name)
 	if pkgExists(p) {
 		info.Path = p
 		info.Loc = LocVendor
@@ -1077,11 +1290,51 @@ func (r *Resolver) FindPkg(name string) *PkgInfo {
 		// with build flags. Need to detect this and handle it.
 		info.Loc = LocGoroot
 		r.findCache[name] = info
+	} else if r.isStdlib(name) {
+		// A standard library package newer than the Go in use. It can't be
+		// fetched so it is not treated as missing.
+		msg.Debug("Treating %s as part of the standard library", name)
+		info.Loc = LocGoroot
+		r.findCache[name] = info
 	}
 
 	return info
 }
 
+// IsStdlib returns if an import path looks like it is in the standard
+// library. Paths that can be fetched have a dot in their first element, the
+// host name, so those without one are taken to be in the standard library.
+// Relative paths are not.
+func IsStdlib(name string) bool {
+	name = filepath.ToSlash(name)
+	if name == "" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "/") {
+		return false
+	}
+	first := strings.SplitN(name, "/", 2)[0]
+	return !strings.Contains(first, ".") && !strings.Contains(first, ":")
+}
+
+// isStdlib returns if an import path is in the standard library. The packages
+// of the project and of the dependencies in the Config are not, even without
+// a dot in their first element. Otherwise it is decided by IsStdlib.
+func (r *Resolver) isStdlib(name string) bool {
+	if r.Config != nil {
+		within := func(root string) bool {
+			return root != "" && (name == root || strings.HasPrefix(name, root+"/"))
+		}
+		if within(r.Config.Name) {
+			return false
+		}
+		for _, dep := range append(r.Config.Imports, r.Config.DevImports...) {
+			if within(dep.Name) {
+				return false
+			}
+		}
+	}
+
+	return IsStdlib(name)
+}
+
 func pkgExists(path string) bool {
 	fi, err := os.Stat(path)
 	return err == nil && (fi.IsDir() || isLink(fi))
@@ -1161,6 +1414,25 @@ func dedupeStrings(s1, s2 []string) (r []string) {
 	return
 }
 
+// missingSubpackage returns if the error scanning a package is because it is
+// a subpackage missing from the checkout of its dependency. The checkout
+// itself needs to be present. A missing directory only counts once the
+// package has been queued, and so fetched, before.
+func (r *Resolver) missingSubpackage(pkg string, err error, fetched bool) bool {
+	root, sub := util.NormalizeName(pkg)
+	if sub == "" || root == r.Config.Name {
+		return false
+	}
+	if _, serr := os.Stat(r.Handler.PkgPath(root)); serr != nil {
+		return false
+	}
+
+	if strings.HasPrefix(err.Error(), "no buildable Go source") {
+		return true
+	}
+	return fetched && osDirNotFound(err, r.Handler.PkgPath(pkg))
+}
+
 // In Go 1.9 go/build.ImportDir changed so that a missing dir
 // no longer responses with os.IsNotExist. Instead the error changed
 // one in the form of fmt.Errorf("cannot find package %q in:\n\t%s", path, p.Dir)
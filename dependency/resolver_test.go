@@ -1,8 +1,11 @@
 package dependency
 
 import (
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"reflect"
+	"sort"
 	"strings"
 	"testing"
 
@@ -41,6 +44,35 @@ func TestResolveLocalShallow(t *testing.T) {
 	}
 }
 
+func TestResolveRoots(t *testing.T) {
+	r, err := NewResolver("../")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l, _, err := r.ResolveRoots([]string{"cfg"})
+	if err != nil {
+		t.Fatalf("Failed to resolve: %s", err)
+	}
+
+	var yaml bool
+	for _, li := range l {
+		if strings.HasSuffix(li, filepath.FromSlash("gopkg.in/yaml.v2")) {
+			yaml = true
+		}
+		if strings.HasSuffix(li, filepath.FromSlash("github.com/urfave/cli")) {
+			t.Error("Resolved github.com/urfave/cli which is not imported by the root")
+		}
+	}
+	if !yaml {
+		t.Error("Could not find gopkg.in/yaml.v2 in resolved list.")
+	}
+
+	if _, _, err := r.ResolveRoots([]string{"does-not-exist"}); err == nil {
+		t.Error("Expected an error resolving a missing root")
+	}
+}
+
 func TestResolveLocalDeep(t *testing.T) {
 	r, err := NewResolver("../")
 	if err != nil {
@@ -106,3 +138,332 @@ func TestResolveAll(t *testing.T) {
 		t.Errorf("Expected at least %d deps, got %d", len(deps), len(l))
 	}
 }
+
+func TestResolveStrictSubpackages(t *testing.T) {
+	dir, err := ioutil.TempDir("", "glide-strict")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// The gone subpackage only has a README left at the version in use.
+	files := map[string]string{
+		"vendor/github.com/example/dep/dep.go":       "package dep\n",
+		"vendor/github.com/example/dep/user/user.go": "package user\n\nimport _ \"github.com/example/dep/gone\"\n",
+		"vendor/github.com/example/dep/gone/README":  "Moved\n",
+	}
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	deps := []*cfg.Dependency{{Name: "github.com/example/dep", Subpackages: []string{"user"}}}
+	for _, strict := range []bool{false, true} {
+		r, err := NewResolver(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.Handler = &DefaultMissingPackageHandler{Missing: []string{}, Gopath: []string{}, Prefix: r.VendorDir}
+		r.StrictSubpackages = strict
+
+		_, err = r.ResolveAll(deps, false)
+		if strict && err == nil {
+			t.Error("Expected an error for the missing subpackage in strict mode")
+		} else if !strict && err != nil {
+			t.Errorf("Unexpected error resolving: %s", err)
+		}
+		if strict && r.importedBy["github.com/example/dep/gone"] != "github.com/example/dep/user" {
+			t.Errorf("Expected the importing package to be recorded, got %q", r.importedBy["github.com/example/dep/gone"])
+		}
+	}
+}
+
+func TestResolverGraph(t *testing.T) {
+	dir, err := ioutil.TempDir("", "glide-graph")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"vendor/github.com/example/a/a.go":          "package a\n\nimport _ \"github.com/example/b/sub\"\n",
+		"vendor/github.com/example/b/b.go":          "package b\n",
+		"vendor/github.com/example/b/sub/sub.go":    "package sub\n\nimport (\n\t_ \"fmt\"\n\t_ \"github.com/example/b\"\n\t_ \"github.com/example/c\"\n)\n",
+		"vendor/github.com/example/c/c.go":          "package c\n",
+		"vendor/github.com/example/c/internal/x.go": "package internal\n",
+	}
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r, err := NewResolver(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Handler = &DefaultMissingPackageHandler{Missing: []string{}, Gopath: []string{}, Prefix: r.VendorDir}
+	if _, err := r.ResolveAll([]*cfg.Dependency{{Name: "github.com/example/a"}}, false); err != nil {
+		t.Fatalf("Unexpected error resolving: %s", err)
+	}
+
+	g := r.Graph()
+	expect := map[string][]string{
+		"github.com/example/a": {"github.com/example/b"},
+		"github.com/example/b": {"github.com/example/c"},
+	}
+	if !reflect.DeepEqual(g, expect) {
+		t.Errorf("Expected the graph %v, got %v", expect, g)
+	}
+}
+
+func TestResolverOnIgnore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "glide-ignore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"vendor/github.com/example/a/a.go": "package a\n\nimport (\n\t_ \"github.com/example/b\"\n\t_ \"github.com/example/skip\"\n)\n",
+		"vendor/github.com/example/b/b.go": "package b\n\nimport _ \"github.com/example/skip/sub\"\n",
+	}
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r, err := NewResolver(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Handler = &DefaultMissingPackageHandler{Missing: []string{}, Gopath: []string{}, Prefix: r.VendorDir}
+	r.Config = &cfg.Config{Ignore: []string{"github.com/example/skip"}}
+	ignored := []string{}
+	r.OnIgnore = func(pkg string) {
+		ignored = append(ignored, pkg)
+	}
+	for ii := 0; ii < 2; ii++ {
+		if _, err := r.ResolveAll([]*cfg.Dependency{{Name: "github.com/example/a"}}, false); err != nil {
+			t.Fatalf("Unexpected error resolving: %s", err)
+		}
+	}
+
+	sort.Strings(ignored)
+	expect := []string{"github.com/example/skip", "github.com/example/skip/sub"}
+	if !reflect.DeepEqual(ignored, expect) {
+		t.Errorf("Expected each ignored package to be reported once, got %v", ignored)
+	}
+}
+
+func TestResolverCycles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "glide-cycles")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// a imports b, whose sub package imports c, which imports a back. Another
+	// package of a imports b again without closing another cycle.
+	files := map[string]string{
+		"vendor/github.com/example/a/a.go":       "package a\n\nimport _ \"github.com/example/b\"\n",
+		"vendor/github.com/example/a/x/x.go":     "package x\n\nimport _ \"github.com/example/b/sub\"\n",
+		"vendor/github.com/example/b/b.go":       "package b\n\nimport _ \"github.com/example/b/sub\"\n",
+		"vendor/github.com/example/b/sub/sub.go": "package sub\n\nimport _ \"github.com/example/c\"\n",
+		"vendor/github.com/example/c/c.go":       "package c\n\nimport _ \"github.com/example/a/x\"\n",
+	}
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r, err := NewResolver(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Handler = &DefaultMissingPackageHandler{Missing: []string{}, Gopath: []string{}, Prefix: r.VendorDir}
+	if _, err := r.ResolveAll([]*cfg.Dependency{{Name: "github.com/example/a"}}, false); err != nil {
+		t.Fatalf("Unexpected error resolving: %s", err)
+	}
+
+	expect := [][]string{{"github.com/example/c", "github.com/example/a", "github.com/example/b", "github.com/example/c"}}
+	if !reflect.DeepEqual(r.cycles, expect) {
+		t.Errorf("Expected the cycle to be found once as %v, got %v", expect, r.cycles)
+	}
+}
+
+func TestResolveSkipTestImports(t *testing.T) {
+	dir, err := ioutil.TempDir("", "glide-skip-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// The project tests import a, whose own tests import testlib.
+	files := map[string]string{
+		"main.go":                                "package main\n",
+		"main_test.go":                           "package main\n\nimport _ \"github.com/example/a\"\n",
+		"vendor/github.com/example/a/a.go":       "package a\n\nimport _ \"github.com/example/b\"\n",
+		"vendor/github.com/example/a/a_test.go":  "package a\n\nimport _ \"github.com/example/testlib\"\n",
+		"vendor/github.com/example/b/b.go":       "package b\n",
+		"vendor/github.com/example/testlib/t.go": "package testlib\n",
+	}
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, skip := range []bool{false, true} {
+		r, err := NewResolver(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.Handler = &DefaultMissingPackageHandler{Missing: []string{}, Gopath: []string{}, Prefix: r.VendorDir}
+		r.ResolveTest = true
+		r.SkipTestImports = skip
+		_, tl, err := r.ResolveLocal(true)
+		if err != nil {
+			t.Fatalf("Unexpected error resolving: %s", err)
+		}
+
+		expect := []string{"github.com/example/a", "github.com/example/testlib"}
+		if skip {
+			expect = []string{"github.com/example/a", "github.com/example/b"}
+		}
+		if !reflect.DeepEqual(tl, expect) {
+			t.Errorf("Expected the test imports %v with SkipTestImports %t, got %v", expect, skip, tl)
+		}
+	}
+}
+
+func TestIsStdlib(t *testing.T) {
+	tests := map[string]bool{
+		"fmt":                     true,
+		"net/http":                true,
+		"iter":                    true,
+		"github.com/example/dep":  false,
+		"gopkg.in/yaml.v2":        false,
+		"example.com":             false,
+		"./local":                 false,
+		"/abs/path":               false,
+		"C:/Users/example/go/src": false,
+	}
+	for name, expect := range tests {
+		if IsStdlib(name) != expect {
+			t.Errorf("Expected IsStdlib(%q) to be %t", name, expect)
+		}
+	}
+}
+
+func TestResolveNewStdlib(t *testing.T) {
+	dir, err := ioutil.TempDir("", "glide-stdlib")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// A package from a newer standard library than the Go in use.
+	p := filepath.Join(dir, "vendor", "github.com", "example", "dep", "dep.go")
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(p, []byte("package dep\n\nimport _ \"future/stdlib\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewResolver(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := &DefaultMissingPackageHandler{Missing: []string{}, Gopath: []string{}, Prefix: r.VendorDir}
+	r.Handler = h
+	if _, err := r.ResolveAll([]*cfg.Dependency{{Name: "github.com/example/dep"}}, false); err != nil {
+		t.Fatalf("Unexpected error resolving: %s", err)
+	}
+	if len(h.Missing) != 0 {
+		t.Errorf("Expected nothing to be missing, got %v", h.Missing)
+	}
+	if len(r.Config.Imports) != 1 || r.Config.Imports[0].Name != "github.com/example/dep" {
+		t.Errorf("Expected only the dependency to be imported, got %v", r.Config.Imports)
+	}
+}
+
+func TestResolverVendorName(t *testing.T) {
+	r, err := NewResolver("../")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.VendorName = strings.ToLower
+
+	p := r.vpath("github.com/Example/Lib/sub")
+	if p != filepath.Join(r.VendorDir, "github.com", "example", "lib", "sub") {
+		t.Errorf("Expected the vendor path to follow VendorName, got %s", p)
+	}
+	if n := r.Stripv(p); n != filepath.FromSlash("github.com/Example/Lib/sub") {
+		t.Errorf("Expected the import path back, got %s", n)
+	}
+
+	// A directory outside of the vendor directory keeps the import path.
+	r.VendorName = func(string) string { return "../outside" }
+	if p := r.vpath("github.com/example/lib"); p != filepath.Join(r.VendorDir, "github.com", "example", "lib") {
+		t.Errorf("Expected the import path layout for an invalid directory, got %s", p)
+	}
+}
+
+func TestFindPkgConfigBeforeStdlib(t *testing.T) {
+	dir, err := ioutil.TempDir("", "glide-stdlib")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	r, err := NewResolver(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.BuildContext.GOPATH = dir
+	r.Config = &cfg.Config{
+		Name:       "myapp",
+		Imports:    cfg.Dependencies{{Name: "corp/lib", Repository: "https://git.example.com/corp/lib"}},
+		DevImports: cfg.Dependencies{{Name: "corp/testlib", Repository: "https://git.example.com/corp/testlib"}},
+	}
+
+	tests := map[string]PkgLoc{
+		"myapp/sub":          LocUnknown,
+		"corp/lib":           LocUnknown,
+		"corp/lib/sub":       LocUnknown,
+		"corp/testlib":       LocUnknown,
+		"corp/library":       LocGoroot,
+		"future/stdlib":      LocGoroot,
+		"myapplication/util": LocGoroot,
+	}
+	for name, loc := range tests {
+		if info := r.FindPkg(name); info.Loc != loc {
+			t.Errorf("Expected %s to be found at %v, got %v", name, loc, info.Loc)
+		}
+	}
+}
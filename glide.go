@@ -32,6 +32,8 @@ import (
 
 	"fmt"
 	"os"
+	"strings"
+	"time"
 )
 
 var version = "0.13.4-dev"
@@ -61,6 +63,7 @@ func main() {
 	app.Name = "glide"
 	app.Usage = usage
 	app.Version = version
+	action.GlideVersion = version
 	app.Flags = []cli.Flag{
 		cli.StringFlag{
 			Name:  "yaml, y",
@@ -75,6 +78,11 @@ func main() {
 			Name:  "debug",
 			Usage: "Print debug verbose informational messages",
 		},
+		cli.StringFlag{
+			Name:   "log-level",
+			Usage:  "The least important messages to print: debug, info, warn or error. Errors are always printed",
+			EnvVar: "GLIDE_LOG_LEVEL",
+		},
 		cli.StringFlag{
 			Name:   "home",
 			Value:  gpath.Home(),
@@ -91,6 +99,16 @@ func main() {
 			Name:  "no-color",
 			Usage: "Turn off colored output for log messages",
 		},
+		cli.BoolFlag{
+			Name:   "canonical-repos",
+			Usage:  "Compare repositories and key the cache by their canonical location (e.g., ssh and https URLs for the same repo are equal)",
+			EnvVar: "GLIDE_CANONICAL_REPOS",
+		},
+		cli.StringFlag{
+			Name:   "http-proxy",
+			Usage:  "The proxy for Glide's own HTTP requests, such as finding where packages are. Overrides HTTP_PROXY, HTTPS_PROXY and NO_PROXY. VCS commands are not affected",
+			EnvVar: "GLIDE_HTTP_PROXY",
+		},
 	}
 	app.CommandNotFound = func(c *cli.Context, command string) {
 		// TODO: Set some useful env vars.
@@ -233,14 +251,158 @@ func commands() []cli.Command {
 					Name:  "strip-vendor, v",
 					Usage: "Removes nested vendor and Godeps/_workspace directories.",
 				},
+				cli.BoolFlag{
+					Name:  "hoist-vendor",
+					Usage: "With --strip-vendor, first copy the packages in nested vendor directories missing from vendor/ to it.",
+				},
 				cli.BoolFlag{
 					Name:  "non-interactive",
 					Usage: "Disable interactive prompts.",
 				},
+				cli.BoolFlag{
+					Name:  "dry-run",
+					Usage: "Report what would be added and vendored without writing glide.yaml, glide.lock or vendor/.",
+				},
+				cli.BoolFlag{
+					Name:  "no-verify",
+					Usage: "Add the packages to glide.yaml without first checking that they resolve.",
+				},
 				cli.BoolFlag{
 					Name:  "skip-test",
 					Usage: "Resolve dependencies in test files.",
 				},
+				cli.BoolFlag{
+					Name:  "allow-custom-checkout",
+					Usage: "Allow dependencies to use the checkout command set in their configuration.",
+				},
+				cli.BoolFlag{
+					Name:  "serial",
+					Usage: "Fetch dependencies one at a time. Useful for debugging.",
+				},
+				cli.BoolFlag{
+					Name:  "shallow",
+					Usage: "Clone git repositories added to the cache with only their newest commits.",
+				},
+				cli.StringFlag{
+					Name:  "auth-prompt",
+					Usage: "Whether the VCS tools may prompt for credentials: terminal to prompt on the terminal, or never to fail straight away.",
+				},
+				cli.StringFlag{
+					Name:  "credentials-mode",
+					Usage: "How credentials are passed to git: header in its environment, the default which needs git 2.31, or url in the URL it fetches from.",
+				},
+				cli.StringFlag{
+					Name:  "missing-revision",
+					Usage: "How a cached repository without a pinned commit is updated: fetch to fetch only the commit, or refresh to fetch all updates.",
+				},
+				cli.StringFlag{
+					Name:  "mirror-dir",
+					Usage: "Fetch dependencies from the bare Git repositories in this directory when present.",
+				},
+				cli.BoolFlag{
+					Name:  "offline",
+					Usage: "Fail for dependencies missing from --mirror-dir instead of using the network.",
+				},
+				cli.StringFlag{
+					Name:  "overlay",
+					Usage: "Use the copies of dependencies in this directory, stored by import path, instead of fetching them.",
+				},
+				cli.StringFlag{
+					Name:  "gopath-policy",
+					Usage: "What to do with packages found in the GOPATH while resolving: ignore and fetch them (the default), copy them and their dependencies from the GOPATH, or fail.",
+				},
+				cli.StringFlag{
+					Name:  "preferred-branch",
+					Usage: "Use this branch for dependencies without a version when they have it.",
+				},
+				cli.StringFlag{
+					Name:  "snapshot",
+					Usage: "Resolve versions as they were at this time, e.g. 2017-06-01 or 2017-06-01T15:04:05Z.",
+				},
+				cli.BoolFlag{
+					Name:  "include-prerelease",
+					Usage: "Consider pre-release tags when resolving semantic version ranges.",
+				},
+				cli.BoolFlag{
+					Name:  "latest-tag",
+					Usage: "Use the highest semantic version tag, rather than the newest commit, for dependencies without a version.",
+				},
+				cli.BoolFlag{
+					Name:  "strict-subpackages",
+					Usage: "Fail when an imported subpackage is missing from the version of its dependency.",
+				},
+				cli.StringFlag{
+					Name:  "conflict",
+					Usage: "How conflicting versions of a dependency are resolved: first-wins, highest-semver or fail.",
+				},
+				cli.StringFlag{
+					Name:  "max-vendor-size",
+					Usage: "Fail when the vendor directory would exceed this size, e.g. 50MB.",
+				},
+				cli.DurationFlag{
+					Name:  "cache-ttl",
+					Usage: "Use cached dependencies fetched within this long, e.g. 1h, rather than fetching updates.",
+				},
+				cli.IntFlag{
+					Name:  "max-retries",
+					Usage: "Retry fetching a dependency this many times after a network or server failure.",
+				},
+				cli.DurationFlag{
+					Name:  "retry-backoff",
+					Usage: "Wait this long, e.g. 2s, before the first retry. The wait doubles for each retry after.",
+				},
+				cli.DurationFlag{
+					Name:  "op-timeout",
+					Usage: "Stop a git clone or update of a dependency taking longer than this, e.g. 5m, and count it as a failure. This applies to git only, other VCS run without a limit.",
+				},
+				cli.BoolFlag{
+					Name:  "fetch-lfs",
+					Usage: "Fetch the git LFS content of dependencies using it. Requires git-lfs.",
+				},
+				cli.BoolFlag{
+					Name:  "keep-vcs",
+					Usage: "Keep the VCS metadata (e.g., .git directory) of dependencies in the vendor folder.",
+				},
+				cli.BoolFlag{
+					Name:  "atomic-swap",
+					Usage: "Export to a new vendor directory beside the existing one and swap them once complete.",
+				},
+				cli.BoolFlag{
+					Name:  "store",
+					Usage: "Copy dependencies into vendor/ from a store in the cache shared between projects.",
+				},
+				cli.BoolFlag{
+					Name:  "store-links",
+					Usage: "Hard link the files from the store into vendor/ rather than copying them. Editing them edits the store.",
+				},
+				cli.BoolFlag{
+					Name:  "verify-build",
+					Usage: "Build the vendored packages with the go tool after exporting them and fail if they don't compile.",
+				},
+				cli.StringFlag{
+					Name:  "build-tags",
+					Usage: "Build tags, separated by commas or spaces, used with --verify-build.",
+				},
+				cli.StringFlag{
+					Name:  "goos",
+					Usage: "Resolve dependencies for this GOOS rather than the one Glide runs on.",
+				},
+				cli.StringFlag{
+					Name:  "goarch",
+					Usage: "Resolve dependencies for this GOARCH rather than the one Glide runs on.",
+				},
+				cli.BoolFlag{
+					Name:  "record-warnings",
+					Usage: "Record warnings about how dependencies were resolved, such as conflicts, in the lock file.",
+				},
+				cli.StringSliceFlag{
+					Name:  "include",
+					Usage: "Only add packages matching this pattern, e.g. github.com/example/*. Can be repeated.",
+				},
+				cli.StringSliceFlag{
+					Name:  "exclude",
+					Usage: "Do not add packages matching this pattern. Can be repeated.",
+				},
 			},
 			Action: func(c *cli.Context) error {
 				if c.Bool("delete") {
@@ -276,9 +438,49 @@ func commands() []cli.Command {
 				}
 
 				inst := repo.NewInstaller()
+				inst.CanonicalRepos = c.GlobalBool("canonical-repos")
 				inst.Force = c.Bool("force")
 				inst.ResolveAllFiles = c.Bool("all-dependencies")
 				inst.ResolveTest = !c.Bool("skip-test")
+				inst.HoistVendor = c.Bool("hoist-vendor")
+				inst.AllowCustomCheckout = c.Bool("allow-custom-checkout")
+				inst.Serial = c.Bool("serial")
+				inst.Shallow = c.Bool("shallow")
+				inst.AuthPrompt = authPrompt(c)
+				inst.CredentialsMode = credentialsMode(c)
+				inst.MissingRevision = missingRevision(c)
+				inst.MirrorDir = c.String("mirror-dir")
+				inst.Offline = c.Bool("offline")
+				inst.Overlay = c.String("overlay")
+				inst.GopathPolicy = gopathPolicy(c)
+				inst.PreferredBranch = c.String("preferred-branch")
+				inst.Snapshot = snapshot(c)
+				inst.IncludePrerelease = c.Bool("include-prerelease")
+				inst.LatestTag = c.Bool("latest-tag")
+				inst.StrictSubpackages = c.Bool("strict-subpackages")
+				inst.ConflictStrategy = conflictStrategy(c)
+				inst.MaxVendorSize = maxVendorSize(c)
+				inst.CacheTTL = c.Duration("cache-ttl")
+				inst.MaxRetries = c.Int("max-retries")
+				inst.RetryBackoff = c.Duration("retry-backoff")
+				inst.OpTimeout = c.Duration("op-timeout")
+				inst.AtomicSwap = c.Bool("atomic-swap")
+				inst.Store = c.Bool("store")
+				inst.StoreLinks = c.Bool("store-links")
+				inst.FetchLFS = c.Bool("fetch-lfs")
+				inst.KeepVCS = c.Bool("keep-vcs")
+				inst.VerifyBuild = c.Bool("verify-build")
+				inst.BuildTags = buildTags(c)
+				inst.GOOS = c.String("goos")
+				inst.GOARCH = c.String("goarch")
+				inst.RecordWarnings = c.Bool("record-warnings")
+				inst.Replace = replaceRules()
+				inst.Credentials = credentials()
+				inst.SSHKeys = sshKeys()
+				inst.DryRun = c.Bool("dry-run")
+				inst.VerifyAdded = !c.Bool("no-verify")
+				inst.Include = c.StringSlice("include")
+				inst.Exclude = c.StringSlice("exclude")
 				packages := []string(c.Args())
 				insecure := c.Bool("insecure")
 				action.Get(packages, inst, insecure, c.Bool("no-recursive"), c.Bool("strip-vendor"), c.Bool("non-interactive"), c.Bool("test"))
@@ -296,6 +498,10 @@ func commands() []cli.Command {
 					Name:  "delete,d",
 					Usage: "Also delete from vendor/ any packages that are no longer used.",
 				},
+				cli.BoolFlag{
+					Name:  "skip-test",
+					Usage: "Do not keep test dependencies in vendor/. Use when they were skipped on install.",
+				},
 			},
 			Action: func(c *cli.Context) error {
 				if len(c.Args()) < 1 {
@@ -308,7 +514,12 @@ func commands() []cli.Command {
 					fmt.Println("Delete is not currently implemented.")
 				}
 				inst := repo.NewInstaller()
+				inst.CanonicalRepos = c.GlobalBool("canonical-repos")
 				inst.Force = c.Bool("force")
+				inst.ResolveTest = !c.Bool("skip-test")
+				inst.Replace = replaceRules()
+				inst.Credentials = credentials()
+				inst.SSHKeys = sshKeys()
 				packages := []string(c.Args())
 				action.Remove(packages, inst)
 				return nil
@@ -385,6 +596,49 @@ func commands() []cli.Command {
 				return nil
 			},
 		},
+		{
+			Name:  "validate",
+			Usage: "Check the glide.yaml file for problems.",
+			Description: `Report mistakes in the glide.yaml file such as unknown keys, repeated or
+   unnamed packages, URLs where a package name or version belongs and
+   subpackages repeating the package name. Versions that may be branches are
+   reported as warnings. Exits with an error when there are any errors.`,
+			Action: func(c *cli.Context) error {
+				action.Validate()
+				return nil
+			},
+		},
+		{
+			Name:  "format",
+			Usage: "Sort and tidy the glide.yaml file.",
+			Description: `Rewrite the glide.yaml file with the imports and test imports sorted by
+   name, their subpackages sorted and packages listed more than once merged.
+   Running it again makes no changes. Comments in the file are removed and a
+   warning is printed when it has any.`,
+			Action: func(c *cli.Context) error {
+				action.Format()
+				return nil
+			},
+		},
+		{
+			Name:  "flatten",
+			Usage: "Remove the vendor directories nested in dependencies.",
+			Description: `Copy the packages in the vendor directories nested in dependencies to the
+   vendor/ directory of the project, when it doesn't already have them, and
+   remove the nested vendor and Godeps/_workspace directories. When a package
+   is in both with different content the one in vendor/ is kept and a warning
+   is printed. The vendor/ directory of the project is never removed.`,
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "no-hoist",
+					Usage: "Only remove the nested directories without copying their packages to vendor/.",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				action.Flatten(!c.Bool("no-hoist"))
+				return nil
+			},
+		},
 		{
 			Name:      "novendor",
 			ShortName: "nv",
@@ -474,10 +728,154 @@ Example:
 					Name:  "strip-vendor, v",
 					Usage: "Removes nested vendor and Godeps/_workspace directories.",
 				},
+				cli.BoolFlag{
+					Name:  "hoist-vendor",
+					Usage: "With --strip-vendor, first copy the packages in nested vendor directories missing from vendor/ to it.",
+				},
 				cli.BoolFlag{
 					Name:  "skip-test",
 					Usage: "Resolve dependencies in test files.",
 				},
+				cli.BoolFlag{
+					Name:  "dev",
+					Usage: "Install the test dependencies in glide.lock. This is the default.",
+				},
+				cli.BoolFlag{
+					Name:  "no-dev",
+					Usage: "Do not install the test dependencies in glide.lock. Those already in vendor/ are kept.",
+				},
+				cli.BoolFlag{
+					Name:  "allow-custom-checkout",
+					Usage: "Allow dependencies to use the checkout command set in their configuration.",
+				},
+				cli.BoolFlag{
+					Name:  "serial",
+					Usage: "Fetch dependencies one at a time. Useful for debugging.",
+				},
+				cli.BoolFlag{
+					Name:  "shallow",
+					Usage: "Clone git repositories added to the cache with only their newest commits.",
+				},
+				cli.IntFlag{
+					Name:  "concurrency",
+					Usage: "The number of dependencies to fetch at once. Defaults to 20.",
+				},
+				cli.BoolFlag{
+					Name:  "dry-run",
+					Usage: "List the packages that would be removed from vendor/ without writing glide.lock or vendor/.",
+				},
+				cli.StringFlag{
+					Name:  "auth-prompt",
+					Usage: "Whether the VCS tools may prompt for credentials: terminal to prompt on the terminal, or never to fail straight away.",
+				},
+				cli.StringFlag{
+					Name:  "credentials-mode",
+					Usage: "How credentials are passed to git: header in its environment, the default which needs git 2.31, or url in the URL it fetches from.",
+				},
+				cli.StringFlag{
+					Name:  "missing-revision",
+					Usage: "How a cached repository without a pinned commit is updated: fetch to fetch only the commit, or refresh to fetch all updates.",
+				},
+				cli.StringFlag{
+					Name:  "mirror-dir",
+					Usage: "Fetch dependencies from the bare Git repositories in this directory when present.",
+				},
+				cli.BoolFlag{
+					Name:  "offline",
+					Usage: "Fail for dependencies missing from --mirror-dir instead of using the network.",
+				},
+				cli.StringFlag{
+					Name:  "overlay",
+					Usage: "Use the copies of dependencies in this directory, stored by import path, instead of fetching them.",
+				},
+				cli.StringFlag{
+					Name:  "gopath-policy",
+					Usage: "What to do with packages found in the GOPATH while resolving: ignore and fetch them (the default), copy them and their dependencies from the GOPATH, or fail.",
+				},
+				cli.BoolFlag{
+					Name:  "strict",
+					Usage: "Fail when dependencies are skipped because of a problem.",
+				},
+				cli.BoolFlag{
+					Name:  "quarantine",
+					Usage: "Skip dependencies that cannot be fetched instead of failing. Ignored with --strict.",
+				},
+				cli.StringFlag{
+					Name:  "failure-report",
+					Usage: "Write a JSON report of the dependencies that failed to be fetched to this file.",
+				},
+				cli.BoolFlag{
+					Name:  "strict-subpackages",
+					Usage: "Fail when an imported subpackage is missing from the version of its dependency.",
+				},
+				cli.StringFlag{
+					Name:  "conflict",
+					Usage: "How conflicting versions of a dependency are resolved: first-wins, highest-semver or fail.",
+				},
+				cli.StringFlag{
+					Name:  "max-vendor-size",
+					Usage: "Fail when the vendor directory would exceed this size, e.g. 50MB.",
+				},
+				cli.DurationFlag{
+					Name:  "cache-ttl",
+					Usage: "Use cached dependencies fetched within this long, e.g. 1h, rather than fetching updates.",
+				},
+				cli.IntFlag{
+					Name:  "max-retries",
+					Usage: "Retry fetching a dependency this many times after a network or server failure.",
+				},
+				cli.DurationFlag{
+					Name:  "retry-backoff",
+					Usage: "Wait this long, e.g. 2s, before the first retry. The wait doubles for each retry after.",
+				},
+				cli.DurationFlag{
+					Name:  "op-timeout",
+					Usage: "Stop a git clone or update of a dependency taking longer than this, e.g. 5m, and count it as a failure. This applies to git only, other VCS run without a limit.",
+				},
+				cli.BoolFlag{
+					Name:  "fetch-lfs",
+					Usage: "Fetch the git LFS content of dependencies using it. Requires git-lfs.",
+				},
+				cli.BoolFlag{
+					Name:  "keep-vcs",
+					Usage: "Keep the VCS metadata (e.g., .git directory) of dependencies in the vendor folder.",
+				},
+				cli.BoolFlag{
+					Name:  "atomic-swap",
+					Usage: "Export to a new vendor directory beside the existing one and swap them once complete.",
+				},
+				cli.BoolFlag{
+					Name:  "store",
+					Usage: "Copy dependencies into vendor/ from a store in the cache shared between projects.",
+				},
+				cli.BoolFlag{
+					Name:  "store-links",
+					Usage: "Hard link the files from the store into vendor/ rather than copying them. Editing them edits the store.",
+				},
+				cli.BoolFlag{
+					Name:  "verify-build",
+					Usage: "Build the vendored packages with the go tool after exporting them and fail if they don't compile.",
+				},
+				cli.StringFlag{
+					Name:  "build-tags",
+					Usage: "Build tags, separated by commas or spaces, used with --verify-build.",
+				},
+				cli.StringFlag{
+					Name:  "goos",
+					Usage: "Resolve dependencies for this GOOS rather than the one Glide runs on.",
+				},
+				cli.StringFlag{
+					Name:  "goarch",
+					Usage: "Resolve dependencies for this GOARCH rather than the one Glide runs on.",
+				},
+				cli.BoolFlag{
+					Name:  "check",
+					Usage: "Check that glide.lock matches glide.yaml and vendor/ matches glide.lock without installing. Fails when they don't.",
+				},
+				cli.BoolFlag{
+					Name:  "check-behind",
+					Usage: "Warn about dependencies pinned to a commit that their branch has moved ahead of.",
+				},
 			},
 			Action: func(c *cli.Context) error {
 				if c.Bool("delete") {
@@ -503,11 +901,52 @@ Example:
 				}
 
 				installer := repo.NewInstaller()
+				installer.CanonicalRepos = c.GlobalBool("canonical-repos")
 				installer.Force = c.Bool("force")
 				installer.Home = c.GlobalString("home")
 				installer.ResolveTest = !c.Bool("skip-test")
-
-				action.Install(installer, c.Bool("strip-vendor"))
+				installer.HoistVendor = c.Bool("hoist-vendor")
+				installer.AllowCustomCheckout = c.Bool("allow-custom-checkout")
+				installer.Serial = c.Bool("serial")
+				installer.Shallow = c.Bool("shallow")
+				installer.Concurrency = concurrency(c)
+				installer.DryRun = c.Bool("dry-run")
+				installer.AuthPrompt = authPrompt(c)
+				installer.CredentialsMode = credentialsMode(c)
+				installer.MissingRevision = missingRevision(c)
+				installer.MirrorDir = c.String("mirror-dir")
+				installer.Offline = c.Bool("offline")
+				installer.Overlay = c.String("overlay")
+				installer.GopathPolicy = gopathPolicy(c)
+				installer.Strict = c.Bool("strict")
+				installer.Quarantine = c.Bool("quarantine")
+				installer.FailureReportFile = c.String("failure-report")
+				installer.StrictSubpackages = c.Bool("strict-subpackages")
+				installer.ConflictStrategy = conflictStrategy(c)
+				installer.MaxVendorSize = maxVendorSize(c)
+				installer.CacheTTL = c.Duration("cache-ttl")
+				installer.MaxRetries = c.Int("max-retries")
+				installer.RetryBackoff = c.Duration("retry-backoff")
+				installer.OpTimeout = c.Duration("op-timeout")
+				installer.AtomicSwap = c.Bool("atomic-swap")
+				installer.Store = c.Bool("store")
+				installer.StoreLinks = c.Bool("store-links")
+				installer.FetchLFS = c.Bool("fetch-lfs")
+				installer.KeepVCS = c.Bool("keep-vcs")
+				installer.VerifyBuild = c.Bool("verify-build")
+				installer.BuildTags = buildTags(c)
+				installer.GOOS = c.String("goos")
+				installer.GOARCH = c.String("goarch")
+				installer.CheckBehind = c.Bool("check-behind")
+				installer.Replace = replaceRules()
+				installer.Credentials = credentials()
+				installer.SSHKeys = sshKeys()
+
+				if c.Bool("check") {
+					action.Check(installer)
+					return nil
+				}
+				action.Install(installer, c.Bool("strip-vendor"), useDev(c))
 				return nil
 			},
 		},
@@ -515,6 +954,7 @@ Example:
 			Name:      "update",
 			ShortName: "up",
 			Usage:     "Update a project's dependencies",
+			ArgsUsage: "[package...]",
 			Description: `This updates the dependencies by scanning the codebase
    to determine the needed dependencies and fetching them following the rules
    in the glide.yaml file. When no rules exist the tip of the default branch
@@ -535,7 +975,11 @@ Example:
    'Godeps/_workspace' folders after an update (along with undoing any Godep
    import rewriting). Note, the Godeps specific functionality is deprecated and
    will be removed when most Godeps users have migrated to using the vendor
-   folder.`,
+   folder.
+
+   When packages are named only they, and the dependencies they bring in that
+   are not in the glide.yaml file, are updated. Every other dependency stays
+   at its version in the glide.lock file.`,
 			Flags: []cli.Flag{
 				cli.BoolFlag{
 					Name:   "delete",
@@ -592,10 +1036,178 @@ Example:
 					Name:  "strip-vendor, v",
 					Usage: "Removes nested vendor and Godeps/_workspace directories.",
 				},
+				cli.BoolFlag{
+					Name:  "hoist-vendor",
+					Usage: "With --strip-vendor, first copy the packages in nested vendor directories missing from vendor/ to it.",
+				},
 				cli.BoolFlag{
 					Name:  "skip-test",
 					Usage: "Resolve dependencies in test files.",
 				},
+				cli.BoolFlag{
+					Name:  "allow-custom-checkout",
+					Usage: "Allow dependencies to use the checkout command set in their configuration.",
+				},
+				cli.BoolFlag{
+					Name:  "serial",
+					Usage: "Fetch dependencies one at a time. Useful for debugging.",
+				},
+				cli.BoolFlag{
+					Name:  "shallow",
+					Usage: "Clone git repositories added to the cache with only their newest commits.",
+				},
+				cli.IntFlag{
+					Name:  "concurrency",
+					Usage: "The number of dependencies to fetch at once. Defaults to 20.",
+				},
+				cli.BoolFlag{
+					Name:  "dry-run",
+					Usage: "List the packages that would be removed from vendor/ without writing glide.lock or vendor/.",
+				},
+				cli.BoolFlag{
+					Name:  "only-changed",
+					Usage: "Only update the dependencies changed in glide.yaml since glide.lock was written.",
+				},
+				cli.StringFlag{
+					Name:  "auth-prompt",
+					Usage: "Whether the VCS tools may prompt for credentials: terminal to prompt on the terminal, or never to fail straight away.",
+				},
+				cli.StringFlag{
+					Name:  "credentials-mode",
+					Usage: "How credentials are passed to git: header in its environment, the default which needs git 2.31, or url in the URL it fetches from.",
+				},
+				cli.StringFlag{
+					Name:  "missing-revision",
+					Usage: "How a cached repository without a pinned commit is updated: fetch to fetch only the commit, or refresh to fetch all updates.",
+				},
+				cli.StringFlag{
+					Name:  "mirror-dir",
+					Usage: "Fetch dependencies from the bare Git repositories in this directory when present.",
+				},
+				cli.BoolFlag{
+					Name:  "offline",
+					Usage: "Fail for dependencies missing from --mirror-dir instead of using the network.",
+				},
+				cli.StringFlag{
+					Name:  "overlay",
+					Usage: "Use the copies of dependencies in this directory, stored by import path, instead of fetching them.",
+				},
+				cli.StringFlag{
+					Name:  "gopath-policy",
+					Usage: "What to do with packages found in the GOPATH while resolving: ignore and fetch them (the default), copy them and their dependencies from the GOPATH, or fail.",
+				},
+				cli.BoolFlag{
+					Name:  "strict",
+					Usage: "Fail when dependencies are skipped because of a problem or downgraded.",
+				},
+				cli.BoolFlag{
+					Name:  "quarantine",
+					Usage: "Skip dependencies that cannot be fetched instead of failing. Ignored with --strict.",
+				},
+				cli.StringFlag{
+					Name:  "failure-report",
+					Usage: "Write a JSON report of the dependencies that failed to be fetched to this file.",
+				},
+				cli.StringFlag{
+					Name:  "preferred-branch",
+					Usage: "Use this branch for dependencies without a version when they have it.",
+				},
+				cli.StringFlag{
+					Name:  "snapshot",
+					Usage: "Resolve versions as they were at this time, e.g. 2017-06-01 or 2017-06-01T15:04:05Z.",
+				},
+				cli.BoolFlag{
+					Name:  "include-prerelease",
+					Usage: "Consider pre-release tags when resolving semantic version ranges.",
+				},
+				cli.BoolFlag{
+					Name:  "latest-tag",
+					Usage: "Use the highest semantic version tag, rather than the newest commit, for dependencies without a version.",
+				},
+				cli.BoolFlag{
+					Name:  "strict-subpackages",
+					Usage: "Fail when an imported subpackage is missing from the version of its dependency.",
+				},
+				cli.StringFlag{
+					Name:  "conflict",
+					Usage: "How conflicting versions of a dependency are resolved: first-wins, highest-semver or fail.",
+				},
+				cli.StringFlag{
+					Name:  "max-vendor-size",
+					Usage: "Fail when the vendor directory would exceed this size, e.g. 50MB.",
+				},
+				cli.DurationFlag{
+					Name:  "cache-ttl",
+					Usage: "Use cached dependencies fetched within this long, e.g. 1h, rather than fetching updates.",
+				},
+				cli.IntFlag{
+					Name:  "max-retries",
+					Usage: "Retry fetching a dependency this many times after a network or server failure.",
+				},
+				cli.DurationFlag{
+					Name:  "retry-backoff",
+					Usage: "Wait this long, e.g. 2s, before the first retry. The wait doubles for each retry after.",
+				},
+				cli.DurationFlag{
+					Name:  "op-timeout",
+					Usage: "Stop a git clone or update of a dependency taking longer than this, e.g. 5m, and count it as a failure. This applies to git only, other VCS run without a limit.",
+				},
+				cli.BoolFlag{
+					Name:  "fetch-lfs",
+					Usage: "Fetch the git LFS content of dependencies using it. Requires git-lfs.",
+				},
+				cli.BoolFlag{
+					Name:  "keep-vcs",
+					Usage: "Keep the VCS metadata (e.g., .git directory) of dependencies in the vendor folder.",
+				},
+				cli.BoolFlag{
+					Name:  "atomic-swap",
+					Usage: "Export to a new vendor directory beside the existing one and swap them once complete.",
+				},
+				cli.BoolFlag{
+					Name:  "store",
+					Usage: "Copy dependencies into vendor/ from a store in the cache shared between projects.",
+				},
+				cli.BoolFlag{
+					Name:  "store-links",
+					Usage: "Hard link the files from the store into vendor/ rather than copying them. Editing them edits the store.",
+				},
+				cli.BoolFlag{
+					Name:  "verify-build",
+					Usage: "Build the vendored packages with the go tool after exporting them and fail if they don't compile.",
+				},
+				cli.StringFlag{
+					Name:  "build-tags",
+					Usage: "Build tags, separated by commas or spaces, used with --verify-build.",
+				},
+				cli.StringFlag{
+					Name:  "goos",
+					Usage: "Resolve dependencies for this GOOS rather than the one Glide runs on.",
+				},
+				cli.StringFlag{
+					Name:  "goarch",
+					Usage: "Resolve dependencies for this GOARCH rather than the one Glide runs on.",
+				},
+				cli.BoolFlag{
+					Name:  "record-warnings",
+					Usage: "Record warnings about how dependencies were resolved, such as conflicts, in the lock file.",
+				},
+				cli.StringFlag{
+					Name:  "unused",
+					Usage: "What to do with imports in glide.yaml the project does not import: warn or skip fetching them.",
+				},
+				cli.StringSliceFlag{
+					Name:  "root",
+					Usage: "Resolve only from this local package rather than the whole project. Can be passed multiple times.",
+				},
+				cli.StringFlag{
+					Name:  "lock-file",
+					Usage: "Write the lock file to this path. Use with --root to keep a scoped lock file.",
+				},
+				cli.StringFlag{
+					Name:  "resolved",
+					Usage: "Use the already resolved dependencies in this file, in the lock file format, instead of resolving.",
+				},
 			},
 			Action: func(c *cli.Context) error {
 				if c.Bool("delete") {
@@ -626,10 +1238,57 @@ Example:
 				}
 
 				installer := repo.NewInstaller()
+				installer.CanonicalRepos = c.GlobalBool("canonical-repos")
 				installer.Force = c.Bool("force")
 				installer.ResolveAllFiles = c.Bool("all-dependencies")
 				installer.Home = c.GlobalString("home")
 				installer.ResolveTest = !c.Bool("skip-test")
+				installer.HoistVendor = c.Bool("hoist-vendor")
+				installer.AllowCustomCheckout = c.Bool("allow-custom-checkout")
+				installer.Serial = c.Bool("serial")
+				installer.Shallow = c.Bool("shallow")
+				installer.Concurrency = concurrency(c)
+				installer.DryRun = c.Bool("dry-run")
+				installer.OnlyChanged = c.Bool("only-changed")
+				installer.AuthPrompt = authPrompt(c)
+				installer.CredentialsMode = credentialsMode(c)
+				installer.MissingRevision = missingRevision(c)
+				installer.MirrorDir = c.String("mirror-dir")
+				installer.Offline = c.Bool("offline")
+				installer.Overlay = c.String("overlay")
+				installer.GopathPolicy = gopathPolicy(c)
+				installer.Strict = c.Bool("strict")
+				installer.Quarantine = c.Bool("quarantine")
+				installer.FailureReportFile = c.String("failure-report")
+				installer.PreferredBranch = c.String("preferred-branch")
+				installer.Snapshot = snapshot(c)
+				installer.IncludePrerelease = c.Bool("include-prerelease")
+				installer.LatestTag = c.Bool("latest-tag")
+				installer.StrictSubpackages = c.Bool("strict-subpackages")
+				installer.ConflictStrategy = conflictStrategy(c)
+				installer.MaxVendorSize = maxVendorSize(c)
+				installer.CacheTTL = c.Duration("cache-ttl")
+				installer.MaxRetries = c.Int("max-retries")
+				installer.RetryBackoff = c.Duration("retry-backoff")
+				installer.OpTimeout = c.Duration("op-timeout")
+				installer.AtomicSwap = c.Bool("atomic-swap")
+				installer.Store = c.Bool("store")
+				installer.StoreLinks = c.Bool("store-links")
+				installer.FetchLFS = c.Bool("fetch-lfs")
+				installer.KeepVCS = c.Bool("keep-vcs")
+				installer.VerifyBuild = c.Bool("verify-build")
+				installer.BuildTags = buildTags(c)
+				installer.GOOS = c.String("goos")
+				installer.GOARCH = c.String("goarch")
+				installer.RecordWarnings = c.Bool("record-warnings")
+				installer.Unused = unusedPolicy(c)
+				installer.Replace = replaceRules()
+				installer.Credentials = credentials()
+				installer.SSHKeys = sshKeys()
+				installer.Roots = c.StringSlice("root")
+				installer.ResolvedFile = c.String("resolved")
+				installer.LockFile = c.String("lock-file")
+				installer.Only = c.Args()
 
 				action.Update(installer, c.Bool("no-recursive"), c.Bool("strip-vendor"))
 
@@ -653,6 +1312,60 @@ Example:
 				return nil
 			},
 		},
+		{
+			Name:  "graph",
+			Usage: "Print the import graph of the dependencies.",
+			Description: `Resolve the dependencies in the vendor/ directory and print which root
+   packages each one imports, with subpackages grouped under their root
+   package. The dot format can be rendered with Graphviz and highlights the
+   project, for example:
+
+       $ glide graph | dot -Tsvg > deps.svg
+
+   The json format maps each root package to those it imports.`,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "format, f",
+					Usage: "Output format. One of: dot|json",
+					Value: "dot",
+				},
+				cli.BoolFlag{
+					Name:  "skip-test",
+					Usage: "Leave out the imports of the tests of the project.",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				installer := repo.NewInstaller()
+				installer.CanonicalRepos = c.GlobalBool("canonical-repos")
+				installer.Home = c.GlobalString("home")
+				installer.ResolveTest = !c.Bool("skip-test")
+				action.Graph(installer, c.String("format"))
+				return nil
+			},
+		},
+		{
+			Name:  "verify",
+			Usage: "Verify the vendor/ directory matches glide.lock.",
+			Description: `Check each dependency in glide.lock is in the vendor/ directory and has
+   not been changed since, using the digest of its content recorded in
+   glide.lock or, for a dependency kept with its VCS metadata, the revision it
+   is at. Nothing is fetched so it can be run offline, such as in CI. Fails
+   listing the dependencies that don't match.`,
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "skip-test",
+					Usage: "Do not verify the test dependencies.",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				installer := repo.NewInstaller()
+				installer.CanonicalRepos = c.GlobalBool("canonical-repos")
+				installer.Home = c.GlobalString("home")
+				installer.ResolveTest = !c.Bool("skip-test")
+				action.Verify(installer)
+				return nil
+			},
+		},
 		{
 			Name:  "list",
 			Usage: "List prints all dependencies that the present code references.",
@@ -830,9 +1543,13 @@ func startup(c *cli.Context) error {
 	action.Debug(c.Bool("debug"))
 	action.NoColor(c.Bool("no-color"))
 	action.Quiet(c.Bool("quiet"))
+	action.LogLevel(c.String("log-level"))
 	action.Init(c.String("yaml"), c.String("home"))
 	action.EnsureGoVendor()
 	gpath.Tmp = c.String("tmp")
+	if err := util.SetHTTPProxy(c.String("http-proxy")); err != nil {
+		msg.Die("%s", err)
+	}
 	return nil
 }
 
@@ -841,6 +1558,137 @@ func shutdown(c *cli.Context) error {
 	return nil
 }
 
+// replaceRules returns the replace rules set in the environment. Invalid
+// rules are fatal rather than being ignored.
+func replaceRules() repo.ReplaceRules {
+	rules, err := repo.ReplaceRulesFromEnv()
+	if err != nil {
+		msg.Die("Unable to read %s: %s", repo.ReplaceEnv, err)
+	}
+	return rules
+}
+
+// credentials reads the credentials in the glide home directory and the
+// environment.
+func credentials() repo.Credentials {
+	creds, err := repo.ReadCredentials(gpath.Home())
+	if err != nil {
+		msg.Die("Unable to read credentials: %s", err)
+	}
+	return creds
+}
+
+// sshKeys reads the SSH keys in the glide home directory.
+func sshKeys() repo.SSHKeys {
+	keys, err := repo.ReadSSHKeys(gpath.Home())
+	if err != nil {
+		msg.Die("Unable to read SSH keys: %s", err)
+	}
+	return keys
+}
+
+// buildTags splits the --build-tags flag on commas and spaces.
+func buildTags(c *cli.Context) []string {
+	return strings.FieldsFunc(c.String("build-tags"), func(r rune) bool {
+		return r == ',' || r == ' '
+	})
+}
+
+// unusedPolicy reads the --unused flag.
+func unusedPolicy(c *cli.Context) string {
+	p := c.String("unused")
+	if p != "" && p != repo.UnusedWarn && p != repo.UnusedSkip {
+		msg.Die("Unknown value %q for --unused, expected %s or %s", p, repo.UnusedWarn, repo.UnusedSkip)
+	}
+	return p
+}
+
+// gopathPolicy reads the --gopath-policy flag.
+func gopathPolicy(c *cli.Context) string {
+	p := c.String("gopath-policy")
+	if p != "" && p != repo.GopathIgnore && p != repo.GopathCopy && p != repo.GopathFail {
+		msg.Die("Unknown value %q for --gopath-policy, expected %s, %s or %s", p, repo.GopathIgnore, repo.GopathCopy, repo.GopathFail)
+	}
+	return p
+}
+
+// authPrompt reads the --auth-prompt flag.
+func authPrompt(c *cli.Context) string {
+	p := c.String("auth-prompt")
+	if p != "" && p != repo.AuthPromptTerminal && p != repo.AuthPromptNever {
+		msg.Die("Unknown value %q for --auth-prompt, expected %s or %s", p, repo.AuthPromptTerminal, repo.AuthPromptNever)
+	}
+	return p
+}
+
+// credentialsMode reads the --credentials-mode flag.
+func credentialsMode(c *cli.Context) string {
+	m := c.String("credentials-mode")
+	if m != "" && m != repo.CredentialsHeader && m != repo.CredentialsURL {
+		msg.Die("Unknown value %q for --credentials-mode, expected %s or %s", m, repo.CredentialsHeader, repo.CredentialsURL)
+	}
+	return m
+}
+
+// conflictStrategy reads the --conflict flag.
+func conflictStrategy(c *cli.Context) string {
+	p := c.String("conflict")
+	if p != "" && p != repo.ConflictFirstWins && p != repo.ConflictHighestSemver && p != repo.ConflictFail {
+		msg.Die("Unknown value %q for --conflict, expected %s, %s or %s", p, repo.ConflictFirstWins, repo.ConflictHighestSemver, repo.ConflictFail)
+	}
+	return p
+}
+
+// missingRevision reads the --missing-revision flag.
+func missingRevision(c *cli.Context) string {
+	p := c.String("missing-revision")
+	if p != "" && p != repo.MissingRevisionFetch && p != repo.MissingRevisionRefresh {
+		msg.Die("Unknown value %q for --missing-revision, expected %s or %s", p, repo.MissingRevisionFetch, repo.MissingRevisionRefresh)
+	}
+	return p
+}
+
+// snapshot parses the --snapshot flag.
+func snapshot(c *cli.Context) time.Time {
+	if c.String("snapshot") == "" {
+		return time.Time{}
+	}
+	t, err := repo.ParseSnapshot(c.String("snapshot"))
+	if err != nil {
+		msg.Die("Unable to read --snapshot: %s", err)
+	}
+	return t
+}
+
+// maxVendorSize parses the --max-vendor-size flag.
+func maxVendorSize(c *cli.Context) int64 {
+	if c.String("max-vendor-size") == "" {
+		return 0
+	}
+	size, err := repo.ParseSize(c.String("max-vendor-size"))
+	if err != nil {
+		msg.Die("Unable to read --max-vendor-size: %s", err)
+	}
+	return size
+}
+
+// concurrency reads the --concurrency flag.
+func concurrency(c *cli.Context) int {
+	n := c.Int("concurrency")
+	if n < 0 {
+		msg.Die("Invalid value %d for --concurrency, expected a positive number or 0 for the default", n)
+	}
+	return n
+}
+
+// useDev reads the --dev and --no-dev flags.
+func useDev(c *cli.Context) bool {
+	if c.Bool("dev") && c.Bool("no-dev") {
+		msg.Die("The --dev and --no-dev flags cannot be used together")
+	}
+	return !c.Bool("no-dev")
+}
+
 // Get the path to the glide.yaml file.
 //
 // This returns the name of the path, even if the file does not exist. The value
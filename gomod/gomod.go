@@ -0,0 +1,140 @@
+// Package gomod reads the requirements in go.mod files.
+//
+// It is not a complete implementation of Go modules. Replace and exclude
+// directives are not applied.
+package gomod
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/Ownercz/glide/cfg"
+	"github.com/Ownercz/glide/msg"
+	gpath "github.com/Ownercz/glide/path"
+	"github.com/Ownercz/glide/util"
+)
+
+// Requirement is a module required by a go.mod file.
+type Requirement struct {
+	Path    string
+	Version string
+}
+
+// pseudoVersion matches versions generated by the go tool for untagged
+// commits. The last group is the commit id.
+var pseudoVersion = regexp.MustCompile(`^v\d+\.\d+\.\d+-(?:.*\.)?\d{14}-([0-9a-f]{12})$`)
+
+// Has indicates whether a go.mod file exists.
+func Has(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, "go.mod"))
+	return err == nil
+}
+
+// ModulePath returns the path of the module declared by the go.mod file in a
+// directory. It is empty when there is no go.mod file or it declares none.
+func ModulePath(dir string) string {
+	file, err := os.Open(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "module" {
+			return strings.Trim(fields[1], `"`)
+		}
+	}
+	return ""
+}
+
+// Parse parses the requirements of a go.mod file.
+func Parse(dir string) ([]*cfg.Dependency, error) {
+	path := filepath.Join(dir, "go.mod")
+	if _, err := os.Stat(path); err != nil {
+		return []*cfg.Dependency{}, nil
+	}
+	msg.Info("Found go.mod file in %s", gpath.StripBasepath(dir))
+	msg.Info("--> Parsing go.mod requirements...")
+
+	file, err := os.Open(path)
+	if err != nil {
+		return []*cfg.Dependency{}, err
+	}
+	defer file.Close()
+
+	reqs, err := ParseRequirements(file)
+	if err != nil {
+		return []*cfg.Dependency{}, fmt.Errorf("Unable to parse %s: %s", path, err)
+	}
+
+	buf := []*cfg.Dependency{}
+	seen := map[string]bool{}
+	for _, r := range reqs {
+		pkg, _ := util.NormalizeName(r.Path)
+		if seen[pkg] {
+			continue
+		}
+		seen[pkg] = true
+		buf = append(buf, &cfg.Dependency{Name: pkg, Reference: Reference(r.Version)})
+	}
+
+	return buf, nil
+}
+
+// ParseRequirements reads the require directives of a go.mod file, in both
+// the single line and block forms.
+func ParseRequirements(r io.Reader) ([]Requirement, error) {
+	reqs := []Requirement{}
+	inBlock := false
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		if inBlock {
+			if fields[0] == ")" {
+				inBlock = false
+				continue
+			}
+		} else if fields[0] == "require" {
+			if len(fields) == 2 && fields[1] == "(" {
+				inBlock = true
+				continue
+			}
+			fields = fields[1:]
+		} else {
+			continue
+		}
+
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("invalid requirement %q", strings.TrimSpace(line))
+		}
+		reqs = append(reqs, Requirement{Path: strings.Trim(fields[0], `"`), Version: fields[1]})
+	}
+
+	return reqs, scanner.Err()
+}
+
+// Reference converts a module version to a reference for a dependency. The
+// commit is used for pseudo-versions and tags are used as is.
+func Reference(version string) string {
+	version = strings.TrimSuffix(version, "+incompatible")
+	if m := pseudoVersion.FindStringSubmatch(version); m != nil {
+		return m[1]
+	}
+	return version
+}
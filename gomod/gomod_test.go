@@ -0,0 +1,79 @@
+package gomod
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const mod = `module github.com/example/app
+
+require github.com/example/single v1.2.0
+
+require (
+	github.com/example/a v1.0.0
+	github.com/example/b v2.1.0+incompatible // indirect
+	github.com/example/c v0.0.0-20170612153648-e4f8dc13e2a9
+)
+
+replace github.com/example/a => ../a
+`
+
+func TestParseRequirements(t *testing.T) {
+	reqs, err := ParseRequirements(strings.NewReader(mod))
+	if err != nil {
+		t.Fatalf("Unexpected error parsing: %s", err)
+	}
+
+	expect := []Requirement{
+		{"github.com/example/single", "v1.2.0"},
+		{"github.com/example/a", "v1.0.0"},
+		{"github.com/example/b", "v2.1.0+incompatible"},
+		{"github.com/example/c", "v0.0.0-20170612153648-e4f8dc13e2a9"},
+	}
+	if len(reqs) != len(expect) {
+		t.Fatalf("Expected %d requirements, got %v", len(expect), reqs)
+	}
+	for i, r := range reqs {
+		if r != expect[i] {
+			t.Errorf("Expected %v, got %v", expect[i], r)
+		}
+	}
+}
+
+func TestReference(t *testing.T) {
+	tests := map[string]string{
+		"v1.2.0":                               "v1.2.0",
+		"v2.1.0+incompatible":                  "v2.1.0",
+		"v0.0.0-20170612153648-e4f8dc13e2a9":   "e4f8dc13e2a9",
+		"v1.2.1-0.20170612153648-e4f8dc13e2a9": "e4f8dc13e2a9",
+	}
+	for in, expect := range tests {
+		if r := Reference(in); r != expect {
+			t.Errorf("Expected the reference for %s to be %s, got %s", in, expect, r)
+		}
+	}
+}
+
+func TestParseMajorVersion(t *testing.T) {
+	dir, err := ioutil.TempDir("", "glide-gomod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	gm := "module github.com/example/app\n\nrequire (\n\tgithub.com/example/lib v1.4.0\n\tgithub.com/example/lib/v2 v2.1.0\n)\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "go.mod"), []byte(gm), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	deps, err := Parse(dir)
+	if err != nil {
+		t.Fatalf("Unexpected error parsing: %s", err)
+	}
+	if len(deps) != 2 || deps[0].Name != "github.com/example/lib" || deps[1].Name != "github.com/example/lib/v2" || deps[1].Reference != "v2.1.0" {
+		t.Errorf("Expected each major version to be its own dependency, got %v", deps)
+	}
+}
@@ -0,0 +1,211 @@
+// Package gopkg reads the Gopkg.lock and Gopkg.toml files of dep.
+//
+// It is not a complete implementation of dep or of TOML. Only the project
+// tables are read.
+package gopkg
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/Ownercz/glide/cfg"
+	"github.com/Ownercz/glide/msg"
+	gpath "github.com/Ownercz/glide/path"
+)
+
+// The files read, in order of preference. The lock file pins exact versions
+// while the manifest only has constraints.
+var files = []string{"Gopkg.lock", "Gopkg.toml"}
+
+// tables are the array tables describing projects in each file.
+var tables = map[string]string{
+	"Gopkg.lock": "projects",
+	"Gopkg.toml": "constraint",
+}
+
+// Has indicates whether a Gopkg.lock or Gopkg.toml file exists.
+func Has(dir string) bool {
+	for _, f := range files {
+		if _, err := os.Stat(filepath.Join(dir, f)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Parse parses the Gopkg.lock file of a project or, when there is none, its
+// Gopkg.toml file.
+func Parse(dir string) ([]*cfg.Dependency, error) {
+	for _, f := range files {
+		path := filepath.Join(dir, f)
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		msg.Info("Found %s file in %s", f, gpath.StripBasepath(dir))
+		msg.Info("--> Parsing dep metadata...")
+
+		file, err := os.Open(path)
+		if err != nil {
+			return []*cfg.Dependency{}, err
+		}
+		defer file.Close()
+
+		projects, err := parseTables(file, tables[f])
+		if err != nil {
+			return []*cfg.Dependency{}, fmt.Errorf("Unable to parse %s: %s", path, err)
+		}
+		return toDependencies(projects), nil
+	}
+
+	return []*cfg.Dependency{}, nil
+}
+
+// toDependencies converts dep projects to dependencies. A revision is
+// preferred as the reference as it is exact, followed by a version and then a
+// branch.
+func toDependencies(projects []map[string][]string) []*cfg.Dependency {
+	buf := []*cfg.Dependency{}
+	for _, p := range projects {
+		name := first(p["name"])
+		if name == "" {
+			continue
+		}
+		dep := &cfg.Dependency{Name: name, Repository: first(p["source"])}
+		for _, k := range []string{"revision", "version", "branch"} {
+			if r := first(p[k]); r != "" {
+				dep.Reference = r
+				break
+			}
+		}
+		for _, sub := range p["packages"] {
+			if sub != "." {
+				dep.Subpackages = append(dep.Subpackages, sub)
+			}
+		}
+		buf = append(buf, dep)
+	}
+
+	return buf
+}
+
+func first(v []string) string {
+	if len(v) == 0 {
+		return ""
+	}
+	return v[0]
+}
+
+// parseTables reads the entries of the array tables with a name, such as
+// [[projects]], from TOML. Values are strings or arrays of strings. Other
+// values are skipped.
+func parseTables(r io.Reader, name string) ([]map[string][]string, error) {
+	var (
+		tables  []map[string][]string
+		current map[string][]string
+		key     string
+		array   []string
+		inArray bool
+	)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(stripComment(scanner.Text()))
+		if line == "" {
+			continue
+		}
+
+		// The remaining lines of a multi-line array.
+		if inArray {
+			done := strings.HasSuffix(line, "]")
+			vals, err := parseValues(strings.TrimSuffix(line, "]"))
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, vals...)
+			if done {
+				inArray = false
+				if current != nil {
+					current[key] = array
+				}
+			}
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			current = nil
+			if line == "[["+name+"]]" {
+				current = make(map[string][]string)
+				tables = append(tables, current)
+			}
+			continue
+		}
+
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("unexpected line %q", line)
+		}
+		key = strings.TrimSpace(parts[0])
+		val := strings.TrimSpace(parts[1])
+
+		if strings.HasPrefix(val, "[") {
+			val = strings.TrimPrefix(val, "[")
+			inArray = !strings.HasSuffix(val, "]")
+			vals, err := parseValues(strings.TrimSuffix(val, "]"))
+			if err != nil {
+				return nil, err
+			}
+			array = vals
+			if !inArray && current != nil {
+				current[key] = array
+			}
+			continue
+		}
+
+		if current != nil && strings.HasPrefix(val, `"`) {
+			s, err := strconv.Unquote(val)
+			if err != nil {
+				return nil, fmt.Errorf("invalid string for %s: %s", key, val)
+			}
+			current[key] = []string{s}
+		}
+	}
+
+	return tables, scanner.Err()
+}
+
+// parseValues parses a comma separated list of strings.
+func parseValues(s string) ([]string, error) {
+	vals := []string{}
+	for _, v := range strings.Split(s, ",") {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		u, err := strconv.Unquote(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid string %s", v)
+		}
+		vals = append(vals, u)
+	}
+	return vals, nil
+}
+
+// stripComment removes a comment from a line, leaving any # inside of a
+// string.
+func stripComment(line string) string {
+	quoted := false
+	for i, c := range line {
+		switch {
+		case c == '"' && (i == 0 || line[i-1] != '\\'):
+			quoted = !quoted
+		case c == '#' && !quoted:
+			return line[:i]
+		}
+	}
+	return line
+}
@@ -0,0 +1,54 @@
+package gopkg
+
+import (
+	"strings"
+	"testing"
+)
+
+const lock = `# This file is autogenerated, do not edit; changes may be undone by the next 'dep ensure'.
+
+[[projects]]
+  name = "github.com/example/a"
+  packages = [
+    ".",
+    "sub"
+  ]
+  revision = "a9949121a2e2192ca92fa6dddfeaaa4a4412d955"
+  version = "v1.0.0"
+
+[[projects]]
+  branch = "master"
+  name = "github.com/example/b"
+  packages = ["."]
+  source = "https://example.com/fork/b.git" # A fork.
+
+[solve-meta]
+  analyzer-name = "dep"
+  inputs-digest = "abc"
+`
+
+func TestParseTables(t *testing.T) {
+	projects, err := parseTables(strings.NewReader(lock), "projects")
+	if err != nil {
+		t.Fatalf("Unexpected error parsing: %s", err)
+	}
+	deps := toDependencies(projects)
+	if len(deps) != 2 {
+		t.Fatalf("Expected 2 dependencies, got %d", len(deps))
+	}
+
+	a, b := deps[0], deps[1]
+	if a.Name != "github.com/example/a" || a.Reference != "a9949121a2e2192ca92fa6dddfeaaa4a4412d955" {
+		t.Errorf("Expected the revision of a to be used, got %s %s", a.Name, a.Reference)
+	}
+	if len(a.Subpackages) != 1 || a.Subpackages[0] != "sub" {
+		t.Errorf("Expected the sub package of a, got %v", a.Subpackages)
+	}
+	if b.Reference != "master" || b.Repository != "https://example.com/fork/b.git" || len(b.Subpackages) != 0 {
+		t.Errorf("Expected the branch and source of b, got %s %s %v", b.Reference, b.Repository, b.Subpackages)
+	}
+
+	if _, err := parseTables(strings.NewReader("[[projects]]\nname = \"unterminated\n"), "projects"); err == nil {
+		t.Error("Expected an error for an invalid string")
+	}
+}
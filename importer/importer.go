@@ -1,8 +1,8 @@
-// Package importer imports dependency configuration from Glide, Godep, GPM, GB and gom
+// Package importer imports dependency configuration from Glide, Godep, GPM,
+// GB, gom, dep and Go modules.
 package importer
 
 import (
-	"io/ioutil"
 	"os"
 	"path/filepath"
 
@@ -10,12 +10,14 @@ import (
 	"github.com/Ownercz/glide/gb"
 	"github.com/Ownercz/glide/godep"
 	"github.com/Ownercz/glide/gom"
+	"github.com/Ownercz/glide/gomod"
+	"github.com/Ownercz/glide/gopkg"
 	"github.com/Ownercz/glide/gpm"
 )
 
 var i = &DefaultImporter{}
 
-// Import uses the DefaultImporter to import from Glide, Godep, GPM, GB and gom.
+// Import uses the DefaultImporter to import from the known Formats.
 func Import(path string) (bool, []*cfg.Dependency, error) {
 	return i.Import(path)
 }
@@ -30,57 +32,48 @@ type Importer interface {
 	Import(path string) (bool, []*cfg.Dependency, error)
 }
 
-// DefaultImporter imports from Glide, Godep, GPM, GB and gom.
-type DefaultImporter struct{}
+// Format is a configuration format dependencies can be imported from.
+type Format struct {
+	// Name identifies the format.
+	Name string
 
-// Import tries to import configuration from Glide, Godep, GPM, GB and gom.
-func (d *DefaultImporter) Import(path string) (bool, []*cfg.Dependency, error) {
+	// Has returns if a directory has configuration in the format.
+	Has func(dir string) bool
 
-	// Try importing from Glide first.
-	p := filepath.Join(path, "glide.yaml")
-	if _, err := os.Stat(p); err == nil {
-		// We found glide configuration.
-		yml, err := ioutil.ReadFile(p)
-		if err != nil {
-			return false, []*cfg.Dependency{}, err
-		}
-		conf, err := cfg.ConfigFromYaml(yml)
-		if err != nil {
-			return false, []*cfg.Dependency{}, err
-		}
-		return true, conf.Imports, nil
-	}
+	// Parse returns the dependencies in the configuration of a directory.
+	Parse func(dir string) ([]*cfg.Dependency, error)
+}
 
-	// Try importing from Godep
-	if godep.Has(path) {
-		deps, err := godep.Parse(path)
-		if err != nil {
-			return false, []*cfg.Dependency{}, err
-		}
-		return true, deps, nil
-	}
+// Formats are the formats the DefaultImporter tries, in order. Only the first
+// one found in a directory is used. The order is documented in
+// docs/importing.md.
+var Formats = []Format{
+	{Name: "glide", Has: hasGlide, Parse: parseGlide},
+	{Name: "godep", Has: godep.Has, Parse: godep.Parse},
+	{Name: "gpm", Has: gpm.Has, Parse: gpm.Parse},
+	{Name: "gb", Has: gb.Has, Parse: gb.Parse},
+	{Name: "gom", Has: gom.Has, Parse: gom.Parse},
+	{Name: "dep", Has: gopkg.Has, Parse: gopkg.Parse},
+	{Name: "gomod", Has: gomod.Has, Parse: gomod.Parse},
+}
 
-	// Try importing from GPM
-	if gpm.Has(path) {
-		deps, err := gpm.Parse(path)
-		if err != nil {
-			return false, []*cfg.Dependency{}, err
-		}
-		return true, deps, nil
-	}
+// Register adds a format to be tried after those already known. It is not
+// safe to call while importing so it should be called before, such as from
+// an init function.
+func Register(f Format) {
+	Formats = append(Formats, f)
+}
 
-	// Try importin from GB
-	if gb.Has(path) {
-		deps, err := gb.Parse(path)
-		if err != nil {
-			return false, []*cfg.Dependency{}, err
-		}
-		return true, deps, nil
-	}
+// DefaultImporter imports from the known Formats.
+type DefaultImporter struct{}
 
-	// Try importing from gom
-	if gom.Has(path) {
-		deps, err := gom.Parse(path)
+// Import tries to import configuration from each of the Formats in turn.
+func (d *DefaultImporter) Import(path string) (bool, []*cfg.Dependency, error) {
+	for _, f := range Formats {
+		if !f.Has(path) {
+			continue
+		}
+		deps, err := f.Parse(path)
 		if err != nil {
 			return false, []*cfg.Dependency{}, err
 		}
@@ -90,3 +83,18 @@ func (d *DefaultImporter) Import(path string) (bool, []*cfg.Dependency, error) {
 	// When none are found.
 	return false, []*cfg.Dependency{}, nil
 }
+
+// hasGlide indicates whether a glide.yaml file exists.
+func hasGlide(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, "glide.yaml"))
+	return err == nil
+}
+
+// parseGlide returns the imports in a glide.yaml file.
+func parseGlide(dir string) ([]*cfg.Dependency, error) {
+	conf, err := cfg.ReadConfigFile(filepath.Join(dir, "glide.yaml"))
+	if err != nil {
+		return []*cfg.Dependency{}, err
+	}
+	return conf.Imports, nil
+}
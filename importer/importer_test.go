@@ -0,0 +1,76 @@
+package importer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestImportDepLock(t *testing.T) {
+	found, deps, err := Import("testdata/dep")
+	if err != nil {
+		t.Fatalf("Unexpected error importing: %s", err)
+	}
+	if !found || len(deps) != 3 {
+		t.Fatalf("Expected 3 dependencies from Gopkg.lock, got %d", len(deps))
+	}
+
+	pinned, branch, source := deps[0], deps[1], deps[2]
+	if pinned.Name != "github.com/example/pinned" || pinned.Reference != "a9949121a2e2192ca92fa6dddfeaaa4a4412d955" {
+		t.Errorf("Expected the revision of the pinned project, got %s %s", pinned.Name, pinned.Reference)
+	}
+	if !reflect.DeepEqual(pinned.Subpackages, []string{"sub/a", "sub/b"}) {
+		t.Errorf("Expected the packages other than . as subpackages, got %v", pinned.Subpackages)
+	}
+	if branch.Reference != "0b2f6c2ca5d8bd1a2d4d3ec5fbb0e3f5bafd7b43" || len(branch.Subpackages) != 0 {
+		t.Errorf("Expected the locked revision of the branch, got %s %v", branch.Reference, branch.Subpackages)
+	}
+	if source.Repository != "https://example.com/fork/source.git" || source.Reference != "5f0e1a1dbb9e6b0bb1de2c2c5da6e3a6b0c5b2d1" {
+		t.Errorf("Expected the source and revision of the forked project, got %s %s", source.Repository, source.Reference)
+	}
+	if !reflect.DeepEqual(source.Subpackages, []string{"client"}) {
+		t.Errorf("Expected the client subpackage, got %v", source.Subpackages)
+	}
+}
+
+func TestImportDepManifest(t *testing.T) {
+	found, deps, err := Import("testdata/depmanifest")
+	if err != nil {
+		t.Fatalf("Unexpected error importing: %s", err)
+	}
+	if !found || len(deps) != 2 {
+		t.Fatalf("Expected the 2 constraints from Gopkg.toml, got %d", len(deps))
+	}
+	if deps[0].Name != "github.com/example/branch" || deps[0].Reference != "develop" {
+		t.Errorf("Expected the branch constraint, got %s %s", deps[0].Name, deps[0].Reference)
+	}
+	if deps[1].Repository != "https://example.com/fork/source.git" || deps[1].Reference != "^0.3.0" {
+		t.Errorf("Expected the source and version constraint, got %s %s", deps[1].Repository, deps[1].Reference)
+	}
+}
+
+func TestImportTree(t *testing.T) {
+	found, deps, err := ImportTree("testdata/tree")
+	if err != nil {
+		t.Fatalf("Unexpected error importing: %s", err)
+	}
+	if !found || len(deps) != 3 {
+		t.Fatalf("Expected 3 dependencies from the manifests in the tree, got %d", len(deps))
+	}
+
+	// The dependencies are sorted by name and the glide.yaml at the top wins
+	// over the Godeps.json of the vendored dependency.
+	expected := []struct{ name, ref string }{
+		{"github.com/example/lib", ""},
+		{"github.com/example/nested", "0b2f6c2ca5d8bd1a2d4d3ec5fbb0e3f5bafd7b43"},
+		{"github.com/example/shared", "^1.2.0"},
+	}
+	for k, e := range expected {
+		if deps[k].Name != e.name || deps[k].Reference != e.ref {
+			t.Errorf("Expected %s at %q, got %s at %q", e.name, e.ref, deps[k].Name, deps[k].Reference)
+		}
+	}
+
+	if found, deps, err := ImportTree("testdata/tree/vendor/github.com"); err != nil || !found || len(deps) != 2 {
+		t.Errorf("Expected the 2 dependencies of the nested manifest, got %v %v", deps, err)
+	}
+}
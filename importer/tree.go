@@ -0,0 +1,120 @@
+package importer
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Ownercz/glide/cfg"
+	"github.com/Ownercz/glide/msg"
+)
+
+// ImportTree uses the DefaultImporter to import from the known Formats in a
+// directory and every directory below it.
+func ImportTree(path string) (bool, []*cfg.Dependency, error) {
+	return i.ImportTree(path)
+}
+
+// manifest is the configuration imported from one directory of a tree.
+type manifest struct {
+	dir   string
+	depth int
+	deps  []*cfg.Dependency
+	err   error
+}
+
+// manifests sorts the manifests of a tree in the order they are merged. The
+// deepest come first and those at the same depth are sorted by path.
+type manifests []manifest
+
+func (m manifests) Len() int {
+	return len(m)
+}
+
+func (m manifests) Less(i, j int) bool {
+	if m[i].depth != m[j].depth {
+		return m[i].depth > m[j].depth
+	}
+	return m[i].dir < m[j].dir
+}
+
+func (m manifests) Swap(i, j int) {
+	m[i], m[j] = m[j], m[i]
+}
+
+// ImportTree imports configuration from a directory and every directory
+// below it, such as those of vendored dependencies with a manifest of their
+// own. Each directory is imported like Import does. The directories are
+// scanned concurrently and the dependencies merged into one list sorted by
+// name. When manifests list the same dependency the shallower one wins and,
+// between those at the same depth, the one sorting later by path. Hidden and
+// testdata directories are skipped and symbolic links are not followed.
+//
+// ImportTree is for use as a library. The glide commands, including the
+// import and migration ones, use Import.
+func (d *DefaultImporter) ImportTree(path string) (bool, []*cfg.Dependency, error) {
+	var (
+		wg    sync.WaitGroup
+		lock  sync.Mutex
+		found manifests
+		sem   = make(chan struct{}, runtime.NumCPU())
+	)
+
+	var walk func(dir string, depth int)
+	walk = func(dir string, depth int) {
+		defer wg.Done()
+
+		sem <- struct{}{}
+		ok, deps, err := d.Import(dir)
+		entries, rerr := ioutil.ReadDir(dir)
+		<-sem
+
+		if err == nil && rerr != nil {
+			err = rerr
+		}
+		if ok || err != nil {
+			lock.Lock()
+			found = append(found, manifest{dir: dir, depth: depth, deps: deps, err: err})
+			lock.Unlock()
+		}
+
+		for _, e := range entries {
+			if !e.IsDir() || strings.HasPrefix(e.Name(), ".") || e.Name() == "testdata" {
+				continue
+			}
+			wg.Add(1)
+			go walk(filepath.Join(dir, e.Name()), depth+1)
+		}
+	}
+	wg.Add(1)
+	walk(path, 0)
+	wg.Wait()
+
+	// The deepest manifests are merged first so shallower ones replace them.
+	sort.Sort(found)
+
+	merged := make(map[string]*cfg.Dependency)
+	for _, m := range found {
+		if m.err != nil {
+			return false, []*cfg.Dependency{}, fmt.Errorf("Unable to import from %s: %s", m.dir, m.err)
+		}
+		for _, dep := range m.deps {
+			if o, ok := merged[dep.Name]; ok && o.Reference != dep.Reference {
+				msg.Debug("Using version %q of %s from %s over %q", dep.Reference, dep.Name, m.dir, o.Reference)
+			}
+			merged[dep.Name] = dep
+		}
+	}
+
+	deps := make(cfg.Dependencies, 0, len(merged))
+	for _, dep := range merged {
+		deps = append(deps, dep)
+	}
+	sort.Sort(deps)
+
+	return len(found) > 0, deps, nil
+}
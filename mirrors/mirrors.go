@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
 
 	"github.com/Ownercz/glide/msg"
 	gpath "github.com/Ownercz/glide/path"
@@ -12,6 +13,10 @@ import (
 
 var mirrors map[string]*mirror
 
+// lock protects mirrors as entries can be added while dependencies are being
+// fetched concurrently.
+var lock sync.RWMutex
+
 func init() {
 	mirrors = make(map[string]*mirror)
 }
@@ -25,6 +30,8 @@ type mirror struct {
 // - new repo location
 // - vcs type
 func Get(k string) (bool, string, string) {
+	lock.RLock()
+	defer lock.RUnlock()
 	o, f := mirrors[k]
 	if !f {
 		return false, "", ""
@@ -33,6 +40,17 @@ func Get(k string) (bool, string, string) {
 	return true, o.Repo, o.Vcs
 }
 
+// Set adds or replaces the mirror for a location in memory. It is not written
+// to the mirrors.yaml file.
+func Set(k, repo, vcs string) {
+	lock.Lock()
+	defer lock.Unlock()
+	mirrors[k] = &mirror{
+		Repo: repo,
+		Vcs:  vcs,
+	}
+}
+
 // Load pulls the mirrors into memory
 func Load() error {
 	home := gpath.Home()
@@ -62,11 +80,7 @@ func Load() error {
 	msg.Info("Loading mirrors from mirrors.yaml file")
 	for _, o := range ov.Repos {
 		msg.Debug("Found mirror: %s to %s (%s)", o.Original, o.Repo, o.Vcs)
-		no := &mirror{
-			Repo: o.Repo,
-			Vcs:  o.Vcs,
-		}
-		mirrors[o.Original] = no
+		Set(o.Original, o.Repo, o.Vcs)
 	}
 
 	return nil
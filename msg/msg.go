@@ -11,6 +11,36 @@ import (
 	"github.com/Ownercz/vcs"
 )
 
+// Level is how important a message is. A Messenger only shows messages at
+// its Level or above.
+type Level int
+
+// The levels in order of importance. The zero value is LevelInfo.
+const (
+	LevelDebug Level = iota - 1
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+// levelNames are the names of the levels used by ParseLevel.
+var levelNames = map[string]Level{
+	"debug": LevelDebug,
+	"info":  LevelInfo,
+	"warn":  LevelWarn,
+	"error": LevelError,
+}
+
+// ParseLevel returns the level with a name, one of debug, info, warn or
+// error.
+func ParseLevel(name string) (Level, error) {
+	l, ok := levelNames[strings.ToLower(name)]
+	if !ok {
+		return LevelInfo, fmt.Errorf("Unknown log level %q, expected debug, info, warn or error", name)
+	}
+	return l, nil
+}
+
 // Messenger provides the underlying implementation that displays output to
 // users.
 type Messenger struct {
@@ -22,6 +52,10 @@ type Messenger struct {
 	// IsDebugging, if true, shows Debug.
 	IsDebugging bool
 
+	// Level is the least important level shown. Quiet and IsDebugging take
+	// precedence over it. Errors are always shown.
+	Level Level
+
 	// NoColor, if true, will not use color in the output.
 	NoColor bool
 
@@ -63,9 +97,28 @@ func NewMessenger() *Messenger {
 // Default contains a default Messenger used by package level functions
 var Default = NewMessenger()
 
+// Enabled returns if messages at a level are shown. It is checked before a
+// message is formatted so those not shown cost next to nothing.
+func (m *Messenger) Enabled(l Level) bool {
+	switch {
+	case l >= LevelError:
+		return true
+	case m.Quiet && l <= LevelInfo:
+		return false
+	case m.IsDebugging && l == LevelDebug:
+		return true
+	}
+	return l >= m.Level
+}
+
+// Enabled returns if messages at a level are shown by the Default Messenger.
+func Enabled(l Level) bool {
+	return Default.Enabled(l)
+}
+
 // Info logs information
 func (m *Messenger) Info(msg string, args ...interface{}) {
-	if m.Quiet {
+	if !m.Enabled(LevelInfo) {
 		return
 	}
 	prefix := m.Color(Green, "[INFO]\t")
@@ -79,7 +132,7 @@ func Info(msg string, args ...interface{}) {
 
 // Debug logs debug information
 func (m *Messenger) Debug(msg string, args ...interface{}) {
-	if m.Quiet || !m.IsDebugging {
+	if !m.Enabled(LevelDebug) {
 		return
 	}
 	prefix := "[DEBUG]\t"
@@ -93,6 +146,9 @@ func Debug(msg string, args ...interface{}) {
 
 // Warn logs a warning
 func (m *Messenger) Warn(msg string, args ...interface{}) {
+	if !m.Enabled(LevelWarn) {
+		return
+	}
 	prefix := m.Color(Yellow, "[WARN]\t")
 	m.Msg(prefix+msg, args...)
 }
@@ -177,7 +233,7 @@ func (m *Messenger) Msg(msg string, args ...interface{}) {
 	// capured here rather than calling Debug because concurrent operations
 	// could cause other messages to appear between the initial error and the
 	// debug output by unlocking and calling Debug.
-	if len(args) != 0 && !m.Quiet && m.IsDebugging {
+	if len(args) != 0 && m.Enabled(LevelDebug) {
 		if err, ok := args[len(args)-1].(error); ok {
 			switch t := err.(type) {
 			case *vcs.LocalError:
@@ -0,0 +1,102 @@
+package msg
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestLevels(t *testing.T) {
+	log := func(m *Messenger) string {
+		var buf bytes.Buffer
+		m.Stderr = &buf
+		m.NoColor = true
+		m.Debug("debug")
+		m.Info("info")
+		m.Warn("warn")
+		m.Err("error")
+		return strings.Replace(buf.String(), "\t", " ", -1)
+	}
+
+	for _, tt := range []struct {
+		level    Level
+		expected string
+	}{
+		{LevelDebug, "[DEBUG] debug\n[INFO] info\n[WARN] warn\n[ERROR] error\n"},
+		{LevelInfo, "[INFO] info\n[WARN] warn\n[ERROR] error\n"},
+		{LevelWarn, "[WARN] warn\n[ERROR] error\n"},
+		{LevelError, "[ERROR] error\n"},
+	} {
+		m := NewMessenger()
+		m.Level = tt.level
+		if out := log(m); out != tt.expected {
+			t.Errorf("Expected at level %d\n%s\ngot\n%s", tt.level, tt.expected, out)
+		}
+	}
+
+	m := NewMessenger()
+	m.IsDebugging = true
+	if out := log(m); !strings.HasPrefix(out, "[DEBUG] debug\n") {
+		t.Errorf("Expected debugging to show debug messages, got\n%s", out)
+	}
+	m = NewMessenger()
+	m.Quiet = true
+	m.Level = LevelDebug
+	if out := log(m); out != "[WARN] warn\n[ERROR] error\n" {
+		t.Errorf("Expected quiet to hide info and debug messages, got\n%s", out)
+	}
+}
+
+func TestDieAtErrorLevel(t *testing.T) {
+	var buf bytes.Buffer
+	m := NewMessenger()
+	m.Stderr = &buf
+	m.NoColor = true
+	m.Level = LevelError
+	m.Quiet = true
+	m.PanicOnDie = true
+
+	func() {
+		defer func() { recover() }()
+		m.Die("fatal %s", "problem")
+	}()
+	if buf.String() != "[ERROR]\tfatal problem\n" {
+		t.Errorf("Expected Die to always print, got %q", buf.String())
+	}
+}
+
+func TestSuppressedNotFormatted(t *testing.T) {
+	m := NewMessenger()
+	m.Level = LevelWarn
+	m.Stderr = &bytes.Buffer{}
+
+	// A value that counts being formatted shows the arguments are left alone.
+	f := &formatCounter{}
+	m.Info("%s", f)
+	m.Debug("%s", f)
+	if f.n != 0 {
+		t.Errorf("Expected suppressed messages not to be formatted, formatted %d times", f.n)
+	}
+	m.Warn("%s", f)
+	if f.n != 1 {
+		t.Errorf("Expected a shown message to be formatted once, formatted %d times", f.n)
+	}
+}
+
+type formatCounter struct{ n int }
+
+func (f *formatCounter) String() string {
+	f.n++
+	return "counted"
+}
+
+func TestParseLevel(t *testing.T) {
+	for name, expected := range map[string]Level{"debug": LevelDebug, "INFO": LevelInfo, "warn": LevelWarn, "error": LevelError} {
+		if l, err := ParseLevel(name); err != nil || l != expected {
+			t.Errorf("Expected %s to be level %d, got %d %v", name, expected, l, err)
+		}
+	}
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Error("Expected an error for an unknown level")
+	}
+}
@@ -0,0 +1,120 @@
+package path
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/Ownercz/glide/msg"
+)
+
+// HoistVendor copies the packages in the vendor directories nested in a
+// vendor directory up to it, so they are still vendored once the nested
+// directories are stripped. A package already in the vendor directory is
+// kept. When the nested copy of it differs, such as being another version, a
+// warning names both. The nested directories are left in place. See
+// StripVendor.
+func HoistVendor(vendor string) error {
+	return filepath.Walk(vendor, func(p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if p == vendor || !fi.IsDir() || fi.Name() != "vendor" {
+			return nil
+		}
+		if err := hoistFrom(p, vendor); err != nil {
+			return err
+		}
+		return filepath.SkipDir
+	})
+}
+
+// hoistFrom copies the packages in a nested vendor directory, and in those
+// nested in it, to the top-level vendor directory.
+func hoistFrom(nested, vendor string) error {
+	return filepath.Walk(nested, func(p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if p == nested || !fi.IsDir() {
+			return nil
+		}
+		if fi.Name() == "vendor" {
+			if err := hoistFrom(p, vendor); err != nil {
+				return err
+			}
+			return filepath.SkipDir
+		}
+
+		files, err := dirFiles(p)
+		if err != nil || len(files) == 0 {
+			return err
+		}
+		rel, err := filepath.Rel(nested, p)
+		if err != nil {
+			return err
+		}
+		dest := filepath.Join(vendor, rel)
+		existing, err := dirFiles(dest)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+
+		if len(existing) > 0 {
+			if !sameFiles(p, dest, files, existing) {
+				msg.Warn("Conflict: %s in %s differs from the one in %s. Keeping the one in %s", filepath.ToSlash(rel), nested, vendor, vendor)
+			}
+			return nil
+		}
+
+		msg.Info("Hoisting %s from %s", filepath.ToSlash(rel), nested)
+		if err := os.MkdirAll(dest, 0755); err != nil {
+			return err
+		}
+		for _, f := range files {
+			if err := CopyFile(filepath.Join(p, f), filepath.Join(dest, f)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// dirFiles returns the names of the files in a directory, leaving out
+// directories.
+func dirFiles(dir string) ([]string, error) {
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	files := []string{}
+	for _, fi := range infos {
+		if !fi.IsDir() {
+			files = append(files, fi.Name())
+		}
+	}
+	return files, nil
+}
+
+// sameFiles returns if two directories have the same files with the same
+// content. The names of the files are sorted.
+func sameFiles(a, b string, aFiles, bFiles []string) bool {
+	if len(aFiles) != len(bFiles) {
+		return false
+	}
+	for ii, f := range aFiles {
+		if bFiles[ii] != f {
+			return false
+		}
+		ac, err := ioutil.ReadFile(filepath.Join(a, f))
+		if err != nil {
+			return false
+		}
+		bc, err := ioutil.ReadFile(filepath.Join(b, f))
+		if err != nil || !bytes.Equal(ac, bc) {
+			return false
+		}
+	}
+	return true
+}
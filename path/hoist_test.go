@@ -0,0 +1,66 @@
+package path
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Ownercz/glide/msg"
+)
+
+func TestHoistVendor(t *testing.T) {
+	dir, err := ioutil.TempDir("", "glide-hoist")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// dep vendors errors, already at the top level at another version, and
+	// log, which is not. log vendors its own dependency in turn.
+	vendor := filepath.Join(dir, "vendor")
+	files := map[string]string{
+		"github.com/example/errors/errors.go":                                              "package errors // v2\n",
+		"github.com/example/dep/dep.go":                                                    "package dep\n",
+		"github.com/example/dep/vendor/github.com/example/errors/errors.go":                "package errors // v1\n",
+		"github.com/example/dep/vendor/github.com/example/log/log.go":                      "package log\n",
+		"github.com/example/dep/vendor/github.com/example/log/vendor/example.com/fmt/f.go": "package fmt\n",
+	}
+	for name, content := range files {
+		p := filepath.Join(vendor, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var buf bytes.Buffer
+	old := msg.Default.Stderr
+	msg.Default.Stderr = &buf
+	defer func() { msg.Default.Stderr = old }()
+
+	if err := HoistVendor(vendor); err != nil {
+		t.Fatalf("Unexpected error hoisting: %s", err)
+	}
+
+	for name, content := range map[string]string{
+		"github.com/example/errors/errors.go": "package errors // v2\n",
+		"github.com/example/log/log.go":       "package log\n",
+		"example.com/fmt/f.go":                "package fmt\n",
+	} {
+		b, err := ioutil.ReadFile(filepath.Join(vendor, filepath.FromSlash(name)))
+		if err != nil || string(b) != content {
+			t.Errorf("Expected %s to be %q at the top level, got %q %v", name, content, b, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(vendor, "github.com", "example", "log", "vendor")); !os.IsNotExist(err) {
+		t.Error("Expected the nested vendor directory not to be hoisted")
+	}
+	if strings.Count(buf.String(), "Conflict: github.com/example/errors") != 1 {
+		t.Errorf("Expected a warning about the conflicting errors package, got %q", buf.String())
+	}
+}
@@ -36,6 +36,10 @@ var homeDir = ""
 // only be set once, at startup, or not at all.
 var GlideFile = DefaultGlideFile
 
+// StdinFile is the GlideFile for a config read from standard input rather
+// than a file. The project is then the working directory.
+const StdinFile = "-"
+
 // LockFile is the default name for the lock file.
 const LockFile = "glide.lock"
 
@@ -135,6 +139,10 @@ func Glide() (string, error) {
 		return "", err
 	}
 
+	if GlideFile == StdinFile {
+		return StdinFile, nil
+	}
+
 	// Find the directory that contains glide.yaml
 	yamldir, err := GlideWD(cwd)
 	if err != nil {
@@ -148,8 +156,11 @@ func Glide() (string, error) {
 // GlideWD finds the working directory of the glide.yaml file, starting at dir.
 //
 // If the glide file is not found in the current directory, it recurses up
-// a directory.
+// a directory. With a config read from standard input it is dir.
 func GlideWD(dir string) (string, error) {
+	if GlideFile == StdinFile {
+		return dir, nil
+	}
 	fullpath := filepath.Join(dir, GlideFile)
 
 	if _, err := os.Stat(fullpath); err == nil {
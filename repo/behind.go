@@ -0,0 +1,71 @@
+package repo
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/Ownercz/glide/cfg"
+	"github.com/Ownercz/glide/msg"
+	v "github.com/Ownercz/vcs"
+)
+
+// checkBehind warns about the dependencies pinned to a commit whose version
+// in the config is a branch that has moved ahead of it. It is advisory so
+// problems are only displayed in the debug output.
+func (i *Installer) checkBehind(deps cfg.Dependencies, conf *cfg.Config) {
+	for _, dep := range deps {
+		c := conf.Imports.Get(dep.Name)
+		if c == nil {
+			c = conf.DevImports.Get(dep.Name)
+		}
+		if c == nil || c.Reference == "" || c.Reference == dep.Reference {
+			continue
+		}
+
+		n, err := i.commitsBehind(dep, c.Reference)
+		if err != nil {
+			msg.Debug("Unable to check if %s is behind %s: %s", dep.Name, c.Reference, err)
+			continue
+		}
+		if n > 0 {
+			msg.Warn("%s is pinned %d commits behind the %s branch", dep.Name, n, c.Reference)
+		}
+	}
+}
+
+// commitsBehind returns the number of commits on a branch that are not in
+// the commit a dependency is pinned to. The branch is fetched into the cache
+// first unless Offline is set. It is zero when the reference is not a branch
+// or the VCS is not git, where it is not supported.
+func (i *Installer) commitsBehind(dep *cfg.Dependency, branch string) (int, error) {
+	key, err := i.cacheKey(dep)
+	if err != nil {
+		return 0, err
+	}
+	repo, err := dep.GetRepo(filepath.Join(i.cacheLocation(), "src", key))
+	if err != nil {
+		return 0, err
+	}
+	if repo.Vcs() != v.Git {
+		return 0, nil
+	}
+	if ib, err := isBranch(branch, repo); err != nil || !ib {
+		return 0, err
+	}
+	if err := i.unshallow(repo); err != nil {
+		return 0, err
+	}
+
+	if !i.Offline {
+		if out, err := i.runRemoteGit(repo.LocalPath(), repo.Remote(), "fetch", "-q", "origin", branch); err != nil {
+			msg.Debug("Unable to fetch %s for %s, using the cache: %s", branch, dep.Name, strings.TrimSpace(string(out)))
+		}
+	}
+
+	out, err := repo.RunFromDir("git", "rev-list", "--count", dep.Reference+"..origin/"+branch)
+	if err != nil {
+		return 0, v.NewLocalError("Unable to count commits", err, string(out))
+	}
+	return strconv.Atoi(strings.TrimSpace(string(out)))
+}
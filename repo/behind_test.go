@@ -0,0 +1,49 @@
+package repo
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/Ownercz/glide/cfg"
+)
+
+func TestCommitsBehind(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir, err := ioutil.TempDir("", "glide-behind")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	remote := filepath.Join(dir, "remote")
+	pin := newTestRemote(t, remote)
+	git := func(args ...string) string { return testGit(t, remote, args...) }
+	git("branch", "-M", "main")
+	git("tag", "v1.0.0")
+
+	i := NewInstaller()
+	i.Home = filepath.Join(dir, "home")
+	dep := &cfg.Dependency{Name: "github.com/example/behind", Repository: remote, VcsType: "git", Reference: pin}
+	if err := i.VcsGet(dep); err != nil {
+		t.Fatal(err)
+	}
+
+	// The commits made after the cache was populated are fetched.
+	git("commit", "-q", "--allow-empty", "-m", "second")
+	git("commit", "-q", "--allow-empty", "-m", "third")
+	n, err := i.commitsBehind(dep, "main")
+	if err != nil || n != 2 {
+		t.Errorf("Expected the pin to be 2 commits behind, got %d (%v)", n, err)
+	}
+
+	n, err = i.commitsBehind(dep, "v1.0.0")
+	if err != nil || n != 0 {
+		t.Errorf("Expected a tag not to be checked, got %d (%v)", n, err)
+	}
+}
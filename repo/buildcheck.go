@@ -0,0 +1,108 @@
+package repo
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/Ownercz/glide/cfg"
+	"github.com/Ownercz/glide/msg"
+)
+
+// CheckBuild builds the vendored packages used by the project with the go
+// tool to make sure the versions resolved work together. Only the packages
+// listed in the config are built. GOOS, GOARCH and the rest of the
+// environment are passed to the go tool along with BuildTags. When the build
+// fails the error lists the packages that failed.
+func (i *Installer) CheckBuild(conf *cfg.Config) error {
+	vp := i.VendorPath()
+	dir := filepath.Dir(vp)
+
+	deps := conf.Imports
+	if i.ResolveTest {
+		deps = append(deps, conf.DevImports...)
+	}
+
+	seen := make(map[string]bool)
+	pkgs := []string{}
+	for _, dep := range deps {
+		if conf.HasIgnore(dep.Name) || i.isQuarantined(dep.Name) || filterArchOs(dep, i) {
+			continue
+		}
+		dest, err := i.vendorDir(vp, dep.Name)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, dest)
+		if err != nil {
+			return err
+		}
+
+		subs := dep.Subpackages
+		if len(subs) == 0 {
+			subs = []string{"."}
+		}
+		for _, sub := range subs {
+			p := "./" + path.Join(filepath.ToSlash(rel), sub)
+			if !seen[p] {
+				seen[p] = true
+				pkgs = append(pkgs, p)
+			}
+		}
+	}
+	if len(pkgs) == 0 {
+		return nil
+	}
+
+	args := []string{"build"}
+	if len(i.BuildTags) > 0 {
+		args = append(args, "-tags", strings.Join(i.BuildTags, " "))
+	}
+	args = append(args, pkgs...)
+
+	msg.Info("Building %d vendored packages to check them", len(pkgs))
+	cmd := exec.Command("go", args...)
+	cmd.Dir = dir
+	cmd.Env = os.Environ()
+	// The vendor directory is laid out for GOPATH mode.
+	if os.Getenv("GO111MODULE") == "" {
+		cmd.Env = append(cmd.Env, "GO111MODULE=off")
+	}
+	if i.GOOS != "" {
+		cmd.Env = append(cmd.Env, "GOOS="+i.GOOS)
+	}
+	if i.GOARCH != "" {
+		cmd.Env = append(cmd.Env, "GOARCH="+i.GOARCH)
+	}
+	out, err := cmd.CombinedOutput()
+	if err == nil {
+		return nil
+	}
+
+	failed := failedPackages(string(out))
+	if len(failed) == 0 {
+		return fmt.Errorf("Unable to build the vendored packages: %s\n%s", err, out)
+	}
+	return fmt.Errorf("The vendored packages failed to build:\n  %s\n%s", strings.Join(failed, "\n  "), out)
+}
+
+// failedPackages returns the packages named in the output of go build. Each
+// package with errors has a line starting with # before them. The names are
+// made relative to the vendor directory.
+func failedPackages(out string) []string {
+	failed := []string{}
+	for _, l := range strings.Split(out, "\n") {
+		if !strings.HasPrefix(l, "# ") {
+			continue
+		}
+		p := strings.TrimSpace(strings.TrimPrefix(l, "# "))
+		if idx := strings.LastIndex(p, "vendor/"); idx >= 0 {
+			p = p[idx+len("vendor/"):]
+		}
+		failed = append(failed, p)
+	}
+	return failed
+}
@@ -0,0 +1,70 @@
+package repo
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Ownercz/glide/cfg"
+)
+
+func TestCheckBuild(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go is not installed")
+	}
+
+	dir, err := ioutil.TempDir("", "glide-checkbuild")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	sources := map[string]string{
+		"github.com/example/ok/ok.go":       "package ok\n",
+		"github.com/example/ok/sub/sub.go":  "package sub\n",
+		"github.com/example/ok/extra/x.go":  "package extra\n\nvar x int = \"unused subpackages are not built\"\n",
+		"github.com/example/bad/bad.go":     "package bad\n\nvar x int = \"not an int\"\n",
+		"github.com/example/tagged/t.go":    "// +build special\n\npackage tagged\n\nvar x int = \"only with the tag\"\n",
+		"github.com/example/tagged/none.go": "package tagged\n",
+	}
+	for name, src := range sources {
+		p := filepath.Join(dir, "vendor", filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	i := NewInstaller()
+	i.Base = dir
+	conf := &cfg.Config{
+		Name: "example.com/app",
+		Imports: cfg.Dependencies{
+			{Name: "github.com/example/ok", Subpackages: []string{".", "sub"}},
+			{Name: "github.com/example/tagged"},
+		},
+	}
+	if err := i.CheckBuild(conf); err != nil {
+		t.Errorf("Unexpected error building: %s", err)
+	}
+
+	i.BuildTags = []string{"special"}
+	if err := i.CheckBuild(conf); err == nil || !strings.Contains(err.Error(), "github.com/example/tagged") {
+		t.Errorf("Expected the build tags to be used, got %v", err)
+	}
+
+	i.BuildTags = nil
+	conf.Imports = append(conf.Imports, &cfg.Dependency{Name: "github.com/example/bad"})
+	err = i.CheckBuild(conf)
+	if err == nil {
+		t.Fatal("Expected an error building a broken package")
+	}
+	if !strings.Contains(err.Error(), "failed to build:\n  github.com/example/bad") {
+		t.Errorf("Expected the failed package to be listed, got %s", err)
+	}
+}
@@ -0,0 +1,52 @@
+package repo
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Ownercz/glide/cfg"
+	"github.com/Ownercz/glide/msg"
+)
+
+// cacheTTL returns how long the cached copy of a dependency is used before
+// fetching updates. The setting on the dependency wins over the Installer's.
+func cacheTTL(dep *cfg.Dependency, i *Installer) time.Duration {
+	if dep.CacheTTL > 0 {
+		return dep.CacheTTL
+	}
+	if i == nil {
+		return 0
+	}
+	return i.CacheTTL
+}
+
+// fetchedPath is the file whose modification time records when a cached
+// repository was last fetched.
+func (i *Installer) fetchedPath(key string) string {
+	return filepath.Join(i.cacheLocation(), "fetched", key)
+}
+
+// markFetched records that a cached repository was fetched now.
+func (i *Installer) markFetched(key string) {
+	p := i.fetchedPath(key)
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		msg.Debug("Unable to record the fetch of %s: %s", key, err)
+		return
+	}
+	f, err := os.Create(p)
+	if err != nil {
+		msg.Debug("Unable to record the fetch of %s: %s", key, err)
+		return
+	}
+	f.Close()
+}
+
+// fetchedWithin returns if a cached repository was fetched within a duration.
+func (i *Installer) fetchedWithin(key string, d time.Duration) bool {
+	fi, err := os.Stat(i.fetchedPath(key))
+	if err != nil {
+		return false
+	}
+	return time.Since(fi.ModTime()) < d
+}
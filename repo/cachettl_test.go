@@ -0,0 +1,100 @@
+package repo
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Ownercz/glide/cfg"
+)
+
+func TestCacheTTL(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir, err := ioutil.TempDir("", "glide-cachettl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	remote := filepath.Join(dir, "remote")
+	newTestRemote(t, remote)
+	commit := func() {
+		testGit(t, remote, "commit", "-q", "--allow-empty", "-m", "commit")
+	}
+
+	update := func(ttl time.Duration) Metrics {
+		i := NewInstaller()
+		i.Home = filepath.Join(dir, "home")
+		i.CacheTTL = ttl
+		dep := &cfg.Dependency{Name: "github.com/example/ttl", Repository: remote, VcsType: "git"}
+		if err := i.VcsUpdate(dep); err != nil {
+			t.Fatalf("Unexpected error updating: %s", err)
+		}
+		return i.Metrics()
+	}
+
+	if m := update(time.Hour); m.Cloned != 1 {
+		t.Fatalf("Expected the repository to be cloned, got %+v", m)
+	}
+	commit()
+	if m := update(time.Hour); m.Skipped != 1 || m.Updated != 0 {
+		t.Errorf("Expected the update to be skipped within the TTL, got %+v", m)
+	}
+	if m := update(0); m.Updated != 1 {
+		t.Errorf("Expected the update to be fetched without a TTL, got %+v", m)
+	}
+
+	// The setting on the dependency wins.
+	if ttl := cacheTTL(&cfg.Dependency{CacheTTL: time.Minute}, &Installer{CacheTTL: time.Hour}); ttl != time.Minute {
+		t.Errorf("Expected the TTL of the dependency to be used, got %s", ttl)
+	}
+}
+
+func TestCacheTTLMissingCommit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir, err := ioutil.TempDir("", "glide-cachettl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	remote := filepath.Join(dir, "remote")
+	first := newTestRemote(t, remote)
+	commit := func() string {
+		testGit(t, remote, "commit", "-q", "--allow-empty", "-m", "commit")
+		return testGit(t, remote, "rev-parse", "HEAD")
+	}
+
+	update := func(ref string) Metrics {
+		i := NewInstaller()
+		i.Home = filepath.Join(dir, "home")
+		i.CacheTTL = time.Hour
+		dep := &cfg.Dependency{Name: "github.com/example/ttl", Reference: ref, Repository: remote, VcsType: "git"}
+		if err := i.VcsUpdate(dep); err != nil {
+			t.Fatalf("Unexpected error updating: %s", err)
+		}
+		return i.Metrics()
+	}
+
+	if m := update(first); m.Cloned != 1 {
+		t.Fatalf("Expected the repository to be cloned, got %+v", m)
+	}
+
+	// A commit made after the cache was fetched is fetched within the TTL.
+	second := commit()
+	if m := update(second); m.Skipped != 0 || m.Updated != 1 {
+		t.Errorf("Expected the missing commit to be fetched within the TTL, got %+v", m)
+	}
+	if m := update(second); m.Skipped != 1 || m.Updated != 0 {
+		t.Errorf("Expected the commit in the cache to be skipped within the TTL, got %+v", m)
+	}
+}
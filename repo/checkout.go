@@ -0,0 +1,77 @@
+package repo
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+	"text/template"
+
+	"github.com/Ownercz/glide/cfg"
+	"github.com/Ownercz/glide/msg"
+)
+
+// checkoutVars are the values available to a custom checkout command template.
+type checkoutVars struct {
+	// Destination is the directory the dependency is checked out into.
+	Destination string
+
+	// Repository is the remote location of the dependency.
+	Repository string
+
+	// Reference is the configured version, if any.
+	Reference string
+}
+
+// customCheckout runs the checkout command configured on a dependency in place
+// of the VCS fetch. The command is a text/template with the fields of
+// checkoutVars available, for example:
+//
+//	checkout: ./hack/sparse-clone.sh {{.Repository}} {{.Destination}} {{.Reference}}
+//
+// The command is split on whitespace and run directly rather than through a
+// shell. It only runs when the Installer allows custom checkouts.
+func customCheckout(dep *cfg.Dependency, dest string, i *Installer) error {
+	if i == nil || !i.AllowCustomCheckout {
+		return fmt.Errorf("%s uses a custom checkout command but custom checkouts are not allowed", dep.Name)
+	}
+
+	args, err := checkoutCommand(dep, dest)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		return fmt.Errorf("Custom checkout command for %s is empty", dep.Name)
+	}
+
+	msg.Info("--> Running custom checkout for %s", dep.Name)
+	msg.Debug("Custom checkout command for %s: %s", dep.Name, strings.Join(args, " "))
+	cmd := exec.Command(args[0], args[1:]...)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("Custom checkout for %s failed: %s\n%s", dep.Name, err, out)
+	}
+
+	return nil
+}
+
+// checkoutCommand renders the custom checkout template for a dependency into
+// the command and its arguments.
+func checkoutCommand(dep *cfg.Dependency, dest string) ([]string, error) {
+	t, err := template.New(dep.Name).Option("missingkey=error").Parse(dep.Checkout)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid checkout command for %s: %s", dep.Name, err)
+	}
+
+	var b bytes.Buffer
+	v := checkoutVars{
+		Destination: dest,
+		Repository:  dep.Remote(),
+		Reference:   dep.Reference,
+	}
+	if err := t.Execute(&b, v); err != nil {
+		return nil, fmt.Errorf("Invalid checkout command for %s: %s", dep.Name, err)
+	}
+
+	return strings.Fields(b.String()), nil
+}
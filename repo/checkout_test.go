@@ -0,0 +1,121 @@
+package repo
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/Ownercz/glide/cfg"
+)
+
+func TestCheckoutCommand(t *testing.T) {
+	dep := &cfg.Dependency{
+		Name:       "example.com/foo/bar",
+		Reference:  "v1.2.3",
+		Repository: "https://example.com/foo/bar.git",
+		Checkout:   "sparse.sh {{.Repository}} {{.Destination}} {{.Reference}}",
+	}
+
+	args, err := checkoutCommand(dep, "/tmp/dest")
+	if err != nil {
+		t.Fatalf("Unexpected error rendering checkout command: %s", err)
+	}
+	expected := []string{"sparse.sh", "https://example.com/foo/bar.git", "/tmp/dest", "v1.2.3"}
+	if !reflect.DeepEqual(args, expected) {
+		t.Errorf("Expected checkout command %v, got %v", expected, args)
+	}
+
+	dep.Checkout = "sparse.sh {{.Missing}}"
+	if _, err := checkoutCommand(dep, "/tmp/dest"); err == nil {
+		t.Error("Expected an error for an unknown template variable")
+	}
+
+	if err := customCheckout(dep, "/tmp/dest", &Installer{}); err == nil {
+		t.Error("Expected custom checkout to be refused when not allowed")
+	}
+}
+
+func TestCustomCheckoutFromDependency(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir, err := ioutil.TempDir("", "glide-checkout")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	write := func(p, src string) {
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The configuration of parent asks for shared to be fetched by running a
+	// command. Running it would create the marker.
+	marker := filepath.Join(dir, "marker")
+	shared := filepath.Join(dir, "remotes", "shared")
+	newTestRemote(t, shared, "shared.go", "package shared\n")
+	parent := filepath.Join(dir, "remotes", "parent")
+	newTestRemote(t, parent,
+		"parent.go", "package parent\n\nimport _ \"github.com/example/shared\"\n",
+		"glide.yaml", "package: github.com/example/parent\nimport:\n- package: github.com/example/shared\n  repo: "+shared+"\n  vcs: git\n  checkout: touch "+marker+"\n")
+
+	project := filepath.Join(dir, "project")
+	write(filepath.Join(project, "main.go"), "package main\n\nimport _ \"github.com/example/parent\"\n")
+	conf := &cfg.Config{
+		Name:    "example.com/project",
+		Imports: cfg.Dependencies{{Name: "github.com/example/parent", Repository: parent, VcsType: "git"}},
+	}
+
+	i := NewInstaller()
+	i.Home = filepath.Join(dir, "home")
+	i.Base = project
+	i.AllowCustomCheckout = true
+	if err := i.Checkout(conf); err != nil {
+		t.Fatal(err)
+	}
+	if err := i.Update(conf); err != nil {
+		t.Fatalf("Unexpected error updating: %s", err)
+	}
+
+	if _, err := os.Stat(marker); err == nil {
+		t.Error("Expected the checkout command of a dependency not to be run")
+	}
+	if dep := conf.Imports.Get("github.com/example/shared"); dep == nil || dep.Checkout != "" {
+		t.Errorf("Expected shared to be fetched without a checkout command, got %+v", dep)
+	}
+
+	// The same goes for the dependencies in a resolved file.
+	os.RemoveAll(filepath.Join(dir, "home"))
+	resolved := filepath.Join(dir, "resolved.lock")
+	lock := &cfg.Lockfile{Imports: cfg.Locks{
+		{Name: "github.com/example/parent", Version: conf.Imports.Get("github.com/example/parent").Pin, Repository: parent, VcsType: "git"},
+		{Name: "github.com/example/shared", Version: conf.Imports.Get("github.com/example/shared").Pin, Repository: shared, VcsType: "git", Checkout: "touch " + marker},
+	}}
+	if err := lock.WriteFile(resolved); err != nil {
+		t.Fatal(err)
+	}
+	i = NewInstaller()
+	i.Home = filepath.Join(dir, "home")
+	i.Base = project
+	i.AllowCustomCheckout = true
+	i.ResolvedFile = resolved
+	conf = &cfg.Config{
+		Name:    "example.com/project",
+		Imports: cfg.Dependencies{{Name: "github.com/example/parent", Repository: parent, VcsType: "git"}},
+	}
+	if err := i.Update(conf); err != nil {
+		t.Fatalf("Unexpected error updating from the resolved file: %s", err)
+	}
+	if _, err := os.Stat(marker); err == nil {
+		t.Error("Expected the checkout command in a resolved file not to be run")
+	}
+}
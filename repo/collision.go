@@ -0,0 +1,66 @@
+package repo
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Ownercz/glide/cfg"
+)
+
+// checkCollisions returns an error when dependencies with different names
+// would be put in the same vendor directory, such as through VendorName, but
+// are fetched from different repositories or at different versions. The
+// error names each pair and their sources. Dependencies that agree on where
+// they come from can share a directory. Directories differing only in case
+// are the same one on case insensitive file systems so they collide too. A
+// dependency listed more than once, such as in both the imports and the test
+// imports, collides with itself when fetched from different repositories.
+func (i *Installer) checkCollisions(deps cfg.Dependencies) error {
+	byDir := make(map[string][]*cfg.Dependency)
+	collisions := []string{}
+	for _, dep := range deps {
+		dir, err := i.vendorDir("", dep.Name)
+		if err != nil {
+			return err
+		}
+
+		key := strings.ToLower(dir)
+		for _, other := range byDir[key] {
+			if (other.Name == dep.Name && i.sameSource(other, dep)) || i.compatibleSource(other, dep) {
+				continue
+			}
+			collisions = append(collisions, fmt.Sprintf("%s (%s) and %s (%s) both use vendor/%s",
+				other.Name, source(other), dep.Name, source(dep), filepath.ToSlash(dir)))
+			break
+		}
+		byDir[key] = append(byDir[key], dep)
+	}
+
+	if len(collisions) == 0 {
+		return nil
+	}
+	sort.Strings(collisions)
+	return fmt.Errorf("Dependencies conflict over their vendor directory:\n  %s", strings.Join(collisions, "\n  "))
+}
+
+// compatibleSource returns if two dependencies are fetched from the same
+// repository at the same version.
+func (i *Installer) compatibleSource(a, b *cfg.Dependency) bool {
+	return i.sameSource(a, b) && a.VcsType == b.VcsType && a.Reference == b.Reference
+}
+
+// sameSource returns if two dependencies are fetched from the same
+// repository. Their canonical locations are compared with CanonicalRepos.
+func (i *Installer) sameSource(a, b *cfg.Dependency) bool {
+	return a.Location() == b.Location() || (i.CanonicalRepos && cfg.SameRepository(a, b, true))
+}
+
+// source describes where a dependency is fetched from.
+func source(dep *cfg.Dependency) string {
+	if dep.Reference == "" {
+		return dep.Location()
+	}
+	return dep.Location() + "@" + dep.Reference
+}
@@ -0,0 +1,77 @@
+package repo
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Ownercz/glide/cfg"
+)
+
+func TestCheckCollisions(t *testing.T) {
+	i := NewInstaller()
+	deps := cfg.Dependencies{
+		{Name: "github.com/example/a", Reference: "v1.0.0"},
+		{Name: "github.com/example/b"},
+		{Name: "github.com/example/b"},
+	}
+	if err := i.checkCollisions(deps); err != nil {
+		t.Errorf("Unexpected collision: %s", err)
+	}
+
+	// Both are put in the same directory.
+	i.VendorName = func(name string) string {
+		return strings.Replace(name, "example.com/fork", "github.com/example", 1)
+	}
+	deps = append(deps, &cfg.Dependency{Name: "example.com/fork/a", Repository: "https://github.com/example/a", Reference: "v1.0.0"})
+	if err := i.checkCollisions(deps); err != nil {
+		t.Errorf("Expected dependencies from the same source to share a directory, got %s", err)
+	}
+
+	deps = append(deps, &cfg.Dependency{Name: "example.com/fork/a/", Repository: "https://example.com/fork/a", Reference: "fix"})
+	err := i.checkCollisions(deps)
+	if err == nil {
+		t.Fatal("Expected an error for dependencies from different sources in the same directory")
+	}
+	for _, s := range []string{"github.com/example/a (https://github.com/example/a@v1.0.0)", "example.com/fork/a/ (https://example.com/fork/a@fix)", "vendor/github.com/example/a"} {
+		if !strings.Contains(err.Error(), s) {
+			t.Errorf("Expected the error to contain %q, got %s", s, err)
+		}
+	}
+}
+
+func TestCheckCollisionsCase(t *testing.T) {
+	i := NewInstaller()
+	deps := cfg.Dependencies{
+		{Name: "github.com/Example/lib"},
+		{Name: "github.com/example/lib"},
+	}
+	err := i.checkCollisions(deps)
+	if err == nil || !strings.Contains(err.Error(), "github.com/Example/lib (https://github.com/Example/lib) and github.com/example/lib (https://github.com/example/lib)") {
+		t.Errorf("Expected directories differing in case to collide, got %v", err)
+	}
+
+	// They are the same repository.
+	deps[1].Repository = "https://github.com/Example/lib"
+	if err := i.checkCollisions(deps); err != nil {
+		t.Errorf("Expected dependencies from the same source to share a directory, got %s", err)
+	}
+}
+
+func TestCheckCollisionsSameName(t *testing.T) {
+	i := NewInstaller()
+
+	// The versions of a dependency listed twice are reconciled later.
+	deps := cfg.Dependencies{
+		{Name: "github.com/example/lib", Reference: "v1.0.0"},
+		{Name: "github.com/example/lib", Reference: "v1.1.0"},
+	}
+	if err := i.checkCollisions(deps); err != nil {
+		t.Errorf("Unexpected collision for another version of the same repository: %s", err)
+	}
+
+	deps[1].Repository = "https://github.com/fork/lib"
+	err := i.checkCollisions(deps)
+	if err == nil || !strings.Contains(err.Error(), "github.com/example/lib (https://github.com/fork/lib@v1.1.0)") {
+		t.Errorf("Expected a dependency listed with another repository to collide, got %v", err)
+	}
+}
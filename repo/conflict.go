@@ -0,0 +1,101 @@
+package repo
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Ownercz/glide/cfg"
+	"github.com/Ownercz/semver"
+)
+
+// The ways conflicting versions of a dependency are resolved. See
+// VersionHandler.Strategy.
+const (
+	// ConflictFirstWins keeps the version set first, merging semantic
+	// version constraints where it can. It is the default.
+	ConflictFirstWins = "first-wins"
+
+	// ConflictHighestSemver uses the greatest of the conflicting versions.
+	// When they are not both semantic versions it falls back to
+	// ConflictFirstWins with a warning.
+	ConflictHighestSemver = "highest-semver"
+
+	// ConflictFail makes any conflict a resolution error.
+	ConflictFail = "fail"
+)
+
+// request records that a package wants a version of a dependency.
+func (d *VersionHandler) request(root, ref, from string) {
+	if d.requested == nil {
+		d.requested = make(map[string]map[string][]string)
+	}
+	if d.requested[root] == nil {
+		d.requested[root] = make(map[string][]string)
+	}
+	for _, f := range d.requested[root][ref] {
+		if f == from {
+			return
+		}
+	}
+	d.requested[root][ref] = append(d.requested[root][ref], from)
+}
+
+// requesters returns the packages that want a version of a dependency. A
+// version no dependency asked for comes from the config of the project.
+func (d *VersionHandler) requesters(root, ref string) string {
+	r := append([]string{}, d.requested[root][ref]...)
+	if len(r) == 0 {
+		return d.Config.Name
+	}
+	sort.Strings(r)
+	return strings.Join(r, ", ")
+}
+
+// resolveConflict picks between the version v already set for a dependency
+// and the one dep is wanted at by req, following the Strategy. The one used
+// is returned.
+func (d *VersionHandler) resolveConflict(v, dep *cfg.Dependency, dest, req string) *cfg.Dependency {
+	kept := d.requesters(v.Name, v.Reference)
+
+	switch d.Strategy {
+	case ConflictFail:
+		d.conflicts = append(d.conflicts, fmt.Sprintf("%s is %s for %s but %s wants %s", v.Name, v.Reference, kept, req, dep.Reference))
+		return v
+	case ConflictHighestSemver:
+		vv, verr := semver.NewVersion(v.Reference)
+		dv, derr := semver.NewVersion(dep.Reference)
+		if verr != nil || derr != nil {
+			singleWarn("Unable to compare %s versions %s and %s as semantic versions. Falling back to %s", v.Name, v.Reference, dep.Reference, ConflictFirstWins)
+			break
+		}
+		if dv.GreaterThan(vv) {
+			singleInfo("Using %s %s wanted by %s over %s wanted by %s", v.Name, dep.Reference, req, v.Reference, kept)
+			v.Reference = dep.Reference
+			v.Pin = ""
+		} else {
+			singleInfo("Keeping %s %s wanted by %s over %s wanted by %s", v.Name, v.Reference, kept, dep.Reference, req)
+		}
+		return v
+	}
+
+	old := v.Reference
+	v = determineDependency(v, dep, dest, req)
+	if v.Reference != old {
+		// The version was merged with, or replaced by, the one wanted.
+		for _, r := range strings.Split(kept, ", ") {
+			d.request(v.Name, v.Reference, r)
+		}
+		d.request(v.Name, v.Reference, req)
+	}
+	return v
+}
+
+// conflictError returns the conflicts found with the ConflictFail strategy
+// as an error.
+func (d *VersionHandler) conflictError() error {
+	if len(d.conflicts) == 0 {
+		return nil
+	}
+	return fmt.Errorf("Dependencies conflict over their versions:\n  %s", strings.Join(d.conflicts, "\n  "))
+}
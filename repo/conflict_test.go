@@ -0,0 +1,59 @@
+package repo
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Ownercz/glide/cfg"
+)
+
+func TestResolveConflict(t *testing.T) {
+	newHandler := func(strategy string) *VersionHandler {
+		return &VersionHandler{
+			Config:   &cfg.Config{Name: "example.com/app"},
+			Strategy: strategy,
+		}
+	}
+	newDep := func(ref string) *cfg.Dependency {
+		return &cfg.Dependency{Name: "example.com/lib", Reference: ref}
+	}
+
+	d := newHandler(ConflictHighestSemver)
+	d.request("example.com/lib", "v1.2.0", "example.com/a")
+	v := newDep("v1.2.0")
+	if dep := d.resolveConflict(v, newDep("v1.10.0"), "", "example.com/b"); dep != v || v.Reference != "v1.10.0" {
+		t.Errorf("Expected the higher version to be used, got %s", v.Reference)
+	}
+	v = newDep("v2.0.0")
+	if d.resolveConflict(v, newDep("v1.10.0"), "", "example.com/b"); v.Reference != "v2.0.0" {
+		t.Errorf("Expected the higher version to be kept, got %s", v.Reference)
+	}
+	v = newDep("master")
+	if d.resolveConflict(v, newDep("v1.10.0"), "", "example.com/b"); v.Reference != "master" {
+		t.Errorf("Expected the first version to win for a branch, got %s", v.Reference)
+	}
+	if err := d.conflictError(); err != nil {
+		t.Errorf("Expected no error without the fail strategy, got %s", err)
+	}
+
+	d = newHandler(ConflictFail)
+	d.request("example.com/lib", "v1.2.0", "example.com/a")
+	d.request("example.com/lib", "v1.2.0", "example.com/c")
+	v = newDep("v1.2.0")
+	if d.resolveConflict(v, newDep("v1.10.0"), "", "example.com/b"); v.Reference != "v1.2.0" {
+		t.Errorf("Expected the version to be left alone, got %s", v.Reference)
+	}
+	d.resolveConflict(newDep("v0.1.0"), newDep("v0.2.0"), "", "example.com/d")
+	err := d.conflictError()
+	if err == nil {
+		t.Fatal("Expected the conflicts to be an error")
+	}
+	for _, s := range []string{
+		"example.com/lib is v1.2.0 for example.com/a, example.com/c but example.com/b wants v1.10.0",
+		"example.com/lib is v0.1.0 for example.com/app but example.com/d wants v0.2.0",
+	} {
+		if !strings.Contains(err.Error(), s) {
+			t.Errorf("Expected the error to contain %q, got %s", s, err)
+		}
+	}
+}
@@ -0,0 +1,248 @@
+package repo
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Ownercz/glide/msg"
+)
+
+// CredentialsEnv is the environment variable credentials are read from, in
+// the same format as the CredentialsFile. Those in it take precedence over
+// the file, e.g.
+// GLIDE_CREDENTIALS="machine github.com password $GITHUB_TOKEN"
+const CredentialsEnv = "GLIDE_CREDENTIALS"
+
+// CredentialsFile is the name of the file in the glide home directory
+// credentials are read from. It uses the netrc format of machine, login and
+// password entries. The machine is a host name, with a port when it is not
+// the default one.
+const CredentialsFile = "credentials"
+
+// defaultLogins are the logins used with a token when none is given for the
+// hosts that expect a particular one.
+var defaultLogins = map[string]string{
+	"github.com": "x-access-token",
+	"gitlab.com": "oauth2",
+}
+
+// Credential is the login and password, or token, for a host.
+type Credential struct {
+	Login, Password string
+}
+
+// Credentials maps the hosts dependencies are fetched from over HTTPS to the
+// credential used for them.
+type Credentials map[string]Credential
+
+// ReadCredentials reads the credentials in the CredentialsFile of a glide
+// home directory and in the CredentialsEnv environment variable. None are
+// returned when neither is set.
+func ReadCredentials(home string) (Credentials, error) {
+	creds := Credentials{}
+
+	p := filepath.Join(home, CredentialsFile)
+	b, err := ioutil.ReadFile(p)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err == nil {
+		if creds, err = ParseCredentials(string(b)); err != nil {
+			return nil, fmt.Errorf("%s: %s", p, err)
+		}
+	}
+
+	env, err := ParseCredentials(os.Getenv(CredentialsEnv))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", CredentialsEnv, err)
+	}
+	for h, c := range env {
+		creds[h] = c
+	}
+
+	return creds, nil
+}
+
+// ParseCredentials parses credentials in the netrc format. A login can be left
+// out for a token on GitHub or GitLab. Entries that cannot be parsed are an
+// error rather than being skipped.
+func ParseCredentials(s string) (Credentials, error) {
+	creds := Credentials{}
+	fields := strings.Fields(s)
+	host := ""
+	var c Credential
+	done := func() error {
+		if host == "" {
+			return nil
+		}
+		if c.Login == "" {
+			c.Login = defaultLogins[host]
+		}
+		if c.Login == "" || c.Password == "" {
+			return fmt.Errorf("Invalid credentials for %s, a login and password are required", host)
+		}
+		creds[host] = c
+		return nil
+	}
+
+	for ii := 0; ii < len(fields); ii += 2 {
+		if ii+1 == len(fields) {
+			return nil, fmt.Errorf("Missing value for %s", fields[ii])
+		}
+		v := fields[ii+1]
+		switch fields[ii] {
+		case "machine":
+			if err := done(); err != nil {
+				return nil, err
+			}
+			host, c = strings.ToLower(v), Credential{}
+		case "login":
+			c.Login = v
+		case "password":
+			c.Password = v
+		default:
+			return nil, fmt.Errorf("Unknown credentials keyword %q", fields[ii])
+		}
+		if host == "" {
+			return nil, fmt.Errorf("Credentials need to start with a machine")
+		}
+	}
+
+	if err := done(); err != nil {
+		return nil, err
+	}
+	return creds, nil
+}
+
+// The ways credentials are passed to git. See Installer.CredentialsMode.
+const (
+	// CredentialsHeader passes the credentials as a basic authorization
+	// header in the environment of each git command. This needs git 2.31 or
+	// newer, older versions fall back to CredentialsURL. It is the default.
+	CredentialsHeader = "header"
+
+	// CredentialsURL passes the credentials in the URL git fetches from,
+	// rewritten on the command line of each git command. It works with every
+	// version of git but the credentials are visible to other users of the
+	// system in the process list.
+	CredentialsURL = "url"
+)
+
+// gitConfigEnv is the first version of git reading configuration from the
+// GIT_CONFIG_COUNT variable and those it numbers. Older versions ignore them.
+var gitConfigEnv = [2]int{2, 31}
+
+// gitVersion returns the major and minor version of the installed git.
+var gitVersion = func() ([2]int, error) {
+	out, err := exec.Command("git", "version").Output()
+	if err != nil {
+		return [2]int{}, err
+	}
+	var ver [2]int
+	if _, err := fmt.Sscanf(string(out), "git version %d.%d", &ver[0], &ver[1]); err != nil {
+		return [2]int{}, fmt.Errorf("Unable to parse the git version %q", strings.TrimSpace(string(out)))
+	}
+	return ver, nil
+}
+
+// hosts returns the hosts of the credentials in order.
+func (c Credentials) hosts() []string {
+	hosts := make([]string, 0, len(c))
+	for h := range c {
+		hosts = append(hosts, h)
+	}
+	sort.Strings(hosts)
+	return hosts
+}
+
+// credentialsConfig returns the git configuration sending the credentials
+// as a basic authorization header to their hosts over HTTPS.
+func (c Credentials) credentialsConfig() [][2]string {
+	conf := make([][2]string, 0, len(c))
+	for _, h := range c.hosts() {
+		auth := base64.StdEncoding.EncodeToString([]byte(c[h].Login + ":" + c[h].Password))
+		conf = append(conf, [2]string{
+			"http.https://" + h + "/.extraHeader",
+			"Authorization: Basic " + auth,
+		})
+	}
+	return conf
+}
+
+// credentialsEnv returns the environment passing the credentialsConfig to a
+// git command. Configuration already set in the environment by the user is
+// kept.
+func (c Credentials) credentialsEnv() []string {
+	n, _ := strconv.Atoi(os.Getenv("GIT_CONFIG_COUNT"))
+	env := []string{}
+	for _, kv := range c.credentialsConfig() {
+		env = append(env, fmt.Sprintf("GIT_CONFIG_KEY_%d=%s", n, kv[0]), fmt.Sprintf("GIT_CONFIG_VALUE_%d=%s", n, kv[1]))
+		n++
+	}
+	return append(env, "GIT_CONFIG_COUNT="+strconv.Itoa(n))
+}
+
+// credentialsArgs returns the git options rewriting the HTTPS URLs of the
+// hosts of the credentials to ones including them. Git splits the options at
+// the first = so it is escaped in the credentials.
+func (c Credentials) credentialsArgs() []string {
+	args := []string{}
+	for _, h := range c.hosts() {
+		user := url.UserPassword(c[h].Login, c[h].Password).String()
+		user = strings.Replace(user, "=", "%3D", -1)
+		args = append(args, "-c", "url.https://"+user+"@"+h+"/.insteadOf=https://"+h+"/")
+	}
+	return args
+}
+
+// credentialsMode returns how the Credentials are passed to git. When the
+// installed git is too old for CredentialsHeader it warns, once, and uses
+// CredentialsURL rather than leaving git to ignore them.
+func (i *Installer) credentialsMode() string {
+	if i.CredentialsMode == CredentialsURL {
+		return CredentialsURL
+	}
+	i.credentialsSetup.Do(func() {
+		ver, err := gitVersion()
+		if err != nil {
+			msg.Debug("Unable to check the version of git: %s", err)
+			return
+		}
+		if ver[0] < gitConfigEnv[0] || (ver[0] == gitConfigEnv[0] && ver[1] < gitConfigEnv[1]) {
+			msg.Warn("git %d.%d ignores credentials passed in its environment, which needs %d.%d or newer. Passing them in the URL instead", ver[0], ver[1], gitConfigEnv[0], gitConfigEnv[1])
+			i.credentialsFallback = true
+		}
+	})
+	if i.credentialsFallback {
+		return CredentialsURL
+	}
+	return CredentialsHeader
+}
+
+// gitEnv returns the environment and options added to a git command reaching
+// a remote so it uses the SSH key for the remote and the Credentials, and
+// follows the AuthPrompt. They are only set on that command, rather than in
+// the environment of glide, so they are not written to the cache, lock file
+// or output. Other VCS are not given the credentials.
+func (i *Installer) gitEnv(remote string) (env, args []string) {
+	if i == nil {
+		return nil, nil
+	}
+	env = i.promptEnv(i.sshEnv(remote))
+	if len(i.Credentials) > 0 {
+		if i.credentialsMode() == CredentialsURL {
+			args = i.Credentials.credentialsArgs()
+		} else {
+			env = append(env, i.Credentials.credentialsEnv()...)
+		}
+	}
+	return env, args
+}
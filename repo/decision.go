@@ -0,0 +1,51 @@
+package repo
+
+// The reasons a VersionDecision gives for the version chosen.
+const (
+	// VersionPinned is a version set in the project's glide.yaml file.
+	VersionPinned = "pinned"
+
+	// VersionImported is a version set in the configuration of another
+	// dependency, named by From.
+	VersionImported = "imported"
+
+	// VersionOverride is a version set by a replace rule. See Installer.Replace.
+	VersionOverride = "override"
+
+	// VersionLinked is the current version of a checkout that is a symlink.
+	// These are left alone rather than being set.
+	VersionLinked = "linked"
+
+	// VersionDefault is used when no version was asked for. The default
+	// branch of the repository is used.
+	VersionDefault = "default"
+)
+
+// VersionDecision describes a version chosen for a dependency while
+// resolving.
+type VersionDecision struct {
+	// Package is the name of the dependency.
+	Package string
+
+	// Reference is the version chosen. It is empty with VersionDefault.
+	Reference string
+
+	// Reason is one of VersionPinned, VersionImported, VersionOverride,
+	// VersionLinked or VersionDefault.
+	Reason string
+
+	// From is the dependency whose configuration set an imported version.
+	From string
+}
+
+// VersionHook receives each version decision made while resolving.
+type VersionHook func(VersionDecision)
+
+// decided passes a version decision to the OnVersion hook of the installer,
+// if there is one.
+func (d *VersionHandler) decided(dec VersionDecision) {
+	if d.installer == nil || d.installer.OnVersion == nil {
+		return
+	}
+	d.installer.OnVersion(dec)
+}
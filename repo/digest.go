@@ -0,0 +1,176 @@
+package repo
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Ownercz/glide/cfg"
+	"github.com/Ownercz/glide/msg"
+)
+
+// digestPrefix names the algorithm of a digest so it can be changed later.
+const digestPrefix = "sha256:"
+
+// AddDigests records in each entry of a lock file the digest, a hash of the
+// content, of the dependency in the vendor directory so VerifyDigests can
+// check it later without the cache or network. Dependencies not in the
+// vendor directory, such as those for another platform, are left without
+// one.
+func (i *Installer) AddDigests(lock *cfg.Lockfile) error {
+	vendor := i.VendorPath()
+	dirs, err := i.lockDirs(lock)
+	if err != nil {
+		return err
+	}
+	for _, l := range append(lock.Imports[:len(lock.Imports):len(lock.Imports)], lock.DevImports...) {
+		dest := filepath.Join(vendor, dirs[l.Name])
+		if fi, err := os.Lstat(dest); err != nil || !fi.IsDir() {
+			l.Digest = ""
+			continue
+		}
+		if l.Digest, err = digest(dest, nestedDirs(dirs, l.Name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// VerifyDigests checks each dependency in the lock file against the vendor
+// directory and returns those that don't match, in the order of the lock
+// file. A dependency missing from the vendor directory doesn't match. One
+// with a digest in the lock file needs to have the same content and one
+// kept with its VCS metadata needs to be at the locked revision. Those with
+// neither can only be checked for being there. Test dependencies are checked
+// when ResolveTest is set. Like VerifyVendor, files removed from nested
+// vendor directories are not counted and symlinks and dependencies for
+// another platform are skipped.
+func (i *Installer) VerifyDigests(lock *cfg.Lockfile) ([]ModifiedDependency, error) {
+	locks := lock.Imports
+	if i.ResolveTest {
+		locks = append(locks[:len(locks):len(locks)], lock.DevImports...)
+	}
+
+	vendor := i.VendorPath()
+	dirs, err := i.lockDirs(lock)
+	if err != nil {
+		return nil, err
+	}
+	modified := []ModifiedDependency{}
+	for _, l := range locks {
+		dep := cfg.DependencyFromLock(l)
+		if filterArchOs(dep, i) {
+			continue
+		}
+		dest := filepath.Join(vendor, dirs[l.Name])
+		if isSymlink(dest) {
+			msg.Debug("%s is a symlink to a local checkout. Not verifying it", dest)
+			continue
+		}
+		if _, err := os.Stat(dest); os.IsNotExist(err) {
+			modified = append(modified, ModifiedDependency{Name: l.Name, Missing: true})
+			continue
+		}
+
+		m := ModifiedDependency{Name: l.Name}
+		verified := false
+		if l.Digest != "" {
+			d, err := digest(dest, nestedDirs(dirs, l.Name))
+			if err != nil {
+				return modified, err
+			}
+			m.Digest = d != l.Digest
+			verified = true
+		}
+		for n := range vcsDirs {
+			if _, err := os.Stat(filepath.Join(dest, n)); err != nil {
+				continue
+			}
+			repo, err := dep.GetRepo(dest)
+			if err == nil {
+				m.Version, err = repo.Version()
+			}
+			if err != nil {
+				msg.Warn("Unable to read the version of %s in the vendor directory: %s", l.Name, err)
+				break
+			}
+			if m.Version == l.Version {
+				m.Version = ""
+			}
+			verified = true
+			break
+		}
+
+		if !verified {
+			msg.Warn("%s has no digest in the lock file. Only checked it is in the vendor directory", l.Name)
+		}
+		if m.Digest || m.Version != "" {
+			modified = append(modified, m)
+		}
+	}
+
+	return modified, nil
+}
+
+// lockDirs returns the directories, relative to the vendor directory, of the
+// dependencies in a lock file by name.
+func (i *Installer) lockDirs(lock *cfg.Lockfile) (map[string]string, error) {
+	dirs := make(map[string]string, len(lock.Imports)+len(lock.DevImports))
+	for _, l := range append(lock.Imports[:len(lock.Imports):len(lock.Imports)], lock.DevImports...) {
+		d, err := i.vendorDir("", l.Name)
+		if err != nil {
+			return nil, err
+		}
+		dirs[l.Name] = d
+	}
+	return dirs, nil
+}
+
+// nestedDirs returns the directories of the other dependencies within the one
+// with a name, relative to its directory.
+func nestedDirs(dirs map[string]string, name string) []string {
+	nested := []string{}
+	for n, d := range dirs {
+		if n != name && strings.HasPrefix(d, dirs[name]+string(os.PathSeparator)) {
+			nested = append(nested, d[len(dirs[name])+1:])
+		}
+	}
+	return nested
+}
+
+// digest returns the hash of the content of the files in a directory, along
+// with their / separated paths, leaving out the skip paths, VCS metadata and
+// the files stripping nested vendor directories removes. The content of
+// symlinks is that of the file they link to.
+func digest(dir string, skip []string) (string, error) {
+	files, err := treeFiles(dir, skip)
+	if err != nil {
+		return "", err
+	}
+	paths := make([]string, 0, len(files))
+	for rel := range files {
+		if !stripped(rel) {
+			paths = append(paths, rel)
+		}
+	}
+	sort.Strings(paths)
+
+	h := sha256.New()
+	for _, rel := range paths {
+		c, err := ioutil.ReadFile(filepath.Join(dir, rel))
+		if err != nil && files[rel].Mode()&os.ModeSymlink != 0 {
+			// A link that can't be followed is hashed by where it points.
+			t, lerr := os.Readlink(filepath.Join(dir, rel))
+			c, err = []byte(t), lerr
+		}
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "%s %x\n", filepath.ToSlash(rel), sha256.Sum256(c))
+	}
+	return fmt.Sprintf("%s%x", digestPrefix, h.Sum(nil)), nil
+}
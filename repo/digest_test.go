@@ -0,0 +1,80 @@
+package repo
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Ownercz/glide/cfg"
+)
+
+func TestVerifyDigests(t *testing.T) {
+	dir, err := ioutil.TempDir("", "glide-digest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	i := NewInstaller()
+	i.Vendor = filepath.Join(dir, "vendor")
+	for _, f := range []string{"a/a.go", "a/vendor/other/o.go", "a/nested/n.go", "b/b.go"} {
+		p := filepath.Join(i.Vendor, "github.com", "example", filepath.FromSlash(f))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte("package "+filepath.Base(f)), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	lock := &cfg.Lockfile{
+		Imports: cfg.Locks{
+			{Name: "github.com/example/a", Version: "1"},
+			{Name: "github.com/example/a/nested", Version: "1"},
+			{Name: "github.com/example/b", Version: "1"},
+			{Name: "github.com/example/missing", Version: "1"},
+		},
+	}
+	if err := i.AddDigests(lock); err != nil {
+		t.Fatal(err)
+	}
+	for ii, l := range lock.Imports {
+		if (ii < 3) != strings.HasPrefix(l.Digest, digestPrefix) {
+			t.Errorf("Unexpected digest %q for %s", l.Digest, l.Name)
+		}
+	}
+
+	// Stripping nested vendor directories and changing a nested dependency
+	// doesn't change the one it is in.
+	a := filepath.Join(i.Vendor, "github.com", "example", "a")
+	if err := os.RemoveAll(filepath.Join(a, "vendor")); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(a, "nested", "n.go"), []byte("package changed"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	m, err := i.VerifyDigests(lock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []ModifiedDependency{
+		{Name: "github.com/example/a/nested", Digest: true},
+		{Name: "github.com/example/missing", Missing: true},
+	}
+	if !reflect.DeepEqual(m, expect) {
+		t.Errorf("Expected the nested and missing dependencies, got %v", m)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(i.Vendor, "github.com", "example", "b", "added.go"), []byte("package b"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	m, err = i.VerifyDigests(lock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m) != 3 || m[1].Name != "github.com/example/b" || !m[1].Digest {
+		t.Errorf("Expected an added file to be a modification, got %v", m)
+	}
+}
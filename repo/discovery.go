@@ -0,0 +1,141 @@
+package repo
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/Ownercz/glide/cfg"
+	"github.com/Ownercz/glide/mirrors"
+	"github.com/Ownercz/glide/msg"
+	"github.com/Ownercz/glide/util"
+)
+
+// DiscoveryFunc maps an import path prefix, the root package of a
+// dependency, to the repository serving it and its VCS type. An empty repo
+// means the prefix is unknown and the built-in go get style discovery is used.
+type DiscoveryFunc func(prefix string) (repo, vcsType string, err error)
+
+type discovered struct {
+	repo, vcs string
+}
+
+// discoveryCache holds the result of a DiscoveryFunc for each prefix. This is
+// a concurrency safe implementation and its zero value is ready to use.
+type discoveryCache struct {
+	sync.Mutex
+
+	found map[string]discovered
+}
+
+// lookup returns the result of a DiscoveryFunc for a prefix, calling it when
+// there is none yet. The lock is not held while it runs so lookups of other
+// prefixes aren't held up. Concurrent lookups of the same prefix can each
+// call it, and the first result is kept.
+func (c *discoveryCache) lookup(prefix string, fn DiscoveryFunc) (discovered, error) {
+	c.Lock()
+	d, ok := c.found[prefix]
+	c.Unlock()
+	if ok {
+		return d, nil
+	}
+
+	repo, vcsType, err := fn(prefix)
+	if err != nil {
+		return discovered{}, err
+	}
+
+	c.Lock()
+	defer c.Unlock()
+	if d, ok := c.found[prefix]; ok {
+		return d, nil
+	}
+	if c.found == nil {
+		c.found = make(map[string]discovered)
+	}
+	d = discovered{repo: repo, vcs: vcsType}
+	c.found[prefix] = d
+
+	return d, nil
+}
+
+// discover looks up the location of a dependency in the Installer's
+// MirrorDir and then, for a dependency without a configured repository, with
+// its Discovery function. The result is set as the Mirror of the dependency,
+// and kept on the Installer for others at the same location, so every place
+// the remote is used picks it up without it being written back to the
+// glide.yaml file. A replace rule takes precedence
+// over everything else, followed by a rewrite rule in the config, which can be
+// nil. A copy in the MirrorDir takes precedence over mirrors
+// configured by the user. Other mirrors configured by the user take
+// precedence over the Discovery function. The URL rewrite rules in the config
+// are applied to whichever remote is used.
+func (i *Installer) discover(dep *cfg.Dependency, conf *cfg.Config) (err error) {
+	if i == nil {
+		return nil
+	}
+	defer func() {
+		if err == nil {
+			i.rewriteURL(dep, conf)
+		}
+	}()
+
+	if i.replaceRepo(dep) || i.rewriteRepo(dep, conf) {
+		return nil
+	}
+
+	if i.MirrorDir != "" {
+		found, err := i.useMirrorDir(dep)
+		if err != nil || found {
+			return err
+		}
+	}
+
+	if i.Discovery == nil || dep.Repository != "" {
+		return nil
+	}
+
+	if f, _, _ := mirrors.Get(dep.Location()); f {
+		return nil
+	}
+
+	d, err := i.discovered.lookup(dep.Name, i.Discovery)
+	if err != nil {
+		return err
+	}
+	if d.repo == "" {
+		return nil
+	}
+
+	msg.Debug("Discovered %s at %s (%s)", dep.Name, d.repo, d.vcs)
+	i.setLocation(dep, d.repo, d.vcs)
+
+	return nil
+}
+
+// useMirrorDir sets the bare Git repository for a dependency in the MirrorDir
+// as where the Installer fetches it from. Repositories are stored by import path with an
+// optional .git suffix, e.g. github.com/Ownercz/vcs.git. When the repository
+// is missing an error is returned in Offline mode.
+func (i *Installer) useMirrorDir(dep *cfg.Dependency) (bool, error) {
+	name, _ := util.SplitMajorVersion(dep.Name)
+	base := filepath.Join(i.MirrorDir, filepath.FromSlash(name))
+	for _, p := range []string{base, base + ".git"} {
+		// Bare repositories have a HEAD file at their top level.
+		if _, err := os.Stat(filepath.Join(p, "HEAD")); err != nil {
+			continue
+		}
+
+		msg.Debug("Using %s from the mirror directory at %s", dep.Name, p)
+		i.setLocation(dep, p, "git")
+		return true, nil
+	}
+
+	if i.Offline {
+		return false, fmt.Errorf("%s is not in the mirror directory %s and fetching from the network is disabled", dep.Name, i.MirrorDir)
+	}
+	msg.Debug("%s is not in the mirror directory. Fetching from the network", dep.Name)
+
+	return false, nil
+}
@@ -0,0 +1,146 @@
+package repo
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Ownercz/glide/cfg"
+	"gopkg.in/yaml.v2"
+)
+
+func TestDiscover(t *testing.T) {
+	calls := 0
+	i := NewInstaller()
+	i.Discovery = func(prefix string) (string, string, error) {
+		calls++
+		if prefix == "go.example.com/known" {
+			return "https://git.example.com/known.git", "git", nil
+		}
+		return "", "", nil
+	}
+
+	known := &cfg.Dependency{Name: "go.example.com/known"}
+	if err := i.discover(known, nil); err != nil {
+		t.Fatalf("Unexpected error discovering %s: %s", known.Name, err)
+	}
+	if known.Remote() != "https://git.example.com/known.git" || known.Vcs() != "git" {
+		t.Errorf("Expected discovered location, got %s (%s)", known.Remote(), known.Vcs())
+	}
+	if known.Repository != "" {
+		t.Error("Expected the dependency not to be altered")
+	}
+
+	unknown := &cfg.Dependency{Name: "go.example.com/unknown"}
+	for ii := 0; ii < 2; ii++ {
+		if err := i.discover(unknown, nil); err != nil {
+			t.Fatalf("Unexpected error discovering %s: %s", unknown.Name, err)
+		}
+	}
+	if unknown.Remote() != "https://go.example.com/unknown" {
+		t.Errorf("Expected default location, got %s", unknown.Remote())
+	}
+	if calls != 2 {
+		t.Errorf("Expected discovery results to be cached, got %d calls", calls)
+	}
+}
+
+func TestDiscoverMirrorDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "glide-mirror-dir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	bare := filepath.Join(dir, "mirror.example.com", "foo.git")
+	if err := os.MkdirAll(bare, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(bare, "HEAD"), []byte("ref: refs/heads/master\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	i := NewInstaller()
+	i.MirrorDir = dir
+	i.Offline = true
+
+	dep := &cfg.Dependency{Name: "mirror.example.com/foo"}
+	if err := i.discover(dep, nil); err != nil {
+		t.Fatalf("Unexpected error using the mirror directory: %s", err)
+	}
+	if dep.Remote() != bare || dep.Vcs() != "git" {
+		t.Errorf("Expected the mirror directory location, got %s (%s)", dep.Remote(), dep.Vcs())
+	}
+
+	if err := i.discover(&cfg.Dependency{Name: "mirror.example.com/missing"}, nil); err == nil {
+		t.Error("Expected an error for a missing repository when offline")
+	}
+	i.Offline = false
+	if err := i.discover(&cfg.Dependency{Name: "mirror.example.com/missing"}, nil); err != nil {
+		t.Errorf("Unexpected error falling back to the network: %s", err)
+	}
+}
+
+func TestDiscoverPerInstaller(t *testing.T) {
+	i := NewInstaller()
+	i.Discovery = func(prefix string) (string, string, error) {
+		return "https://git.example.com/scoped.git", "git", nil
+	}
+	dep := &cfg.Dependency{Name: "go.example.com/scoped"}
+	if err := i.discover(dep, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// Another dependency at the same location picks it up from the
+	// Installer, while other Installers and the dependency as written to
+	// the config don't see it.
+	other := &cfg.Dependency{Name: "go.example.com/scoped"}
+	i.replace(other)
+	if other.Remote() != "https://git.example.com/scoped.git" {
+		t.Errorf("Expected the discovered location on the Installer, got %s", other.Remote())
+	}
+	fresh := &cfg.Dependency{Name: "go.example.com/scoped"}
+	NewInstaller().replace(fresh)
+	if fresh.Remote() != "https://go.example.com/scoped" {
+		t.Errorf("Expected another Installer not to use the discovered location, got %s", fresh.Remote())
+	}
+	out, err := dep.MarshalYAML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if y, _ := yaml.Marshal(out); strings.Contains(string(y), "git.example.com") {
+		t.Errorf("Expected the discovered location not to be written, got %s", y)
+	}
+}
+
+func TestDiscoverConcurrentPrefixes(t *testing.T) {
+	// A slow lookup doesn't hold up the lookup of another prefix.
+	block := make(chan struct{})
+	i := NewInstaller()
+	i.Discovery = func(prefix string) (string, string, error) {
+		if prefix == "go.example.com/slow" {
+			<-block
+		}
+		return "", "", nil
+	}
+	done := make(chan error)
+	go func() { done <- i.discover(&cfg.Dependency{Name: "go.example.com/slow"}, nil) }()
+
+	fast := make(chan error)
+	go func() { fast <- i.discover(&cfg.Dependency{Name: "go.example.com/fast"}, nil) }()
+	select {
+	case err := <-fast:
+		if err != nil {
+			t.Error(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("Expected the lookup of another prefix not to wait for a slow one")
+	}
+	close(block)
+	if err := <-done; err != nil {
+		t.Error(err)
+	}
+}
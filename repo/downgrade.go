@@ -0,0 +1,122 @@
+package repo
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/Ownercz/glide/cfg"
+	"github.com/Ownercz/glide/msg"
+	"github.com/Ownercz/semver"
+	v "github.com/Ownercz/vcs"
+)
+
+// CheckDowngrades compares the versions resolved in a config to those in the
+// lock file being replaced and warns about each dependency moving to an
+// older version. With Strict an error naming them is returned instead.
+//
+// Versions are compared using the tags on each commit, as semantic versions
+// when possible. For Git commits without tags the history is used. Others
+// are not reported.
+func (i *Installer) CheckDowngrades(conf *cfg.Config, lock *cfg.Lockfile) error {
+	if lock == nil {
+		return nil
+	}
+
+	locked := make(map[string]string)
+	for _, l := range append(lock.Imports, lock.DevImports...) {
+		locked[l.Name] = l.Version
+	}
+
+	var down []string
+	for _, dep := range append(conf.Imports, conf.DevImports...) {
+		old, found := locked[dep.Name]
+		if !found || dep.Pin == "" || dep.Pin == old {
+			continue
+		}
+
+		key, err := i.cacheKey(dep)
+		if err != nil {
+			continue
+		}
+		repo, err := dep.GetRepo(filepath.Join(i.cacheLocation(), "src", key))
+		if err != nil {
+			msg.Debug("Unable to check %s for a downgrade: %s", dep.Name, err)
+			continue
+		}
+
+		if isDowngrade(repo, old, dep.Pin, i) {
+			m := fmt.Sprintf("%s is downgraded from %s to %s", dep.Name, describeVersion(repo, old), describeVersion(repo, dep.Pin))
+			down = append(down, m)
+			if !i.Strict {
+				msg.Warn(m)
+				i.recordWarning("%s", m)
+			}
+		}
+	}
+
+	if len(down) > 0 && i.Strict {
+		return fmt.Errorf("Dependencies would be downgraded:\n  %s", strings.Join(down, "\n  "))
+	}
+	return nil
+}
+
+// isDowngrade returns if moving a repository from the old to the new commit
+// goes back to an older version.
+func isDowngrade(repo v.Repo, old, nw string, i *Installer) bool {
+	if err := i.unshallow(repo); err != nil {
+		msg.Debug("Unable to check for a downgrade in %s: %s", repo.Remote(), err)
+		return false
+	}
+	ov, nv := tagVersion(repo, old), tagVersion(repo, nw)
+	if ov != "" && nv != "" {
+		return compareVersions(nv, ov) < 0
+	}
+
+	// Without tags a Git commit is older when it is in the history of the
+	// old one.
+	if repo.Vcs() == v.Git {
+		err := exec.Command("git", "-C", repo.LocalPath(), "merge-base", "--is-ancestor", nw, old).Run()
+		return err == nil
+	}
+
+	return false
+}
+
+// tagVersion returns the newest tag on a commit or an empty string when it
+// has none.
+func tagVersion(repo v.Repo, commit string) string {
+	tags, err := repo.TagsFromCommit(commit)
+	if err != nil || len(tags) == 0 {
+		return ""
+	}
+
+	newest := tags[0]
+	for _, t := range tags[1:] {
+		if compareVersions(t, newest) > 0 {
+			newest = t
+		}
+	}
+	return newest
+}
+
+// compareVersions compares two versions as semantic versions when both can be
+// parsed and as strings otherwise.
+func compareVersions(a, b string) int {
+	av, aerr := semver.NewVersion(a)
+	bv, berr := semver.NewVersion(b)
+	if aerr == nil && berr == nil {
+		return av.Compare(bv)
+	}
+
+	return strings.Compare(a, b)
+}
+
+// describeVersion returns a commit along with its tag, when it has one.
+func describeVersion(repo v.Repo, commit string) string {
+	if t := tagVersion(repo, commit); t != "" {
+		return fmt.Sprintf("%s (%s)", t, commit)
+	}
+	return commit
+}
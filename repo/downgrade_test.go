@@ -0,0 +1,71 @@
+package repo
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	v "github.com/Ownercz/vcs"
+)
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b   string
+		expect int
+	}{
+		{"v1.2.0", "v1.10.0", -1},
+		{"1.10.0", "v1.2.0", 1},
+		{"v1.0.0", "1.0.0", 0},
+		{"release-2017", "release-2016", 1},
+	}
+	for _, tt := range tests {
+		if c := compareVersions(tt.a, tt.b); c != tt.expect {
+			t.Errorf("Expected comparing %s to %s to be %d, got %d", tt.a, tt.b, tt.expect, c)
+		}
+	}
+}
+
+func TestIsDowngrade(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir, err := ioutil.TempDir("", "glide-downgrade")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Three commits where the first two are tagged v1.0.0 and v1.1.0.
+	remote := filepath.Join(dir, "remote")
+	git := func(args ...string) string { return testGit(t, remote, args...) }
+	commits := []string{newTestRemote(t, remote)}
+	git("tag", "v1.0.0")
+	for _, tag := range []string{"v1.1.0", ""} {
+		git("commit", "-q", "--allow-empty", "-m", "commit")
+		if tag != "" {
+			git("tag", tag)
+		}
+		commits = append(commits, git("rev-parse", "HEAD"))
+	}
+
+	repo, err := v.NewGitRepo(remote, filepath.Join(dir, "local"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Get(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !isDowngrade(repo, commits[1], commits[0], nil) {
+		t.Error("Expected moving from v1.1.0 to v1.0.0 to be a downgrade")
+	}
+	if !isDowngrade(repo, commits[2], commits[1], nil) {
+		t.Error("Expected moving to an earlier commit to be a downgrade")
+	}
+	if isDowngrade(repo, commits[0], commits[2], nil) {
+		t.Error("Expected moving to a newer commit not to be a downgrade")
+	}
+}
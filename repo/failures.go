@@ -0,0 +1,110 @@
+package repo
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"sync"
+	"time"
+
+	"github.com/Ownercz/glide/cfg"
+	"github.com/Ownercz/glide/msg"
+)
+
+// FailureReport lists the dependencies that failed to be fetched during a
+// run. It is written as JSON to the Installer's FailureReportFile.
+type FailureReport struct {
+	Created  time.Time `json:"created"`
+	Failures []Failure `json:"failures"`
+}
+
+// Failure is a dependency that failed to be fetched. Retries is the number of
+// times fetching it was retried after the first attempt. See MaxRetries.
+type Failure struct {
+	Name       string `json:"name"`
+	Repository string `json:"repository"`
+	Error      string `json:"error"`
+	Retries    int    `json:"retries"`
+}
+
+// failureList tracks the dependencies that failed to be fetched. This is a
+// concurrency safe implementation and its zero value is ready to use.
+type failureList struct {
+	sync.Mutex
+
+	failures []Failure
+	names    map[string]bool
+	retries  map[string]int
+}
+
+// recordFailure records a dependency that failed to be fetched. Only the
+// first failure of each dependency is kept. It is safe to call with a nil
+// Installer.
+func (i *Installer) recordFailure(dep *cfg.Dependency, err error) {
+	if i == nil || err == nil {
+		return
+	}
+
+	i.failures.Lock()
+	defer i.failures.Unlock()
+	if i.failures.names == nil {
+		i.failures.names = make(map[string]bool)
+	}
+	if i.failures.names[dep.Name] {
+		return
+	}
+	i.failures.names[dep.Name] = true
+	i.failures.failures = append(i.failures.failures, Failure{
+		Name:       dep.Name,
+		Repository: dep.Remote(),
+		Error:      err.Error(),
+		Retries:    i.failures.retries[dep.Name],
+	})
+}
+
+// countRetry counts a retry of fetching a dependency for its failure, should
+// it fail.
+func (i *Installer) countRetry(name string) {
+	i.failures.Lock()
+	defer i.failures.Unlock()
+	if i.failures.retries == nil {
+		i.failures.retries = make(map[string]int)
+	}
+	i.failures.retries[name]++
+}
+
+// Failures returns the dependencies that failed to be fetched so far in the
+// order they failed. This includes those that were quarantined.
+func (i *Installer) Failures() []Failure {
+	i.failures.Lock()
+	defer i.failures.Unlock()
+	f := make([]Failure, len(i.failures.failures))
+	copy(f, i.failures.failures)
+	return f
+}
+
+// WriteFailureReport writes the failures so far to FailureReportFile. Nothing
+// is written when it is not set. A report is written even when nothing failed
+// so an old one is not mistaken for the latest run.
+func (i *Installer) WriteFailureReport() error {
+	if i.FailureReportFile == "" {
+		return nil
+	}
+
+	r := &FailureReport{
+		Created:  time.Now(),
+		Failures: i.Failures(),
+	}
+	o, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(i.FailureReportFile, append(o, '\n'), 0666)
+}
+
+// writeFailureReport writes the failure report at the end of Install and
+// Update. A problem writing it is displayed rather than failing the run.
+func (i *Installer) writeFailureReport() {
+	if err := i.WriteFailureReport(); err != nil {
+		msg.Warn("Unable to write the failure report to %s: %s", i.FailureReportFile, err)
+	}
+}
@@ -0,0 +1,68 @@
+package repo
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/Ownercz/glide/cfg"
+)
+
+func TestFailureReport(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir, err := ioutil.TempDir("", "glide-failures")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	remote := filepath.Join(dir, "remotes", "good")
+	newTestRemote(t, remote)
+
+	missing := filepath.Join(dir, "remotes", "missing")
+	conf := &cfg.Config{
+		Name: "example.com/app",
+		Imports: cfg.Dependencies{
+			{Name: "github.com/example/good", Repository: remote, VcsType: "git"},
+			{Name: "github.com/example/bad", Repository: missing, VcsType: "git"},
+		},
+	}
+
+	i := NewInstaller()
+	i.Home = filepath.Join(dir, "home")
+	i.Quarantine = true
+	if err := ConcurrentUpdate(conf.Imports, i, conf); err != nil {
+		t.Fatalf("Unexpected error with Quarantine: %s", err)
+	}
+
+	// Nothing is written without a file.
+	if err := i.WriteFailureReport(); err != nil {
+		t.Errorf("Unexpected error without a report file: %s", err)
+	}
+
+	i.FailureReportFile = filepath.Join(dir, "failures.json")
+	if err := i.WriteFailureReport(); err != nil {
+		t.Fatalf("Unable to write the failure report: %s", err)
+	}
+	b, err := ioutil.ReadFile(i.FailureReportFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &FailureReport{}
+	if err := json.Unmarshal(b, r); err != nil {
+		t.Fatalf("Unable to parse the failure report: %s", err)
+	}
+	if len(r.Failures) != 1 {
+		t.Fatalf("Expected one failure, got %v", r.Failures)
+	}
+	f := r.Failures[0]
+	if f.Name != "github.com/example/bad" || f.Repository != missing || f.Error == "" {
+		t.Errorf("Unexpected failure %+v", f)
+	}
+}
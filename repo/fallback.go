@@ -0,0 +1,56 @@
+package repo
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Ownercz/glide/cfg"
+	"github.com/Ownercz/glide/msg"
+	v "github.com/Ownercz/vcs"
+)
+
+// getFromFallbacks fetches a dependency into the cache from its fallback
+// repositories, in order, after fetching or updating it failed with err. Each
+// fallback has its own location in the src directory of the cache, updated
+// when it is already there. The repository it was fetched from is kept on the
+// Installer for the rest of the run so later operations find the checkout.
+// The last error is returned when none of them work.
+func (i *Installer) getFromFallbacks(dep *cfg.Dependency, src string, err error) (v.Repo, error) {
+	remote, vcsType := dep.Remote(), dep.Vcs()
+	for _, fb := range dep.Fallbacks {
+		msg.Warn("Unable to fetch %s from %s, trying %s: %s", dep.Name, remote, fb, err)
+		remote = fb
+
+		fd := dep.Clone()
+		fd.Repository = fb
+		fd.VcsType = vcsType
+		key, kerr := i.cacheKey(fd)
+		if kerr != nil {
+			err = fmt.Errorf("Cache key generation error: %s", kerr)
+			continue
+		}
+		d := filepath.Join(src, key)
+
+		repo, gerr := fd.GetRepo(d)
+		if gerr == nil {
+			if _, serr := os.Stat(d); os.IsNotExist(serr) {
+				if gerr = i.clone(repo); gerr != nil {
+					os.RemoveAll(d)
+				}
+			} else {
+				gerr = i.update(repo)
+			}
+		}
+		if gerr != nil {
+			err = gerr
+			continue
+		}
+
+		msg.Info("--> Fetched %s from %s", dep.Name, fb)
+		i.setLocation(dep, fb, vcsType)
+		return repo, nil
+	}
+
+	return nil, err
+}
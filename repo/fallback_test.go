@@ -0,0 +1,125 @@
+package repo
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/Ownercz/glide/cfg"
+)
+
+func TestVcsGetFallbacks(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir, err := ioutil.TempDir("", "glide-fallback")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	remote := filepath.Join(dir, "remotes", "mirror")
+	newTestRemote(t, remote)
+
+	i := NewInstaller()
+	i.Home = filepath.Join(dir, "home")
+	primary := filepath.Join(dir, "remotes", "missing")
+	dep := &cfg.Dependency{
+		Name:       "github.com/example/fallback",
+		Repository: primary,
+		VcsType:    "git",
+		Fallbacks:  []string{filepath.Join(dir, "remotes", "gone"), remote},
+	}
+	if err := i.VcsGet(dep); err != nil {
+		t.Fatalf("Expected the dependency to be fetched from a fallback, got %s", err)
+	}
+	if dep.Repository != primary {
+		t.Errorf("Expected the repository to be left as %s, got %s", primary, dep.Repository)
+	}
+	if dep.Remote() != remote {
+		t.Errorf("Expected the rest of the run to use %s, got %s", remote, dep.Remote())
+	}
+	key, err := i.cacheKey(dep)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dep.GetRepo(filepath.Join(i.cacheLocation(), "src", key)); err != nil {
+		t.Errorf("Expected the cached checkout to be usable, got %s", err)
+	}
+
+	// A fallback already in the cache is updated.
+	dep = &cfg.Dependency{
+		Name:       "github.com/example/fallback",
+		Repository: filepath.Join(dir, "remotes", "missing2"),
+		VcsType:    "git",
+		Fallbacks:  []string{remote},
+	}
+	if err := i.VcsGet(dep); err != nil {
+		t.Errorf("Expected a cached fallback to be updated, got %s", err)
+	}
+
+	dep = &cfg.Dependency{
+		Name:       "github.com/example/nofallback",
+		Repository: filepath.Join(dir, "remotes", "missing3"),
+		VcsType:    "git",
+		Fallbacks:  []string{filepath.Join(dir, "remotes", "gone")},
+	}
+	if err := i.VcsGet(dep); err == nil {
+		t.Error("Expected an error when every fallback fails")
+	}
+}
+
+func TestVcsUpdateFallbacks(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir, err := ioutil.TempDir("", "glide-fallback-update")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	primary := filepath.Join(dir, "remotes", "primary")
+	remote := filepath.Join(dir, "remotes", "mirror")
+	for _, r := range []string{primary, remote} {
+		newTestRemote(t, r)
+	}
+
+	i := NewInstaller()
+	i.Home = filepath.Join(dir, "home")
+	dep := &cfg.Dependency{
+		Name:       "github.com/example/fallback",
+		Repository: primary,
+		VcsType:    "git",
+		Fallbacks:  []string{remote},
+	}
+	if err := i.VcsGet(dep); err != nil {
+		t.Fatalf("Unexpected error fetching the dependency: %s", err)
+	}
+	if dep.Remote() != primary {
+		t.Fatalf("Expected the primary repository to be used, got %s", dep.Remote())
+	}
+
+	// The primary repository going away after it was cached makes the update
+	// use the fallback.
+	if err := os.RemoveAll(primary); err != nil {
+		t.Fatal(err)
+	}
+	if err := i.VcsGet(dep); err != nil {
+		t.Fatalf("Expected the dependency to be updated from a fallback, got %s", err)
+	}
+	if dep.Remote() != remote {
+		t.Errorf("Expected the rest of the run to use %s, got %s", remote, dep.Remote())
+	}
+
+	// The fallback is only used by the Installer that fetched from it.
+	other := &cfg.Dependency{Name: "github.com/example/fallback", Repository: primary}
+	NewInstaller().replace(other)
+	if other.Remote() != primary {
+		t.Errorf("Expected the fallback not to leak into another Installer, got %s", other.Remote())
+	}
+}
@@ -0,0 +1,70 @@
+package repo
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/Ownercz/glide/cache"
+	"github.com/Ownercz/glide/cfg"
+	"github.com/Ownercz/glide/msg"
+)
+
+// fetchCache records the repositories fetched into the cache at each
+// reference during the run. This is a concurrency safe implementation and its
+// zero value is ready to use.
+type fetchCache struct {
+	sync.Mutex
+
+	fetched map[string]map[string]bool
+}
+
+// has returns if a repository in the cache was fetched at a reference.
+func (c *fetchCache) has(key, ref string) bool {
+	c.Lock()
+	defer c.Unlock()
+	return c.fetched[key][ref]
+}
+
+// add records that a repository in the cache was fetched at a reference.
+func (c *fetchCache) add(key, ref string) {
+	c.Lock()
+	defer c.Unlock()
+	if c.fetched == nil {
+		c.fetched = make(map[string]map[string]bool)
+	}
+	if c.fetched[key] == nil {
+		c.fetched[key] = make(map[string]bool)
+	}
+	c.fetched[key][ref] = true
+}
+
+// fetchDep fetches a dependency into the cache with VcsUpdate while holding
+// the lock of its repository in the cache. Workers fetching dependencies that
+// share a repository, such as two subpackages listed on their own, take turns
+// and only the first fetches it. A repository already fetched at the same
+// reference during the run is not fetched again.
+func (i *Installer) fetchDep(dep *cfg.Dependency) error {
+	key, err := i.cacheKey(dep)
+	if err != nil {
+		return err
+	}
+	cache.Lock(key)
+	defer cache.Unlock(key)
+
+	if i.fetched.has(key, dep.Reference) {
+		msg.Debug("%s was already fetched at %q. Skipping update", dep.Remote(), dep.Reference)
+		i.countMetric(func(m *Metrics) { m.Skipped++ })
+		return nil
+	}
+	if err := i.VcsUpdate(dep); err != nil {
+		return err
+	}
+
+	// VcsUpdate leaves the cache alone for pinned dependencies, so only a
+	// repository that is there without a pin counts as fetched.
+	if _, err := os.Stat(filepath.Join(i.cacheLocation(), "src", key)); err == nil && dep.Pin == "" {
+		i.fetched.add(key, dep.Reference)
+	}
+	return nil
+}
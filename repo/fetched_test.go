@@ -0,0 +1,50 @@
+package repo
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/Ownercz/glide/cfg"
+)
+
+func TestConcurrentUpdateSharedRepo(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir, err := ioutil.TempDir("", "glide-fetched")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	remote := filepath.Join(dir, "remote")
+	newTestRemote(t, remote, "a/a.go", "package a\n", "b/b.go", "package b\n")
+
+	// Two subpackages of one repository listed as dependencies of their own
+	// share its location in the cache.
+	deps := []*cfg.Dependency{
+		{Name: "github.com/example/repo/a", Repository: remote, VcsType: "git"},
+		{Name: "github.com/example/repo/b", Repository: remote, VcsType: "git"},
+	}
+	i := NewInstaller()
+	i.Home = filepath.Join(dir, "home")
+	if err := ConcurrentUpdate(deps, i, &cfg.Config{Name: "example.com/app", Imports: deps}); err != nil {
+		t.Fatalf("Unexpected error updating: %s", err)
+	}
+
+	m := i.Metrics()
+	if m.Cloned != 1 || m.Updated != 0 {
+		t.Errorf("Expected the repository to be fetched once, got %d clones and %d updates", m.Cloned, m.Updated)
+	}
+	key, err := i.cacheKey(deps[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !i.fetched.has(key, "") {
+		t.Error("Expected the repository to be recorded as fetched")
+	}
+}
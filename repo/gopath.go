@@ -0,0 +1,41 @@
+package repo
+
+import (
+	"os"
+	"path/filepath"
+
+	gpath "github.com/Ownercz/glide/path"
+)
+
+// The policies for packages found in the GOPATH while resolving, set with
+// GopathPolicy.
+const (
+	// GopathIgnore fetches the package into the cache like any other,
+	// ignoring the copy in the GOPATH. It is the default.
+	GopathIgnore = "ignore"
+
+	// GopathCopy uses the copy of the repository in the GOPATH, along with
+	// the dependencies of it also found there, and copies it to the vendor
+	// directory as it is. A package whose repository isn't in the GOPATH as
+	// a whole is fetched, which is logged.
+	GopathCopy = "copy"
+
+	// GopathFail makes a package found in the GOPATH an error.
+	GopathFail = "fail"
+)
+
+// gopaths returns the GOPATH directories. Tests replace it.
+var gopaths = gpath.Gopaths
+
+// fromGopath returns if the GOPATH has a copy of the repository of a root
+// package. When it does the package is used from it for the rest of the run.
+func (i *Installer) fromGopath(root string) bool {
+	for _, p := range gopaths() {
+		dir := filepath.Join(p, "src", filepath.FromSlash(root))
+		if fi, err := os.Stat(dir); err == nil && fi.IsDir() {
+			i.useCopy(root, dir, "the GOPATH "+p)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,97 @@
+package repo
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/Ownercz/glide/cfg"
+)
+
+func TestOnGopathPolicy(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir, err := ioutil.TempDir("", "glide-gopath")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// lib is in the GOPATH and in a git remote. other is only in the remote.
+	write := func(p, src string) {
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	gopath := filepath.Join(dir, "gopath")
+	write(filepath.Join(gopath, "src", "github.com", "example", "lib", "lib.go"), "package lib\n")
+	remote := filepath.Join(dir, "remote")
+	newTestRemote(t, remote, "lib.go", "package lib\n")
+	defer func(g func() []string) { gopaths = g }(gopaths)
+	gopaths = func() []string { return []string{gopath} }
+
+	handler := func(policy string) *MissingPackageHandler {
+		i := NewInstaller()
+		i.Home = filepath.Join(dir, "home-"+policy)
+		i.GopathPolicy = policy
+		conf := &cfg.Config{
+			Name: "example.com/project",
+			Imports: cfg.Dependencies{
+				{Name: "github.com/example/lib", Repository: remote, VcsType: "git"},
+				{Name: "github.com/example/other", Repository: remote, VcsType: "git"},
+			},
+		}
+		return &MissingPackageHandler{Config: conf, Use: newImportCache(), installer: i}
+	}
+	cached := func(m *MissingPackageHandler, name string) bool {
+		key, err := m.installer.cacheKey(m.Config.Imports.Get(name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = os.Stat(filepath.Join(m.installer.cacheLocation(), "src", key, "lib.go"))
+		return err == nil
+	}
+
+	// The default ignores the copy and fetches it.
+	m := handler("")
+	if ok, err := m.OnGopath("github.com/example/lib/sub", false); !ok || err != nil {
+		t.Fatalf("Expected the package to be fetched, got %t %v", ok, err)
+	}
+	if m.installer.inOverlay("github.com/example/lib") || !cached(m, "github.com/example/lib") {
+		t.Error("Expected the package to be fetched into the cache instead of using the GOPATH")
+	}
+
+	// copy uses the repository in the GOPATH and falls back to fetching.
+	m = handler(GopathCopy)
+	if ok, err := m.OnGopath("github.com/example/lib/sub", false); !ok || err != nil {
+		t.Fatalf("Expected the package to be used from the GOPATH, got %t %v", ok, err)
+	}
+	if !m.installer.inOverlay("github.com/example/lib") || cached(m, "github.com/example/lib") {
+		t.Error("Expected the package to be used from the GOPATH without being fetched")
+	}
+	if p := m.PkgPath("github.com/example/lib/sub"); p != filepath.Join(gopath, "src", "github.com", "example", "lib", "sub") {
+		t.Errorf("Expected the package to be resolved in the GOPATH, got %s", p)
+	}
+	if ok, err := m.OnGopath("github.com/example/other", false); !ok || err != nil {
+		t.Fatalf("Expected the package to be fetched, got %t %v", ok, err)
+	}
+	if m.installer.inOverlay("github.com/example/other") || !cached(m, "github.com/example/other") {
+		t.Error("Expected a package whose repository isn't in the GOPATH to be fetched")
+	}
+
+	// fail is an error.
+	m = handler(GopathFail)
+	if ok, err := m.OnGopath("github.com/example/lib", false); ok || err == nil {
+		t.Errorf("Expected an error for a package in the GOPATH, got %t %v", ok, err)
+	}
+	if cached(m, "github.com/example/lib") {
+		t.Error("Expected nothing to be fetched")
+	}
+}
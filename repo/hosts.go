@@ -0,0 +1,104 @@
+package repo
+
+import (
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Ownercz/glide/cfg"
+	"github.com/Ownercz/glide/msg"
+	"github.com/Ownercz/glide/util"
+)
+
+// Hosts returns the sorted unique list of hosts contacted to fetch the
+// dependencies. Mirrors are taken into account. It uses a new Installer, see
+// Installer.Hosts to include its rules.
+func Hosts(deps cfg.Dependencies, resolveVanity bool) ([]string, error) {
+	return NewInstaller().Hosts(nil, deps, resolveVanity)
+}
+
+// Hosts returns the sorted unique list of hosts the Installer contacts to
+// fetch the dependencies. Each one is located the way the Installer fetches
+// it, following mirrors, replace and rewrite rules, the mirror directory and
+// discovery, and the hosts of its fallbacks are included. Repositories on
+// the local file system have no host and are left out.
+//
+// Pass conf.Imports for the direct dependencies or the lock file entries for
+// the full transitive set. When resolveVanity is true the go-import meta data
+// of vanity import paths is fetched so the host serving the repository is
+// listed alongside the vanity host. This makes network requests.
+func (i *Installer) Hosts(conf *cfg.Config, deps cfg.Dependencies, resolveVanity bool) ([]string, error) {
+	found := make(map[string]bool)
+	add := func(remote string) error {
+		h, err := remoteHost(remote)
+		if err != nil {
+			return err
+		}
+		if h != "" {
+			found[h] = true
+		}
+		return nil
+	}
+
+	for _, dep := range deps {
+		// The rules are applied to a copy so the passed in dependencies are
+		// left as they are.
+		dep = dep.Clone()
+		i.replace(dep)
+		if err := i.discover(dep, conf); err != nil {
+			return nil, err
+		}
+
+		remote := dep.Remote()
+		if err := add(remote); err != nil {
+			return nil, err
+		}
+		for _, f := range dep.Fallbacks {
+			if err := add(f); err != nil {
+				return nil, err
+			}
+		}
+
+		if !resolveVanity || remote != dep.Location() || dep.Repository != "" {
+			continue
+		}
+
+		// The VCS lookup follows go-import meta tags for vanity paths. The
+		// cache location is used the same way the installer does.
+		key, err := i.cacheKey(dep)
+		if err != nil {
+			return nil, err
+		}
+		r, err := dep.GetRepo(filepath.Join(i.cacheLocation(), "src", key))
+		if err != nil {
+			msg.Debug("Unable to resolve the repository for %s: %s", dep.Name, err)
+			continue
+		}
+		if err := add(r.Remote()); err != nil {
+			return nil, err
+		}
+	}
+
+	hosts := make([]string, 0, len(found))
+	for h := range found {
+		hosts = append(hosts, h)
+	}
+	sort.Strings(hosts)
+
+	return hosts, nil
+}
+
+// remoteHost returns the host name from a remote location.
+func remoteHost(remote string) (string, error) {
+	if m := util.ScpSyntaxRe.FindStringSubmatch(remote); m != nil {
+		return strings.ToLower(m[2]), nil
+	}
+
+	u, err := url.Parse(remote)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.ToLower(urlHost(u)), nil
+}
@@ -0,0 +1,64 @@
+package repo
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Ownercz/glide/cfg"
+)
+
+func TestHosts(t *testing.T) {
+	deps := cfg.Dependencies{
+		&cfg.Dependency{Name: "github.com/Ownercz/vcs"},
+		&cfg.Dependency{Name: "github.com/Ownercz/semver"},
+		&cfg.Dependency{Name: "example.com/foo", Repository: "git@Git.Example.com:foo/bar.git"},
+		&cfg.Dependency{Name: "golang.org/x/net", Repository: "https://go.googlesource.com/net"},
+	}
+
+	hosts, err := Hosts(deps, false)
+	if err != nil {
+		t.Fatalf("Unexpected error listing hosts: %s", err)
+	}
+	expected := []string{"git.example.com", "github.com", "go.googlesource.com"}
+	if !reflect.DeepEqual(hosts, expected) {
+		t.Errorf("Expected hosts %v, got %v", expected, hosts)
+	}
+}
+
+func TestInstallerHosts(t *testing.T) {
+	i := NewInstaller()
+	i.Replace = ReplaceRules{
+		"github.com/Ownercz/semver": {Repo: "git@fork.example.com:me/semver.git"},
+	}
+	conf := &cfg.Config{
+		Rewrite: cfg.Rewrites{
+			{Prefix: "example.com/rewritten", Repo: "https://git.example.com/myorg"},
+		},
+		URLRewrite: cfg.URLRewrites{
+			{Prefix: "https://github.com/", URL: "https://mirror.internal.example.com/github/"},
+		},
+	}
+	deps := cfg.Dependencies{
+		&cfg.Dependency{Name: "github.com/Ownercz/vcs"},
+		&cfg.Dependency{Name: "github.com/Ownercz/semver"},
+		&cfg.Dependency{Name: "example.com/rewritten/repo"},
+		&cfg.Dependency{
+			Name:       "golang.org/x/net",
+			Repository: "https://go.googlesource.com/net",
+			Fallbacks:  []string{"https://backup.example.com/net"},
+		},
+		&cfg.Dependency{Name: "example.com/local", Repository: "/srv/repos/local"},
+	}
+
+	hosts, err := i.Hosts(conf, deps, false)
+	if err != nil {
+		t.Fatalf("Unexpected error listing hosts: %s", err)
+	}
+	expected := []string{"backup.example.com", "fork.example.com", "git.example.com", "go.googlesource.com", "mirror.internal.example.com"}
+	if !reflect.DeepEqual(hosts, expected) {
+		t.Errorf("Expected hosts %v, got %v", expected, hosts)
+	}
+	if deps[2].Remote() != "https://example.com/rewritten/repo" {
+		t.Errorf("Expected the dependencies to be left as they are, got %s", deps[2].Remote())
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strings"
 	"sync"
 	"syscall"
@@ -20,21 +21,44 @@ import (
 	"github.com/Ownercz/glide/util"
 	"github.com/Ownercz/semver"
 	"github.com/Ownercz/vcs"
-	"github.com/urfave/cli"
 )
 
 // Installer provides facilities for installing the repos in a config file.
+//
+// Installers with their own Base, or Vendor, and Home do not share state so
+// they can run concurrently. Where dependencies are fetched from, such as
+// through the MirrorDir, Discovery or Replace, is kept on each Installer.
+// The mirrors configured by the user in the glide home apply to every
+// Installer in the process.
 type Installer struct {
 
 	// Force the install when certain normally stopping conditions occur.
 	Force bool
 
-	// Home is the location of cache
+	// Home is the location of cache. When empty the Glide home is used.
 	Home string
 
+	// Tmp is the directory temporary files, such as the new vendor directory,
+	// are created in. When empty the one set for glide, or the system one,
+	// is used.
+	Tmp string
+
+	// Base is the directory of the project. When empty the working directory
+	// is used. Setting it, along with Home, allows installers for different
+	// projects to run at the same time in one process.
+	Base string
+
 	// Vendor contains the path to put the vendor packages
 	Vendor string
 
+	// VendorName, when set, maps the import path of a dependency to its
+	// directory within the vendor directory. The Go tools only find packages
+	// laid out by import path, the default, so this is for tooling with its
+	// own requirements. Dependencies mapped to the same directory must be
+	// fetched from the same repository at the same version or installing
+	// fails before anything is fetched.
+	VendorName VendorNameFunc
+
 	// ResolveAllFiles enables a resolver that will examine the dependencies
 	// of every file of every package, rather than only following imported
 	// packages.
@@ -43,10 +67,353 @@ type Installer struct {
 	// ResolveTest sets if test dependencies should be resolved.
 	ResolveTest bool
 
+	// Roots, when set, are the only local packages resolved from by Update.
+	// Each is a directory relative to the project or an import path within
+	// it. Dependencies in the config that the roots do not reach are left
+	// out of the config passed to Update.
+	Roots []string
+
 	// Updated tracks the packages that have been remotely fetched.
 	Updated *UpdateTracker
+
+	// AllowCustomCheckout permits dependencies to use their own checkout
+	// command rather than the built-in VCS handling. Without it a dependency
+	// declaring a checkout command fails to fetch. Only the commands in the
+	// config and lock file of the project are used, never those set by the
+	// configuration of a dependency.
+	AllowCustomCheckout bool
+
+	// PreferredBranch, when set, is used for dependencies without a
+	// version. If a dependency does not have the branch its default branch
+	// is used instead.
+	PreferredBranch string
+
+	// IncludePrerelease makes pre-release tags candidates when resolving a
+	// semantic version range. Dependencies can override this setting.
+	IncludePrerelease bool
+
+	// LatestTag resolves dependencies without a version to their highest
+	// semantic version tag rather than the newest commit on a branch.
+	// Dependencies can override this setting.
+	LatestTag bool
+
+	// Discovery, when set, is consulted for the location of dependencies
+	// without a repository before the built-in go get style discovery.
+	Discovery DiscoveryFunc
+
+	// MirrorDir is a directory of bare Git repositories stored by import
+	// path. Dependencies found there are fetched from it rather than the
+	// network.
+	MirrorDir string
+
+	// Overlay is a directory of copies of dependencies, stored by root import
+	// path, used in place of fetching them while resolving and updating.
+	// They are not VCS checkouts so no version is set on them and they are
+	// copied to the vendor directory as they are. Dependencies not in it are
+	// fetched as usual. Installing from a lock file does not use it.
+	Overlay string
+
+	// GopathPolicy sets what is done with a package found in the GOPATH while
+	// resolving: GopathIgnore, the default when empty, GopathCopy or
+	// GopathFail.
+	GopathPolicy string
+
+	// Offline makes dependencies missing from the MirrorDir an error rather
+	// than fetching them from the network.
+	Offline bool
+
+	// Replace holds rules replacing the repository or version of
+	// dependencies. They take precedence over the config and mirrors. See
+	// ReplaceRulesFromEnv.
+	Replace ReplaceRules
+
+	// Credentials are used to fetch dependencies from their hosts over HTTPS
+	// with git. They are never written to a file. See ReadCredentials.
+	Credentials Credentials
+
+	// CanonicalRepos compares repositories, and keys the cache, by their
+	// canonical location so the ways of referring to a repository, such as
+	// its SSH and HTTPS URLs, are the same. See util.CanonicalRepo.
+	CanonicalRepos bool
+
+	// CredentialsMode is how the Credentials are passed to git:
+	// CredentialsHeader, the default when empty, or CredentialsURL.
+	CredentialsMode string
+
+	// SSHKeys are the private keys git uses to clone and update dependencies
+	// fetched over SSH, in place of those of the ssh agent or configuration.
+	// See ReadSSHKeys.
+	SSHKeys SSHKeys
+
+	// BeforeWrite, when set, is called with the final config and lock file
+	// before they are written.
+	BeforeWrite WriteHook
+
+	// CacheTTL is how long a cached copy of a dependency is used before
+	// fetching updates for it again. Dependencies can set their own. When
+	// zero updates are fetched on every run.
+	CacheTTL time.Duration
+
+	// Snapshot, when set, resolves versions as they were at that time. Branches
+	// and dependencies without a version use the newest commit made before
+	// it, which is only supported for Git, and semantic version ranges only
+	// match tags on commits made before it. Pinned versions are not changed.
+	Snapshot time.Time
+
+	// OnVersion, when set, is called each time a version is chosen for a
+	// dependency while resolving, along with the reason for it. The calls are
+	// made one at a time.
+	OnVersion VersionHook
+
+	// OnExport, when set, is called after each dependency is exported with the
+	// directory it was exported to, within a temporary vendor directory.
+	// Returning an error fails the export. It is called concurrently by the
+	// export workers.
+	OnExport ExportHook
+
+	// Topological exports dependencies in the order of the import graph, so
+	// those a dependency imports are exported, and passed to OnExport, before
+	// it. Dependencies that don't import each other are still exported
+	// concurrently.
+	Topological bool
+
+	// Unused is the policy, UnusedWarn or UnusedSkip, for imports in the
+	// config the project does not import when updating. By default they are
+	// fetched like any other. Without ResolveTest those only used by tests
+	// are unused.
+	Unused string
+
+	// DryRun stops an install or update before anything is exported to the
+	// vendor directory. The packages Export would delete from it, as they
+	// are not part of a dependency, are listed instead. A get reports what
+	// it would change without writing the glide.yaml or glide.lock files.
+	DryRun bool
+
+	// VerifyAdded has get resolve each package it adds before anything is
+	// written so one that does not exist is never added to the config.
+	VerifyAdded bool
+
+	// Include and Exclude are patterns limiting the packages get adds. When
+	// Include is set only names matching one of its patterns are added.
+	// Names matching an Exclude pattern are never added.
+	Include, Exclude []string
+
+	// HoistVendor has the vendor directories nested in dependencies, when
+	// they are stripped after exporting, first copy their packages missing
+	// from the vendor directory to it. See path.HoistVendor.
+	HoistVendor bool
+
+	// OnlyChanged updates only the dependencies changed in the config since
+	// the lock file was written, and what they bring in. The rest stay at
+	// their locked commit. See UpdateChanged.
+	OnlyChanged bool
+
+	// Only names the dependencies an update changes, along with what they
+	// bring in. The rest stay at their locked commit. See UpdateOnly.
+	Only []string
+
+	// LockFile is where an update reads and writes the lock file rather
+	// than the glide.lock file of the project.
+	LockFile string
+
+	// AtomicSwap exports dependencies to a new vendor directory next to the
+	// existing one and swaps them with renames once all of the dependencies
+	// are exported. If the export fails the existing vendor directory is
+	// left as it was. Both need to be on the same file system.
+	AtomicSwap bool
+
+	// Serial updates dependencies one at a time, in order, rather than
+	// concurrently. This is useful for debugging as the output is ordered.
+	Serial bool
+
+	// Concurrency is the number of dependencies fetched, exported and set at
+	// once. When zero the default of 20 is used.
+	Concurrency int
+
+	// Shallow clones git repositories added to the cache with only the newest
+	// commit of each branch. A commit a dependency needs is fetched on its
+	// own and the full history is fetched when the tags are needed.
+	Shallow bool
+
+	// AuthPrompt is how the VCS tools may ask for credentials,
+	// AuthPromptTerminal or AuthPromptNever. By default it is left to them,
+	// and git or ssh waiting on a prompt that can't be seen looks like a
+	// hang.
+	AuthPrompt string
+
+	// Pressure, when set, is consulted by ConcurrentUpdate before starting
+	// each fetch. While it reports pressure no more are started until those
+	// running complete. When nil fetches are not throttled.
+	Pressure PressureFunc
+
+	// Strict makes dependencies skipped because of a problem, or downgraded,
+	// an error rather than a warning. See CheckUnexpected and CheckDowngrades.
+	Strict bool
+
+	// StrictSubpackages makes an imported subpackage missing from the version
+	// of its dependency in use a resolution error.
+	StrictSubpackages bool
+
+	// ConflictStrategy is how conflicting versions of a dependency are
+	// resolved. See VersionHandler.Strategy.
+	ConflictStrategy string
+
+	// MaxVendorSize is the largest size in bytes the exported dependencies may
+	// have. When it is exceeded Export fails and the existing vendor directory
+	// is left in place. Zero means there is no limit.
+	MaxVendorSize int64
+
+	// Store exports dependencies through a store in the cache shared by
+	// projects. Each revision is exported to it once and its files are
+	// copied into the vendor directory from there.
+	Store bool
+
+	// StoreLinks hard links the files from the Store into the vendor
+	// directory rather than copying them, where links can be made. Editing a
+	// linked file edits the one in the store, and every project using it.
+	StoreLinks bool
+
+	// KeepVCS copies the checkout of each dependency into the vendor
+	// directory with its VCS metadata, such as the .git directory, rather
+	// than exporting only the tracked files. It takes precedence over Store.
+	KeepVCS bool
+
+	// FetchLFS fetches the git LFS content of dependencies using it before
+	// they are exported. This requires git-lfs to be installed. Without it
+	// the files tracked by LFS are exported as pointers.
+	FetchLFS bool
+
+	// VerifyBuild has the commands exporting dependencies run CheckBuild
+	// once they are in the vendor directory.
+	VerifyBuild bool
+
+	// BuildTags are passed to the go tool by CheckBuild.
+	BuildTags []string
+
+	// GOOS and GOARCH are the platform to resolve dependencies for in place
+	// of the one Glide runs on. They are used for the build constraints when
+	// scanning packages, the os and arch of dependencies and by CheckBuild.
+	GOOS   string
+	GOARCH string
+
+	// RecordWarnings keeps the warnings about how dependencies were resolved,
+	// such as version conflicts, for the metadata of the lock file. See
+	// LockMetadata.
+	RecordWarnings bool
+
+	// Quarantine skips dependencies that fail to be fetched rather than
+	// stopping. They are left out of the vendor directory and listed by
+	// Quarantined. It has no effect when Strict is set.
+	Quarantine bool
+
+	// CheckBehind has Install warn about dependencies pinned to a commit
+	// whose version in the config is a branch, with the number of commits
+	// the branch has moved ahead of the pin. This is only supported for git.
+	CheckBehind bool
+
+	// ResolvedFile, when set, is a file of already resolved dependencies,
+	// in the format of a lock file, that Update uses in place of resolving.
+	// It needs to agree with the dependencies in the config.
+	ResolvedFile string
+
+	// MaxRetries is the number of times fetching a dependency is retried
+	// after a failure that is likely to pass, such as a reset connection or
+	// a server error from the host. Failures such as a missing repository
+	// are never retried.
+	MaxRetries int
+
+	// RetryBackoff is the wait before the first retry. It doubles for each
+	// retry after. When zero one second is used.
+	RetryBackoff time.Duration
+
+	// OpTimeout is the longest a git clone or update of a dependency may
+	// take before it is killed and counted as a failure. Zero means there is
+	// no limit. The other VCS are run without one.
+	OpTimeout time.Duration
+
+	// MissingRevision controls how a cached repository without the commit a
+	// dependency is pinned to is updated. It is one of MissingRevisionFetch
+	// or MissingRevisionRefresh. When empty MissingRevisionFetch is used.
+	MissingRevision string
+
+	// FailureReportFile, when set, is where Install and Update write a JSON
+	// report of the dependencies that failed to be fetched. See
+	// FailureReport.
+	FailureReportFile string
+
+	// SuppressMetrics disables displaying the collected counters in LogMetrics.
+	SuppressMetrics bool
+
+	// metrics collects counters across the concurrent workers.
+	metrics metricsTracker
+
+	// quarantined holds the dependencies skipped with Quarantine.
+	quarantined quarantineList
+
+	// overlaid holds the dependencies used from the Overlay.
+	overlaid overlayList
+
+	// failures holds the dependencies that failed to be fetched.
+	failures failureList
+
+	// frozen holds the dependencies UpdateOnly leaves at their locked
+	// version.
+	frozen frozenSet
+
+	// warnings holds the warnings kept with RecordWarnings.
+	warnings warningList
+
+	// unused holds the imports found to be unused by checkUnused.
+	unused []string
+
+	// skippedDev holds the test imports Install left out without useDev.
+	skippedDev map[string]bool
+
+	// graph is the import graph of the dependencies recorded by Update.
+	graph map[string][]string
+
+	// discovered caches the Discovery results for each prefix.
+	discovered discoveryCache
+
+	// locations holds where dependencies are fetched from for the run.
+	locations locationTable
+
+	// fetched records the repositories fetched by fetchDep.
+	fetched fetchCache
+
+	// cacheSetup creates the cache directories in Home once, recording the
+	// error doing so in cacheErr.
+	cacheSetup sync.Once
+	cacheErr   error
+
+	// promptSetup looks for a terminal once, recording it in terminal.
+	promptSetup sync.Once
+	terminal    bool
+
+	// promptMu is held by promptLock.
+	promptMu sync.Mutex
+
+	// credentialsSetup checks git supports CredentialsHeader once, recording
+	// when it does not in credentialsFallback.
+	credentialsSetup    sync.Once
+	credentialsFallback bool
 }
 
+// WriteHook receives the config and lock file about to be written. Either
+// can be changed or checked. Returning an error stops them from being
+// written. The lock file is nil when none will be written and changes to the
+// config are only written by commands that write the glide.yaml file. The
+// Hash of the lock file is read-only. Glide sets it from the config that
+// ends up in the glide.yaml file.
+type WriteHook func(conf *cfg.Config, lock *cfg.Lockfile) error
+
+// ExportHook receives a dependency once it is exported and the directory it
+// was exported to.
+type ExportHook func(dep *cfg.Dependency, dir string) error
+
+// VendorNameFunc maps an import path to a relative, / separated, directory.
+type VendorNameFunc func(importPath string) string
+
 // NewInstaller returns an Installer instance ready to use. This is the constructor.
 func NewInstaller() *Installer {
 	i := &Installer{}
@@ -59,6 +426,9 @@ func (i *Installer) VendorPath() string {
 	if i.Vendor != "" {
 		return i.Vendor
 	}
+	if i.Base != "" {
+		return filepath.Join(i.Base, gpath.VendorDir)
+	}
 
 	vp, err := gpath.Vendor()
 	if err != nil {
@@ -68,13 +438,96 @@ func (i *Installer) VendorPath() string {
 	return vp
 }
 
-// Install installs the dependencies from a Lockfile.
-func (i *Installer) Install(lock *cfg.Lockfile, conf *cfg.Config) (*cfg.Config, error) {
+// PackagePath returns the location of a dependency in the vendor directory.
+func (i *Installer) PackagePath(name string) (string, error) {
+	return i.vendorDir(i.VendorPath(), name)
+}
+
+// vendorDir returns the location of a dependency within a vendor directory.
+// Every path to a dependency in the vendor directory goes through it so the
+// VendorName is applied consistently.
+func (i *Installer) vendorDir(vendor, name string) (string, error) {
+	return util.VendorPath(vendor, name, i.VendorName)
+}
+
+// basePath returns the directory of the project.
+func (i *Installer) basePath() string {
+	if i != nil && i.Base != "" {
+		return i.Base
+	}
+	return gpath.Basepath()
+}
+
+// cacheLocation returns the location of the cache in Home.
+func (i *Installer) cacheLocation() string {
+	if i == nil {
+		return cache.Location()
+	}
+
+	home := i.Home
+	if home == "" {
+		home = gpath.Home()
+	}
+	p := filepath.Join(home, "cache")
+	i.cacheSetup.Do(func() {
+		for _, d := range []string{"src", "info"} {
+			if err := os.MkdirAll(filepath.Join(p, d), 0755); err != nil {
+				i.cacheErr = fmt.Errorf("Cache directory unavailable: %s", err)
+				return
+			}
+		}
+	})
+
+	return p
+}
+
+// cacheError returns the error creating the directories of the cache, which
+// the operations writing to it return.
+func (i *Installer) cacheError() error {
+	if i == nil {
+		return nil
+	}
+	i.cacheLocation()
+	return i.cacheErr
+}
+
+// cachePath returns the location of a package of a dependency in the cache.
+// A dependency without a cache key, such as one with an invalid repository,
+// has no location so one that does not exist is returned. Fetching it returns
+// the error.
+func (i *Installer) cachePath(dep *cfg.Dependency, sub string) string {
+	key, err := i.cacheKey(dep)
+	if err != nil {
+		msg.Debug("Error generating cache key for %s: %s", dep.Name, err)
+		key = ".invalid-" + strings.Replace(dep.Name, "/", "-", -1)
+	}
+
+	dir := filepath.Join(i.cacheLocation(), "src", key)
+	return filepath.Join(dir, majorSubdir(dep.Name, dir), filepath.FromSlash(sub))
+}
+
+// tmpDir returns the directory temporary files are created in, the Tmp of
+// the Installer or the system one.
+func (i *Installer) tmpDir() string {
+	if i != nil && i.Tmp != "" {
+		return i.Tmp
+	}
+	return gpath.Tmp
+}
+
+// Install installs the dependencies from a Lockfile. The test imports are
+// only installed with useDev. Those skipped are still in the returned config
+// so it matches the lock file, and their packages already in the vendor
+// directory are kept by Export as installing with useDev brings them back.
+func (i *Installer) Install(lock *cfg.Lockfile, conf *cfg.Config, useDev bool) (*cfg.Config, error) {
+	defer i.writeFailureReport()
 
 	// Create a config setup based on the Lockfile data to process with
 	// existing commands.
 	newConf := &cfg.Config{}
 	newConf.Name = conf.Name
+	newConf.Rewrite = conf.Rewrite
+	newConf.Allow = conf.Allow
 
 	newConf.Imports = make(cfg.Dependencies, len(lock.Imports))
 	for k, v := range lock.Imports {
@@ -88,10 +541,42 @@ func (i *Installer) Install(lock *cfg.Lockfile, conf *cfg.Config) (*cfg.Config,
 
 	newConf.DeDupe()
 
-	if len(newConf.Imports) == 0 && len(newConf.DevImports) == 0 {
+	for _, dep := range append(newConf.Imports, newConf.DevImports...) {
+		// The lock file does not record fallbacks so they come from the
+		// config.
+		if d := conf.Imports.Get(dep.Name); d != nil {
+			dep.Fallbacks = d.Fallbacks
+		} else if d := conf.DevImports.Get(dep.Name); d != nil {
+			dep.Fallbacks = d.Fallbacks
+		}
+		i.replace(dep)
+	}
+
+	installed := newConf.Imports
+	i.skippedDev = make(map[string]bool)
+	if useDev {
+		installed = append(installed[:len(installed):len(installed)], newConf.DevImports...)
+	} else {
+		for _, dep := range newConf.DevImports {
+			if !newConf.Imports.Has(dep.Name) {
+				i.skippedDev[dep.Name] = true
+			}
+		}
+	}
+	if len(installed) == 0 {
 		msg.Info("No dependencies found. Nothing installed.")
 		return newConf, nil
 	}
+	if err := i.checkCollisions(installed); err != nil {
+		return newConf, err
+	}
+	// Dependencies already in the cache are not updated so the allow list is
+	// checked up front.
+	for _, dep := range installed {
+		if err := checkAllowed(dep, conf); err != nil {
+			return newConf, err
+		}
+	}
 
 	msg.Info("Downloading dependencies. Please wait...")
 
@@ -99,7 +584,12 @@ func (i *Installer) Install(lock *cfg.Lockfile, conf *cfg.Config) (*cfg.Config,
 	if err != nil {
 		return newConf, err
 	}
-	err = LazyConcurrentUpdate(newConf.DevImports, i, newConf)
+	if useDev {
+		err = LazyConcurrentUpdate(newConf.DevImports, i, newConf)
+	}
+	if err == nil && i.CheckBehind {
+		i.checkBehind(installed, conf)
+	}
 
 	return newConf, err
 }
@@ -109,6 +599,9 @@ func (i *Installer) Install(lock *cfg.Lockfile, conf *cfg.Config) (*cfg.Config,
 // This is used when initializing an empty vendor directory, or when updating a
 // vendor directory based on changed config.
 func (i *Installer) Checkout(conf *cfg.Config) error {
+	if err := i.checkCollisions(append(conf.Imports, conf.DevImports...)); err != nil {
+		return err
+	}
 
 	msg.Info("Downloading dependencies. Please wait...")
 
@@ -125,22 +618,92 @@ func (i *Installer) Checkout(conf *cfg.Config) error {
 
 // Update updates all dependencies.
 //
+// Failures to create the resolver or to resolve packages are returned as errors
+// instead of exiting so that callers embedding glide can recover from them.
+//
 // It begins with the dependencies in the config file, but also resolves
 // transitive dependencies. The returned lockfile has all of the dependencies
 // listed, but the version reconciliation has not been done.
 //
 // In other words, all versions in the Lockfile will be empty.
+//
+// With ResolvedFile the dependencies are taken from the file rather than
+// resolved.
 func (i *Installer) Update(conf *cfg.Config) error {
-	base := "."
+	defer i.writeFailureReport()
+	if i.ResolvedFile != "" {
+		return i.updateResolved(conf)
+	}
+	if err := i.resolve(conf); err != nil {
+		return err
+	}
+
+	msg.Info("Downloading dependencies. Please wait...")
+
+	err := ConcurrentUpdate(conf.Imports, i, conf)
+	if err != nil {
+		return err
+	}
+
+	if i.ResolveTest {
+		err = ConcurrentUpdate(conf.DevImports, i, conf)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Resolve returns the full set of dependencies of a config at the versions
+// an update would use, without writing to the vendor directory or a lock
+// file. The config is cloned so it is left as it was. The imports are
+// returned followed, when ResolveTest is set, by the test imports not
+// already imported.
+//
+// Resolving still fetches dependencies to the cache and checks out their
+// versions there so their imports are read at the version used. Set Home to
+// a temporary directory to keep this out of the shared cache. Dependencies in
+// the config the project does not import are returned without a Pin as they
+// are not fetched. ResolvedFile is not used.
+func (i *Installer) Resolve(conf *cfg.Config) ([]*cfg.Dependency, error) {
+	conf = conf.Clone()
+	if err := i.resolve(conf); err != nil {
+		return nil, err
+	}
+
+	deps := conf.Imports
+	if i.ResolveTest {
+		for _, d := range conf.DevImports {
+			if !conf.Imports.Has(d.Name) {
+				deps = append(deps, d)
+			}
+		}
+	}
+	return deps, nil
+}
+
+// resolve resolves the imports of the project, fetching and setting the
+// version of each dependency in the cache as it goes. The dependencies found
+// are added to the config.
+func (i *Installer) resolve(conf *cfg.Config) error {
+	base := i.basePath()
+
+	for _, dep := range append(conf.Imports, conf.DevImports...) {
+		i.replace(dep)
+	}
+	if err := i.checkCollisions(append(conf.Imports, conf.DevImports...)); err != nil {
+		return err
+	}
 
 	ic := newImportCache()
+	roots := &rootCache{}
 
 	m := &MissingPackageHandler{
-		home:    i.Home,
-		force:   i.Force,
-		Config:  conf,
-		Use:     ic,
-		updated: i.Updated,
+		Config:    conf,
+		Use:       ic,
+		installer: i,
+		roots:     roots,
 	}
 
 	v := &VersionHandler{
@@ -148,23 +711,37 @@ func (i *Installer) Update(conf *cfg.Config) error {
 		Imported:  make(map[string]bool),
 		Conflicts: make(map[string]bool),
 		Config:    conf,
+		Strategy:  i.ConflictStrategy,
+		installer: i,
+		roots:     roots,
 	}
 
 	// Update imports
 	res, err := dependency.NewResolver(base)
-	res.ResolveTest = i.ResolveTest
 	if err != nil {
-		msg.Die("Failed to create a resolver: %s", err)
+		return fmt.Errorf("Failed to create a resolver: %s", err)
 	}
+	res.ResolveTest = i.ResolveTest
 	res.Config = conf
 	res.Handler = m
 	res.VersionHandler = v
 	res.ResolveAllFiles = i.ResolveAllFiles
+	res.VendorName = i.VendorName
+	res.StrictSubpackages = i.StrictSubpackages
+	res.OnIgnore = func(string) {
+		i.countMetric(func(m *Metrics) { m.Ignored++ })
+	}
+	i.setPlatform(res)
 	msg.Info("Resolving imports")
 
-	imps, timps, err := res.ResolveLocal(false)
+	var imps, timps []string
+	if len(i.Roots) > 0 {
+		imps, timps, err = res.ResolveRoots(i.Roots)
+	} else {
+		imps, timps, err = res.ResolveLocal(false)
+	}
 	if err != nil {
-		msg.Die("Failed to resolve local packages: %s", err)
+		return fmt.Errorf("Failed to resolve local packages: %s", err)
 	}
 	var deps cfg.Dependencies
 	var tdeps cfg.Dependencies
@@ -214,39 +791,56 @@ func (i *Installer) Update(conf *cfg.Config) error {
 		}
 	}
 
-	_, err = allPackages(deps, res, false)
+	// Import the configuration of dependencies already in the cache up front
+	// rather than one at a time while resolving.
+	pre := []string{}
+	for _, d := range append(deps, tdeps...) {
+		pre = append(pre, d.Name)
+	}
+	v.Prefetch(pre)
+
+	pkgs, err := allPackages(deps, res, false)
 	if err != nil {
-		msg.Die("Failed to retrieve a list of dependencies: %s", err)
+		return fmt.Errorf("Failed to retrieve a list of dependencies: %s", err)
 	}
 
+	var tpkgs []string
 	if i.ResolveTest {
 		msg.Debug("Resolving test dependencies")
-		_, err = allPackages(tdeps, res, true)
+		tpkgs, err = allPackages(tdeps, res, true)
 		if err != nil {
-			msg.Die("Failed to retrieve a list of test dependencies: %s", err)
+			return fmt.Errorf("Failed to retrieve a list of test dependencies: %s", err)
 		}
 	}
 
-	msg.Info("Downloading dependencies. Please wait...")
-
-	err = ConcurrentUpdate(conf.Imports, i, conf)
-	if err != nil {
-		return err
+	used := append(append(pre, pkgs...), tpkgs...)
+	if len(i.Roots) > 0 {
+		scopeToPackages(conf, used)
 	}
-
-	if i.ResolveTest {
-		err = ConcurrentUpdate(conf.DevImports, i, conf)
-		if err != nil {
-			return err
-		}
+	if err := v.conflictError(); err != nil {
+		return err
 	}
+	i.checkUnused(conf, usedRoots(used))
+	i.graph = res.Graph()
 
-	return nil
+	// Resolving can add dependencies that collide with those configured.
+	return i.checkCollisions(append(conf.Imports, conf.DevImports...))
 }
 
 // Export from the cache to the vendor directory
+//
+// The vendor directory is replaced so packages that are not in scope are
+// deleted. The scope is the imports plus, when ResolveTest is set, the test
+// imports. It needs to match the preceding install or test dependencies will
+// be removed.
 func (i *Installer) Export(conf *cfg.Config) error {
-	tempDir, err := ioutil.TempDir(gpath.Tmp, "glide-vendor")
+	// For the swap to be atomic the new vendor directory needs to be on the
+	// same file system so it is created next to the existing one.
+	tmp, prefix := i.tmpDir(), "glide-vendor"
+	if i.AtomicSwap {
+		tmp, prefix = filepath.Dir(i.VendorPath()), ".glide-vendor"
+	}
+	tempDir, err := ioutil.TempDir(tmp, prefix)
 	if err != nil {
 		return err
 	}
@@ -261,96 +855,48 @@ func (i *Installer) Export(conf *cfg.Config) error {
 	err = os.MkdirAll(vp, 0755)
 
 	msg.Info("Exporting resolved dependencies...")
-	done := make(chan struct{}, concurrentWorkers)
-	in := make(chan *cfg.Dependency, concurrentWorkers)
-	var wg sync.WaitGroup
-	var lock sync.Mutex
-	var returnErr error
-
-	for ii := 0; ii < concurrentWorkers; ii++ {
-		go func(ch <-chan *cfg.Dependency) {
-			for {
-				select {
-				case dep := <-ch:
-					loc := dep.Remote()
-					key, err := cache.Key(loc)
-					if err != nil {
-						msg.Die(err.Error())
-					}
-					cache.Lock(key)
-
-					cdir := filepath.Join(cache.Location(), "src", key)
-					repo, err := dep.GetRepo(cdir)
-					if err != nil {
-						msg.Die(err.Error())
-					}
-					msg.Info("--> Exporting %s", dep.Name)
-					if err := repo.ExportDir(filepath.Join(vp, filepath.ToSlash(dep.Name))); err != nil {
-						msg.Err("Export failed for %s: %s\n", dep.Name, err)
-						// Capture the error while making sure the concurrent
-						// operations don't step on each other.
-						lock.Lock()
-						if returnErr == nil {
-							returnErr = err
-						} else {
-							returnErr = cli.NewMultiError(returnErr, err)
-						}
-						lock.Unlock()
-					}
-					cache.Unlock(key)
-					wg.Done()
-				case <-done:
-					return
-				}
-			}
-		}(in)
-	}
-
-	for _, dep := range conf.Imports {
-		if !conf.HasIgnore(dep.Name) {
-			err = os.MkdirAll(filepath.Join(vp, filepath.ToSlash(dep.Name)), 0755)
-			if err != nil {
-				lock.Lock()
-				if returnErr == nil {
-					returnErr = err
-				} else {
-					returnErr = cli.NewMultiError(returnErr, err)
-				}
-				lock.Unlock()
-			}
-			wg.Add(1)
-			in <- dep
+	pool := i.newPool()
+	defer pool.close()
+
+	exported := i.exportDeps(conf)
+	for _, dep := range exported {
+		dest, err := i.vendorDir(vp, dep.Name)
+		if err == nil {
+			err = os.MkdirAll(dest, 0755)
+		}
+		if err != nil {
+			pool.fail(err)
 		}
 	}
 
-	if i.ResolveTest {
-		for _, dep := range conf.DevImports {
-			if !conf.HasIgnore(dep.Name) {
-				err = os.MkdirAll(filepath.Join(vp, filepath.ToSlash(dep.Name)), 0755)
-				if err != nil {
-					lock.Lock()
-					if returnErr == nil {
-						returnErr = err
-					} else {
-						returnErr = cli.NewMultiError(returnErr, err)
-					}
-					lock.Unlock()
+	levels := [][]*cfg.Dependency{exported}
+	if i.Topological {
+		levels = topoLevels(exported, i.importGraph(exported))
+	}
+	for _, level := range levels {
+		for _, dep := range level {
+			dep := dep
+			pool.run(func() {
+				if err := i.exportDep(dep, vp); err != nil {
+					msg.Err("Export failed for %s: %s\n", dep.Name, err)
+					pool.fail(err)
 				}
-				wg.Add(1)
-				in <- dep
-			}
+			})
 		}
+		// A level is exported before the next is started.
+		pool.wait()
 	}
 
-	wg.Wait()
+	if err := pool.wait(); err != nil {
+		return err
+	}
 
-	// Close goroutines setting the version
-	for ii := 0; ii < concurrentWorkers; ii++ {
-		done <- struct{}{}
+	if err := i.keepVendored(conf, vp); err != nil {
+		return err
 	}
 
-	if returnErr != nil {
-		return returnErr
+	if err := i.checkVendorSize(vp, exported); err != nil {
+		return err
 	}
 
 	msg.Info("Replacing existing vendor dependencies")
@@ -380,6 +926,10 @@ func (i *Installer) Export(conf *cfg.Config) error {
 		}
 	}
 
+	if i.AtomicSwap {
+		return swapVendor(vp, i.VendorPath(), tempDir+".old")
+	}
+
 	err = gpath.CustomRemoveAll(i.VendorPath())
 	if err != nil {
 		return err
@@ -394,6 +944,124 @@ func (i *Installer) Export(conf *cfg.Config) error {
 
 }
 
+// keepVendored copies the packages of the test imports Install skipped from
+// the vendor directory to the one being built at vp so they are not deleted.
+func (i *Installer) keepVendored(conf *cfg.Config, vp string) error {
+	for _, dep := range i.keptDeps(conf) {
+		src, err := i.PackagePath(dep.Name)
+		if err != nil {
+			return err
+		}
+		if _, err := os.Stat(src); err != nil {
+			continue
+		}
+		dest, err := i.vendorDir(vp, dep.Name)
+		if err != nil {
+			return err
+		}
+		msg.Info("--> Keeping %s in the vendor directory", dep.Name)
+		if err := gpath.CopyDir(src, dest); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// exportDep exports a dependency from the overlay or the cache into the
+// vendor directory being built at vp.
+func (i *Installer) exportDep(dep *cfg.Dependency, vp string) error {
+	if i.inOverlay(dep.Name) {
+		msg.Info("--> Exporting %s from the overlay", dep.Name)
+		dest, err := i.vendorDir(vp, dep.Name)
+		if err != nil {
+			return err
+		}
+		return gpath.CopyDir(i.overlayPath(dep.Name), dest)
+	}
+
+	key, err := i.cacheKey(dep)
+	if err != nil {
+		return err
+	}
+	cache.Lock(key)
+	defer cache.Unlock(key)
+
+	repo, err := dep.GetRepo(filepath.Join(i.cacheLocation(), "src", key))
+	if err != nil {
+		return err
+	}
+	msg.Info("--> Exporting %s", dep.Name)
+	dest, err := i.vendorDir(vp, dep.Name)
+	if err != nil {
+		return err
+	}
+	if err := i.fetchLFS(dep.Name, repo); err != nil {
+		return err
+	}
+
+	// With the major subdirectory layout only the directory of the major
+	// version is exported. The repository is exported next to the
+	// destination and the directory moved into place.
+	to := dest
+	major := majorSubdir(dep.Name, repo.LocalPath())
+	if major != "" {
+		msg.Debug("Exporting the %s directory of %s", major, dep.Name)
+		tmp, err := ioutil.TempDir(filepath.Dir(dest), ".glide-major")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(tmp)
+		to = filepath.Join(tmp, "tree")
+	}
+
+	switch {
+	case i.KeepVCS:
+		err = exportWithVCS(repo, to)
+	case i.Store:
+		err = i.exportFromStore(repo, key, to)
+	default:
+		err = repo.ExportDir(to)
+	}
+	if err == nil && major != "" {
+		if err = os.RemoveAll(dest); err == nil {
+			err = os.Rename(filepath.Join(to, major), dest)
+		}
+	}
+	if err == nil && i.OnExport != nil {
+		err = i.OnExport(dep, dest)
+	}
+	return err
+}
+
+// swapVendor replaces a vendor directory with a new one by renaming. The
+// existing vendor directory is moved to old, which is removed once the new
+// one is in place, and is moved back if it can't be.
+func swapVendor(newVendor, vendor, old string) error {
+	moved := false
+	if _, err := os.Lstat(vendor); err == nil {
+		if err := os.Rename(vendor, old); err != nil {
+			return fmt.Errorf("Unable to move %s aside: %s", vendor, err)
+		}
+		moved = true
+	}
+
+	if err := os.Rename(newVendor, vendor); err != nil {
+		if moved {
+			if rerr := os.Rename(old, vendor); rerr != nil {
+				msg.Err("Unable to restore %s from %s: %s", vendor, old, rerr)
+			}
+		}
+		return fmt.Errorf("Unable to move the new vendor directory into place: %s", err)
+	}
+
+	if moved {
+		if err := gpath.CustomRemoveAll(old); err != nil {
+			msg.Warn("Unable to remove the previous vendor directory at %s: %s", old, err)
+		}
+	}
+	return nil
+}
+
 // fixcle is a helper function that tries to recover from cross-device rename
 // errors by falling back to copying.
 func fixcle(from, to string, terr *os.LinkError) error {
@@ -422,8 +1090,29 @@ func fixcle(from, to string, terr *os.LinkError) error {
 }
 
 // List resolves the complete dependency tree and returns a list of dependencies.
-func (i *Installer) List(conf *cfg.Config) []*cfg.Dependency {
-	base := "."
+// When ResolveTest is set the test dependencies are resolved too and those
+// not already imported are listed after the imports.
+//
+// Errors creating the resolver or resolving packages are returned rather than
+// terminating the process so the repo package can be embedded as a library.
+func (i *Installer) List(conf *cfg.Config) ([]*cfg.Dependency, error) {
+	deps, _, err := i.list(conf)
+	return deps, err
+}
+
+// Graph resolves the dependency tree like List and returns its import graph.
+// It maps each root package to the sorted root packages it imports, with the
+// subpackages of each grouped under their root package. The packages of the
+// project are grouped under the name of the config, which maps to the root
+// packages they import directly. See dependency.Resolver.Graph.
+func (i *Installer) Graph(conf *cfg.Config) (map[string][]string, error) {
+	_, g, err := i.list(conf)
+	return g, err
+}
+
+// list resolves the dependency tree for List and Graph.
+func (i *Installer) list(conf *cfg.Config) ([]*cfg.Dependency, map[string][]string, error) {
+	base := i.basePath()
 
 	ic := newImportCache()
 
@@ -432,33 +1121,71 @@ func (i *Installer) List(conf *cfg.Config) []*cfg.Dependency {
 		Imported:  make(map[string]bool),
 		Conflicts: make(map[string]bool),
 		Config:    conf,
+		Strategy:  i.ConflictStrategy,
+		installer: i,
 	}
 
 	// Update imports
 	res, err := dependency.NewResolver(base)
 	if err != nil {
-		msg.Die("Failed to create a resolver: %s", err)
+		return nil, nil, fmt.Errorf("Failed to create a resolver: %s", err)
 	}
+	res.ResolveTest = i.ResolveTest
 	res.Config = conf
+	// Nothing is fetched when listing so the packages are looked for in the
+	// vendor directory of the Base.
+	res.Handler = &dependency.DefaultMissingPackageHandler{Missing: []string{}, Gopath: []string{}, Prefix: res.VendorDir}
 	res.VersionHandler = v
 	res.ResolveAllFiles = i.ResolveAllFiles
+	res.VendorName = i.VendorName
+	res.StrictSubpackages = i.StrictSubpackages
+	i.setPlatform(res)
 
 	msg.Info("Resolving imports")
-	_, _, err = res.ResolveLocal(false)
+	imps, timps, err := res.ResolveLocal(false)
 	if err != nil {
-		msg.Die("Failed to resolve local packages: %s", err)
+		return nil, nil, fmt.Errorf("Failed to resolve local packages: %s", err)
 	}
 
 	_, err = allPackages(conf.Imports, res, false)
 	if err != nil {
-		msg.Die("Failed to retrieve a list of dependencies: %s", err)
+		return nil, nil, fmt.Errorf("Failed to retrieve a list of dependencies: %s", err)
+	}
+
+	deps := conf.Imports
+	if i.ResolveTest {
+		_, err = allPackages(conf.DevImports, res, true)
+		if err != nil {
+			return nil, nil, fmt.Errorf("Failed to retrieve a list of test dependencies: %s", err)
+		}
+		for _, d := range conf.DevImports {
+			if !conf.Imports.Has(d.Name) {
+				deps = append(deps, d)
+			}
+		}
+	}
+	if err := v.conflictError(); err != nil {
+		return nil, nil, err
 	}
 
-	if len(conf.DevImports) > 0 {
-		msg.Warn("dev imports not resolved.")
+	g := res.Graph()
+	if !i.ResolveTest {
+		timps = nil
+	}
+	direct := make(map[string]bool)
+	for _, imp := range append(imps, timps...) {
+		n := res.Stripv(imp)
+		if filepath.IsAbs(n) || conf.HasIgnore(n) {
+			continue
+		}
+		if root, _ := util.NormalizeName(n); root != conf.Name && !direct[root] {
+			direct[root] = true
+			g[conf.Name] = append(g[conf.Name], root)
+		}
 	}
+	sort.Strings(g[conf.Name])
 
-	return conf.Imports
+	return deps, g, nil
 }
 
 // LazyConcurrentUpdate updates only deps that are not already checkout out at the right version.
@@ -467,14 +1194,21 @@ func (i *Installer) List(conf *cfg.Config) []*cfg.Dependency {
 func LazyConcurrentUpdate(deps []*cfg.Dependency, i *Installer, c *cfg.Config) error {
 
 	newDeps := []*cfg.Dependency{}
-	for _, dep := range deps {
+	for _, dep := range i.platformDeps(deps) {
+		if err := i.discover(dep, c); err != nil {
+			err = fmt.Errorf("Discovery failed for %s: %s", dep.Name, err)
+			if i.quarantine(dep.Name, err) {
+				continue
+			}
+			return err
+		}
 
-		key, err := cache.Key(dep.Remote())
+		key, err := i.cacheKey(dep)
 		if err != nil {
 			newDeps = append(newDeps, dep)
 			continue
 		}
-		destPath := filepath.Join(cache.Location(), "src", key)
+		destPath := filepath.Join(i.cacheLocation(), "src", key)
 
 		// Get a VCS object for this directory
 		repo, err := dep.GetRepo(destPath)
@@ -492,6 +1226,7 @@ func LazyConcurrentUpdate(deps []*cfg.Dependency, i *Installer, c *cfg.Config) e
 			ci, err := repo.CommitInfo(dep.Reference)
 			if err == nil && ci.Commit == dep.Reference {
 				msg.Info("--> Found desired version locally %s %s!", dep.Name, dep.Reference)
+				i.countMetric(func(m *Metrics) { m.Skipped++ })
 				continue
 			}
 		}
@@ -507,60 +1242,102 @@ func LazyConcurrentUpdate(deps []*cfg.Dependency, i *Installer, c *cfg.Config) e
 }
 
 // ConcurrentUpdate takes a list of dependencies and updates in parallel.
+//
+// When the Installer is set to Serial the dependencies are updated one at a
+// time in order instead. Dependencies for another platform are skipped. See
+// platformDeps. Once all of them are done a summary of how many were updated,
+// skipped and failed is logged.
 func ConcurrentUpdate(deps []*cfg.Dependency, i *Installer, c *cfg.Config) error {
-	done := make(chan struct{}, concurrentWorkers)
-	in := make(chan *cfg.Dependency, concurrentWorkers)
-	var wg sync.WaitGroup
-	var lock sync.Mutex
-	var returnErr error
-
-	for ii := 0; ii < concurrentWorkers; ii++ {
-		go func(ch <-chan *cfg.Dependency) {
-			for {
-				select {
-				case dep := <-ch:
-					loc := dep.Remote()
-					key, err := cache.Key(loc)
-					if err != nil {
-						msg.Die(err.Error())
-					}
-					cache.Lock(key)
-					if err := VcsUpdate(dep, i.Force, i.Updated); err != nil {
-						msg.Err("Update failed for %s: %s\n", dep.Name, err)
-						// Capture the error while making sure the concurrent
-						// operations don't step on each other.
-						lock.Lock()
-						if returnErr == nil {
-							returnErr = err
-						} else {
-							returnErr = cli.NewMultiError(returnErr, err)
-						}
-						lock.Unlock()
-					}
-					cache.Unlock(key)
-					wg.Done()
-				case <-done:
-					return
-				}
+	summary := &updateSummary{}
+	defer summary.log()
+	used := i.platformDeps(deps)
+	summary.skip(len(deps) - len(used))
+	deps = used
+
+	n := i.workers()
+	if i.Serial {
+		n = 1
+	}
+	pool := startPool(n)
+	defer pool.close()
+	for _, dep := range deps {
+		if c.HasIgnore(dep.Name) {
+			summary.skip(1)
+			continue
+		}
+		i.waitOnPressure(pool.busy)
+		dep := dep
+		pool.run(func() {
+			err := updateDep(dep, i, c)
+			i.recordFailure(dep, err)
+			summary.add(dep.Name, err)
+			if err != nil && !i.quarantine(dep.Name, err) {
+				pool.fail(err)
 			}
-		}(in)
+		})
 	}
 
-	for _, dep := range deps {
-		if !c.HasIgnore(dep.Name) {
-			wg.Add(1)
-			in <- dep
-		}
+	return pool.wait()
+}
+
+// updateDep updates a single dependency in the cache while holding the lock
+// for its cache location.
+func updateDep(dep *cfg.Dependency, i *Installer, c *cfg.Config) error {
+	if err := checkAllowed(dep, c); err != nil {
+		msg.Err(err.Error())
+		return err
+	}
+	if err := i.discover(dep, c); err != nil {
+		err = fmt.Errorf("Discovery failed for %s: %s", dep.Name, err)
+		msg.Err(err.Error())
+		return err
 	}
 
-	wg.Wait()
+	if err := i.fetchDep(dep); err != nil {
+		msg.Err("Update failed for %s: %s\n", dep.Name, err)
+		return err
+	}
 
-	// Close goroutines setting the version
-	for ii := 0; ii < concurrentWorkers; ii++ {
-		done <- struct{}{}
+	return nil
+}
+
+// checkAllowed returns an error when the allow list of a config does not
+// include a dependency so nothing outside of it is fetched, whether it comes
+// from the config, the lock file or another dependency.
+func checkAllowed(dep *cfg.Dependency, c *cfg.Config) error {
+	if c == nil || c.IsAllowed(dep.Name) {
+		return nil
 	}
+	return fmt.Errorf("%s is not in the allow list", dep.Name)
+}
+
+// usedRoots returns the root packages of the given packages.
+func usedRoots(pkgs []string) map[string]bool {
+	used := make(map[string]bool, len(pkgs))
+	for _, p := range pkgs {
+		used[util.GetRootFromPackage(filepath.ToSlash(p))] = true
+	}
+	return used
+}
+
+// scopeToPackages removes the dependencies from a config that none of the
+// given packages are part of.
+func scopeToPackages(conf *cfg.Config, pkgs []string) {
+	used := usedRoots(pkgs)
 
-	return returnErr
+	scope := func(deps cfg.Dependencies) cfg.Dependencies {
+		var scoped cfg.Dependencies
+		for _, dep := range deps {
+			if used[dep.Name] {
+				scoped = append(scoped, dep)
+			} else {
+				msg.Debug("%s is not used by the resolution roots", dep.Name)
+			}
+		}
+		return scoped
+	}
+	conf.Imports = scope(conf.Imports)
+	conf.DevImports = scope(conf.DevImports)
 }
 
 // allPackages gets a list of all packages required to satisfy the given deps.
@@ -569,18 +1346,15 @@ func allPackages(deps []*cfg.Dependency, res *dependency.Resolver, addTest bool)
 		return []string{}, nil
 	}
 
-	vdir, err := gpath.Vendor()
-	if err != nil {
-		return []string{}, err
-	}
-	vdir += string(os.PathSeparator)
 	ll, err := res.ResolveAll(deps, addTest)
 	if err != nil {
 		return []string{}, err
 	}
 
+	// The vendor directory of the resolver is used rather than the one for
+	// the working directory as they can differ when the Installer has a Base.
 	for i := 0; i < len(ll); i++ {
-		ll[i] = strings.TrimPrefix(ll[i], vdir)
+		ll[i] = res.Stripv(ll[i])
 	}
 	return ll, nil
 }
@@ -591,16 +1365,23 @@ func allPackages(deps []*cfg.Dependency, res *dependency.Resolver, addTest bool)
 //
 // When a package is found on the GOPATH, this notifies the user.
 type MissingPackageHandler struct {
-	home    string
-	force   bool
-	Config  *cfg.Config
-	Use     *importCache
-	updated *UpdateTracker
+	Config    *cfg.Config
+	Use       *importCache
+	installer *Installer
+
+	// roots, when set, is shared with the VersionHandler so each root is
+	// only fetched once.
+	roots *rootCache
 }
 
 // NotFound attempts to retrieve a package when not found in the local cache
 // folder. It will attempt to get it from the remote location info.
 func (m *MissingPackageHandler) NotFound(pkg string, addTest bool) (bool, error) {
+	// A copy in the overlay is used without going to the network.
+	if root := util.GetRootFromPackage(pkg); root != m.Config.Name && m.installer.fromOverlay(root) {
+		return true, nil
+	}
+
 	err := m.fetchToCache(pkg, addTest)
 	if err != nil {
 		return false, err
@@ -609,10 +1390,26 @@ func (m *MissingPackageHandler) NotFound(pkg string, addTest bool) (bool, error)
 	return true, err
 }
 
-// OnGopath will either copy a package, already found in the GOPATH, to the
-// vendor/ directory or download it from the internet. This is dependent if
-// useGopath on the installer is set to true to copy from the GOPATH.
+// OnGopath handles a package found in the GOPATH following the GopathPolicy
+// of the Installer. By default the copy in the GOPATH is ignored and the
+// package is fetched into the cache like any other. With GopathCopy the copy
+// is used, and as its own dependencies are found in the GOPATH too those there
+// are used as well, while the rest are fetched. With GopathFail it is an
+// error.
 func (m *MissingPackageHandler) OnGopath(pkg string, addTest bool) (bool, error) {
+	switch m.installer.GopathPolicy {
+	case GopathFail:
+		m.installer.countMetric(func(c *Metrics) { c.Unexpected++ })
+		return false, fmt.Errorf("%s was found in the GOPATH, which the GOPATH policy %q does not allow", pkg, GopathFail)
+	case GopathCopy:
+		root := util.GetRootFromPackage(pkg)
+		if root != m.Config.Name && m.installer.fromGopath(root) {
+			return true, nil
+		}
+		msg.Info("--> The repository of %s is not in the GOPATH. Fetching it instead", pkg)
+	default:
+		msg.Debug("%s found in the GOPATH. Using the cache instead", pkg)
+	}
 
 	err := m.fetchToCache(pkg, addTest)
 	if err != nil {
@@ -635,9 +1432,12 @@ func (m *MissingPackageHandler) PkgPath(pkg string) string {
 
 	// For the parent applications source skip the cache.
 	if root == m.Config.Name {
-		pth := gpath.Basepath()
+		pth := m.installer.basePath()
 		return filepath.Join(pth, filepath.FromSlash(sub))
 	}
+	if m.installer.inOverlay(root) {
+		return m.installer.overlayPath(pkg)
+	}
 
 	d := m.Config.Imports.Get(root)
 	if d == nil {
@@ -652,12 +1452,7 @@ func (m *MissingPackageHandler) PkgPath(pkg string) string {
 		}
 	}
 
-	key, err := cache.Key(d.Remote())
-	if err != nil {
-		msg.Die("Error generating cache key for %s", d.Name)
-	}
-
-	return filepath.Join(cache.Location(), "src", key, filepath.FromSlash(sub))
+	return m.installer.cachePath(d, sub)
 }
 
 func (m *MissingPackageHandler) fetchToCache(pkg string, addTest bool) error {
@@ -667,14 +1462,26 @@ func (m *MissingPackageHandler) fetchToCache(pkg string, addTest bool) error {
 		return nil
 	}
 
+	// Nothing outside of the allow list is fetched, even when a dependency
+	// imports it.
+	if !m.Config.IsAllowed(root) {
+		return fmt.Errorf("%s is not in the allow list", pkg)
+	}
+
 	d := m.Config.Imports.Get(root)
 	if d == nil && addTest {
 		d = m.Config.DevImports.Get(root)
 	}
 
 	// If the dependency is nil it means the Config doesn't yet know about it.
+	// One left at its locked version by UpdateOnly is fetched at that version
+	// rather than the one asked for by the dependency importing it.
 	if d == nil {
-		d, _ = m.Use.Get(root)
+		if l := m.installer.frozenLock(root); l != nil {
+			d = cfg.DependencyFromLock(l)
+		} else {
+			d, _ = m.Use.Get(root)
+		}
 		// We don't know about this dependency so we create a basic instance.
 		if d == nil {
 			d = &cfg.Dependency{Name: root}
@@ -687,7 +1494,25 @@ func (m *MissingPackageHandler) fetchToCache(pkg string, addTest bool) error {
 		}
 	}
 
-	return VcsUpdate(d, m.force, m.updated)
+	return m.roots.fetch(root, func() error {
+		if err := m.installer.discover(d, m.Config); err != nil {
+			m.installer.countMetric(func(c *Metrics) { c.Unexpected++ })
+			err = fmt.Errorf("Discovery failed for %s: %s", d.Name, err)
+			m.installer.recordFailure(d, err)
+			m.installer.quarantine(d.Name, err)
+			return err
+		}
+
+		// The resolver carries on without packages it failed to fetch so
+		// these are tracked as unexpected skips.
+		err := m.installer.fetchDep(d)
+		if err != nil {
+			m.installer.countMetric(func(c *Metrics) { c.Unexpected++ })
+			m.installer.recordFailure(d, err)
+			m.installer.quarantine(d.Name, err)
+		}
+		return err
+	})
 }
 
 // VersionHandler handles setting the proper version in the VCS.
@@ -707,6 +1532,95 @@ type VersionHandler struct {
 	// same. We are keeping track to only display them once.
 	// the parent pac
 	Conflicts map[string]bool
+
+	// Strategy is how conflicting versions of a dependency are resolved,
+	// ConflictFirstWins, ConflictHighestSemver or ConflictFail. Empty is
+	// ConflictFirstWins.
+	Strategy string
+
+	// requested holds the packages wanting each version of a dependency so
+	// conflicts can name them.
+	requested map[string]map[string][]string
+
+	// conflicts holds the conflicts found with ConflictFail.
+	conflicts []string
+
+	// installer, when set, receives counters about the versions set.
+	installer *Installer
+
+	// roots, when set, is shared with the MissingPackageHandler so the
+	// version of each root is only set once.
+	roots *rootCache
+
+	// importedBy holds the dependency whose configuration set the version of
+	// each package added to the config so one with a higher priority can
+	// replace it.
+	importedBy map[string]string
+
+	// prefetched holds configuration imported ahead of Process by Prefetch.
+	prefetched   map[string][]*cfg.Dependency
+	prefetchLock sync.Mutex
+}
+
+// Prefetch imports the configuration of the given packages concurrently.
+// The results are only used when Process reaches each package so the order
+// in which imports are added to Use, and therefore which one wins, does not
+// change. Packages not in the cache yet are left for Process, as are those
+// SetVersion moves to another version before Process reaches them.
+func (d *VersionHandler) Prefetch(pkgs []string) {
+	roots := []string{}
+	seen := make(map[string]bool)
+	for _, pkg := range pkgs {
+		root := util.GetRootFromPackage(pkg)
+		if root == d.Config.Name || d.Imported[root] || seen[root] {
+			continue
+		}
+		seen[root] = true
+		roots = append(roots, root)
+	}
+
+	pool := d.installer.newPool()
+	defer pool.close()
+	for _, root := range roots {
+		root := root
+		pool.run(func() {
+			f, deps, err := importConfig(d.pkgPath(root))
+			if f && err == nil {
+				d.prefetchLock.Lock()
+				if d.prefetched == nil {
+					d.prefetched = make(map[string][]*cfg.Dependency)
+				}
+				d.prefetched[root] = deps
+				d.prefetchLock.Unlock()
+			}
+		})
+	}
+	pool.wait()
+}
+
+// importRoot returns the prefetched configuration for a root package or
+// imports it when it was not prefetched.
+func (d *VersionHandler) importRoot(root string) (bool, []*cfg.Dependency, error) {
+	d.prefetchLock.Lock()
+	deps, found := d.prefetched[root]
+	delete(d.prefetched, root)
+	d.prefetchLock.Unlock()
+	if found {
+		return true, deps, nil
+	}
+
+	return importConfig(d.pkgPath(root))
+}
+
+// importConfig imports the configuration of a dependency. A checkout command
+// is only run from the config and lock file of the project so one set by a
+// dependency is cleared.
+func importConfig(pth string) (bool, []*cfg.Dependency, error) {
+	f, deps, err := importer.Import(pth)
+	for _, dep := range deps {
+		dep.Checkout = ""
+	}
+	return f, deps, err
 }
 
 // Process imports dependencies for a package
@@ -722,15 +1636,24 @@ func (d *VersionHandler) Process(pkg string) (e error) {
 	// Should we look in places other than the root of the project?
 	if d.Imported[root] == false {
 		d.Imported[root] = true
-		p := d.pkgPath(root)
-		f, deps, err := importer.Import(p)
+		f, deps, err := d.importRoot(root)
 		if f && err == nil {
 			for _, dep := range deps {
 
-				// The fist one wins. Would something smater than this be better?
-				exists, _ := d.Use.Get(dep.Name)
-				if exists == nil && (dep.Reference != "" || dep.Repository != "") {
+				// When more than one dependency sets a version for a package
+				// the one with the higher priority wins.
+				exists, from := d.Use.Get(dep.Name)
+				if (exists == nil || d.preferImport(root, from)) && (dep.Reference != "" || dep.Repository != "") {
 					d.Use.Add(dep.Name, dep, root)
+
+					// The version may already be set from the configuration
+					// of a dependency with a lower priority.
+					if exists != nil && d.importedBy[dep.Name] != "" {
+						addTest := !d.Config.Imports.Has(dep.Name) && d.Config.DevImports.Has(dep.Name)
+						if err := d.SetVersion(dep.Name, addTest); err != nil {
+							e = err
+						}
+					}
 				}
 			}
 		} else if err != nil {
@@ -742,10 +1665,20 @@ func (d *VersionHandler) Process(pkg string) (e error) {
 	return
 }
 
+// preferImport returns if the versions set by the configuration of one
+// dependency take precedence over those from another. The Priority list of
+// the config decides. Between dependencies not ordered by it the first one
+// wins.
+func (d *VersionHandler) preferImport(root, other string) bool {
+	return d.Config.PriorityRank(root) < d.Config.PriorityRank(other)
+}
+
 // SetVersion sets the version for a package. If that package version is already
 // set it handles the case by:
-// - keeping the already set version
+// - keeping the already set version, or the one the Strategy picks
 // - proviting messaging about the version conflict
+// When the checkout of the package is a symlink its current version is used
+// rather than changing it, unless the installer forces it.
 // TODO(mattfarina): The way version setting happens can be improved. Currently not optimal.
 func (d *VersionHandler) SetVersion(pkg string, addTest bool) (e error) {
 	root := util.GetRootFromPackage(pkg)
@@ -755,6 +1688,11 @@ func (d *VersionHandler) SetVersion(pkg string, addTest bool) (e error) {
 		return nil
 	}
 
+	// There is no checkout to set a version on for a quarantined dependency.
+	if d.installer.isQuarantined(root) {
+		return nil
+	}
+
 	v := d.Config.Imports.Get(root)
 	if addTest {
 		if v == nil {
@@ -771,17 +1709,58 @@ func (d *VersionHandler) SetVersion(pkg string, addTest bool) (e error) {
 		}
 	}
 
+	// A dependency left at its locked version by UpdateOnly is treated like
+	// one in the config.
+	if l := d.installer.frozenLock(root); v == nil && l != nil {
+		v = cfg.DependencyFromLock(l)
+		if addTest {
+			d.Config.DevImports = append(d.Config.DevImports, v)
+		} else {
+			d.Config.Imports = append(d.Config.Imports, v)
+		}
+	}
+
 	dep, req := d.Use.Get(root)
+	imported := dep
+	dec := VersionDecision{Package: root, Reason: VersionPinned}
 	if dep != nil && v != nil {
 		if v.Reference == "" && dep.Reference != "" {
 			v.Reference = dep.Reference
 			// Clear the pin, if set, so the new version can be used.
 			v.Pin = ""
 			dep = v
+			imported = v
+			d.setImportedBy(root, req)
 		} else if v.Reference != "" && dep.Reference != "" && v.Reference != dep.Reference {
 			dest := d.pkgPath(pkg)
-			dep = determineDependency(v, dep, dest, req)
+			d.installer.countMetric(func(m *Metrics) { m.Conflicts++ })
+			wanted, had := dep.Reference, v.Reference
+			d.request(root, wanted, req)
+			if d.installer.frozenLock(root) != nil {
+				// Recorded so a dependency with a higher priority asking for
+				// another version later is checked against the lock too.
+				d.installer.frozenConflict(v, d.pkgPath(root), req, wanted)
+				d.setImportedBy(root, req)
+				dep = v
+			} else if from := d.importedBy[root]; from != "" && d.preferImport(req, from) {
+				// The version came from the configuration of a dependency
+				// with a lower priority than the one wanting another.
+				singleInfo("Using %s %s wanted by %s over %s wanted by %s", root, wanted, req, had, from)
+				v.Reference = wanted
+				v.Pin = ""
+				dep = v
+				imported = v
+				d.setImportedBy(root, req)
+			} else {
+				dep = d.resolveConflict(v, dep, dest, req)
+			}
+			d.installer.recordWarning("Conflict: %s is %s but %s wants %s. Using %s", root, had, req, wanted, dep.Reference)
 		} else {
+			// The MissingPackageHandler adds a dependency it fetches from
+			// Use to the config.
+			if v == dep {
+				d.setImportedBy(root, req)
+			}
 			dep = v
 		}
 
@@ -795,6 +1774,7 @@ func (d *VersionHandler) SetVersion(pkg string, addTest bool) (e error) {
 		} else {
 			d.Config.Imports = append(d.Config.Imports, dep)
 		}
+		d.setImportedBy(root, req)
 	} else {
 		// If we've gotten here we don't have any depenency objects.
 		r, sp := util.NormalizeName(pkg)
@@ -811,23 +1791,93 @@ func (d *VersionHandler) SetVersion(pkg string, addTest bool) (e error) {
 		}
 	}
 
-	err := VcsVersion(dep)
+	// A dependency added from the configuration of another one is the same
+	// instance as the one in Use.
+	if dep == imported {
+		dec.Reason = VersionImported
+		dec.From = req
+		if dep.Reference != "" {
+			d.request(root, dep.Reference, req)
+		}
+	}
+	ref := dep.Reference
+	d.installer.rewriteRepo(dep, d.Config)
+	d.installer.replace(dep)
+	d.installer.rewriteURL(dep, d.Config)
+	if dep.Reference != ref {
+		dec.Reason = VersionOverride
+		dec.From = ""
+	} else if dep.Reference == "" {
+		dec.Reason = VersionDefault
+	}
+	dec.Reference = dep.Reference
+
+	// A checkout that is a symlink was most likely linked in by a developer
+	// working on it locally. Rather than moving it to another version its
+	// current version is pinned so it is not changed later either.
+	if dest := d.pkgPath(root); isSymlink(dest) && (d.installer == nil || !d.installer.Force) {
+		msg.Debug("%s is a symlink to a local checkout. Leaving its version alone", dest)
+		repo, err := dep.GetRepo(dest)
+		if err == nil {
+			dep.Pin, err = repo.Version()
+		}
+		if err != nil {
+			msg.Warn("Unable to read the version of the linked checkout of %s: %s", root, err)
+			e = err
+		} else {
+			d.decided(VersionDecision{Package: root, Reference: dep.Pin, Reason: VersionLinked})
+		}
+		return
+	}
+
+	d.decided(dec)
+
+	err := d.roots.setVersion(root, dep.Reference, func() error {
+		// A pinned dependency is already at its version so what was
+		// prefetched for it is kept. Otherwise the checkout can change.
+		if dep.Pin == "" {
+			d.prefetchLock.Lock()
+			delete(d.prefetched, root)
+			d.prefetchLock.Unlock()
+		}
+		return d.installer.VcsVersion(dep)
+	})
 	if err != nil {
+		d.installer.countMetric(func(m *Metrics) { m.Unexpected++ })
 		msg.Warn("Unable to set version on %s to %s. Err: %s", root, dep.Reference, err)
+		d.installer.recordWarning("Unable to set version on %s to %s: %s", root, dep.Reference, err)
 		e = err
 	}
 
 	return
 }
 
+// setImportedBy records the dependency whose configuration set the version of
+// a package.
+func (d *VersionHandler) setImportedBy(name, from string) {
+	if d.importedBy == nil {
+		d.importedBy = make(map[string]string)
+	}
+	d.importedBy[name] = from
+}
+
+// isSymlink returns if a path is a symbolic link.
+func isSymlink(pth string) bool {
+	fi, err := os.Lstat(pth)
+	return err == nil && fi.Mode()&os.ModeSymlink != 0
+}
+
 func (d *VersionHandler) pkgPath(pkg string) string {
 	root, sub := util.NormalizeName(pkg)
 
 	// For the parent applications source skip the cache.
 	if root == d.Config.Name {
-		pth := gpath.Basepath()
+		pth := d.installer.basePath()
 		return filepath.Join(pth, filepath.FromSlash(sub))
 	}
+	if d.installer.inOverlay(root) {
+		return d.installer.overlayPath(pkg)
+	}
 
 	dep := d.Config.Imports.Get(root)
 	if dep == nil {
@@ -842,12 +1892,7 @@ func (d *VersionHandler) pkgPath(pkg string) string {
 		}
 	}
 
-	key, err := cache.Key(dep.Remote())
-	if err != nil {
-		msg.Die("Error generating cache key for %s", dep.Name)
-	}
-
-	return filepath.Join(cache.Location(), "src", key, filepath.FromSlash(sub))
+	return d.installer.cachePath(dep, sub)
 }
 
 func determineDependency(v, dep *cfg.Dependency, dest, req string) *cfg.Dependency {
@@ -964,7 +2009,13 @@ func determineDependency(v, dep *cfg.Dependency, dest, req string) *cfg.Dependen
 var warningMessage = make(map[string]bool)
 var infoMessage = make(map[string]bool)
 
+// messageLock protects the messages displayed once as several installers
+// can run at the same time.
+var messageLock sync.Mutex
+
 func singleWarn(ft string, v ...interface{}) {
+	messageLock.Lock()
+	defer messageLock.Unlock()
 	m := fmt.Sprintf(ft, v...)
 	_, f := warningMessage[m]
 	if !f {
@@ -974,6 +2025,8 @@ func singleWarn(ft string, v ...interface{}) {
 }
 
 func singleInfo(ft string, v ...interface{}) {
+	messageLock.Lock()
+	defer messageLock.Unlock()
 	m := fmt.Sprintf(ft, v...)
 	_, f := infoMessage[m]
 	if !f {
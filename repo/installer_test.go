@@ -0,0 +1,912 @@
+package repo
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Ownercz/glide/cache"
+	"github.com/Ownercz/glide/cfg"
+	gpath "github.com/Ownercz/glide/path"
+)
+
+func TestSetVersionSymlink(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir, err := ioutil.TempDir("", "glide-symlink")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	home := gpath.Home()
+	gpath.SetHome(filepath.Join(dir, "home"))
+	defer gpath.SetHome(home)
+
+	// A local checkout on its first commit with a newer one available.
+	remote := filepath.Join(dir, "remote")
+	local := filepath.Join(dir, "local")
+	first := newTestRemote(t, remote)
+	testGit(t, remote, "commit", "-q", "--allow-empty", "-m", "commit")
+	testGit(t, "", "clone", "-q", remote, local)
+	testGit(t, local, "checkout", "-q", "HEAD~1")
+
+	dep := &cfg.Dependency{Name: "example.com/linked", Repository: remote, VcsType: "git", Reference: "master"}
+	key, err := cache.Key(dep.Remote())
+	if err != nil {
+		t.Fatal(err)
+	}
+	dest := filepath.Join(cache.Location(), "src", key)
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(local, dest); err != nil {
+		t.Skipf("Unable to create a symlink: %s", err)
+	}
+
+	v := &VersionHandler{
+		Use:       newImportCache(),
+		Imported:  make(map[string]bool),
+		Conflicts: make(map[string]bool),
+		Config:    &cfg.Config{Name: "example.com/app", Imports: cfg.Dependencies{dep}},
+		installer: NewInstaller(),
+	}
+	if err := v.SetVersion(dep.Name, false); err != nil {
+		t.Fatalf("Unexpected error setting the version: %s", err)
+	}
+	if dep.Pin != first {
+		t.Errorf("Expected the linked checkout version %s to be pinned, got %s", first, dep.Pin)
+	}
+	if testGit(t, local, "rev-parse", "HEAD") != first {
+		t.Error("Expected the linked checkout to be left alone")
+	}
+}
+
+func TestInstallerBase(t *testing.T) {
+	dir, err := ioutil.TempDir("", "glide-base")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Two projects, each only using the standard library.
+	var wg sync.WaitGroup
+	for _, name := range []string{"one", "two"} {
+		base := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Join(base, "vendor"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(base, "main.go"), []byte("package main\n\nimport _ \"fmt\"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		i := NewInstaller()
+		i.Base = base
+		i.Home = filepath.Join(dir, name+"-home")
+		if i.VendorPath() != filepath.Join(base, "vendor") {
+			t.Errorf("Expected the vendor path in %s, got %s", base, i.VendorPath())
+		}
+		if _, err := os.Stat(filepath.Join(i.cacheLocation(), "src")); err != nil {
+			t.Errorf("Expected the cache to be set up in %s: %s", i.Home, err)
+		}
+
+		wg.Add(1)
+		go func(i *Installer, name string) {
+			defer wg.Done()
+			deps, err := i.List(&cfg.Config{Name: "example.com/" + name})
+			if err != nil {
+				t.Errorf("Unexpected error listing %s: %s", name, err)
+			} else if len(deps) != 0 {
+				t.Errorf("Expected no dependencies for %s, got %d", name, len(deps))
+			}
+		}(i, name)
+	}
+	wg.Wait()
+}
+
+func TestPackagePath(t *testing.T) {
+	i := NewInstaller()
+	i.Vendor = filepath.FromSlash("/tmp/vendor")
+
+	p, err := i.PackagePath("github.com/Ownercz/vcs")
+	if err != nil || p != filepath.FromSlash("/tmp/vendor/github.com/Ownercz/vcs") {
+		t.Errorf("Expected the import path layout, got %s (%v)", p, err)
+	}
+
+	i.VendorName = strings.ToLower
+	p, err = i.PackagePath("github.com/Ownercz/vcs")
+	if err != nil || p != filepath.FromSlash("/tmp/vendor/github.com/ownercz/vcs") {
+		t.Errorf("Expected the custom layout, got %s (%v)", p, err)
+	}
+
+	for _, bad := range []string{"", "../outside", "/abs"} {
+		i.VendorName = func(string) string { return bad }
+		if _, err := i.PackagePath("github.com/Ownercz/vcs"); err == nil {
+			t.Errorf("Expected an error for the vendor directory %q", bad)
+		}
+	}
+}
+
+func TestPreferImport(t *testing.T) {
+	d := &VersionHandler{
+		Config: &cfg.Config{Priority: []string{"github.com/example/z"}},
+	}
+
+	if !d.preferImport("github.com/example/z", "github.com/example/a") {
+		t.Error("Expected a listed dependency to win over one not listed")
+	}
+	if d.preferImport("github.com/example/b", "github.com/example/z") {
+		t.Error("Expected a dependency not listed to lose to one listed")
+	}
+	if d.preferImport("github.com/example/a", "github.com/example/b") || d.preferImport("github.com/example/b", "github.com/example/a") {
+		t.Error("Expected the first of the dependencies not listed to win")
+	}
+}
+
+func TestSetVersionDecisions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "glide-decisions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	decisions := make(map[string]VersionDecision)
+	i := NewInstaller()
+	i.Home = dir
+	i.Replace = ReplaceRules{"github.com/example/over": {Ref: "v3.0.0"}}
+	i.OnVersion = func(dec VersionDecision) {
+		decisions[dec.Package] = dec
+	}
+
+	ic := newImportCache()
+	ic.Add("github.com/example/imp", &cfg.Dependency{Name: "github.com/example/imp", Reference: "v2.0.0", Pin: "b"}, "github.com/example/pinned")
+	v := &VersionHandler{
+		Use:       ic,
+		Imported:  make(map[string]bool),
+		Conflicts: make(map[string]bool),
+		Config: &cfg.Config{
+			Name: "example.com/app",
+			Imports: cfg.Dependencies{
+				{Name: "github.com/example/pinned", Reference: "v1.0.0", Pin: "a"},
+				{Name: "github.com/example/over", Reference: "v1.0.0", Pin: "c"},
+				{Name: "github.com/example/none", Pin: "d"},
+			},
+		},
+		installer: i,
+	}
+	for _, pkg := range []string{"github.com/example/pinned", "github.com/example/imp/sub", "github.com/example/over", "github.com/example/none"} {
+		// The replaced version has no checkout to be set on.
+		v.SetVersion(pkg, false)
+	}
+
+	expect := map[string]VersionDecision{
+		"github.com/example/pinned": {Package: "github.com/example/pinned", Reference: "v1.0.0", Reason: VersionPinned},
+		"github.com/example/imp":    {Package: "github.com/example/imp", Reference: "v2.0.0", Reason: VersionImported, From: "github.com/example/pinned"},
+		"github.com/example/over":   {Package: "github.com/example/over", Reference: "v3.0.0", Reason: VersionOverride},
+		"github.com/example/none":   {Package: "github.com/example/none", Reason: VersionDefault},
+	}
+	for name, e := range expect {
+		if d := decisions[name]; d != e {
+			t.Errorf("Expected the decision for %s to be %+v, got %+v", name, e, d)
+		}
+	}
+}
+
+func TestListTestImports(t *testing.T) {
+	dir, err := ioutil.TempDir("", "glide-list")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a", "b"} {
+		d := filepath.Join(dir, "vendor", "github.com", "example", name)
+		if err := os.MkdirAll(d, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(d, name+".go"), []byte("package "+name+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	conf := func() *cfg.Config {
+		return &cfg.Config{
+			Name:       "example.com/app",
+			Imports:    cfg.Dependencies{{Name: "github.com/example/a", Pin: "a"}},
+			DevImports: cfg.Dependencies{{Name: "github.com/example/a", Pin: "a"}, {Name: "github.com/example/b", Pin: "b"}},
+		}
+	}
+
+	i := NewInstaller()
+	i.Home = filepath.Join(dir, "home")
+	i.Base = dir
+	deps, err := i.List(conf())
+	if err != nil {
+		t.Fatalf("Unexpected error listing: %s", err)
+	}
+	if len(deps) != 1 {
+		t.Errorf("Expected only the imports without ResolveTest, got %d", len(deps))
+	}
+
+	i.ResolveTest = true
+	deps, err = i.List(conf())
+	if err != nil {
+		t.Fatalf("Unexpected error listing: %s", err)
+	}
+	if len(deps) != 2 || deps[0].Name != "github.com/example/a" || deps[1].Name != "github.com/example/b" {
+		t.Errorf("Expected the test imports to be listed once after the imports, got %v", deps)
+	}
+}
+
+func TestInstallerGraph(t *testing.T) {
+	dir, err := ioutil.TempDir("", "glide-graph")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"main.go":                                "package main\n\nimport (\n\t_ \"github.com/example/a\"\n\t_ \"github.com/example/a/sub\"\n)\n",
+		"vendor/github.com/example/a/a.go":       "package a\n",
+		"vendor/github.com/example/a/sub/sub.go": "package sub\n\nimport _ \"github.com/example/b\"\n",
+		"vendor/github.com/example/b/b.go":       "package b\n",
+	}
+	for name, src := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	i := NewInstaller()
+	i.Home = filepath.Join(dir, "home")
+	i.Base = dir
+	g, err := i.Graph(&cfg.Config{
+		Name:    "example.com/app",
+		Imports: cfg.Dependencies{{Name: "github.com/example/a", Pin: "a", Subpackages: []string{"sub"}}, {Name: "github.com/example/b", Pin: "b"}},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error resolving the graph: %s", err)
+	}
+	expected := map[string][]string{
+		"example.com/app":      {"github.com/example/a"},
+		"github.com/example/a": {"github.com/example/b"},
+	}
+	if !reflect.DeepEqual(g, expected) {
+		t.Errorf("Expected the graph %v, got %v", expected, g)
+	}
+}
+
+func TestLockMetadata(t *testing.T) {
+	i := NewInstaller()
+	i.recordWarning("Conflict for %s", "a")
+	if i.LockMetadata() != nil {
+		t.Error("Expected no metadata unless warnings are recorded")
+	}
+
+	i.RecordWarnings = true
+	i.recordWarning("Conflict for %s", "a")
+	i.recordWarning("Conflict for %s", "a")
+	i.recordWarning("Conflict for %s", "b")
+	m := i.LockMetadata()
+	if m == nil || len(m.Warnings) != 2 || m.Warnings[0] != "Conflict for a" {
+		t.Errorf("Expected each warning to be recorded once, got %v", m)
+	}
+}
+
+func TestNotFoundAllowList(t *testing.T) {
+	conf := &cfg.Config{Name: "example.com/app", Allow: []string{"github.com/allowed"}}
+	m := &MissingPackageHandler{Config: conf, Use: newImportCache(), installer: NewInstaller()}
+
+	found, err := m.NotFound("github.com/other/pkg/sub", false)
+	if found || err == nil || !strings.Contains(err.Error(), "github.com/other/pkg/sub") {
+		t.Errorf("Expected an error naming the package outside of the allow list, got %v", err)
+	}
+	if len(conf.Imports) != 0 {
+		t.Error("Expected a package outside of the allow list to not be added to the imports")
+	}
+}
+
+func TestUpdateAllowList(t *testing.T) {
+	dir, err := ioutil.TempDir("", "glide-allow")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	i := NewInstaller()
+	i.Home = filepath.Join(dir, "home")
+	i.Vendor = filepath.Join(dir, "vendor")
+	remote := filepath.Join(dir, "remotes", "other")
+	fetched := func() bool {
+		entries, _ := ioutil.ReadDir(filepath.Join(dir, "home", "cache", "src"))
+		return len(entries) > 0
+	}
+
+	// A dependency listed in the config is not fetched when the allow list
+	// does not include it.
+	conf := &cfg.Config{
+		Name:    "example.com/app",
+		Allow:   []string{"github.com/allowed"},
+		Imports: cfg.Dependencies{{Name: "github.com/other/pkg", Repository: remote, VcsType: "git"}},
+	}
+	err = ConcurrentUpdate(conf.Imports, i, conf)
+	if err == nil || !strings.Contains(err.Error(), "github.com/other/pkg is not in the allow list") {
+		t.Errorf("Expected an error for a dependency in the config outside of the allow list, got %v", err)
+	}
+	if fetched() {
+		t.Error("Expected nothing to be fetched")
+	}
+
+	// Nor is one in the lock file.
+	lock := &cfg.Lockfile{Imports: cfg.Locks{
+		{Name: "github.com/other/pkg", Version: "1111111111111111111111111111111111111111", Repository: remote, VcsType: "git"},
+	}}
+	_, err = i.Install(lock, &cfg.Config{Name: "example.com/app", Allow: []string{"github.com/allowed"}}, true)
+	if err == nil || !strings.Contains(err.Error(), "github.com/other/pkg is not in the allow list") {
+		t.Errorf("Expected an error for a dependency in the lock file outside of the allow list, got %v", err)
+	}
+	if fetched() {
+		t.Error("Expected nothing to be fetched")
+	}
+}
+
+func TestExportAtomicSwap(t *testing.T) {
+	dir, err := ioutil.TempDir("", "glide-swap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	vendor := filepath.Join(dir, "vendor")
+	keep := filepath.Join(vendor, "keep")
+	if err := os.MkdirAll(vendor, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(keep, []byte("keep"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	i := NewInstaller()
+	i.Home = filepath.Join(dir, "home")
+	i.Vendor = vendor
+	i.AtomicSwap = true
+
+	// The dependency is not in the cache so exporting it fails.
+	conf := &cfg.Config{
+		Name:    "example.com/app",
+		Imports: cfg.Dependencies{{Name: "github.com/example/missing", Repository: filepath.Join(dir, "missing"), VcsType: "git"}},
+	}
+	if err := i.Export(conf); err == nil {
+		t.Fatal("Expected the export to fail")
+	}
+	if _, err := os.Stat(keep); err != nil {
+		t.Error("Expected the vendor directory to be untouched when the export fails")
+	}
+
+	if err := i.Export(&cfg.Config{Name: "example.com/app"}); err != nil {
+		t.Fatalf("Unexpected error exporting: %s", err)
+	}
+	if _, err := os.Stat(keep); err == nil {
+		t.Error("Expected the vendor directory to be replaced")
+	}
+	if _, err := os.Stat(vendor); err != nil {
+		t.Error("Expected the new vendor directory to be in place")
+	}
+
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), ".glide-vendor") {
+			t.Errorf("Expected %s to be removed", e.Name())
+		}
+	}
+}
+
+func TestCheckUnused(t *testing.T) {
+	newConf := func() *cfg.Config {
+		return &cfg.Config{
+			Name:   "example.com/app",
+			Ignore: []string{"github.com/example/ignored"},
+			Imports: cfg.Dependencies{
+				{Name: "github.com/example/used"},
+				{Name: "github.com/example/tool"},
+				{Name: "github.com/example/ignored"},
+			},
+		}
+	}
+	used := usedRoots([]string{"github.com/example/used/sub"})
+
+	i := NewInstaller()
+	conf := newConf()
+	i.checkUnused(conf, used)
+	if len(conf.Imports) != 3 || len(i.UnusedImports()) != 0 {
+		t.Error("Expected nothing to be checked without a policy")
+	}
+
+	i.Unused = UnusedWarn
+	i.checkUnused(conf, used)
+	if len(conf.Imports) != 3 {
+		t.Error("Expected unused imports to be kept when warning")
+	}
+	if u := i.UnusedImports(); len(u) != 1 || u[0] != "github.com/example/tool" {
+		t.Errorf("Expected the tool to be unused, got %v", u)
+	}
+
+	i = NewInstaller()
+	i.Unused = UnusedSkip
+	conf = newConf()
+	i.checkUnused(conf, used)
+	if len(conf.Imports) != 2 || conf.Imports.Get("github.com/example/tool") != nil {
+		t.Errorf("Expected the unused import to be skipped, got %v", conf.Imports)
+	}
+}
+
+func TestUnusedVendored(t *testing.T) {
+	dir, err := ioutil.TempDir("", "glide-unused-vendored")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	vendor := filepath.Join(dir, "vendor")
+	for _, f := range []string{
+		"github.com/example/used/used.go",
+		"github.com/example/used/sub/sub.go",
+		"github.com/example/test/test.go",
+		"github.com/example/old/old.go",
+		"github.com/example/old/README.md",
+		"github.com/example/hand/tweaked/tweaked.go",
+		"github.com/example/docs/README.md",
+	} {
+		p := filepath.Join(vendor, filepath.FromSlash(f))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte("package x\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	conf := &cfg.Config{
+		Name:       "example.com/app",
+		Imports:    cfg.Dependencies{{Name: "github.com/example/used"}},
+		DevImports: cfg.Dependencies{{Name: "github.com/example/test"}},
+	}
+	i := NewInstaller()
+	i.Vendor = vendor
+
+	unused, err := i.UnusedVendored(conf)
+	if err != nil {
+		t.Fatalf("Unexpected error listing unused packages: %s", err)
+	}
+	expected := []string{"github.com/example/hand/tweaked", "github.com/example/old", "github.com/example/test"}
+	if !reflect.DeepEqual(unused, expected) {
+		t.Errorf("Expected %v to be unused, got %v", expected, unused)
+	}
+	if _, err := os.Stat(filepath.Join(vendor, "github.com", "example", "old", "old.go")); err != nil {
+		t.Error("Expected the unused packages to be left alone")
+	}
+
+	i.ResolveTest = true
+	unused, _ = i.UnusedVendored(conf)
+	if len(unused) != 2 {
+		t.Errorf("Expected the test import to be used with ResolveTest, got %v", unused)
+	}
+}
+
+func TestTopoLevels(t *testing.T) {
+	deps := []*cfg.Dependency{
+		{Name: "github.com/example/app"},
+		{Name: "github.com/example/lib"},
+		{Name: "github.com/example/base"},
+		{Name: "github.com/example/other"},
+	}
+	graph := map[string][]string{
+		"github.com/example/app": {"github.com/example/base", "github.com/example/lib"},
+		"github.com/example/lib": {"github.com/example/base", "golang.org/x/net"},
+	}
+
+	levels := topoLevels(deps, graph)
+	names := [][]string{}
+	for _, l := range levels {
+		n := []string{}
+		for _, dep := range l {
+			n = append(n, dep.Name)
+		}
+		names = append(names, n)
+	}
+	expect := [][]string{
+		{"github.com/example/base", "github.com/example/other"},
+		{"github.com/example/lib"},
+		{"github.com/example/app"},
+	}
+	if !reflect.DeepEqual(names, expect) {
+		t.Errorf("Expected the levels %v, got %v", expect, names)
+	}
+
+	// A cycle can't be ordered.
+	graph["github.com/example/base"] = []string{"github.com/example/app"}
+	levels = topoLevels(deps, graph)
+	if len(levels) != 2 || len(levels[1]) != 3 {
+		t.Errorf("Expected the dependencies in the cycle to be in a final level, got %v", levels)
+	}
+}
+
+func TestExportTopological(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir, err := ioutil.TempDir("", "glide-topo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	i := NewInstaller()
+	i.Home = filepath.Join(dir, "home")
+	i.Vendor = filepath.Join(dir, "vendor")
+	i.Topological = true
+	var lock sync.Mutex
+	order := []string{}
+	i.OnExport = func(dep *cfg.Dependency, d string) error {
+		if _, err := os.Stat(filepath.Join(d, "a.go")); err != nil {
+			t.Errorf("Expected %s to be exported before the hook, got %s", dep.Name, err)
+		}
+		lock.Lock()
+		order = append(order, dep.Name)
+		lock.Unlock()
+		return nil
+	}
+
+	// Each package imports the next.
+	names := []string{"github.com/example/app", "github.com/example/lib", "github.com/example/base"}
+	conf := &cfg.Config{Name: "example.com/project"}
+	for ii, name := range names {
+		src := "package a\n"
+		if ii+1 < len(names) {
+			src = "package a\n\nimport _ \"" + names[ii+1] + "\"\n"
+		}
+		remote := filepath.Join(dir, "remotes", filepath.Base(name))
+		newTestRemote(t, remote, "a.go", src)
+
+		dep := &cfg.Dependency{Name: name, Repository: remote, VcsType: "git"}
+		if err := i.VcsGet(dep); err != nil {
+			t.Fatal(err)
+		}
+		conf.Imports = append(conf.Imports, dep)
+	}
+
+	if err := i.Export(conf); err != nil {
+		t.Fatalf("Unexpected error exporting: %s", err)
+	}
+	expect := []string{"github.com/example/base", "github.com/example/lib", "github.com/example/app"}
+	if !reflect.DeepEqual(order, expect) {
+		t.Errorf("Expected the dependencies to be exported in the order %v, got %v", expect, order)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir, err := ioutil.TempDir("", "glide-resolve")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// The project imports lib, which imports base.
+	commits := map[string]string{}
+	for name, src := range map[string]string{
+		"lib":  "package lib\n\nimport _ \"github.com/example/base\"\n",
+		"base": "package base\n",
+	} {
+		commits[name] = newTestRemote(t, filepath.Join(dir, "remotes", name), name+".go", src)
+	}
+
+	project := filepath.Join(dir, "project")
+	if err := os.MkdirAll(project, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(project, "main.go"), []byte("package main\n\nimport _ \"github.com/example/lib\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	conf := &cfg.Config{
+		Name: "example.com/project",
+		Imports: cfg.Dependencies{
+			{Name: "github.com/example/lib", Repository: filepath.Join(dir, "remotes", "lib"), VcsType: "git"},
+			{Name: "github.com/example/base", Repository: filepath.Join(dir, "remotes", "base"), VcsType: "git"},
+		},
+	}
+
+	i := NewInstaller()
+	i.Home = filepath.Join(dir, "home")
+	i.Base = project
+	deps, err := i.Resolve(conf)
+	if err != nil {
+		t.Fatalf("Unexpected error resolving: %s", err)
+	}
+	if len(deps) != 2 {
+		t.Fatalf("Expected lib and base to be resolved, got %v", deps)
+	}
+	for _, d := range deps {
+		if c := commits[filepath.Base(d.Name)]; d.Pin != c {
+			t.Errorf("Expected %s to be at %s, got %q", d.Name, c, d.Pin)
+		}
+	}
+
+	if conf.Imports[0].Pin != "" || conf.Imports[1].Pin != "" {
+		t.Error("Expected the config to be left as it was")
+	}
+	if _, err := os.Stat(filepath.Join(project, "vendor")); !os.IsNotExist(err) {
+		t.Error("Expected nothing to be written to the vendor directory")
+	}
+	if _, err := os.Stat(filepath.Join(project, gpath.LockFile)); !os.IsNotExist(err) {
+		t.Error("Expected no lock file to be written")
+	}
+}
+
+func TestUpdateTracksBranch(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir, err := ioutil.TempDir("", "glide-track")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	remote := filepath.Join(dir, "remote")
+	first := newTestRemote(t, remote, "lib.go", "package lib\n")
+	testGit(t, remote, "branch", "-M", "main")
+
+	project := filepath.Join(dir, "project")
+	if err := os.MkdirAll(project, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(project, "main.go"), []byte("package main\n\nimport _ \"github.com/example/lib\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	newConf := func() *cfg.Config {
+		return &cfg.Config{
+			Name: "example.com/project",
+			Imports: cfg.Dependencies{
+				{Name: "github.com/example/lib", Reference: "main", Repository: remote, VcsType: "git"},
+			},
+		}
+	}
+
+	i := NewInstaller()
+	i.Home = filepath.Join(dir, "home")
+	i.Base = project
+
+	// An update, followed by setting the references as the update command
+	// does, pins the dependency to the tip of the branch while the config
+	// keeps tracking the branch.
+	conf := newConf()
+	if err := i.Update(conf); err != nil {
+		t.Fatalf("Unexpected error updating: %s", err)
+	}
+	if err := i.SetReference(conf); err != nil {
+		t.Fatalf("Unexpected error setting references: %s", err)
+	}
+	dep := conf.Imports.Get("github.com/example/lib")
+	if dep.Reference != "main" || dep.Pin != first {
+		t.Fatalf("Expected to track main pinned to %s, got %q pinned to %q", first, dep.Reference, dep.Pin)
+	}
+	lock, err := cfg.NewLockfile(conf.Imports, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lock.Imports[0].Version != first {
+		t.Fatalf("Expected the lock to record %s, got %q", first, lock.Imports[0].Version)
+	}
+
+	testGit(t, remote, "commit", "-q", "--allow-empty", "-m", "second")
+	second := testGit(t, remote, "rev-parse", "HEAD")
+
+	// An install reproduces the locked commit after the branch moved on.
+	i = NewInstaller()
+	i.Home = filepath.Join(dir, "home")
+	i.Base = project
+	if _, err := i.Install(lock, newConf(), true); err != nil {
+		t.Fatalf("Unexpected error installing: %s", err)
+	}
+	key, err := i.cacheKey(dep)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := testGit(t, filepath.Join(i.cacheLocation(), "src", key), "rev-parse", "HEAD"); c != first {
+		t.Errorf("Expected the install to check out %s, got %s", first, c)
+	}
+
+	// Another update advances along the branch.
+	i = NewInstaller()
+	i.Home = filepath.Join(dir, "home")
+	i.Base = project
+	conf = newConf()
+	if err := i.Update(conf); err != nil {
+		t.Fatalf("Unexpected error updating: %s", err)
+	}
+	if err := i.SetReference(conf); err != nil {
+		t.Fatalf("Unexpected error setting references: %s", err)
+	}
+	if dep := conf.Imports.Get("github.com/example/lib"); dep.Pin != second {
+		t.Errorf("Expected the update to move to %s, got %q", second, dep.Pin)
+	}
+}
+
+func TestInstallTestImports(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir, err := ioutil.TempDir("", "glide-install-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	lock := &cfg.Lockfile{}
+	for _, n := range []string{"lib", "testlib"} {
+		remote := filepath.Join(dir, n)
+		commit := newTestRemote(t, remote, n+".go", "package "+n+"\n")
+		l := &cfg.Lock{Name: "github.com/example/" + n, Version: commit, Repository: remote, VcsType: "git"}
+		if n == "lib" {
+			lock.Imports = append(lock.Imports, l)
+		} else {
+			lock.DevImports = append(lock.DevImports, l)
+		}
+	}
+
+	install := func(useDev bool) *Installer {
+		i := NewInstaller()
+		i.Home = filepath.Join(dir, "home")
+		i.Vendor = filepath.Join(dir, "vendor")
+		i.ResolveTest = true
+		conf, err := i.Install(lock, &cfg.Config{Name: "example.com/app"}, useDev)
+		if err != nil {
+			t.Fatalf("Unexpected error installing: %s", err)
+		}
+		if len(conf.DevImports) != 1 {
+			t.Errorf("Expected the test imports to stay in the config, got %d", len(conf.DevImports))
+		}
+		if err := i.SetReference(conf); err != nil {
+			t.Fatalf("Unexpected error setting references: %s", err)
+		}
+		if unused, err := i.UnusedVendored(conf); err != nil || len(unused) != 0 {
+			t.Errorf("Expected nothing to be removed from the vendor directory, got %v (%v)", unused, err)
+		}
+		if err := i.Export(conf); err != nil {
+			t.Fatalf("Unexpected error exporting: %s", err)
+		}
+		return i
+	}
+	vendored := func(n string) bool {
+		_, err := os.Stat(filepath.Join(dir, "vendor", "github.com", "example", n, n+".go"))
+		return err == nil
+	}
+
+	// Without useDev the test imports are not fetched and there are none to
+	// keep.
+	i := install(false)
+	if m := i.Metrics(); m.Cloned != 1 {
+		t.Errorf("Expected only the imports to be fetched, got %d clones", m.Cloned)
+	}
+	if !vendored("lib") || vendored("testlib") {
+		t.Error("Expected only the imports in the vendor directory")
+	}
+
+	i = install(true)
+	if m := i.Metrics(); m.Cloned != 1 {
+		t.Errorf("Expected the test imports to be fetched, got %d clones", m.Cloned)
+	}
+	if !vendored("lib") || !vendored("testlib") {
+		t.Error("Expected the imports and test imports in the vendor directory")
+	}
+
+	// The test imports already in the vendor directory are kept when they are
+	// skipped, without being fetched.
+	if err := os.RemoveAll(filepath.Join(dir, "home")); err != nil {
+		t.Fatal(err)
+	}
+	i = install(false)
+	if m := i.Metrics(); m.Cloned != 1 {
+		t.Errorf("Expected only the imports to be fetched, got %d clones", m.Cloned)
+	}
+	if !vendored("lib") || !vendored("testlib") {
+		t.Error("Expected the skipped test imports to be kept in the vendor directory")
+	}
+}
+
+func TestConcurrentUpdateSerial(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir, err := ioutil.TempDir("", "glide-serial")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Every other dependency does not exist so fetching it fails.
+	base := &cfg.Config{Name: "example.com/app"}
+	var failing []string
+	for ii := 0; ii < 6; ii++ {
+		name := fmt.Sprintf("github.com/example/%d", ii)
+		remote := filepath.Join(dir, "remotes", fmt.Sprint(ii))
+		if ii%2 == 0 {
+			newTestRemote(t, remote)
+		} else {
+			failing = append(failing, name)
+		}
+		base.Imports = append(base.Imports, &cfg.Dependency{Name: name, Repository: remote, VcsType: "git"})
+	}
+
+	type result struct {
+		conf     *cfg.Config
+		errs     []string
+		metrics  Metrics
+		failures []string
+	}
+	update := func(serial bool) result {
+		home := filepath.Join(dir, "home")
+		if err := os.RemoveAll(home); err != nil {
+			t.Fatal(err)
+		}
+		i := NewInstaller()
+		i.Home = home
+		i.Concurrency = 4
+		i.Serial = serial
+		conf := base.Clone()
+		err := ConcurrentUpdate(conf.Imports, i, conf)
+		if err == nil {
+			t.Fatalf("Expected the missing dependencies to fail with serial %t", serial)
+		}
+		errs := strings.Split(err.Error(), "\n")
+		sort.Strings(errs)
+		var failures []string
+		for _, f := range i.Failures() {
+			failures = append(failures, f.Name)
+		}
+		return result{conf: conf, errs: errs, metrics: i.Metrics(), failures: failures}
+	}
+
+	concurrent := update(false)
+	serial := update(true)
+	if !reflect.DeepEqual(serial.conf, concurrent.conf) {
+		t.Errorf("Expected the same config, got %+v serially and %+v concurrently", serial.conf, concurrent.conf)
+	}
+	if !reflect.DeepEqual(serial.errs, concurrent.errs) {
+		t.Errorf("Expected the same errors, got %q serially and %q concurrently", serial.errs, concurrent.errs)
+	}
+	if serial.metrics != concurrent.metrics || serial.metrics.Cloned != 3 {
+		t.Errorf("Expected the same metrics with three clones, got %+v serially and %+v concurrently", serial.metrics, concurrent.metrics)
+	}
+
+	// Serially the dependencies are processed in the order they are given.
+	if !reflect.DeepEqual(serial.failures, failing) {
+		t.Errorf("Expected the failures in the order %v, got %v", failing, serial.failures)
+	}
+}
@@ -0,0 +1,21 @@
+package repo
+
+import (
+	gpath "github.com/Ownercz/glide/path"
+	v "github.com/Ownercz/vcs"
+)
+
+// vcsDirs are the names of the directories holding VCS metadata.
+var vcsDirs = map[string]bool{
+	".git": true,
+	".hg":  true,
+	".bzr": true,
+	".svn": true,
+}
+
+// exportWithVCS copies a checkout to a directory along with its VCS metadata
+// so the copy is a working repository. Unlike an export it includes files
+// the VCS ignores or does not track.
+func exportWithVCS(repo v.Repo, dest string) error {
+	return gpath.CopyDir(repo.LocalPath(), dest)
+}
@@ -0,0 +1,59 @@
+package repo
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	v "github.com/Ownercz/vcs"
+)
+
+func TestExportWithVCS(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir, err := ioutil.TempDir("", "glide-keepvcs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	remote := filepath.Join(dir, "remote")
+	newTestRemote(t, remote, "a.go", "package a\n")
+
+	repo, err := v.NewGitRepo(remote, filepath.Join(dir, "checkout"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Get(); err != nil {
+		t.Fatal(err)
+	}
+	rev, err := repo.Version()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	kept, exported := filepath.Join(dir, "kept", "a"), filepath.Join(dir, "exported", "a")
+	if err := exportWithVCS(repo, kept); err != nil {
+		t.Fatalf("Unexpected error exporting with the VCS metadata: %s", err)
+	}
+	out, err := exec.Command("git", "-C", kept, "rev-parse", "HEAD").Output()
+	if err != nil || strings.TrimSpace(string(out)) != rev {
+		t.Errorf("Expected the copy to be a repository at %s, got %s", rev, out)
+	}
+
+	if err := repo.ExportDir(exported); err != nil {
+		t.Fatal(err)
+	}
+	files, err := diffTrees(exported, kept, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 0 {
+		t.Errorf("Expected the VCS metadata to not be a modification, got %v", files)
+	}
+}
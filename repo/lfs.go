@@ -0,0 +1,47 @@
+package repo
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/Ownercz/glide/msg"
+	v "github.com/Ownercz/vcs"
+)
+
+// usesLFS returns if a Git checkout tracks files with LFS. This is detected
+// through the lfs filter in the .gitattributes file at the root.
+func usesLFS(repo v.Repo) bool {
+	if repo.Vcs() != v.Git {
+		return false
+	}
+	b, err := ioutil.ReadFile(filepath.Join(repo.LocalPath(), ".gitattributes"))
+	if err != nil {
+		return false
+	}
+	return bytes.Contains(b, []byte("filter=lfs"))
+}
+
+// fetchLFS fetches the LFS content for the checked out revision of a
+// dependency when FetchLFS is set. The filter is installed in the checkout
+// so exporting it writes the content rather than the pointer files. Without
+// FetchLFS a warning is displayed instead.
+func (i *Installer) fetchLFS(name string, repo v.Repo) error {
+	if !usesLFS(repo) {
+		return nil
+	}
+	if !i.FetchLFS {
+		msg.Warn("%s uses git LFS but its LFS content was not fetched. Files tracked by LFS will be pointers. Use --fetch-lfs to fetch it", name)
+		return nil
+	}
+
+	msg.Info("--> Fetching the LFS content for %s", name)
+	if out, err := repo.RunFromDir("git", "lfs", "install", "--local"); err != nil {
+		return fmt.Errorf("Unable to setup git LFS for %s, is it installed? %s", name, out)
+	}
+	if out, err := i.runRemoteGit(repo.LocalPath(), repo.Remote(), "lfs", "pull"); err != nil {
+		return fmt.Errorf("Unable to fetch the LFS content for %s: %s", name, out)
+	}
+	return nil
+}
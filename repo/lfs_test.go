@@ -0,0 +1,47 @@
+package repo
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	v "github.com/Ownercz/vcs"
+)
+
+func TestUsesLFS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "glide-lfs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	repo, err := v.NewGitRepo("https://github.com/example/lfs", dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usesLFS(repo) {
+		t.Error("Expected a checkout without a .gitattributes file to not use LFS")
+	}
+
+	attr := filepath.Join(dir, ".gitattributes")
+	if err := ioutil.WriteFile(attr, []byte("*.go text eol=lf\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if usesLFS(repo) {
+		t.Error("Expected a checkout without the lfs filter to not use LFS")
+	}
+
+	if err := ioutil.WriteFile(attr, []byte("*.bin filter=lfs diff=lfs merge=lfs -text\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if !usesLFS(repo) {
+		t.Error("Expected a checkout with the lfs filter to use LFS")
+	}
+
+	// Without FetchLFS only a warning is displayed.
+	i := NewInstaller()
+	if err := i.fetchLFS("github.com/example/lfs", repo); err != nil {
+		t.Errorf("Expected no error without FetchLFS, got %s", err)
+	}
+}
@@ -0,0 +1,46 @@
+package repo
+
+import (
+	"sync"
+
+	"github.com/Ownercz/glide/cfg"
+)
+
+// locationTable holds where the Installer fetches each location from, by the
+// location of the dependency, such as after discovery or a replace rule. This
+// is a concurrency safe implementation and its zero value is ready to use.
+type locationTable struct {
+	sync.Mutex
+
+	found map[string]cfg.Mirror
+}
+
+// setLocation records the repository, and VCS type, a dependency is fetched
+// from for the rest of the run and sets it on the dependency. Other
+// dependencies at the same location pick it up with locate. Unlike the
+// mirrors configured by the user it only applies to this Installer.
+func (i *Installer) setLocation(dep *cfg.Dependency, repo, vcsType string) {
+	m := cfg.Mirror{Repo: repo, Vcs: vcsType}
+	i.locations.Lock()
+	if i.locations.found == nil {
+		i.locations.found = make(map[string]cfg.Mirror)
+	}
+	i.locations.found[dep.Location()] = m
+	i.locations.Unlock()
+	dep.Mirror = &m
+}
+
+// locate sets where a dependency is fetched from when the Installer already
+// recorded it for its location and returns if it did.
+func (i *Installer) locate(dep *cfg.Dependency) bool {
+	if i == nil {
+		return false
+	}
+	i.locations.Lock()
+	m, ok := i.locations.found[dep.Location()]
+	i.locations.Unlock()
+	if ok {
+		dep.Mirror = &m
+	}
+	return ok
+}
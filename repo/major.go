@@ -0,0 +1,33 @@
+package repo
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Ownercz/glide/gomod"
+	"github.com/Ownercz/glide/util"
+)
+
+// majorSubdir returns the directory within the checkout of a repository at
+// dir a dependency with a major version, such as github.com/example/lib/v2,
+// is in when the repository uses the major subdirectory layout of Go modules.
+// There the v2 directory holds the major version while the root holds an
+// older one. It is empty for the major branch layout, where the root is the
+// major version, and for dependencies without one.
+func majorSubdir(name, dir string) string {
+	_, major := util.SplitMajorVersion(name)
+	if major == "" {
+		return ""
+	}
+	if fi, err := os.Stat(filepath.Join(dir, major)); err != nil || !fi.IsDir() {
+		return ""
+	}
+
+	// A root declaring the major version is the major branch layout, even
+	// with a directory named like it.
+	if strings.HasSuffix(gomod.ModulePath(dir), "/"+major) {
+		return ""
+	}
+	return major
+}
@@ -0,0 +1,85 @@
+package repo
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/Ownercz/glide/cfg"
+)
+
+func TestExportMajorSubdir(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir, err := ioutil.TempDir("", "glide-major")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// One repository uses the major subdirectory layout, with v2 in its own
+	// directory, and the other the major branch layout.
+	layouts := map[string]map[string]string{
+		"subdir": {
+			"go.mod":    "module github.com/example/subdir\n",
+			"lib.go":    "package lib\n",
+			"v2/go.mod": "module github.com/example/subdir/v2\n",
+			"v2/lib.go": "package lib // v2\n",
+		},
+		"branch": {
+			"go.mod":     "module github.com/example/branch/v2\n",
+			"lib.go":     "package lib // v2\n",
+			"v2/doc.txt": "A directory named like the major version\n",
+		},
+	}
+	for name, files := range layouts {
+		pairs := []string{}
+		for f, c := range files {
+			pairs = append(pairs, f, c)
+		}
+		newTestRemote(t, filepath.Join(dir, "remotes", name), pairs...)
+	}
+
+	i := NewInstaller()
+	i.Home = filepath.Join(dir, "home")
+	vp := filepath.Join(dir, "vendor")
+	read := func(name, f string) string {
+		b, _ := ioutil.ReadFile(filepath.Join(vp, filepath.FromSlash(name), f))
+		return string(b)
+	}
+	for _, name := range []string{"subdir", "branch"} {
+		dep := &cfg.Dependency{Name: "github.com/example/" + name + "/v2", Repository: filepath.Join(dir, "remotes", name), VcsType: "git"}
+		if err := i.VcsGet(dep); err != nil {
+			t.Fatalf("Unexpected error fetching %s: %s", dep.Name, err)
+		}
+		dest, err := i.vendorDir(vp, dep.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.MkdirAll(dest, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := i.exportDep(dep, vp); err != nil {
+			t.Fatalf("Unexpected error exporting %s: %s", dep.Name, err)
+		}
+		if read(dep.Name, "lib.go") != "package lib // v2\n" {
+			t.Errorf("Expected the v2 package of %s to be exported", dep.Name)
+		}
+
+		// The resolver finds the packages in the same place.
+		m := &MissingPackageHandler{Config: &cfg.Config{Name: "example.com/app", Imports: cfg.Dependencies{dep}}, Use: newImportCache(), installer: i}
+		if b, err := ioutil.ReadFile(filepath.Join(m.PkgPath(dep.Name), "lib.go")); err != nil || string(b) != "package lib // v2\n" {
+			t.Errorf("Expected the package path of %s to be the v2 package, got %s", dep.Name, m.PkgPath(dep.Name))
+		}
+	}
+	if _, err := os.Stat(filepath.Join(vp, "github.com", "example", "subdir", "v2", "v2")); err == nil {
+		t.Error("Expected only the v2 directory of the major subdirectory layout to be exported")
+	}
+	if read("github.com/example/branch/v2", "v2/doc.txt") == "" {
+		t.Error("Expected the whole repository of the major branch layout to be exported")
+	}
+}
@@ -0,0 +1,191 @@
+package repo
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Ownercz/glide/cfg"
+	"github.com/Ownercz/glide/msg"
+)
+
+// LicenseUnknown is the license of a package when none could be detected. It
+// is the value SPDX uses for the same case.
+const LicenseUnknown = "NOASSERTION"
+
+// Manifest is a bill of materials for the resolved dependencies of a
+// project. It is written as JSON. The format is described in
+// docs/manifest.md.
+type Manifest struct {
+	Name     string           `json:"name"`
+	Created  time.Time        `json:"created"`
+	Packages ManifestPackages `json:"packages"`
+}
+
+// ManifestPackages is a slice of packages in a Manifest sorted by name.
+type ManifestPackages []*ManifestPackage
+
+// Len returns the length of the packages. This is needed for sorting with
+// the sort package.
+func (p ManifestPackages) Len() int {
+	return len(p)
+}
+
+// Less is needed for the sort interface. It compares two packages based on
+// their name.
+func (p ManifestPackages) Less(i, j int) bool {
+	return p[i].Name < p[j].Name
+}
+
+// Swap is needed for the sort interface. It swaps the position of two
+// packages.
+func (p ManifestPackages) Swap(i, j int) {
+	p[i], p[j] = p[j], p[i]
+}
+
+// ManifestPackage describes a single dependency in a Manifest. License is a
+// best effort SPDX identifier detected from the license file. It is
+// LicenseUnknown when no license file was found or it was not recognized.
+type ManifestPackage struct {
+	Name        string `json:"name"`
+	Version     string `json:"version,omitempty"`
+	Commit      string `json:"commit"`
+	Repository  string `json:"repository"`
+	VcsType     string `json:"vcs,omitempty"`
+	License     string `json:"license"`
+	LicenseFile string `json:"licenseFile,omitempty"`
+}
+
+// Manifest generates the manifest for resolved dependencies. The checkouts in
+// the cache are used to detect licenses and, for dependencies without a
+// pinned version, the commit.
+func (i *Installer) Manifest(name string, deps []*cfg.Dependency) (*Manifest, error) {
+	m := &Manifest{
+		Name:     name,
+		Created:  time.Now(),
+		Packages: make(ManifestPackages, 0, len(deps)),
+	}
+
+	for _, dep := range deps {
+		p := &ManifestPackage{
+			Name:       dep.Name,
+			Version:    dep.Reference,
+			Commit:     dep.Pin,
+			Repository: dep.Remote(),
+			VcsType:    dep.Vcs(),
+			License:    LicenseUnknown,
+		}
+
+		key, err := i.cacheKey(dep)
+		if err != nil {
+			return nil, err
+		}
+		dir := filepath.Join(i.cacheLocation(), "src", key)
+		if p.Commit == "" {
+			repo, err := dep.GetRepo(dir)
+			if err == nil {
+				p.Commit, err = repo.Version()
+			}
+			if err != nil {
+				msg.Warn("Unable to determine the commit of %s: %s", dep.Name, err)
+			}
+		}
+		p.License, p.LicenseFile = detectLicense(dir)
+
+		m.Packages = append(m.Packages, p)
+	}
+
+	sort.Sort(m.Packages)
+
+	return m, nil
+}
+
+// WriteFile writes the manifest as JSON. If the file exists, it will be
+// clobbered.
+func (m *Manifest) WriteFile(pth string) error {
+	o, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(pth, append(o, '\n'), 0666)
+}
+
+// licenseFiles are the names, without extension, of files holding a license.
+var licenseFiles = []string{"license", "licence", "copying", "unlicense"}
+
+// licenseMatchers identify licenses from phrases in their text. They are
+// checked in order so more specific ones come first.
+var licenseMatchers = []struct {
+	id      string
+	phrases []string
+}{
+	{"Apache-2.0", []string{"apache license", "version 2.0"}},
+	{"MPL-2.0", []string{"mozilla public license", "version 2.0"}},
+	{"LGPL-3.0", []string{"gnu lesser general public license", "version 3"}},
+	{"LGPL-2.1", []string{"gnu lesser general public license", "version 2.1"}},
+	{"AGPL-3.0", []string{"gnu affero general public license", "version 3"}},
+	{"GPL-3.0", []string{"gnu general public license", "version 3"}},
+	{"GPL-2.0", []string{"gnu general public license", "version 2"}},
+	{"BSD-3-Clause", []string{"redistribution and use in source and binary forms", "neither the name"}},
+	{"BSD-2-Clause", []string{"redistribution and use in source and binary forms"}},
+	{"MIT", []string{"permission is hereby granted, free of charge"}},
+	{"ISC", []string{"permission to use, copy, modify, and/or distribute this software for any"}},
+	{"Unlicense", []string{"this is free and unencumbered software released into the public domain"}},
+}
+
+// detectLicense looks for a license file at the top of a checkout and returns
+// the SPDX identifier of the license along with the file name.
+func detectLicense(dir string) (string, string) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return LicenseUnknown, ""
+	}
+
+	for _, f := range files {
+		if f.IsDir() {
+			continue
+		}
+		base := strings.ToLower(strings.TrimSuffix(f.Name(), filepath.Ext(f.Name())))
+		known := false
+		for _, l := range licenseFiles {
+			if base == l {
+				known = true
+				break
+			}
+		}
+		if !known {
+			continue
+		}
+
+		b, err := ioutil.ReadFile(filepath.Join(dir, f.Name()))
+		if err != nil {
+			msg.Debug("Unable to read %s: %s", f.Name(), err)
+			continue
+		}
+		return matchLicense(string(b)), f.Name()
+	}
+
+	return LicenseUnknown, ""
+}
+
+// matchLicense returns the SPDX identifier of a license text.
+func matchLicense(text string) string {
+	text = strings.ToLower(strings.Join(strings.Fields(text), " "))
+	for _, m := range licenseMatchers {
+		matched := true
+		for _, p := range m.phrases {
+			if !strings.Contains(text, p) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return m.id
+		}
+	}
+
+	return LicenseUnknown
+}
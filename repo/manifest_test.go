@@ -0,0 +1,41 @@
+package repo
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDetectLicense(t *testing.T) {
+	dir, err := ioutil.TempDir("", "glide-license")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if l, f := detectLicense(dir); l != LicenseUnknown || f != "" {
+		t.Errorf("Expected an unknown license without a file, got %s (%s)", l, f)
+	}
+
+	mit := "The MIT License\n\nPermission is hereby granted, free of charge, to any person\nobtaining a copy of this software"
+	if err := ioutil.WriteFile(filepath.Join(dir, "LICENSE.txt"), []byte(mit), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if l, f := detectLicense(dir); l != "MIT" || f != "LICENSE.txt" {
+		t.Errorf("Expected the MIT license in LICENSE.txt, got %s (%s)", l, f)
+	}
+
+	tests := map[string]string{
+		"Apache License\n Version 2.0, January 2004":                                           "Apache-2.0",
+		"Redistribution and use in source and binary forms... Neither the name of Google Inc.": "BSD-3-Clause",
+		"Redistribution and use in source and binary forms, with or without modification":      "BSD-2-Clause",
+		"GNU LESSER GENERAL PUBLIC LICENSE\n Version 3, 29 June 2007":                          "LGPL-3.0",
+		"All rights reserved. Do not copy.":                                                    LicenseUnknown,
+	}
+	for text, expect := range tests {
+		if l := matchLicense(text); l != expect {
+			t.Errorf("Expected license %s, got %s", expect, l)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package repo
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/Ownercz/glide/msg"
+)
+
+// Metrics contains counters collected over the course of an install or
+// update run.
+type Metrics struct {
+	// Cloned is the number of repositories fetched for the first time.
+	Cloned int
+
+	// Updated is the number of existing repositories updated from a remote.
+	Updated int
+
+	// Skipped is the number of dependencies that did not need to be fetched.
+	Skipped int
+
+	// Ignored is the number of imported packages left out by the ignore
+	// rules of the config.
+	Ignored int
+
+	// OtherPlatform is the number of dependencies left out because they are
+	// only used on another platform.
+	OtherPlatform int
+
+	// Bytes is the size of newly cloned repositories. Updates to existing
+	// repositories are not measured.
+	Bytes int64
+
+	// Retries is the number of VCS operations retried after a failure.
+	Retries int
+
+	// Conflicts is the number of version conflicts encountered.
+	Conflicts int
+
+	// Unexpected is the number of dependencies skipped because of a problem,
+	// such as a package that could not be fetched, one the GOPATH policy does
+	// not allow to be used from the GOPATH, or a version that could not be
+	// set. These are not included in Skipped.
+	Unexpected int
+}
+
+// metricsTracker accumulates Metrics. This is a concurrency safe
+// implementation and its zero value is ready to use.
+type metricsTracker struct {
+	sync.Mutex
+
+	m Metrics
+}
+
+// count applies a change to the tracked metrics while holding the lock.
+func (t *metricsTracker) count(f func(m *Metrics)) {
+	t.Lock()
+	f(&t.m)
+	t.Unlock()
+}
+
+// snapshot returns a copy of the current metrics.
+func (t *metricsTracker) snapshot() Metrics {
+	t.Lock()
+	defer t.Unlock()
+	return t.m
+}
+
+// Metrics returns the counters collected by the Installer so far.
+func (i *Installer) Metrics() Metrics {
+	return i.metrics.snapshot()
+}
+
+// LogMetrics displays the collected counters unless SuppressMetrics is set.
+func (i *Installer) LogMetrics() {
+	if i.SuppressMetrics {
+		return
+	}
+
+	m := i.Metrics()
+	msg.Info("Cloned %d, updated %d, skipped %d repositories (%d bytes cloned, %d retries, %d conflicts)",
+		m.Cloned, m.Updated, m.Skipped, m.Bytes, m.Retries, m.Conflicts)
+	if m.Ignored > 0 || m.OtherPlatform > 0 {
+		msg.Info("Left out %d ignored packages and %d dependencies for other platforms", m.Ignored, m.OtherPlatform)
+	}
+	if m.Unexpected > 0 {
+		msg.Warn("%d dependencies were skipped unexpectedly. See the messages above for details", m.Unexpected)
+	}
+}
+
+// CheckUnexpected returns an error when Strict is set and dependencies were
+// skipped unexpectedly.
+func (i *Installer) CheckUnexpected() error {
+	if !i.Strict {
+		return nil
+	}
+	if n := i.Metrics().Unexpected; n > 0 {
+		return fmt.Errorf("%d dependencies were skipped unexpectedly", n)
+	}
+	return nil
+}
+
+// countMetric records a metric change. It is safe to call with a nil Installer.
+func (i *Installer) countMetric(f func(m *Metrics)) {
+	if i == nil {
+		return
+	}
+	i.metrics.count(f)
+}
+
+// dirSize returns the total size of the regular files under a directory.
+func dirSize(dir string) int64 {
+	var size int64
+	filepath.Walk(dir, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if fi.Mode().IsRegular() {
+			size += fi.Size()
+		}
+		return nil
+	})
+	return size
+}
@@ -0,0 +1,56 @@
+package repo
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Ownercz/glide/cfg"
+)
+
+func TestConcurrentUpdateMetrics(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir, err := ioutil.TempDir("", "glide-metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	conf := &cfg.Config{Name: "example.com/app"}
+	for ii := 0; ii < 4; ii++ {
+		remote := filepath.Join(dir, "remotes", fmt.Sprint(ii))
+		newTestRemote(t, remote)
+		conf.Imports = append(conf.Imports, &cfg.Dependency{Name: fmt.Sprintf("github.com/example/%d", ii), Repository: remote, VcsType: "git"})
+	}
+
+	update := func() Metrics {
+		i := NewInstaller()
+		i.Home = filepath.Join(dir, "home")
+		i.Concurrency = 4
+		if err := ConcurrentUpdate(conf.Imports, i, conf); err != nil {
+			t.Fatalf("Unexpected error updating: %s", err)
+		}
+		return i.Metrics()
+	}
+
+	if m := update(); m.Cloned != 4 || m.Updated != 0 || m.Skipped != 0 {
+		t.Errorf("Expected every dependency to be cloned, got %+v", m)
+	}
+	if m := update(); m.Cloned != 0 || m.Updated != 4 || m.Skipped != 0 {
+		t.Errorf("Expected every dependency to be updated, got %+v", m)
+	}
+
+	// Half of the dependencies were fetched within their TTL.
+	conf.Imports[0].CacheTTL = time.Hour
+	conf.Imports[2].CacheTTL = time.Hour
+	if m := update(); m.Cloned != 0 || m.Updated != 2 || m.Skipped != 2 {
+		t.Errorf("Expected two dependencies to be updated and two skipped, got %+v", m)
+	}
+}
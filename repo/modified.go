@@ -0,0 +1,267 @@
+package repo
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Ownercz/glide/cache"
+	"github.com/Ownercz/glide/cfg"
+	"github.com/Ownercz/glide/msg"
+	"github.com/Ownercz/vcs"
+)
+
+// ModifiedDependency is a dependency whose files in the vendor directory
+// don't match its locked revision.
+type ModifiedDependency struct {
+	Name string
+
+	// Missing is set when the dependency is not in the vendor directory.
+	Missing bool
+
+	// Files are the / separated paths, relative to the dependency, of the
+	// files that were changed, added or removed.
+	Files []string
+
+	// Digest is set by VerifyDigests when the content of the dependency
+	// doesn't match its digest in the lock file.
+	Digest bool
+
+	// Version is set by VerifyDigests to the revision of a dependency kept
+	// with its VCS metadata when it is not the locked one.
+	Version string
+}
+
+// VerifyVendor checks the files of each dependency in the lock file against
+// its locked revision and returns those that were modified, in the order of
+// the lock file. The revision is exported from the cache, where it is fetched
+// when missing, and compared with the vendor directory. Test dependencies are
+// checked when ResolveTest is set.
+//
+// Files removed from nested vendor and Godeps/_workspace directories, as
+// stripping them does, are not counted. Neither are the directories of other
+// dependencies within one. Dependencies that are symlinks are skipped as they
+// are local checkouts, as are those for another platform as they are not
+// exported. The rewrite rules of the config, which can be nil, apply to where
+// the revisions are fetched from.
+func (i *Installer) VerifyVendor(lock *cfg.Lockfile, conf *cfg.Config) ([]ModifiedDependency, error) {
+	locks := lock.Imports
+	if i.ResolveTest {
+		locks = append(locks[:len(locks):len(locks)], lock.DevImports...)
+	}
+
+	vendor := i.VendorPath()
+	deps := make([]*cfg.Dependency, 0, len(locks))
+	dirs := make([]string, 0, len(locks))
+	for _, l := range locks {
+		dep := cfg.DependencyFromLock(l)
+		if filterArchOs(dep, i) {
+			continue
+		}
+		d, err := i.vendorDir("", l.Name)
+		if err != nil {
+			return nil, err
+		}
+		deps = append(deps, dep)
+		dirs = append(dirs, d)
+	}
+
+	tmp, err := ioutil.TempDir(i.tmpDir(), "glide-verify")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+
+	modified := []ModifiedDependency{}
+	for ii, dep := range deps {
+		dest := filepath.Join(vendor, dirs[ii])
+		if isSymlink(dest) {
+			msg.Debug("%s is a symlink to a local checkout. Not verifying it", dest)
+			continue
+		}
+		if _, err := os.Stat(dest); os.IsNotExist(err) {
+			modified = append(modified, ModifiedDependency{Name: dep.Name, Missing: true})
+			continue
+		}
+
+		msg.Info("--> Verifying %s", dep.Name)
+		pristine := filepath.Join(tmp, dirs[ii])
+		if err := i.exportLocked(dep, conf, pristine); err != nil {
+			return modified, err
+		}
+
+		// The directories of other dependencies within this one.
+		nested := []string{}
+		for _, d := range dirs {
+			if strings.HasPrefix(d, dirs[ii]+string(os.PathSeparator)) {
+				nested = append(nested, d[len(dirs[ii])+1:])
+			}
+		}
+		files, err := diffTrees(pristine, dest, nested)
+		if err != nil {
+			return modified, err
+		}
+		if len(files) > 0 {
+			modified = append(modified, ModifiedDependency{Name: dep.Name, Files: files})
+		}
+	}
+
+	return modified, nil
+}
+
+// exportLocked exports a dependency at its locked revision from the cache to
+// a directory. The revision is fetched when it is not in the cache, following
+// the rewrite rules of the config.
+func (i *Installer) exportLocked(dep *cfg.Dependency, conf *cfg.Config, dest string) error {
+	key, err := i.cacheKey(dep)
+	if err != nil {
+		return err
+	}
+	cache.Lock(key)
+	defer cache.Unlock(key)
+
+	repo, err := i.checkoutLocked(dep, conf)
+	if err != nil {
+		return err
+	}
+	return repo.ExportDir(dest)
+}
+
+// checkoutLocked checks out a dependency at its locked revision in the cache.
+// The revision is fetched when it is not in the cache, following the rewrite
+// rules of the config. The caller holds the cache lock for the dependency.
+func (i *Installer) checkoutLocked(dep *cfg.Dependency, conf *cfg.Config) (vcs.Repo, error) {
+	if err := i.discover(dep, conf); err != nil {
+		return nil, err
+	}
+	key, err := i.cacheKey(dep)
+	if err != nil {
+		return nil, err
+	}
+
+	repo, err := dep.GetRepo(filepath.Join(i.cacheLocation(), "src", key))
+	if err != nil {
+		return nil, err
+	}
+	if !repo.CheckLocal() {
+		if err := i.VcsGet(dep); err != nil {
+			return nil, err
+		}
+	} else if _, err := repo.CommitInfo(dep.Reference); err != nil {
+		if err := i.update(repo); err != nil {
+			return nil, err
+		}
+	}
+	return repo, repo.UpdateVersion(dep.Reference)
+}
+
+// diffTrees returns the sorted, / separated, paths of the files that differ
+// between two directories. The skip paths, relative to the directories, are
+// not compared.
+func diffTrees(a, b string, skip []string) ([]string, error) {
+	af, err := treeFiles(a, skip)
+	if err != nil {
+		return nil, err
+	}
+	bf, err := treeFiles(b, skip)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := []string{}
+	for rel, fi := range af {
+		if _, found := bf[rel]; !found {
+			if !stripped(rel) {
+				diff = append(diff, filepath.ToSlash(rel))
+			}
+			continue
+		}
+		same, err := sameFile(filepath.Join(a, rel), fi, filepath.Join(b, rel), bf[rel])
+		if err != nil {
+			return nil, err
+		}
+		if !same {
+			diff = append(diff, filepath.ToSlash(rel))
+		}
+	}
+	for rel := range bf {
+		if _, found := af[rel]; !found {
+			diff = append(diff, filepath.ToSlash(rel))
+		}
+	}
+
+	sort.Strings(diff)
+	return diff, nil
+}
+
+// treeFiles returns the files under a directory by their relative path,
+// leaving out those under the skip paths and VCS metadata directories.
+func treeFiles(dir string, skip []string) (map[string]os.FileInfo, error) {
+	files := make(map[string]os.FileInfo)
+	err := filepath.Walk(dir, func(p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		for _, s := range skip {
+			if rel == s {
+				return filepath.SkipDir
+			}
+		}
+		if fi.IsDir() && vcsDirs[fi.Name()] {
+			return filepath.SkipDir
+		}
+		if !fi.IsDir() {
+			files[rel] = fi
+		}
+		return nil
+	})
+	return files, err
+}
+
+// stripped returns if a path is within a nested vendor or Godeps/_workspace
+// directory, which are removed when stripping vendor directories.
+func stripped(rel string) bool {
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	for ii, p := range parts[:len(parts)-1] {
+		if p == "vendor" || (p == "Godeps" && ii+1 < len(parts)-1 && parts[ii+1] == "_workspace") {
+			return true
+		}
+	}
+	return false
+}
+
+// sameFile returns if two files have the same content, or for symlinks the
+// same target. A symlink matches a file with the content it links to as the
+// store copies them.
+func sameFile(a string, afi os.FileInfo, b string, bfi os.FileInfo) (bool, error) {
+	asl, bsl := afi.Mode()&os.ModeSymlink != 0, bfi.Mode()&os.ModeSymlink != 0
+	if asl && bsl {
+		at, aerr := os.Readlink(a)
+		bt, berr := os.Readlink(b)
+		return aerr == nil && berr == nil && at == bt, nil
+	}
+	if !asl && !bsl && afi.Size() != bfi.Size() {
+		return false, nil
+	}
+
+	ac, aerr := ioutil.ReadFile(a)
+	bc, berr := ioutil.ReadFile(b)
+	if asl || bsl {
+		// A link that can't be followed doesn't match.
+		return aerr == nil && berr == nil && bytes.Equal(ac, bc), nil
+	}
+	if aerr != nil {
+		return false, aerr
+	}
+	if berr != nil {
+		return false, berr
+	}
+	return bytes.Equal(ac, bc), nil
+}
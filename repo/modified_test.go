@@ -0,0 +1,78 @@
+package repo
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/Ownercz/glide/cfg"
+)
+
+func TestVerifyVendor(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir, err := ioutil.TempDir("", "glide-modified")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	remote := filepath.Join(dir, "remote")
+	commit := newTestRemote(t, remote, "a.go", "package a.go", "b.go", "package b.go", "vendor/other/c.go", "package c.go")
+
+	i := NewInstaller()
+	i.Home = filepath.Join(dir, "home")
+	i.Vendor = filepath.Join(dir, "vendor")
+
+	// The repository of modified is only found through the URL rewrite
+	// rules of the config.
+	conf := &cfg.Config{
+		Name:       "example.com/app",
+		URLRewrite: cfg.URLRewrites{{Prefix: "https://git.example.com/", URL: dir + string(os.PathSeparator)}},
+	}
+	lock := &cfg.Lockfile{
+		Imports: cfg.Locks{
+			{Name: "github.com/example/modified", Repository: "https://git.example.com/remote", VcsType: "git", Version: commit},
+			{Name: "github.com/example/missing", Repository: remote, VcsType: "git", Version: commit},
+		},
+	}
+
+	dest := filepath.Join(i.Vendor, "github.com", "example", "modified")
+	if err := i.exportLocked(cfg.DependencyFromLock(lock.Imports[0]), conf, dest); err != nil {
+		t.Fatal(err)
+	}
+	// Stripping nested vendor directories is not a modification.
+	if err := os.RemoveAll(filepath.Join(dest, "vendor")); err != nil {
+		t.Fatal(err)
+	}
+	m, err := i.VerifyVendor(lock, conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []ModifiedDependency{{Name: "github.com/example/missing", Missing: true}}
+	if !reflect.DeepEqual(m, expect) {
+		t.Errorf("Expected only the missing dependency, got %v", m)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(dest, "a.go"), []byte("package edited"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(dest, "b.go")); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dest, "d.go"), []byte("package d"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	m, err = i.VerifyVendor(lock, conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m) != 2 || !reflect.DeepEqual(m[0].Files, []string{"a.go", "b.go", "d.go"}) {
+		t.Errorf("Expected the changed, removed and added files, got %v", m)
+	}
+}
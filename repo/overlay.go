@@ -0,0 +1,75 @@
+package repo
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/Ownercz/glide/msg"
+	"github.com/Ownercz/glide/util"
+)
+
+// overlayList tracks the dependencies used from a local copy, in the Overlay
+// or the GOPATH, by the directory of the copy. This is a concurrency safe
+// implementation and its zero value is ready to use.
+type overlayList struct {
+	sync.Mutex
+
+	dirs map[string]string
+}
+
+// fromOverlay returns if the Overlay has a copy of a root package. When it
+// does the package is recorded as used from the Overlay for the rest of the
+// run.
+func (i *Installer) fromOverlay(root string) bool {
+	if i == nil || i.Overlay == "" {
+		return false
+	}
+	dir := filepath.Join(i.Overlay, filepath.FromSlash(root))
+	if fi, err := os.Stat(dir); err != nil || !fi.IsDir() {
+		msg.Debug("%s is not in the overlay %s", root, i.Overlay)
+		return false
+	}
+	i.useCopy(root, dir, "the overlay "+i.Overlay)
+	return true
+}
+
+// useCopy records that a root package is used from the copy in a directory
+// for the rest of the run.
+func (i *Installer) useCopy(root, dir, from string) {
+	i.overlaid.Lock()
+	defer i.overlaid.Unlock()
+	if i.overlaid.dirs == nil {
+		i.overlaid.dirs = make(map[string]string)
+	}
+	if _, ok := i.overlaid.dirs[root]; !ok {
+		msg.Info("--> Using %s from %s", root, from)
+		i.overlaid.dirs[root] = dir
+	}
+}
+
+// inOverlay returns if a dependency is used from a local copy, in the Overlay
+// or the GOPATH.
+func (i *Installer) inOverlay(name string) bool {
+	if i == nil {
+		return false
+	}
+
+	i.overlaid.Lock()
+	defer i.overlaid.Unlock()
+	_, ok := i.overlaid.dirs[name]
+	return ok
+}
+
+// overlayPath returns the location of a package in the local copy its root
+// package is used from, or the Overlay when it isn't used from one.
+func (i *Installer) overlayPath(pkg string) string {
+	root, sub := util.NormalizeName(pkg)
+	i.overlaid.Lock()
+	dir, ok := i.overlaid.dirs[root]
+	i.overlaid.Unlock()
+	if !ok {
+		dir = filepath.Join(i.Overlay, filepath.FromSlash(root))
+	}
+	return filepath.Join(dir, filepath.FromSlash(sub))
+}
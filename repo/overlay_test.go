@@ -0,0 +1,79 @@
+package repo
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/Ownercz/glide/cfg"
+)
+
+func TestOverlay(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir, err := ioutil.TempDir("", "glide-overlay")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// The project imports lib and base. The overlay only has a copy of lib,
+	// which is not a checkout. base is in a git remote.
+	write := func(p, src string) {
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	overlay := filepath.Join(dir, "overlay")
+	write(filepath.Join(overlay, "github.com", "example", "lib", "lib.go"), "package lib\n")
+	remote := filepath.Join(dir, "remotes", "base")
+	newTestRemote(t, remote, "base.go", "package base\n")
+	project := filepath.Join(dir, "project")
+	write(filepath.Join(project, "main.go"), "package main\n\nimport (\n\t_ \"github.com/example/base\"\n\t_ \"github.com/example/lib\"\n)\n")
+
+	conf := &cfg.Config{
+		Name: "example.com/project",
+		Imports: cfg.Dependencies{
+			{Name: "github.com/example/base", Repository: remote, VcsType: "git"},
+		},
+	}
+	i := NewInstaller()
+	i.Home = filepath.Join(dir, "home")
+	i.Base = project
+	i.Overlay = overlay
+	if err := i.Update(conf); err != nil {
+		t.Fatalf("Unexpected error updating: %s", err)
+	}
+	if err := i.Export(conf); err != nil {
+		t.Fatalf("Unexpected error exporting: %s", err)
+	}
+
+	// lib is a hit, copied from the overlay without being fetched.
+	if !i.inOverlay("github.com/example/lib") {
+		t.Error("Expected lib to be used from the overlay")
+	}
+	if _, err := os.Stat(filepath.Join(project, "vendor", "github.com", "example", "lib", "lib.go")); err != nil {
+		t.Errorf("Expected lib to be copied from the overlay: %s", err)
+	}
+	if d := conf.Imports.Get("github.com/example/lib"); d == nil || d.Pin != "" {
+		t.Errorf("Expected lib to be an import without a version, got %+v", d)
+	}
+
+	// base is a miss, fetched from its remote.
+	if i.inOverlay("github.com/example/base") {
+		t.Error("Expected base not to be used from the overlay")
+	}
+	if _, err := os.Stat(filepath.Join(project, "vendor", "github.com", "example", "base", "base.go")); err != nil {
+		t.Errorf("Expected base to be fetched: %s", err)
+	}
+	if m := i.Metrics(); m.Cloned != 1 {
+		t.Errorf("Expected only base to be cloned, got %d clones", m.Cloned)
+	}
+}
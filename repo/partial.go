@@ -0,0 +1,267 @@
+package repo
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/Ownercz/glide/cache"
+	"github.com/Ownercz/glide/cfg"
+	"github.com/Ownercz/glide/msg"
+	"github.com/Ownercz/glide/util"
+	"github.com/Ownercz/semver"
+	v "github.com/Ownercz/vcs"
+)
+
+// frozenSet holds the locked dependencies UpdateOnly leaves alone along with
+// the conflicts found with them. This is a concurrency safe implementation
+// and its zero value is ready to use.
+type frozenSet struct {
+	sync.Mutex
+
+	locks     map[string]*cfg.Lock
+	conflicts []string
+}
+
+// UpdateOnly is Update for some of the dependencies. Those named, and the
+// dependencies they bring in that the config does not list, are resolved
+// again. Every other dependency in the lock file stays at its locked commit.
+// When an updated dependency asks for a different version of one that stays
+// the locked commit is kept and the conflict is reported.
+func (i *Installer) UpdateOnly(conf *cfg.Config, lock *cfg.Lockfile, only []string) error {
+	names := make(map[string]bool, len(only))
+	for _, n := range only {
+		root, _ := util.NormalizeName(n)
+		if !conf.HasDependency(root) && lock.Imports.Get(root) == nil && lock.DevImports.Get(root) == nil {
+			return fmt.Errorf("%s is not a dependency of the project", n)
+		}
+		names[root] = true
+	}
+
+	locks := append(append(cfg.Locks{}, lock.Imports...), lock.DevImports...)
+	deps := make([]*cfg.Dependency, len(locks))
+	for ii, l := range locks {
+		deps[ii] = cfg.DependencyFromLock(l)
+
+		// The import graph is read from the cache so it needs to be at the
+		// locked commits. Setting references beforehand, as glide update
+		// does, moves the dependencies in the config to other versions.
+		if filterArchOs(deps[ii], i) {
+			continue
+		}
+		if err := i.lockedCheckout(deps[ii], conf); err != nil {
+			return fmt.Errorf("Unable to check out %s at its locked commit: %s", l.Name, err)
+		}
+	}
+	open := updateClosure(names, i.importGraph(deps), conf)
+
+	i.frozen.Lock()
+	i.frozen.locks = make(map[string]*cfg.Lock)
+	for _, l := range locks {
+		if !open[l.Name] {
+			i.frozen.locks[l.Name] = l
+		}
+	}
+	i.frozen.Unlock()
+
+	// The pins set beforehand are cleared as the checkouts were moved to
+	// the locked commits. Those updated go back to their version in the
+	// config while the frozen ones stay at the locked commit.
+	for _, dep := range append(conf.Imports, conf.DevImports...) {
+		if l := i.frozenLock(dep.Name); l != nil {
+			dep.Reference = l.Version
+			dep.Pin = ""
+		} else if lock.Imports.Get(dep.Name) != nil || lock.DevImports.Get(dep.Name) != nil {
+			dep.Pin = ""
+		}
+	}
+	if err := i.SetReference(conf); err != nil {
+		return err
+	}
+
+	if err := i.Update(conf); err != nil {
+		return err
+	}
+
+	if c := i.FrozenConflicts(); len(c) > 0 {
+		msg.Warn("The updated dependencies conflict with %d that were left at their locked version:", len(c))
+		for _, s := range c {
+			msg.Warn("--> %s", s)
+		}
+	}
+	return nil
+}
+
+// UpdateChanged is Update for the dependencies in the config that changed
+// since the lock file was written. Those are the ones new to the config,
+// with a different repository, or with a version their locked commit does
+// not meet. They are passed to UpdateOnly so the dependencies they bring in
+// are updated too and every other one stays at its locked commit. A
+// dependency removed from the config is left out of the new lock file, along
+// with those only it brought in, unless the project still imports it.
+func (i *Installer) UpdateChanged(conf *cfg.Config, lock *cfg.Lockfile) error {
+	changed := i.changedDependencies(conf, lock)
+	if len(changed) == 0 {
+		msg.Info("No dependencies changed since the lock file was written")
+	} else {
+		msg.Info("Updating the changed dependencies: %s", strings.Join(changed, ", "))
+	}
+	return i.UpdateOnly(conf, lock, changed)
+}
+
+// changedDependencies returns the names of the dependencies in the config
+// whose entry in the lock file is out of date.
+func (i *Installer) changedDependencies(conf *cfg.Config, lock *cfg.Lockfile) []string {
+	changed := []string{}
+	for _, dep := range append(append(cfg.Dependencies{}, conf.Imports...), conf.DevImports...) {
+		l := lock.Imports.Get(dep.Name)
+		if l == nil {
+			l = lock.DevImports.Get(dep.Name)
+		}
+		if !i.lockCurrent(dep, l) {
+			changed = append(changed, dep.Name)
+		}
+	}
+	return changed
+}
+
+// lockCurrent returns if a lock is still current for a dependency in the
+// config. A dependency without a version stays at its locked commit. The
+// repository in the cache is used to check other versions so nothing is
+// fetched. When it can't tell the lock is not current.
+func (i *Installer) lockCurrent(dep *cfg.Dependency, l *cfg.Lock) bool {
+	if l == nil || !i.sameRepository(dep, cfg.DependencyFromLock(l)) || dep.VcsType != l.VcsType {
+		return false
+	}
+	if dep.Reference == "" || strings.HasPrefix(l.Version, dep.Reference) {
+		return true
+	}
+
+	key, err := i.cacheKey(dep)
+	if err != nil {
+		return false
+	}
+	repo, err := dep.GetRepo(filepath.Join(i.cacheLocation(), "src", key))
+	if err != nil {
+		return false
+	}
+	return lockedMeets(repo, l.Version, dep.Reference)
+}
+
+// lockedMeets returns if a locked commit is a version asked for. It is when
+// the version is the commit, a tag on it, a semantic version constraint a tag
+// on it meets or, for Git, a branch the commit is on.
+func lockedMeets(repo v.Repo, commit, wanted string) bool {
+	if strings.HasPrefix(commit, wanted) {
+		return true
+	}
+
+	tags, _ := repo.TagsFromCommit(commit)
+	for _, t := range tags {
+		if t == wanted {
+			return true
+		}
+	}
+	if c, err := semver.NewConstraint(wanted); err == nil {
+		for _, sv := range getSemVers(tags) {
+			if c.Check(sv) {
+				return true
+			}
+		}
+	}
+
+	if g, ok := repo.(*v.GitRepo); ok {
+		if ib, err := isBranch(wanted, repo); err == nil && ib {
+			_, err := g.RunFromDir("git", "merge-base", "--is-ancestor", commit, g.RemoteLocation+"/"+wanted)
+			return err == nil
+		}
+	}
+	return false
+}
+
+// lockedCheckout checks out a dependency at its locked commit in the cache
+// while holding its cache lock.
+func (i *Installer) lockedCheckout(dep *cfg.Dependency, conf *cfg.Config) error {
+	key, err := i.cacheKey(dep)
+	if err != nil {
+		return err
+	}
+	cache.Lock(key)
+	defer cache.Unlock(key)
+
+	_, err = i.checkoutLocked(dep, conf)
+	return err
+}
+
+// updateClosure returns the named dependencies along with those they import,
+// directly or not, using an import graph of dependency names. Dependencies
+// listed in the config are only included when named.
+func updateClosure(names map[string]bool, graph map[string][]string, conf *cfg.Config) map[string]bool {
+	open := make(map[string]bool, len(names))
+	queue := []string{}
+	for n := range names {
+		open[n] = true
+		queue = append(queue, n)
+	}
+
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		for _, imp := range graph[n] {
+			if open[imp] || conf.HasDependency(imp) {
+				continue
+			}
+			open[imp] = true
+			queue = append(queue, imp)
+		}
+	}
+
+	return open
+}
+
+// frozenLock returns the locked version of a dependency UpdateOnly leaves
+// alone. It is nil for every other dependency. It is safe to call with a nil
+// Installer.
+func (i *Installer) frozenLock(name string) *cfg.Lock {
+	if i == nil {
+		return nil
+	}
+
+	i.frozen.Lock()
+	defer i.frozen.Unlock()
+	return i.frozen.locks[name]
+}
+
+// frozenConflict records a dependency asking for a version of a frozen one
+// other than its locked commit. Nothing is recorded when the locked commit
+// meets the version asked for, or when the conflict was already recorded.
+// See lockedMeets.
+func (i *Installer) frozenConflict(dep *cfg.Dependency, dest, req, wanted string) {
+	if strings.HasPrefix(dep.Reference, wanted) {
+		return
+	}
+	if repo, err := dep.GetRepo(dest); err == nil && lockedMeets(repo, dep.Reference, wanted) {
+		return
+	}
+
+	c := fmt.Sprintf("%s is locked at %s but %s wants %s", dep.Name, dep.Reference, req, wanted)
+	i.frozen.Lock()
+	defer i.frozen.Unlock()
+	for _, had := range i.frozen.conflicts {
+		if had == c {
+			return
+		}
+	}
+	i.frozen.conflicts = append(i.frozen.conflicts, c)
+}
+
+// FrozenConflicts returns the conflicts found by UpdateOnly between the
+// updated dependencies and those left at their locked version.
+func (i *Installer) FrozenConflicts() []string {
+	i.frozen.Lock()
+	defer i.frozen.Unlock()
+	c := make([]string, len(i.frozen.conflicts))
+	copy(c, i.frozen.conflicts)
+	return c
+}
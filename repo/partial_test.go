@@ -0,0 +1,233 @@
+package repo
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Ownercz/glide/cfg"
+)
+
+func TestUpdateClosure(t *testing.T) {
+	conf := &cfg.Config{
+		Name: "example.com/app",
+		Imports: cfg.Dependencies{
+			{Name: "example.com/a"},
+			{Name: "example.com/direct"},
+		},
+	}
+	graph := map[string][]string{
+		"example.com/a":      {"example.com/b", "example.com/direct"},
+		"example.com/b":      {"example.com/c"},
+		"example.com/direct": {"example.com/d"},
+	}
+
+	open := updateClosure(map[string]bool{"example.com/a": true}, graph, conf)
+	expected := map[string]bool{"example.com/a": true, "example.com/b": true, "example.com/c": true}
+	if !reflect.DeepEqual(open, expected) {
+		t.Errorf("Expected %v to be updated, got %v", expected, open)
+	}
+}
+
+func TestUpdateOnlyUnknown(t *testing.T) {
+	conf := &cfg.Config{
+		Name:    "example.com/app",
+		Imports: cfg.Dependencies{{Name: "example.com/a"}},
+	}
+	lock := &cfg.Lockfile{}
+
+	i := NewInstaller()
+	if err := i.UpdateOnly(conf, lock, []string{"example.com/unknown/pkg"}); err == nil {
+		t.Error("Expected an error updating a package that is not a dependency")
+	}
+	if i.frozenLock("example.com/a") != nil {
+		t.Error("Expected nothing to be frozen")
+	}
+}
+
+func TestChangedDependencies(t *testing.T) {
+	lock := &cfg.Lockfile{
+		Imports: cfg.Locks{
+			{Name: "example.com/pinned", Version: "9b33ce2e7d38f5d5b7b1ed2b31d1ae3cc28e8b6f"},
+			{Name: "example.com/floating", Version: "3b2a1c5e7d38f5d5b7b1ed2b31d1ae3cc28e8b6f"},
+			{Name: "example.com/moved", Version: "3b2a1c5e7d38f5d5b7b1ed2b31d1ae3cc28e8b6f"},
+			{Name: "example.com/tagged", Version: "3b2a1c5e7d38f5d5b7b1ed2b31d1ae3cc28e8b6f"},
+		},
+		DevImports: cfg.Locks{
+			{Name: "example.com/dev", Version: "9b33ce2e7d38f5d5b7b1ed2b31d1ae3cc28e8b6f"},
+		},
+	}
+	conf := &cfg.Config{
+		Name: "example.com/app",
+		Imports: cfg.Dependencies{
+			{Name: "example.com/pinned", Reference: "9b33ce2"},
+			{Name: "example.com/floating"},
+			{Name: "example.com/moved", Repository: "https://example.com/fork/moved"},
+			{Name: "example.com/tagged", Reference: "v2.0.0"},
+			{Name: "example.com/new"},
+		},
+		DevImports: cfg.Dependencies{
+			{Name: "example.com/dev", Reference: "9b33ce2"},
+		},
+	}
+
+	dir, err := ioutil.TempDir("", "glide-changed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	i := NewInstaller()
+	i.Home = dir
+
+	changed := i.changedDependencies(conf, lock)
+	expected := []string{"example.com/moved", "example.com/tagged", "example.com/new"}
+	if !reflect.DeepEqual(changed, expected) {
+		t.Errorf("Expected %v to have changed, got %v", expected, changed)
+	}
+}
+
+func TestLockedMeets(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir, err := ioutil.TempDir("", "glide-meets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	remote := filepath.Join(dir, "remote")
+	first := newTestRemote(t, remote)
+	testGit(t, remote, "tag", "v1.2.0")
+	testGit(t, remote, "checkout", "-q", "-b", "feature")
+	testGit(t, remote, "commit", "-q", "--allow-empty", "-m", "second")
+	second := testGit(t, remote, "rev-parse", "HEAD")
+
+	dep := &cfg.Dependency{Name: "example.com/meets", Repository: remote, VcsType: "git"}
+	repo, err := dep.GetRepo(filepath.Join(dir, "cache"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Get(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		commit, wanted string
+		meets          bool
+	}{
+		{first, first[:7], true},
+		{first, "v1.2.0", true},
+		{first, "^1.0.0", true},
+		{first, "^2.0.0", false},
+		{first, "feature", true},
+		{second, "feature", true},
+		{second, "^1.0.0", false},
+	} {
+		if m := lockedMeets(repo, tt.commit, tt.wanted); m != tt.meets {
+			t.Errorf("Expected %s meeting %s to be %t", tt.commit, tt.wanted, tt.meets)
+		}
+	}
+}
+
+func TestUpdateOnlyFrozenTransitive(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir, err := ioutil.TempDir("", "glide-frozen")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	write := func(p, src string) {
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wants := func(name, v string) string {
+		return "package: github.com/example/" + name + "\nimport:\n- package: github.com/example/shared\n  version: " + v + "\n  repo: " + filepath.Join(dir, "remotes", "shared") + "\n  vcs: git\n"
+	}
+
+	// shared is only brought in by tools, which stays at its locked commit.
+	// The new commit of parent imports it too and wants another version.
+	shared := filepath.Join(dir, "remotes", "shared")
+	commits := map[string]string{"v1.0.0": newTestRemote(t, shared, "shared.go", "package shared\n")}
+	testGit(t, shared, "tag", "v1.0.0")
+	testGit(t, shared, "commit", "-q", "--allow-empty", "-m", "v2.0.0")
+	testGit(t, shared, "tag", "v2.0.0")
+	commits["v2.0.0"] = testGit(t, shared, "rev-parse", "HEAD")
+	tools := filepath.Join(dir, "remotes", "tools")
+	newTestRemote(t, tools,
+		"tools.go", "package tools\n\nimport _ \"github.com/example/shared\"\n",
+		"glide.yaml", wants("tools", "v1.0.0"))
+	parent := filepath.Join(dir, "remotes", "parent")
+	locked := newTestRemote(t, parent, "parent.go", "package parent\n")
+	write(filepath.Join(parent, "parent.go"), "package parent\n\nimport _ \"github.com/example/shared\"\n")
+	write(filepath.Join(parent, "glide.yaml"), wants("parent", "v2.0.0"))
+	testGit(t, parent, "add", ".")
+	testGit(t, parent, "commit", "-q", "-m", "use shared")
+
+	project := filepath.Join(dir, "project")
+	write(filepath.Join(project, "main.go"), "package main\n\nimport (\n\t_ \"github.com/example/parent\"\n\t_ \"github.com/example/tools\"\n)\n")
+
+	conf := &cfg.Config{
+		Name:     "example.com/project",
+		Priority: []string{"github.com/example/parent"},
+		Imports: cfg.Dependencies{
+			{Name: "github.com/example/parent", Repository: parent, VcsType: "git"},
+			{Name: "github.com/example/tools", Repository: tools, VcsType: "git"},
+		},
+	}
+	lock := &cfg.Lockfile{Imports: cfg.Locks{
+		{Name: "github.com/example/parent", Version: locked, Repository: parent, VcsType: "git"},
+		{Name: "github.com/example/tools", Version: testGit(t, tools, "rev-parse", "HEAD"), Repository: tools, VcsType: "git"},
+		{Name: "github.com/example/shared", Version: commits["v1.0.0"], Repository: shared, VcsType: "git"},
+	}}
+
+	// This follows glide update with a package named, which sets the
+	// references of the config before updating.
+	i := NewInstaller()
+	i.Home = filepath.Join(dir, "home")
+	i.Base = project
+	if err := i.Checkout(conf); err != nil {
+		t.Fatal(err)
+	}
+	if err := i.SetReference(conf); err != nil {
+		t.Fatal(err)
+	}
+	confcopy := conf.Clone()
+	if err := i.UpdateOnly(confcopy, lock, []string{"github.com/example/parent"}); err != nil {
+		t.Fatalf("Unexpected error updating: %s", err)
+	}
+	if err := i.SetReference(confcopy); err != nil {
+		t.Fatal(err)
+	}
+
+	if i.frozenLock("github.com/example/shared") == nil {
+		t.Error("Expected shared to be left at its locked commit")
+	}
+	dep := confcopy.Imports.Get("github.com/example/shared")
+	if dep == nil || dep.Reference != commits["v1.0.0"] {
+		t.Fatalf("Expected shared to stay at its locked commit, got %+v", dep)
+	}
+	key, err := i.cacheKey(dep)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if head := testGit(t, filepath.Join(i.cacheLocation(), "src", key), "rev-parse", "HEAD"); head != commits["v1.0.0"] {
+		t.Errorf("Expected the checkout of shared to be at its locked commit, got %s", head)
+	}
+	if c := i.FrozenConflicts(); len(c) != 1 || !strings.Contains(c[0], "github.com/example/parent wants v2.0.0") {
+		t.Errorf("Expected the conflict with the parent to be reported, got %v", c)
+	}
+}
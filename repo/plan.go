@@ -0,0 +1,202 @@
+package repo
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"time"
+
+	"github.com/Ownercz/glide/cfg"
+	"github.com/Ownercz/glide/msg"
+	gpath "github.com/Ownercz/glide/path"
+	"gopkg.in/yaml.v2"
+)
+
+// The actions a plan can take for a dependency compared to the lock file
+// present when the plan was made.
+const (
+	PlanAdd    = "add"
+	PlanChange = "change"
+	PlanKeep   = "keep"
+)
+
+// PlanFile is a reviewable install plan. It holds the resolved revision and
+// concrete repository of every dependency so applying it does not resolve
+// anything again.
+type PlanFile struct {
+	Name       string      `yaml:"package"`
+	Hash       string      `yaml:"hash"`
+	Created    time.Time   `yaml:"created"`
+	Imports    PlanEntries `yaml:"imports"`
+	DevImports PlanEntries `yaml:"testImports"`
+
+	// Remove lists the dependencies in the lock file that are no longer used.
+	Remove []string `yaml:"remove,omitempty"`
+}
+
+// PlanEntries is a slice of planned dependencies.
+type PlanEntries []*PlanEntry
+
+// PlanEntry is a dependency in a plan along with the action taken for it.
+type PlanEntry struct {
+	cfg.Lock `yaml:",inline"`
+
+	Action string `yaml:"action"`
+}
+
+// ReadPlanFile loads the contents of a plan file.
+func ReadPlanFile(planpath string) (*PlanFile, error) {
+	yml, err := ioutil.ReadFile(planpath)
+	if err != nil {
+		return nil, err
+	}
+	plan := &PlanFile{}
+	if err := yaml.Unmarshal(yml, plan); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// WriteFile writes a plan file. If the file exists, it will be clobbered.
+func (p *PlanFile) WriteFile(planpath string) error {
+	o, err := yaml.Marshal(p)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(planpath, o, 0666)
+}
+
+// Plan resolves the dependencies of a config and returns the plan to install
+// them. The cache is updated while resolving but the vendor directory and
+// the config are left untouched. Actions are relative to the existing lock
+// file, when there is one.
+func (i *Installer) Plan(conf *cfg.Config) (*PlanFile, error) {
+	hash, err := conf.Hash()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to generate config hash: %s", err)
+	}
+
+	confcopy := conf.Clone()
+	if err := i.Update(confcopy); err != nil {
+		return nil, err
+	}
+	if err := i.SetReference(confcopy); err != nil {
+		return nil, err
+	}
+
+	current := &cfg.Lockfile{}
+	base := i.basePath()
+	if gpath.HasLock(base) {
+		current, err = cfg.ReadLockFile(filepath.Join(base, gpath.LockFile))
+		if err != nil {
+			return nil, fmt.Errorf("Could not load lockfile: %s", err)
+		}
+	}
+
+	plan := &PlanFile{
+		Name:    conf.Name,
+		Hash:    hash,
+		Created: time.Now(),
+	}
+	plan.Imports, err = i.planEntries(confcopy.Imports, current.Imports)
+	if err != nil {
+		return nil, err
+	}
+	if i.ResolveTest {
+		plan.DevImports, err = i.planEntries(confcopy.DevImports, current.DevImports)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	planned := make(map[string]bool)
+	for _, e := range append(plan.Imports, plan.DevImports...) {
+		planned[e.Name] = true
+	}
+	for _, l := range append(current.Imports, current.DevImports...) {
+		if !planned[l.Name] {
+			plan.Remove = append(plan.Remove, l.Name)
+		}
+	}
+
+	return plan, nil
+}
+
+// planEntries converts resolved dependencies to plan entries. The repository
+// and VCS type are recorded as they were found so mirrors or vanity import
+// paths changing later do not alter the plan.
+func (i *Installer) planEntries(deps cfg.Dependencies, current cfg.Locks) (PlanEntries, error) {
+	existing := make(map[string]string, len(current))
+	for _, l := range current {
+		existing[l.Name] = l.Version
+	}
+
+	entries := make(PlanEntries, 0, len(deps))
+	for _, dep := range deps {
+		if dep.Pin == "" {
+			return nil, fmt.Errorf("No revision resolved for %s", dep.Name)
+		}
+
+		key, err := i.cacheKey(dep)
+		if err != nil {
+			return nil, fmt.Errorf("Cache key generation error: %s", err)
+		}
+		repo, err := dep.GetRepo(filepath.Join(i.cacheLocation(), "src", key))
+		if err != nil {
+			return nil, err
+		}
+
+		e := &PlanEntry{Lock: *cfg.LockFromDependency(dep)}
+		e.Repository = repo.Remote()
+		e.VcsType = string(repo.Vcs())
+
+		ver, found := existing[dep.Name]
+		switch {
+		case !found:
+			e.Action = PlanAdd
+		case ver != dep.Pin:
+			e.Action = PlanChange
+		default:
+			e.Action = PlanKeep
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, nil
+}
+
+// Apply installs exactly the revisions in a plan and exports them to the
+// vendor directory. The returned config has the dependencies as installed
+// and can be used to write a lock file. ResolveTest needs to be set as it was
+// for the plan for the test dependencies to be exported.
+func (i *Installer) Apply(plan *PlanFile) (*cfg.Config, error) {
+	lock := &cfg.Lockfile{
+		Hash:    plan.Hash,
+		Updated: plan.Created,
+	}
+	for _, e := range plan.Imports {
+		l := e.Lock
+		lock.Imports = append(lock.Imports, &l)
+	}
+	for _, e := range plan.DevImports {
+		l := e.Lock
+		lock.DevImports = append(lock.DevImports, &l)
+	}
+
+	newConf, err := i.Install(lock, &cfg.Config{Name: plan.Name}, i.ResolveTest)
+	if err != nil {
+		return newConf, err
+	}
+
+	// The Reference is the planned revision so setting it does not resolve
+	// anything.
+	if err := i.SetReference(newConf); err != nil {
+		return newConf, err
+	}
+
+	for _, name := range plan.Remove {
+		msg.Info("--> Removing %s", name)
+	}
+
+	return newConf, i.Export(newConf)
+}
@@ -0,0 +1,55 @@
+package repo
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/Ownercz/glide/cfg"
+)
+
+func TestPlanFileRoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "glide-plan")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	plan := &PlanFile{
+		Name: "example.com/app",
+		Hash: "abc123",
+		Imports: PlanEntries{
+			&PlanEntry{
+				Lock: cfg.Lock{
+					Name:       "example.com/foo",
+					Version:    "0123456789abcdef0123456789abcdef01234567",
+					Repository: "https://git.example.com/foo.git",
+					VcsType:    "git",
+				},
+				Action: PlanAdd,
+			},
+		},
+		Remove: []string{"example.com/bar"},
+	}
+
+	p := filepath.Join(dir, "glide.plan")
+	if err := plan.WriteFile(p); err != nil {
+		t.Fatalf("Unable to write plan: %s", err)
+	}
+	read, err := ReadPlanFile(p)
+	if err != nil {
+		t.Fatalf("Unable to read plan: %s", err)
+	}
+
+	if read.Name != plan.Name || read.Hash != plan.Hash {
+		t.Errorf("Expected plan for %s (%s), got %s (%s)", plan.Name, plan.Hash, read.Name, read.Hash)
+	}
+	if len(read.Imports) != 1 || !reflect.DeepEqual(*read.Imports[0], *plan.Imports[0]) {
+		t.Errorf("Expected imports %v, got %v", plan.Imports, read.Imports)
+	}
+	if !reflect.DeepEqual(read.Remove, plan.Remove) {
+		t.Errorf("Expected removals %v, got %v", plan.Remove, read.Remove)
+	}
+}
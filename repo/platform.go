@@ -0,0 +1,59 @@
+package repo
+
+import (
+	"os"
+	"runtime"
+
+	"github.com/Ownercz/glide/cfg"
+	"github.com/Ownercz/glide/dependency"
+	"github.com/Ownercz/glide/msg"
+)
+
+// platform returns the GOOS and GOARCH dependencies are resolved for. These
+// are the ones Glide runs on unless the Installer overrides them.
+func (i *Installer) platform() (string, string) {
+	goos, goarch := runtime.GOOS, runtime.GOARCH
+	if i != nil && i.GOOS != "" {
+		goos = i.GOOS
+	}
+	if i != nil && i.GOARCH != "" {
+		goarch = i.GOARCH
+	}
+	return goos, goarch
+}
+
+// platformDeps returns the dependencies used on the platform of the
+// Installer. Those whose os or arch is for another platform are left out
+// before anything, such as discovering their VCS, is done for them.
+func (i *Installer) platformDeps(deps []*cfg.Dependency) []*cfg.Dependency {
+	used := make([]*cfg.Dependency, 0, len(deps))
+	for _, dep := range deps {
+		if filterArchOs(dep, i) {
+			goos, goarch := i.platform()
+			msg.Info("%s is not used for %s/%s", dep.Name, goos, goarch)
+			i.countMetric(func(m *Metrics) { m.OtherPlatform++ })
+			continue
+		}
+		used = append(used, dep)
+	}
+	return used
+}
+
+// setPlatform has a resolver evaluate build constraints for the platform of
+// the Installer. Files for other platforms are left out, as they are with
+// util.ResolveCurrent, rather than all files being scanned. Like the go tool,
+// cgo is off when building for another platform unless CGO_ENABLED is set.
+func (i *Installer) setPlatform(res *dependency.Resolver) {
+	if i.GOOS == "" && i.GOARCH == "" {
+		return
+	}
+
+	goos, goarch := i.platform()
+	msg.Debug("Resolving imports for %s/%s", goos, goarch)
+	res.BuildContext.GOOS = goos
+	res.BuildContext.GOARCH = goarch
+	res.BuildContext.UseAllFiles = false
+	if os.Getenv("CGO_ENABLED") == "" && (goos != runtime.GOOS || goarch != runtime.GOARCH) {
+		res.BuildContext.CgoEnabled = false
+	}
+}
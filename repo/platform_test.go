@@ -0,0 +1,139 @@
+package repo
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/Ownercz/glide/cfg"
+	"github.com/Ownercz/glide/dependency"
+)
+
+func TestFilterArchOsPlatform(t *testing.T) {
+	dep := &cfg.Dependency{Name: "github.com/example/a", Os: []string{"linux"}, Arch: []string{"arm64"}}
+
+	i := NewInstaller()
+	i.GOOS = "linux"
+	i.GOARCH = "arm64"
+	if filterArchOs(dep, i) {
+		t.Error("Expected a dependency for the platform of the Installer to be used")
+	}
+
+	i.GOOS = "windows"
+	if !filterArchOs(dep, i) {
+		t.Error("Expected a dependency for another GOOS to be filtered out")
+	}
+
+	i.GOOS = ""
+	i.GOARCH = ""
+	if goos, goarch := i.platform(); goos != runtime.GOOS || goarch != runtime.GOARCH {
+		t.Errorf("Expected the platform to default to %s/%s, got %s/%s", runtime.GOOS, runtime.GOARCH, goos, goarch)
+	}
+}
+
+func TestConcurrentUpdatePlatform(t *testing.T) {
+	dir, err := ioutil.TempDir("", "glide-platform")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// The repository does not exist so fetching it fails.
+	dep := &cfg.Dependency{Name: "github.com/example/winapi", Repository: "file://" + filepath.Join(dir, "missing"), VcsType: "git", Os: []string{"windows"}}
+	conf := &cfg.Config{Name: "example.com/app", Imports: cfg.Dependencies{dep}}
+
+	i := NewInstaller()
+	i.Home = filepath.Join(dir, "home")
+	i.GOOS = "linux"
+	for _, serial := range []bool{false, true} {
+		i.Serial = serial
+		if err := ConcurrentUpdate(conf.Imports, i, conf); err != nil {
+			t.Errorf("Expected the windows dependency to be skipped on linux, got %s", err)
+		}
+	}
+	if m := i.Metrics(); m.OtherPlatform != 2 || m.Skipped != 0 {
+		t.Errorf("Expected the windows dependency to be counted as for another platform, got %+v", m)
+	}
+	if len(i.exportDeps(conf)) != 0 {
+		t.Error("Expected the windows dependency not to be exported on linux")
+	}
+
+	i.GOOS = "windows"
+	if err := ConcurrentUpdate(conf.Imports, i, conf); err == nil {
+		t.Error("Expected the windows dependency to be fetched on windows")
+	}
+}
+
+func TestSetPlatform(t *testing.T) {
+	if os.Getenv("CGO_ENABLED") != "" {
+		t.Skip("CGO_ENABLED is set")
+	}
+
+	res, err := dependency.NewResolver(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cgo := res.BuildContext.CgoEnabled
+	NewInstaller().setPlatform(res)
+	if res.BuildContext.GOOS != runtime.GOOS || res.BuildContext.CgoEnabled != cgo {
+		t.Error("Expected the resolver to be unchanged without a platform")
+	}
+
+	i := NewInstaller()
+	i.GOOS = "plan9"
+	i.GOARCH = "arm"
+	i.setPlatform(res)
+	if res.BuildContext.GOOS != "plan9" || res.BuildContext.GOARCH != "arm" {
+		t.Errorf("Expected the resolver to use plan9/arm, got %s/%s", res.BuildContext.GOOS, res.BuildContext.GOARCH)
+	}
+	if res.BuildContext.CgoEnabled {
+		t.Error("Expected cgo to be off for another platform")
+	}
+}
+
+func TestSetPlatformBuildConstraints(t *testing.T) {
+	dir, err := ioutil.TempDir("", "glide-platform")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// The import of winapi is only built on windows.
+	files := map[string]string{
+		"lib.go":         "package lib\n\nimport _ \"github.com/example/common\"\n",
+		"lib_windows.go": "package lib\n\nimport _ \"github.com/example/winapi\"\n",
+	}
+	for name, src := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	imports := func(goos string) map[string]bool {
+		res, err := dependency.NewResolver(".")
+		if err != nil {
+			t.Fatal(err)
+		}
+		i := NewInstaller()
+		i.GOOS = goos
+		i.setPlatform(res)
+		pkg, err := res.BuildContext.ImportDir(dir, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		found := make(map[string]bool)
+		for _, imp := range pkg.Imports {
+			found[imp] = true
+		}
+		return found
+	}
+
+	if found := imports("linux"); !found["github.com/example/common"] || found["github.com/example/winapi"] {
+		t.Errorf("Expected only the common import on linux, got %v", found)
+	}
+	if found := imports("windows"); !found["github.com/example/common"] || !found["github.com/example/winapi"] {
+		t.Errorf("Expected both imports on windows, got %v", found)
+	}
+}
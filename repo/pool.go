@@ -0,0 +1,86 @@
+package repo
+
+import (
+	"sync"
+
+	"github.com/urfave/cli"
+)
+
+// workerPool runs tasks concurrently on the workers of an Installer and
+// collects the errors they report. Start one with newPool and stop it with
+// close once the tasks are done.
+type workerPool struct {
+	in chan func()
+	wg sync.WaitGroup
+
+	sync.Mutex
+	running int
+	err     error
+}
+
+// newPool starts a pool with as many workers as the Installer uses for
+// concurrent operations. See workers. It is safe to call with a nil
+// Installer.
+func (i *Installer) newPool() *workerPool {
+	return startPool(i.workers())
+}
+
+// startPool starts a pool with n workers. With one worker the tasks are run
+// one at a time in the order they are handed out.
+func startPool(n int) *workerPool {
+	p := &workerPool{in: make(chan func(), n)}
+	for ii := 0; ii < n; ii++ {
+		go func() {
+			for task := range p.in {
+				task()
+				p.Lock()
+				p.running--
+				p.Unlock()
+				p.wg.Done()
+			}
+		}()
+	}
+	return p
+}
+
+// run hands a task to the next free worker.
+func (p *workerPool) run(task func()) {
+	p.wg.Add(1)
+	p.Lock()
+	p.running++
+	p.Unlock()
+	p.in <- task
+}
+
+// fail records an error to be returned by wait. Errors from more than one
+// task are combined.
+func (p *workerPool) fail(err error) {
+	p.Lock()
+	defer p.Unlock()
+	if p.err == nil {
+		p.err = err
+	} else {
+		p.err = cli.NewMultiError(p.err, err)
+	}
+}
+
+// busy returns if any task is running or waiting for a worker.
+func (p *workerPool) busy() bool {
+	p.Lock()
+	defer p.Unlock()
+	return p.running > 0
+}
+
+// wait blocks until the tasks handed out so far are done and returns the
+// errors recorded.
+func (p *workerPool) wait() error {
+	p.wg.Wait()
+	p.Lock()
+	defer p.Unlock()
+	return p.err
+}
+
+// close stops the workers. No more tasks can be run.
+func (p *workerPool) close() {
+	close(p.in)
+}
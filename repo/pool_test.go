@@ -0,0 +1,40 @@
+package repo
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestWorkerPool(t *testing.T) {
+	i := NewInstaller()
+	i.Concurrency = 2
+	pool := i.newPool()
+	defer pool.close()
+
+	var lock sync.Mutex
+	ran := 0
+	for ii := 0; ii < 10; ii++ {
+		ii := ii
+		pool.run(func() {
+			lock.Lock()
+			ran++
+			lock.Unlock()
+			if ii%5 == 0 {
+				pool.fail(errors.New("task failed"))
+			}
+		})
+	}
+
+	err := pool.wait()
+	if ran != 10 {
+		t.Errorf("Expected 10 tasks to run, got %d", ran)
+	}
+	if err == nil || strings.Count(err.Error(), "task failed") != 2 {
+		t.Errorf("Expected the errors of both failed tasks, got %v", err)
+	}
+	if pool.busy() {
+		t.Error("Expected the pool not to be busy once the tasks are done")
+	}
+}
@@ -0,0 +1,34 @@
+package repo
+
+import (
+	"time"
+
+	"github.com/Ownercz/glide/msg"
+)
+
+// PressureFunc reports if the system is under pressure, such as being low on
+// disk space or memory, and no more fetches should be started for now.
+type PressureFunc func() bool
+
+// pressureWait is how long dispatching waits before checking the pressure
+// again.
+var pressureWait = time.Second
+
+// waitOnPressure blocks while the Pressure callback of the installer reports
+// pressure and busy reports fetches are still running. Once none are running
+// the wait ends, even under pressure, so the fetches continue one at a time
+// rather than stopping.
+func (i *Installer) waitOnPressure(busy func() bool) {
+	if i.Pressure == nil {
+		return
+	}
+
+	waited := false
+	for busy() && i.Pressure() {
+		if !waited {
+			msg.Debug("Under pressure. Waiting for running fetches before starting more")
+			waited = true
+		}
+		time.Sleep(pressureWait)
+	}
+}
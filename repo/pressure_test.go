@@ -0,0 +1,85 @@
+package repo
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Ownercz/glide/cache"
+	"github.com/Ownercz/glide/cfg"
+)
+
+func TestWaitOnPressure(t *testing.T) {
+	defer func(d time.Duration) { pressureWait = d }(pressureWait)
+	pressureWait = time.Millisecond
+
+	i := NewInstaller()
+	i.waitOnPressure(func() bool {
+		t.Error("Expected no checks without a Pressure callback")
+		return true
+	})
+
+	checks := 0
+	i.Pressure = func() bool { return true }
+	i.waitOnPressure(func() bool {
+		checks++
+		return checks < 3
+	})
+	if checks != 3 {
+		t.Errorf("Expected to wait until nothing was running, checked %d times", checks)
+	}
+}
+
+func TestConcurrentUpdatePressure(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	defer func(d time.Duration) { pressureWait = d }(pressureWait)
+	pressureWait = time.Millisecond
+
+	dir, err := ioutil.TempDir("", "glide-pressure")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	conf := &cfg.Config{Name: "example.com/app"}
+	for ii := 0; ii < 3; ii++ {
+		remote := filepath.Join(dir, "remotes", fmt.Sprint(ii))
+		newTestRemote(t, remote)
+		conf.Imports = append(conf.Imports, &cfg.Dependency{Name: fmt.Sprintf("github.com/example/%d", ii), Repository: remote, VcsType: "git"})
+	}
+
+	var mu sync.Mutex
+	calls := 0
+	i := NewInstaller()
+	i.Home = filepath.Join(dir, "home")
+	i.Pressure = func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		return true
+	}
+
+	// Constant pressure slows the fetches down but does not stop them.
+	if err := ConcurrentUpdate(conf.Imports, i, conf); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	for _, dep := range conf.Imports {
+		key, err := cache.Key(dep.Remote())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := os.Stat(filepath.Join(i.cacheLocation(), "src", key)); err != nil {
+			t.Errorf("Expected %s to be fetched under pressure", dep.Name)
+		}
+	}
+	if calls == 0 {
+		t.Error("Expected the Pressure callback to be consulted")
+	}
+}
@@ -0,0 +1,86 @@
+package repo
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/Ownercz/glide/cfg"
+)
+
+func TestUpdatePriority(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir, err := ioutil.TempDir("", "glide-priority")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	write := func(p, src string) {
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// shared has a v1.0.0 and a v2.0.0. a wants v1.0.0 and b wants v2.0.0.
+	shared := filepath.Join(dir, "remotes", "shared")
+	newTestRemote(t, shared, "shared.go", "package shared\n")
+	testGit(t, shared, "tag", "v1.0.0")
+	testGit(t, shared, "commit", "-q", "--allow-empty", "-m", "v2.0.0")
+	testGit(t, shared, "tag", "v2.0.0")
+	for name, v := range map[string]string{"a": "v1.0.0", "b": "v2.0.0"} {
+		newTestRemote(t, filepath.Join(dir, "remotes", name),
+			name+".go", "package "+name+"\n\nimport _ \"github.com/example/shared\"\n",
+			"glide.yaml", "package: github.com/example/"+name+"\nimport:\n- package: github.com/example/shared\n  version: "+v+"\n  repo: "+shared+"\n  vcs: git\n")
+	}
+	project := filepath.Join(dir, "project")
+	write(filepath.Join(project, "main.go"), "package main\n\nimport (\n\t_ \"github.com/example/a\"\n\t_ \"github.com/example/b\"\n)\n")
+
+	update := func(priority ...string) string {
+		conf := &cfg.Config{
+			Name:     "example.com/project",
+			Priority: priority,
+			Imports: cfg.Dependencies{
+				{Name: "github.com/example/a", Repository: filepath.Join(dir, "remotes", "a"), VcsType: "git"},
+				{Name: "github.com/example/b", Repository: filepath.Join(dir, "remotes", "b"), VcsType: "git"},
+			},
+		}
+		i := NewInstaller()
+		i.Home = filepath.Join(dir, "home")
+		i.Base = project
+
+		// The configuration of a and b is read from the cache.
+		for _, dep := range conf.Imports {
+			if err := i.VcsGet(dep); err != nil {
+				t.Fatalf("Unexpected error fetching %s: %s", dep.Name, err)
+			}
+		}
+		if err := i.Update(conf); err != nil {
+			t.Fatalf("Unexpected error updating: %s", err)
+		}
+		dep := conf.Imports.Get("github.com/example/shared")
+		if dep == nil {
+			t.Fatal("Expected shared to be resolved")
+		}
+		return dep.Reference
+	}
+
+	// Without a priority the first one found wins.
+	if v := update(); v != "v1.0.0" {
+		t.Errorf("Expected the version wanted by a, got %s", v)
+	}
+
+	// b has the higher priority so its version wins even though the version
+	// wanted by a was set first.
+	if v := update("github.com/example/b"); v != "v2.0.0" {
+		t.Errorf("Expected the version wanted by b, got %s", v)
+	}
+}
@@ -0,0 +1,125 @@
+package repo
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// The ways the VCS tools may ask for credentials. See Installer.AuthPrompt.
+const (
+	// AuthPromptTerminal lets the VCS tools prompt for credentials on the
+	// controlling terminal. Fetches are made one at a time so the prompts
+	// don't interleave. Without a terminal it is the same as
+	// AuthPromptNever.
+	AuthPromptTerminal = "terminal"
+
+	// AuthPromptNever stops the VCS tools from prompting for credentials so
+	// a repository requiring them fails straight away.
+	AuthPromptNever = "never"
+)
+
+// authFailures are found in the output of VCS tools that could not
+// authenticate, or were stopped from prompting to.
+var authFailures = []string{
+	"terminal prompts disabled",
+	"could not read Username",
+	"could not read Password",
+	"Authentication failed",
+	"Permission denied (publickey",
+	"Host key verification failed",
+	"authorization failed",
+}
+
+// hasTerminal returns if there is a controlling terminal to prompt on. On
+// systems without /dev/tty it is always false.
+var hasTerminal = func() bool {
+	f, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return false
+	}
+	f.Close()
+	return true
+}
+
+// prompting returns if the VCS tools can prompt for credentials.
+func (i *Installer) prompting() bool {
+	if i == nil || i.AuthPrompt != AuthPromptTerminal {
+		return false
+	}
+	i.promptSetup.Do(func() {
+		i.terminal = hasTerminal()
+	})
+	return i.terminal
+}
+
+// promptLock serializes the VCS operations of the Installer that can prompt
+// for credentials, such as fetching or setting a version, when prompting is
+// possible so the prompts don't interleave. It returns the function releasing
+// it.
+func (i *Installer) promptLock() func() {
+	if !i.prompting() {
+		return func() {}
+	}
+	i.promptMu.Lock()
+	return i.promptMu.Unlock
+}
+
+// promptEnv adds the environment for AuthPrompt to that of a git command.
+// Git and ssh prompt on the terminal even when their input is not connected
+// to it so they are told not to when prompting is not possible. It is only
+// set on the git commands of the Installer so the environment of glide is
+// left alone. A GIT_SSH or GIT_SSH_COMMAND set by the user is kept, other
+// than the SSH key of the Installer being added to it.
+func (i *Installer) promptEnv(env []string) []string {
+	if i == nil || i.AuthPrompt == "" || i.prompting() {
+		return env
+	}
+
+	env = append(env, "GIT_TERMINAL_PROMPT=0")
+	for n, e := range env {
+		if strings.HasPrefix(e, "GIT_SSH_COMMAND=") {
+			env[n] = e + " -o BatchMode=yes"
+			return env
+		}
+	}
+	if os.Getenv("GIT_SSH") == "" && os.Getenv("GIT_SSH_COMMAND") == "" {
+		env = append(env, "GIT_SSH_COMMAND=ssh -o BatchMode=yes")
+	}
+	return env
+}
+
+// authRequiredError is returned when fetching a dependency needed
+// credentials that could not be prompted for.
+type authRequiredError struct {
+	name, reason string
+	err          error
+}
+
+func (e *authRequiredError) Error() string {
+	return fmt.Sprintf("Authentication required for %s and %s: %s", e.name, e.reason, e.err)
+}
+
+// authError explains an error fetching a dependency that is due to
+// credentials that could not be prompted for. Other errors are returned as
+// they are.
+func (i *Installer) authError(name string, err error) error {
+	if err == nil || i == nil || i.AuthPrompt == "" || i.prompting() {
+		return err
+	}
+	if _, ok := err.(*authRequiredError); ok {
+		return err
+	}
+
+	out := errorOutput(err)
+	reason := "there is no terminal to prompt on"
+	if i.AuthPrompt == AuthPromptNever {
+		reason = "prompting is disabled"
+	}
+	for _, f := range authFailures {
+		if strings.Contains(out, f) {
+			return &authRequiredError{name: name, reason: reason, err: err}
+		}
+	}
+	return err
+}
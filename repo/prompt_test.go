@@ -0,0 +1,84 @@
+package repo
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestAuthError(t *testing.T) {
+	defer func(f func() bool) { hasTerminal = f }(hasTerminal)
+	hasTerminal = func() bool { return false }
+
+	failed := errors.New("Unable to get repository: fatal: could not read Username for 'https://example.com': terminal prompts disabled")
+	other := errors.New("Unable to get repository: fatal: repository not found")
+
+	i := NewInstaller()
+	if err := i.authError("example.com/private", failed); err != failed {
+		t.Errorf("Expected errors to be left alone by default, got %s", err)
+	}
+
+	i.AuthPrompt = AuthPromptTerminal
+	if i.prompting() {
+		t.Error("Expected no prompting without a terminal")
+	}
+	err := i.authError("example.com/private", failed)
+	if _, ok := err.(*authRequiredError); !ok || !strings.Contains(err.Error(), "no terminal to prompt on") {
+		t.Errorf("Expected an authentication error, got %s", err)
+	}
+	if i.authError("example.com/private", err) != err {
+		t.Error("Expected an authentication error not to be wrapped again")
+	}
+	if err := i.authError("example.com/private", other); err != other {
+		t.Errorf("Expected other errors to be left alone, got %s", err)
+	}
+
+	i = NewInstaller()
+	i.AuthPrompt = AuthPromptNever
+	err = i.authError("example.com/private", failed)
+	if err == nil || !strings.Contains(err.Error(), "Authentication required for example.com/private and prompting is disabled") {
+		t.Errorf("Expected an authentication error, got %s", err)
+	}
+}
+
+func TestPromptEnv(t *testing.T) {
+	defer func(f func() bool) { hasTerminal = f }(hasTerminal)
+	hasTerminal = func() bool { return false }
+	for _, k := range []string{"GIT_SSH", "GIT_SSH_COMMAND", "GIT_TERMINAL_PROMPT"} {
+		if v, ok := os.LookupEnv(k); ok {
+			defer os.Setenv(k, v)
+			os.Unsetenv(k)
+		}
+	}
+
+	i := NewInstaller()
+	if env, _ := i.gitEnv("https://example.com/private"); env != nil {
+		t.Errorf("Expected no environment by default, got %v", env)
+	}
+
+	i.AuthPrompt = AuthPromptNever
+	env, _ := i.gitEnv("https://example.com/private")
+	if strings.Join(env, " ") != "GIT_TERMINAL_PROMPT=0 GIT_SSH_COMMAND=ssh -o BatchMode=yes" {
+		t.Errorf("Expected git and ssh to be told not to prompt, got %v", env)
+	}
+	if os.Getenv("GIT_TERMINAL_PROMPT") != "" || os.Getenv("GIT_SSH_COMMAND") != "" {
+		t.Error("Expected the environment of the process to be left alone")
+	}
+
+	i.SSHKeys = SSHKeys{{Pattern: "example.com", Path: "/keys/id"}}
+	env, _ = i.gitEnv("git@example.com:private.git")
+	if len(env) != 2 || !strings.HasPrefix(env[0], "GIT_SSH_COMMAND=ssh -i ") || !strings.HasSuffix(env[0], " -o BatchMode=yes") {
+		t.Errorf("Expected the SSH key to be used without prompting, got %v", env)
+	}
+
+	i = NewInstaller()
+	i.AuthPrompt = AuthPromptTerminal
+	hasTerminal = func() bool { return true }
+	if env, _ := i.gitEnv("https://example.com/private"); env != nil {
+		t.Errorf("Expected no environment when prompting, got %v", env)
+	}
+	if i.workers() != 1 {
+		t.Errorf("Expected one worker when prompting, got %d", i.workers())
+	}
+}
@@ -0,0 +1,79 @@
+package repo
+
+import (
+	"sync"
+
+	"github.com/Ownercz/glide/msg"
+)
+
+// QuarantinedDependency is a dependency skipped because it could not be
+// fetched, along with the reason.
+type QuarantinedDependency struct {
+	Name string
+	Err  error
+}
+
+// quarantineList tracks the quarantined dependencies. This is a concurrency
+// safe implementation and its zero value is ready to use.
+type quarantineList struct {
+	sync.Mutex
+
+	deps  []QuarantinedDependency
+	names map[string]bool
+}
+
+// quarantine records a dependency that failed to be fetched when Quarantine
+// is set and Strict is not. It returns if the dependency was quarantined, in
+// which case the failure should not stop the run.
+func (i *Installer) quarantine(name string, err error) bool {
+	if i == nil || !i.Quarantine || i.Strict {
+		return false
+	}
+
+	i.quarantined.Lock()
+	defer i.quarantined.Unlock()
+	if i.quarantined.names == nil {
+		i.quarantined.names = make(map[string]bool)
+	}
+	if !i.quarantined.names[name] {
+		msg.Warn("Quarantining %s and continuing without it", name)
+		i.recordWarning("%s could not be fetched and was left out: %s", name, err)
+		i.quarantined.names[name] = true
+		i.quarantined.deps = append(i.quarantined.deps, QuarantinedDependency{Name: name, Err: err})
+	}
+	return true
+}
+
+// isQuarantined returns if a dependency has been quarantined.
+func (i *Installer) isQuarantined(name string) bool {
+	if i == nil {
+		return false
+	}
+
+	i.quarantined.Lock()
+	defer i.quarantined.Unlock()
+	return i.quarantined.names[name]
+}
+
+// Quarantined returns the dependencies quarantined so far in the order they
+// failed.
+func (i *Installer) Quarantined() []QuarantinedDependency {
+	i.quarantined.Lock()
+	defer i.quarantined.Unlock()
+	q := make([]QuarantinedDependency, len(i.quarantined.deps))
+	copy(q, i.quarantined.deps)
+	return q
+}
+
+// LogQuarantined displays the dependencies that were quarantined, if any.
+func (i *Installer) LogQuarantined() {
+	q := i.Quarantined()
+	if len(q) == 0 {
+		return
+	}
+
+	msg.Warn("%d dependencies could not be fetched and were left out:", len(q))
+	for _, d := range q {
+		msg.Warn("--> %s: %s", d.Name, d.Err)
+	}
+}
@@ -0,0 +1,60 @@
+package repo
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/Ownercz/glide/cfg"
+)
+
+func TestQuarantine(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir, err := ioutil.TempDir("", "glide-quarantine")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	remote := filepath.Join(dir, "remotes", "good")
+	newTestRemote(t, remote)
+
+	conf := &cfg.Config{
+		Name: "example.com/app",
+		Imports: cfg.Dependencies{
+			{Name: "github.com/example/good", Repository: remote, VcsType: "git"},
+			{Name: "github.com/example/bad", Repository: filepath.Join(dir, "remotes", "missing"), VcsType: "git"},
+		},
+	}
+
+	i := NewInstaller()
+	i.Home = filepath.Join(dir, "home")
+	i.Strict = true
+	i.Quarantine = true
+	if err := ConcurrentUpdate(conf.Imports, i, conf); err == nil {
+		t.Error("Expected the failure to be returned with Strict")
+	}
+	if len(i.Quarantined()) != 0 {
+		t.Error("Expected nothing to be quarantined with Strict")
+	}
+
+	// Each installer only tries to fetch a dependency once.
+	i = NewInstaller()
+	i.Home = filepath.Join(dir, "home")
+	i.Quarantine = true
+	if err := ConcurrentUpdate(conf.Imports, i, conf); err != nil {
+		t.Errorf("Unexpected error with Quarantine: %s", err)
+	}
+	q := i.Quarantined()
+	if len(q) != 1 || q[0].Name != "github.com/example/bad" || q[0].Err == nil {
+		t.Errorf("Expected the failed dependency to be quarantined, got %v", q)
+	}
+	if !i.isQuarantined("github.com/example/bad") || i.isQuarantined("github.com/example/good") {
+		t.Error("Expected only the failed dependency to be quarantined")
+	}
+}
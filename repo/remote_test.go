@@ -0,0 +1,45 @@
+package repo
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newTestRemote creates a git repository at dir for tests to fetch from and
+// returns the commit it is at. The files are given as pairs of a path
+// relative to dir and its contents and are added in one commit. Without
+// files the commit is empty.
+func newTestRemote(t *testing.T, dir string, files ...string) string {
+	testGit(t, "", "init", "-q", dir)
+	for ii := 0; ii+1 < len(files); ii += 2 {
+		p := filepath.Join(dir, filepath.FromSlash(files[ii]))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(files[ii+1]), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	testGit(t, dir, "add", "-A")
+	testGit(t, dir, "commit", "-q", "--allow-empty", "-m", "commit")
+	return testGit(t, dir, "rev-parse", "HEAD")
+}
+
+// testGit runs git in dir, or the working directory when empty, with a test
+// identity for commits. The test fails when git does. The trimmed output is
+// returned.
+func testGit(t *testing.T, dir string, args ...string) string {
+	args = append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)
+	if dir != "" {
+		args = append([]string{"-C", dir}, args...)
+	}
+	out, err := exec.Command("git", args...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("Unable to setup the test repo: %s", out)
+	}
+	return strings.TrimSpace(string(out))
+}
@@ -0,0 +1,147 @@
+package repo
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/Ownercz/glide/cfg"
+	"github.com/Ownercz/glide/msg"
+)
+
+// ReplaceEnv is the environment variable replace rules are read from. Rules
+// are separated by commas or whitespace and take the form
+// import/path=repo@ref. Either the repo or the ref can be left out, e.g.
+// github.com/foo/bar=https://github.com/me/bar@fix,golang.org/x/net=@v1.0.0
+const ReplaceEnv = "GLIDE_REPLACE"
+
+// ReplaceRule replaces the repository and, when Ref is set, the version of a
+// dependency.
+type ReplaceRule struct {
+	Repo, Ref string
+}
+
+// ReplaceRules maps the root package of a dependency to its replacement.
+type ReplaceRules map[string]ReplaceRule
+
+// ReplaceRulesFromEnv parses the replace rules in the ReplaceEnv environment
+// variable. No rules are returned when it is not set.
+func ReplaceRulesFromEnv() (ReplaceRules, error) {
+	return ParseReplaceRules(os.Getenv(ReplaceEnv))
+}
+
+// ParseReplaceRules parses replace rules in the format used by ReplaceEnv.
+// Rules that cannot be parsed are an error rather than being skipped.
+func ParseReplaceRules(s string) (ReplaceRules, error) {
+	rules := ReplaceRules{}
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || unicode.IsSpace(r)
+	})
+	for _, f := range fields {
+		parts := strings.SplitN(f, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("Invalid replace rule %q, expected import/path=repo@ref", f)
+		}
+		name := parts[0]
+		if _, found := rules[name]; found {
+			return nil, fmt.Errorf("Invalid replace rule %q, %s is replaced more than once", f, name)
+		}
+
+		// The ref follows the last @ as the repo can contain one, e.g.
+		// git@github.com:foo/bar. A ref cannot contain a : which tells them
+		// apart when the ref is left out. Other repos containing an @ need
+		// to end with one when there is no ref.
+		r := ReplaceRule{Repo: parts[1]}
+		idx := strings.LastIndex(parts[1], "@")
+		if idx != -1 && !strings.Contains(parts[1][idx+1:], ":") {
+			r.Repo, r.Ref = parts[1][:idx], parts[1][idx+1:]
+		}
+		if r.Repo == "" && r.Ref == "" {
+			return nil, fmt.Errorf("Invalid replace rule %q, a repo or version is required", f)
+		}
+
+		rules[name] = r
+	}
+
+	return rules, nil
+}
+
+// replaceRepo sets the replacement repository for a dependency as where it is
+// fetched from, taking precedence over all other ways of locating it. It is
+// kept on the Installer rather than in the mirrors so it only applies to this
+// Installer, and the replacement is not written to the glide.yaml file.
+func (i *Installer) replaceRepo(dep *cfg.Dependency) bool {
+	if i == nil {
+		return false
+	}
+	r, found := i.Replace[dep.Name]
+	if !found || r.Repo == "" {
+		return false
+	}
+
+	msg.Debug("Replacing the repository of %s with %s", dep.Name, r.Repo)
+	i.setLocation(dep, r.Repo, "")
+
+	return true
+}
+
+// rewriteRepo sets the repository a rewrite rule in the config gives a
+// dependency as where it is fetched from. As with replaceRepo it is kept on
+// the Installer so the dependency keeps its import path in the vendor
+// directory and lock file. A dependency with its own repository is not
+// rewritten.
+func (i *Installer) rewriteRepo(dep *cfg.Dependency, conf *cfg.Config) bool {
+	if conf == nil || dep.Repository != "" {
+		return false
+	}
+	repo := conf.Rewrite.Repo(dep.Name)
+	if repo == "" {
+		return false
+	}
+
+	msg.Debug("Rewriting the repository of %s to %s", dep.Name, repo)
+	i.setLocation(dep, repo, "")
+
+	return true
+}
+
+// rewriteURL sets the URL a URL rewrite rule in the config gives the remote
+// of a dependency as where the Installer fetches it from. It is applied to
+// the remote after every other way of locating the dependency so it covers
+// all of them. Like the other rules it only applies to this Installer.
+func (i *Installer) rewriteURL(dep *cfg.Dependency, conf *cfg.Config) bool {
+	if conf == nil {
+		return false
+	}
+	url := conf.URLRewrite.Apply(dep.Remote())
+	if url == "" {
+		return false
+	}
+
+	msg.Debug("Rewriting the URL of %s to %s", dep.Name, url)
+	i.setLocation(dep, url, dep.Vcs())
+
+	return true
+}
+
+// replace applies the replace rule for a dependency. Along with the
+// repository the version is replaced, clearing any pinned commit, so it
+// should only be used on dependencies that are not written back to the
+// glide.yaml file. Where the Installer already fetches the location of the
+// dependency from is set on it too.
+func (i *Installer) replace(dep *cfg.Dependency) {
+	if i == nil {
+		return
+	}
+	i.locate(dep)
+	i.replaceRepo(dep)
+	r := i.Replace[dep.Name]
+	if r.Ref == "" || r.Ref == dep.Reference {
+		return
+	}
+
+	msg.Info("--> Replacing the version of %s with %s", dep.Name, r.Ref)
+	dep.Reference = r.Ref
+	dep.Pin = ""
+}
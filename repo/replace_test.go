@@ -0,0 +1,173 @@
+package repo
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Ownercz/glide/cfg"
+)
+
+func TestParseReplaceRules(t *testing.T) {
+	rules, err := ParseReplaceRules("github.com/foo/bar=https://github.com/me/bar@fix, example.com/baz=git@github.com:me/baz\n golang.org/x/net=@v1.0.0")
+	if err != nil {
+		t.Fatalf("Unexpected error parsing replace rules: %s", err)
+	}
+	expect := ReplaceRules{
+		"github.com/foo/bar": {Repo: "https://github.com/me/bar", Ref: "fix"},
+		"example.com/baz":    {Repo: "git@github.com:me/baz"},
+		"golang.org/x/net":   {Ref: "v1.0.0"},
+	}
+	if len(rules) != len(expect) {
+		t.Errorf("Expected %d rules but got %d", len(expect), len(rules))
+	}
+	for k, v := range expect {
+		if rules[k] != v {
+			t.Errorf("Expected rule %v for %s but got %v", v, k, rules[k])
+		}
+	}
+
+	if rules, err := ParseReplaceRules(""); err != nil || len(rules) != 0 {
+		t.Errorf("Expected no rules for an empty string, got %v (%v)", rules, err)
+	}
+
+	for _, bad := range []string{"github.com/foo/bar", "=https://github.com/me/bar", "github.com/foo/bar=", "github.com/foo/bar=@", "a=b@c,a=d@e"} {
+		if _, err := ParseReplaceRules(bad); err == nil {
+			t.Errorf("Expected an error parsing %q", bad)
+		}
+	}
+}
+
+func TestReplace(t *testing.T) {
+	i := NewInstaller()
+	i.MirrorDir = "testdata/does-not-exist"
+	i.Offline = true
+	i.Replace = ReplaceRules{
+		"example.com/replaced/repo": {Repo: "https://git.example.com/fork.git", Ref: "fix"},
+	}
+
+	dep := &cfg.Dependency{Name: "example.com/replaced/repo", Reference: "v1.0.0", Pin: "abc123"}
+	if err := i.discover(dep, nil); err != nil {
+		t.Fatalf("Expected the replace rule to take precedence over the mirror directory: %s", err)
+	}
+	if dep.Remote() != "https://git.example.com/fork.git" {
+		t.Errorf("Expected the replaced repository, got %s", dep.Remote())
+	}
+	if dep.Reference != "v1.0.0" {
+		t.Error("Expected discover not to alter the dependency")
+	}
+
+	i.replace(dep)
+	if dep.Reference != "fix" || dep.Pin != "" {
+		t.Errorf("Expected the version to be replaced and unpinned, got %s (%s)", dep.Reference, dep.Pin)
+	}
+
+	// The replacement only applies to the Installer with the rule.
+	other := &cfg.Dependency{Name: "example.com/replaced/repo"}
+	if err := NewInstaller().discover(other, nil); err != nil {
+		t.Fatal(err)
+	}
+	if other.Remote() != "https://example.com/replaced/repo" {
+		t.Errorf("Expected the replacement not to leak into another Installer, got %s", other.Remote())
+	}
+}
+
+func TestRewriteRepo(t *testing.T) {
+	i := NewInstaller()
+	i.MirrorDir = "testdata/does-not-exist"
+	i.Offline = true
+	conf := &cfg.Config{
+		Rewrite: cfg.Rewrites{
+			{Prefix: "example.com/rewritten", Repo: "https://git.example.com/myorg"},
+		},
+	}
+
+	dep := &cfg.Dependency{Name: "example.com/rewritten/repo"}
+	if err := i.discover(dep, conf); err != nil {
+		t.Fatalf("Expected the rewrite rule to take precedence over the mirror directory: %s", err)
+	}
+	if dep.Remote() != "https://git.example.com/myorg/repo" {
+		t.Errorf("Expected the rewritten repository, got %s", dep.Remote())
+	}
+	if dep.Repository != "" {
+		t.Error("Expected discover not to alter the dependency")
+	}
+	other := &cfg.Dependency{Name: "example.com/rewritten/repo"}
+	if err := NewInstaller().discover(other, nil); err != nil {
+		t.Fatal(err)
+	}
+	if other.Remote() != "https://example.com/rewritten/repo" {
+		t.Errorf("Expected the rewrite not to leak into another Installer, got %s", other.Remote())
+	}
+
+	dep = &cfg.Dependency{Name: "example.com/rewritten/own", Repository: "https://git.example.com/own"}
+	if i.rewriteRepo(dep, conf) || dep.Remote() != "https://git.example.com/own" {
+		t.Errorf("Expected a dependency with its own repository not to be rewritten, got %s", dep.Remote())
+	}
+}
+
+func TestRewriteURL(t *testing.T) {
+	dir, err := ioutil.TempDir("", "glide-rewrite-url")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	i := NewInstaller()
+	i.Home = dir
+	conf := &cfg.Config{
+		Name: "example.com/app",
+		Rewrite: cfg.Rewrites{
+			{Prefix: "example.com/forked", Repo: "https://github.com/myorg/forked"},
+		},
+		URLRewrite: cfg.URLRewrites{
+			{Prefix: "https://github.com/", URL: "https://mirror.internal.example.com/github/"},
+		},
+	}
+
+	// The missing package handler discovers a dependency before fetching it.
+	dep := &cfg.Dependency{Name: "github.com/example/fetched"}
+	if err := i.discover(dep, conf); err != nil {
+		t.Fatalf("Unexpected error discovering: %s", err)
+	}
+	if dep.Remote() != "https://mirror.internal.example.com/github/example/fetched" {
+		t.Errorf("Expected the URL to be rewritten to the mirror, got %s", dep.Remote())
+	}
+	if dep.Repository != "" {
+		t.Error("Expected discover not to alter the dependency")
+	}
+
+	dep = &cfg.Dependency{Name: "example.com/forked"}
+	i.discover(dep, conf)
+	if dep.Remote() != "https://mirror.internal.example.com/github/myorg/forked" {
+		t.Errorf("Expected the URL from a rewrite rule to be rewritten, got %s", dep.Remote())
+	}
+
+	// Setting a version locates the dependency again, in case it was never
+	// fetched through the missing package handler.
+	v := &VersionHandler{
+		Use:       newImportCache(),
+		Imported:  make(map[string]bool),
+		Conflicts: make(map[string]bool),
+		Config:    conf,
+		installer: i,
+	}
+	v.SetVersion("github.com/example/versioned/sub", false)
+	dep = conf.Imports.Get("github.com/example/versioned")
+	if dep == nil || dep.Remote() != "https://mirror.internal.example.com/github/example/versioned" {
+		t.Fatalf("Expected setting the version to rewrite the URL, got %v", dep)
+	}
+	if p, _ := i.vendorDir("vendor", dep.Name); p != filepath.Join("vendor", "github.com", "example", "versioned") {
+		t.Errorf("Expected the import path to be kept in the vendor directory, got %s", p)
+	}
+
+	// The rewritten URL only applies to the Installer with the rule.
+	other := &cfg.Dependency{Name: "github.com/example/fetched"}
+	if err := NewInstaller().discover(other, nil); err != nil {
+		t.Fatal(err)
+	}
+	if other.Remote() != "https://github.com/example/fetched" {
+		t.Errorf("Expected the URL rewrite not to leak into another Installer, got %s", other.Remote())
+	}
+}
@@ -4,9 +4,23 @@
 // systems of each repository upon which the code relies.
 package repo
 
-// concurrentWorkers is the number of workers to be used in concurrent operations.
+// concurrentWorkers is the number of workers to be used in concurrent
+// operations unless the Installer's Concurrency is set.
 var concurrentWorkers = 20
 
+// workers returns the number of workers to use in concurrent operations. When
+// the VCS tools can prompt for credentials there is one so the prompts don't
+// interleave. It is safe to call with a nil Installer.
+func (i *Installer) workers() int {
+	if i.prompting() {
+		return 1
+	}
+	if i != nil && i.Concurrency > 0 {
+		return i.Concurrency
+	}
+	return concurrentWorkers
+}
+
 // UpdatingVendored indicates whether this run of Glide is updating a vendored vendor/ path.
 //
 // It is related to the --update-vendor flag for update and install.
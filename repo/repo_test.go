@@ -0,0 +1,20 @@
+package repo
+
+import "testing"
+
+func TestWorkers(t *testing.T) {
+	var i *Installer
+	if w := i.workers(); w != concurrentWorkers {
+		t.Errorf("Expected %d workers for a nil Installer, got %d", concurrentWorkers, w)
+	}
+
+	i = NewInstaller()
+	if w := i.workers(); w != concurrentWorkers {
+		t.Errorf("Expected %d workers by default, got %d", concurrentWorkers, w)
+	}
+
+	i.Concurrency = 4
+	if w := i.workers(); w != 4 {
+		t.Errorf("Expected Concurrency to set the workers to 4, got %d", w)
+	}
+}
@@ -0,0 +1,112 @@
+package repo
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Ownercz/glide/cfg"
+	"github.com/Ownercz/glide/msg"
+)
+
+// updateResolved is Update for a ResolvedFile. The dependencies and their
+// commits are taken from the file so resolving is skipped and only fetching
+// remains. The file has the format of a lock file.
+func (i *Installer) updateResolved(conf *cfg.Config) error {
+	lock, err := cfg.ReadLockFile(i.ResolvedFile)
+	if err != nil {
+		return fmt.Errorf("Unable to read the resolved dependencies in %s: %s", i.ResolvedFile, err)
+	}
+	if err := i.checkResolved(conf, lock); err != nil {
+		return err
+	}
+
+	msg.Info("Using the resolved dependencies in %s", i.ResolvedFile)
+	conf.Imports = resolvedDeps(conf.Imports, lock.Imports, conf)
+	if i.ResolveTest {
+		conf.DevImports = resolvedDeps(conf.DevImports, lock.DevImports, conf)
+	}
+	for _, dep := range append(conf.Imports, conf.DevImports...) {
+		i.replace(dep)
+	}
+	if err := i.checkCollisions(append(conf.Imports, conf.DevImports...)); err != nil {
+		return err
+	}
+
+	msg.Info("Downloading dependencies. Please wait...")
+
+	err = ConcurrentUpdate(conf.Imports, i, conf)
+	if err != nil {
+		return err
+	}
+
+	if i.ResolveTest {
+		err = ConcurrentUpdate(conf.DevImports, i, conf)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkResolved returns an error when resolved dependencies do not agree
+// with the dependencies in the config. Each one in the config needs to be
+// resolved, from the same repository and, when the config names a commit, at
+// that commit. The error lists every problem.
+func (i *Installer) checkResolved(conf *cfg.Config, lock *cfg.Lockfile) error {
+	deps := conf.Imports
+	if i.ResolveTest {
+		deps = append(deps, conf.DevImports...)
+	}
+
+	problems := []string{}
+	for _, dep := range deps {
+		if conf.HasIgnore(dep.Name) {
+			continue
+		}
+		l := lock.Imports.Get(dep.Name)
+		if l == nil {
+			l = lock.DevImports.Get(dep.Name)
+		}
+		switch {
+		case l == nil:
+			problems = append(problems, fmt.Sprintf("%s is not resolved", dep.Name))
+		case dep.Repository != "" && !i.sameRepository(dep, cfg.DependencyFromLock(l)):
+			problems = append(problems, fmt.Sprintf("%s is resolved from %s rather than %s", dep.Name, l.Repository, dep.Repository))
+		case commitID.MatchString(dep.Reference) && !strings.HasPrefix(l.Version, dep.Reference):
+			problems = append(problems, fmt.Sprintf("%s is resolved to %s rather than %s", dep.Name, l.Version, dep.Reference))
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	sort.Strings(problems)
+	return fmt.Errorf("The resolved dependencies do not match the config:\n  %s", strings.Join(problems, "\n  "))
+}
+
+// resolvedDeps returns the dependencies in a resolved set at their resolved
+// commit. Settings only found in the config, such as fallbacks, are kept. A
+// checkout command is only taken from the config, not the resolved set.
+func resolvedDeps(deps cfg.Dependencies, locks cfg.Locks, conf *cfg.Config) cfg.Dependencies {
+	resolved := make(cfg.Dependencies, 0, len(locks))
+	for _, l := range locks {
+		if conf.HasIgnore(l.Name) {
+			continue
+		}
+		dep := deps.Get(l.Name)
+		if dep == nil {
+			dep = cfg.DependencyFromLock(l)
+			dep.Checkout = ""
+		} else {
+			dep.Reference = l.Version
+			dep.Repository = l.Repository
+			dep.VcsType = l.VcsType
+			dep.Subpackages = l.Subpackages
+		}
+		dep.Pin = ""
+		resolved = append(resolved, dep)
+	}
+	return resolved
+}
@@ -0,0 +1,123 @@
+package repo
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Ownercz/glide/cfg"
+)
+
+func TestCheckResolved(t *testing.T) {
+	conf := &cfg.Config{
+		Name:   "example.com/app",
+		Ignore: []string{"github.com/example/ignored"},
+		Imports: cfg.Dependencies{
+			{Name: "github.com/example/a"},
+			{Name: "github.com/example/b", Repository: "https://example.com/fork/b"},
+			{Name: "github.com/example/c", Reference: "abc1234"},
+			{Name: "github.com/example/ignored"},
+		},
+		DevImports: cfg.Dependencies{
+			{Name: "github.com/example/test"},
+		},
+	}
+	lock := &cfg.Lockfile{
+		Imports: cfg.Locks{
+			{Name: "github.com/example/a", Version: "1111111111111111111111111111111111111111"},
+			{Name: "github.com/example/b", Version: "2222222222222222222222222222222222222222", Repository: "https://example.com/fork/b"},
+			{Name: "github.com/example/c", Version: "abc1234333333333333333333333333333333333"},
+		},
+	}
+
+	i := NewInstaller()
+	if err := i.checkResolved(conf, lock); err != nil {
+		t.Errorf("Unexpected error for matching dependencies: %s", err)
+	}
+	i.ResolveTest = true
+	if err := i.checkResolved(conf, lock); err == nil || !strings.Contains(err.Error(), "github.com/example/test is not resolved") {
+		t.Errorf("Expected the missing test import to be reported, got %v", err)
+	}
+
+	// With CanonicalRepos another way of referring to the repository is the
+	// same one.
+	i = NewInstaller()
+	lock.Imports[1].Repository = "git@Example.com:fork/b.git"
+	if err := i.checkResolved(conf, lock); err == nil {
+		t.Error("Expected another URL for the repository to be a mismatch")
+	}
+	i.CanonicalRepos = true
+	if err := i.checkResolved(conf, lock); err != nil {
+		t.Errorf("Expected the canonical repositories to match, got %s", err)
+	}
+
+	lock.Imports[1].Repository = ""
+	lock.Imports[2].Version = "4444444444444444444444444444444444444444"
+	err := i.checkResolved(conf, lock)
+	if err == nil {
+		t.Fatal("Expected an error for mismatched dependencies")
+	}
+	for _, s := range []string{"github.com/example/b is resolved from  rather than", "github.com/example/c is resolved to 4444"} {
+		if !strings.Contains(err.Error(), s) {
+			t.Errorf("Expected the error to contain %q, got %s", s, err)
+		}
+	}
+}
+
+func TestUpdateResolved(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir, err := ioutil.TempDir("", "glide-resolved")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	lock := &cfg.Lockfile{}
+	for _, name := range []string{"direct", "transitive"} {
+		remote := filepath.Join(dir, "remotes", name)
+		lock.Imports = append(lock.Imports, &cfg.Lock{
+			Name:       "github.com/example/" + name,
+			Version:    newTestRemote(t, remote),
+			Repository: remote,
+			VcsType:    "git",
+		})
+	}
+	resolved := filepath.Join(dir, "resolved.lock")
+	if err := lock.WriteFile(resolved); err != nil {
+		t.Fatal(err)
+	}
+
+	conf := &cfg.Config{
+		Name: "example.com/app",
+		Imports: cfg.Dependencies{
+			{Name: "github.com/example/direct", Repository: lock.Imports[0].Repository, Fallbacks: []string{"fallback"}},
+		},
+	}
+
+	i := NewInstaller()
+	i.Home = filepath.Join(dir, "home")
+	i.ResolvedFile = resolved
+	if err := i.Update(conf); err != nil {
+		t.Fatalf("Unexpected error updating from resolved dependencies: %s", err)
+	}
+	if len(conf.Imports) != 2 {
+		t.Fatalf("Expected the resolved dependencies to be used, got %d", len(conf.Imports))
+	}
+	for ii, dep := range conf.Imports {
+		if dep.Name != lock.Imports[ii].Name || dep.Reference != lock.Imports[ii].Version {
+			t.Errorf("Expected %s at %s, got %s at %s", lock.Imports[ii].Name, lock.Imports[ii].Version, dep.Name, dep.Reference)
+		}
+	}
+	if len(conf.Imports[0].Fallbacks) != 1 {
+		t.Error("Expected settings from the config to be kept")
+	}
+	if m := i.Metrics(); m.Cloned != 2 {
+		t.Errorf("Expected both dependencies to be fetched, got %+v", m)
+	}
+}
@@ -0,0 +1,107 @@
+package repo
+
+import (
+	"strings"
+	"time"
+
+	"github.com/Ownercz/glide/msg"
+)
+
+// defaultRetryBackoff is the wait before the first retry when MaxRetries is
+// set without a RetryBackoff.
+const defaultRetryBackoff = time.Second
+
+// transientFailures are found in the output of VCS tools that failed because
+// of a problem with the network or the host that is likely to pass.
+var transientFailures = []string{
+	"connection reset",
+	"Connection reset",
+	"timed out",
+	"i/o timeout",
+	"TLS handshake timeout",
+	"Could not resolve host",
+	"Temporary failure in name resolution",
+	"the remote end hung up unexpectedly",
+	"early EOF",
+	"unexpected disconnect",
+	"RPC failed",
+	"The requested URL returned error: 5",
+	"500 Internal Server Error",
+	"502 Bad Gateway",
+	"503 Service Unavailable",
+	"504 Gateway Timeout",
+}
+
+// permanentFailures are found in the output of VCS tools that failed in a way
+// retrying won't fix. They win over transientFailures as the tools often add
+// a generic message about the connection.
+var permanentFailures = []string{
+	"not found",
+	"does not exist",
+	"does not appear to be a git repository",
+}
+
+// sleep waits between retries. It is a variable so tests don't wait.
+var sleep = time.Sleep
+
+// errorOutput returns the message of an error along with the output of the
+// VCS tool, for the errors of the vcs package that carry it.
+func errorOutput(err error) string {
+	out := err.Error()
+	if o, ok := err.(interface {
+		Out() string
+	}); ok {
+		out += o.Out()
+	}
+	return out
+}
+
+// transientError returns if a VCS operation failed in a way that is likely to
+// pass when tried again, such as a reset connection or a server error from
+// the host.
+func transientError(err error) bool {
+	out := errorOutput(err)
+	for _, f := range append(permanentFailures, authFailures...) {
+		if strings.Contains(out, f) {
+			return false
+		}
+	}
+	for _, f := range transientFailures {
+		if strings.Contains(out, f) {
+			return true
+		}
+	}
+	return false
+}
+
+// retry runs a VCS operation for a dependency. After a transient failure it
+// is tried again up to MaxRetries times, waiting RetryBackoff before the first
+// retry and twice as long before each one after. The error of the last
+// attempt is returned.
+func (i *Installer) retry(name string, op func() error) error {
+	err := op()
+	if i == nil || i.MaxRetries <= 0 {
+		return err
+	}
+
+	backoff := i.RetryBackoff
+	if backoff <= 0 {
+		backoff = defaultRetryBackoff
+	}
+	n := 0
+	for ; err != nil && n < i.MaxRetries && transientError(err); n++ {
+		msg.Warn("Fetching %s failed, retrying in %s (%d of %d): %s", name, backoff, n+1, i.MaxRetries, err)
+		sleep(backoff)
+		backoff *= 2
+		i.countMetric(func(m *Metrics) { m.Retries++ })
+		i.countRetry(name)
+		err = op()
+	}
+
+	if n > 0 && err == nil {
+		msg.Info("--> Fetched %s after %d retries", name, n)
+	} else if n > 0 {
+		msg.Warn("Fetching %s failed after %d retries", name, n)
+	}
+	return err
+}
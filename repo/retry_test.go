@@ -0,0 +1,78 @@
+package repo
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Ownercz/glide/cfg"
+	v "github.com/Ownercz/vcs"
+)
+
+func TestTransientError(t *testing.T) {
+	tests := []struct {
+		err    error
+		expect bool
+	}{
+		{errors.New("read: connection reset by peer"), true},
+		{v.NewRemoteError("Unable to update repository", errors.New("exit status 128"), "error: RPC failed; curl 56 GnuTLS recv error\nfatal: early EOF"), true},
+		{v.NewRemoteError("Unable to get repository", errors.New("exit status 128"), "fatal: unable to access 'https://example.com/a/': The requested URL returned error: 503"), true},
+		{v.NewRemoteError("Unable to get repository", errors.New("exit status 128"), "remote: Repository not found.\nfatal: the remote end hung up unexpectedly"), false},
+		{v.NewRemoteError("Unable to get repository", errors.New("exit status 128"), "fatal: Authentication failed for 'https://example.com/a/'"), false},
+		{errors.New("something else went wrong"), false},
+	}
+	for _, tt := range tests {
+		if got := transientError(tt.err); got != tt.expect {
+			t.Errorf("Expected transientError(%q) to be %t", errorOutput(tt.err), tt.expect)
+		}
+	}
+}
+
+func TestRetry(t *testing.T) {
+	waits := []time.Duration{}
+	defer func(s func(time.Duration)) { sleep = s }(sleep)
+	sleep = func(d time.Duration) { waits = append(waits, d) }
+
+	attempts := 0
+	failTimes := func(n int, err error) func() error {
+		attempts = 0
+		return func() error {
+			attempts++
+			if attempts <= n {
+				return err
+			}
+			return nil
+		}
+	}
+	reset := errors.New("connection reset by peer")
+
+	i := NewInstaller()
+	if err := i.retry("example.com/a", failTimes(1, reset)); err == nil {
+		t.Error("Expected no retries by default")
+	}
+
+	i.MaxRetries = 3
+	i.RetryBackoff = time.Second
+	if err := i.retry("example.com/a", failTimes(2, reset)); err != nil || attempts != 3 {
+		t.Errorf("Expected success on the third attempt, got %v after %d", err, attempts)
+	}
+	if len(waits) != 2 || waits[0] != time.Second || waits[1] != 2*time.Second {
+		t.Errorf("Expected the backoff to double, got %v", waits)
+	}
+	if m := i.Metrics(); m.Retries != 2 {
+		t.Errorf("Expected 2 retries to be counted, got %d", m.Retries)
+	}
+
+	if err := i.retry("example.com/b", failTimes(10, reset)); err != reset || attempts != 4 {
+		t.Errorf("Expected the last error after 4 attempts, got %v after %d", err, attempts)
+	}
+	i.recordFailure(&cfg.Dependency{Name: "example.com/b"}, reset)
+	if f := i.Failures(); len(f) != 1 || f[0].Retries != 3 {
+		t.Errorf("Expected the failure to record 3 retries, got %+v", f)
+	}
+
+	notFound := errors.New("repository not found")
+	if err := i.retry("example.com/c", failTimes(1, notFound)); err != notFound || attempts != 1 {
+		t.Errorf("Expected a permanent failure not to be retried, got %d attempts", attempts)
+	}
+}
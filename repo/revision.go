@@ -0,0 +1,53 @@
+package repo
+
+import (
+	"fmt"
+
+	v "github.com/Ownercz/vcs"
+)
+
+const (
+	// MissingRevisionFetch fetches only a pinned revision missing from a
+	// cached repository, falling back to fetching all updates if that fails.
+	// This is the default.
+	MissingRevisionFetch = "fetch"
+
+	// MissingRevisionRefresh fetches all updates for a cached repository
+	// missing a pinned revision.
+	MissingRevisionRefresh = "refresh"
+)
+
+// missingRevision returns if a version is a commit id the cached repository
+// does not have, such as a commit made after it was last fetched. Git accepts
+// a full commit id as a reference whether or not it has the commit so the
+// object itself is looked up.
+func missingRevision(repo v.Repo, ver string) bool {
+	if !commitID.MatchString(ver) {
+		return false
+	}
+
+	if g, ok := repo.(*v.GitRepo); ok {
+		_, err := g.RunFromDir("git", "cat-file", "-e", ver+"^{commit}")
+		return err != nil
+	}
+	return !repo.IsReference(ver)
+}
+
+// fetchRevision fetches a single revision into a cached repository. Git can
+// only fetch a full commit id this way, and only from servers allowing it.
+func (i *Installer) fetchRevision(repo v.Repo, ver string) error {
+	var out []byte
+	var err error
+	switch r := repo.(type) {
+	case *v.GitRepo:
+		out, err = i.runRemoteGit(r.LocalPath(), r.Remote(), "fetch", "-q", r.RemoteLocation, ver)
+	case *v.HgRepo:
+		out, err = r.RunFromDir("hg", "pull", "-r", ver)
+	default:
+		return fmt.Errorf("fetching a single revision is not supported for %s", repo.Vcs())
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %s", err, out)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package repo
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Ownercz/glide/cfg"
+)
+
+func TestMissingRevision(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir, err := ioutil.TempDir("", "glide-revision")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	remote := filepath.Join(dir, "remote")
+	newTestRemote(t, remote)
+	commit := func() string {
+		testGit(t, remote, "commit", "-q", "--allow-empty", "-m", "commit")
+		return testGit(t, remote, "rev-parse", "HEAD")
+	}
+
+	update := func(ref, policy string) (*cfg.Dependency, Metrics) {
+		i := NewInstaller()
+		i.Home = filepath.Join(dir, "home")
+		i.CacheTTL = time.Hour
+		i.MissingRevision = policy
+		dep := &cfg.Dependency{Name: "github.com/example/revision", Repository: remote, VcsType: "git", Reference: ref}
+		if err := i.VcsUpdate(dep); err != nil {
+			t.Fatalf("Unexpected error updating: %s", err)
+		}
+		return dep, i.Metrics()
+	}
+	dep, _ := update("", "")
+
+	key, err := NewInstaller().cacheKey(dep)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := dep.GetRepo(filepath.Join(dir, "home", "cache", "src", key))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The TTL would skip the update but the pinned commit is missing.
+	for _, policy := range []string{MissingRevisionFetch, MissingRevisionRefresh} {
+		pin := commit()
+		if !missingRevision(repo, pin) {
+			t.Fatalf("Expected %s to be missing from the cache", pin)
+		}
+		if _, m := update(pin, policy); m.Updated != 1 {
+			t.Errorf("Expected the cache to be updated with %s, got %+v", policy, m)
+		}
+		if missingRevision(repo, pin) {
+			t.Errorf("Expected %s to be fetched with %s", pin, policy)
+		}
+	}
+
+	if missingRevision(repo, "master") || missingRevision(repo, "^1.2.0") {
+		t.Error("Expected only commit ids to be checked")
+	}
+}
@@ -0,0 +1,75 @@
+package repo
+
+import (
+	"sync"
+)
+
+// rootCache records the repository roots already fetched or set to a version
+// while resolving. The MissingPackageHandler and VersionHandler of a resolver
+// share one so the packages under a root after the first do not fetch it or
+// check out its version again. This is a concurrency safe implementation and
+// its zero value is ready to use.
+type rootCache struct {
+	sync.Mutex
+
+	fetched   map[string]*rootFetch
+	versioned map[string]string
+}
+
+// rootFetch is the fetch of a root. Those asking for the root while it is
+// being fetched wait for it to finish.
+type rootFetch struct {
+	once sync.Once
+	err  error
+}
+
+// fetch runs fn to fetch a root the first time it is asked for. Every later
+// call returns the error of the first without running fn. It always runs fn
+// on a nil rootCache.
+func (c *rootCache) fetch(root string, fn func() error) error {
+	if c == nil {
+		return fn()
+	}
+
+	c.Lock()
+	if c.fetched == nil {
+		c.fetched = make(map[string]*rootFetch)
+	}
+	f, ok := c.fetched[root]
+	if !ok {
+		f = &rootFetch{}
+		c.fetched[root] = f
+	}
+	c.Unlock()
+
+	f.once.Do(func() { f.err = fn() })
+	return f.err
+}
+
+// setVersion runs fn to set the version of a root unless it was already set
+// to ref. A version that failed to be set is tried again next time. It always
+// runs fn on a nil rootCache.
+func (c *rootCache) setVersion(root, ref string, fn func() error) error {
+	if c == nil {
+		return fn()
+	}
+
+	c.Lock()
+	set, ok := c.versioned[root]
+	c.Unlock()
+	if ok && set == ref {
+		return nil
+	}
+
+	if err := fn(); err != nil {
+		return err
+	}
+
+	c.Lock()
+	defer c.Unlock()
+	if c.versioned == nil {
+		c.versioned = make(map[string]string)
+	}
+	c.versioned[root] = ref
+	return nil
+}
@@ -0,0 +1,86 @@
+package repo
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestRootCacheFetch(t *testing.T) {
+	// A synthetic graph where each of 10 dependencies imports 5 packages
+	// from each of 20 roots, as happens with large trees sharing libraries.
+	imports := []string{}
+	for d := 0; d < 10; d++ {
+		for r := 0; r < 20; r++ {
+			for p := 0; p < 5; p++ {
+				imports = append(imports, fmt.Sprintf("example.com/root%d/pkg%d", r, p))
+			}
+		}
+	}
+
+	var calls int64
+	c := &rootCache{}
+	var wg sync.WaitGroup
+	for _, imp := range imports {
+		wg.Add(1)
+		go func(pkg string) {
+			defer wg.Done()
+			root := pkg[:strings.LastIndex(pkg, "/")]
+			c.fetch(root, func() error {
+				atomic.AddInt64(&calls, 1)
+				return nil
+			})
+		}(imp)
+	}
+	wg.Wait()
+
+	if calls != 20 {
+		t.Errorf("Expected 20 fetches for %d packages, got %d", len(imports), calls)
+	}
+
+	failed := errors.New("unable to fetch")
+	c.fetch("example.com/failing", func() error { return failed })
+	if err := c.fetch("example.com/failing", func() error { return nil }); err != failed {
+		t.Errorf("Expected the error of the first fetch, got %v", err)
+	}
+
+	var nilCache *rootCache
+	calls = 0
+	for ii := 0; ii < 2; ii++ {
+		nilCache.fetch("example.com/a", func() error { calls++; return nil })
+	}
+	if calls != 2 {
+		t.Errorf("Expected a nil cache to always fetch, got %d fetches", calls)
+	}
+}
+
+func TestRootCacheSetVersion(t *testing.T) {
+	calls := 0
+	set := func() error {
+		calls++
+		return nil
+	}
+
+	c := &rootCache{}
+	for ii := 0; ii < 5; ii++ {
+		c.setVersion("example.com/a", "v1.0.0", set)
+	}
+	if calls != 1 {
+		t.Errorf("Expected the version to be set once, got %d", calls)
+	}
+
+	c.setVersion("example.com/a", "v1.1.0", set)
+	if calls != 2 {
+		t.Error("Expected a different version to be set")
+	}
+
+	failed := errors.New("unable to checkout")
+	c.setVersion("example.com/b", "master", func() error { return failed })
+	c.setVersion("example.com/b", "master", set)
+	if calls != 3 {
+		t.Error("Expected a version that failed to be set to be tried again")
+	}
+}
@@ -1,91 +1,60 @@
 package repo
 
 import (
-	"sync"
-
 	"github.com/Ownercz/glide/cache"
 	"github.com/Ownercz/glide/cfg"
 	"github.com/Ownercz/glide/msg"
-	"github.com/urfave/cli"
 )
 
 // SetReference is a command to set the VCS reference (commit id, tag, etc) for
-// a project.
+// a project. It uses the default settings of an Installer other than
+// resolveTest. See Installer.SetReference.
 func SetReference(conf *cfg.Config, resolveTest bool) error {
+	i := NewInstaller()
+	i.ResolveTest = resolveTest
+	return i.SetReference(conf)
+}
+
+// SetReference is a command to set the VCS reference (commit id, tag, etc) for
+// a project. The Installer provides the options used, such as whether test
+// dependencies are included.
+func (i *Installer) SetReference(conf *cfg.Config) error {
 
 	if len(conf.Imports) == 0 && len(conf.DevImports) == 0 {
 		msg.Info("No references set.\n")
 		return nil
 	}
 
-	done := make(chan struct{}, concurrentWorkers)
-	in := make(chan *cfg.Dependency, concurrentWorkers)
-	var wg sync.WaitGroup
-	var lock sync.Mutex
-	var returnErr error
-
-	for i := 0; i < concurrentWorkers; i++ {
-		go func(ch <-chan *cfg.Dependency) {
-			for {
-				select {
-				case dep := <-ch:
-
-					var loc string
-					if dep.Repository != "" {
-						loc = dep.Repository
-					} else {
-						loc = "https://" + dep.Name
-					}
-					key, err := cache.Key(loc)
-					if err != nil {
-						msg.Die(err.Error())
-					}
-					cache.Lock(key)
-					if err := VcsVersion(dep); err != nil {
-						msg.Err("Failed to set version on %s to %s: %s\n", dep.Name, dep.Reference, err)
-
-						// Capture the error while making sure the concurrent
-						// operations don't step on each other.
-						lock.Lock()
-						if returnErr == nil {
-							returnErr = err
-						} else {
-							returnErr = cli.NewMultiError(returnErr, err)
-						}
-						lock.Unlock()
-					}
-					cache.Unlock(key)
-					wg.Done()
-				case <-done:
-					return
-				}
+	pool := i.newPool()
+	defer pool.close()
+	set := func(dep *cfg.Dependency) {
+		pool.run(func() {
+			key, err := i.cacheKey(dep)
+			if err == nil {
+				cache.Lock(key)
+				err = i.VcsVersion(dep)
+				cache.Unlock(key)
+			}
+			if err != nil {
+				msg.Err("Failed to set version on %s to %s: %s\n", dep.Name, dep.Reference, err)
+				pool.fail(err)
 			}
-		}(in)
+		})
 	}
 
 	for _, dep := range conf.Imports {
-		if !conf.HasIgnore(dep.Name) {
-			wg.Add(1)
-			in <- dep
+		if !conf.HasIgnore(dep.Name) && !i.isQuarantined(dep.Name) {
+			set(dep)
 		}
 	}
 
-	if resolveTest {
+	if i.ResolveTest {
 		for _, dep := range conf.DevImports {
-			if !conf.HasIgnore(dep.Name) {
-				wg.Add(1)
-				in <- dep
+			if !conf.HasIgnore(dep.Name) && !i.isQuarantined(dep.Name) && !i.skippedDev[dep.Name] {
+				set(dep)
 			}
 		}
 	}
 
-	wg.Wait()
-	// Close goroutines setting the version
-	for i := 0; i < concurrentWorkers; i++ {
-		done <- struct{}{}
-	}
-	// close(done)
-	// close(in)
-
-	return returnErr
+	return pool.wait()
 }
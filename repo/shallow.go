@@ -0,0 +1,94 @@
+package repo
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/Ownercz/glide/msg"
+	v "github.com/Ownercz/vcs"
+)
+
+// shallowCloneArgs returns the git arguments cloning a repository with only
+// the newest commit of each branch. Every branch is kept so a dependency
+// following one other than the default can still be checked out.
+func shallowCloneArgs(remote, dest string) []string {
+	return []string{"clone", "--recursive", "--depth", "1", "--no-single-branch", remote, dest}
+}
+
+// clone gets a repository for the first time. When the Installer is set to
+// Shallow a git repository is cloned with shallowCloneArgs. There is no
+// equivalent for the other VCS so they are cloned in full. With an OpTimeout
+// git is run by runGit so a hung clone is stopped, and with gitEnv for the
+// remote so the key and credentials are used.
+func (i *Installer) clone(repo v.Repo) error {
+	g, ok := repo.(*v.GitRepo)
+	if !ok {
+		return repo.Get()
+	}
+	env, opts := i.gitEnv(g.Remote())
+	if i == nil || (!i.Shallow && i.OpTimeout <= 0 && env == nil && opts == nil) {
+		return repo.Get()
+	}
+
+	if err := os.MkdirAll(filepath.Dir(g.LocalPath()), 0755); err != nil {
+		return v.NewLocalError("Unable to create directory", err, "")
+	}
+	args := []string{"clone", "--recursive", g.Remote(), g.LocalPath()}
+	if i.Shallow {
+		args = shallowCloneArgs(g.Remote(), g.LocalPath())
+	}
+	out, err := i.runGitEnv("", env, append(opts, args...)...)
+	if err != nil {
+		// A clone that was killed leaves a partial checkout behind.
+		os.RemoveAll(g.LocalPath())
+		return v.NewRemoteError("Unable to get repository", err, string(out))
+	}
+	return nil
+}
+
+// isShallow returns if a repository is a shallow git clone.
+func isShallow(repo v.Repo) bool {
+	if _, ok := repo.(*v.GitRepo); !ok {
+		return false
+	}
+	_, err := os.Stat(filepath.Join(repo.LocalPath(), ".git", "shallow"))
+	return err == nil
+}
+
+// unshallow fetches the full history and tags of a shallow clone. Anything
+// looking through the tags or history of a repository calls it first. Other
+// repositories are left alone.
+func (i *Installer) unshallow(repo v.Repo) error {
+	if !isShallow(repo) {
+		return nil
+	}
+
+	g := repo.(*v.GitRepo)
+	msg.Debug("Fetching the full history of the shallow clone of %s", g.Remote())
+	out, err := i.runRemoteGit(g.LocalPath(), g.Remote(), "fetch", "--unshallow", "--tags", g.RemoteLocation)
+	if err != nil {
+		return v.NewRemoteError("Unable to fetch the full history", err, string(out))
+	}
+	return nil
+}
+
+// deepen makes sure a shallow clone has the commit a version refers to. A
+// missing commit is fetched on its own. When that fails, or the version is
+// not a commit, branch or tag the clone has, such as a semantic version
+// constraint, the full history is fetched.
+func (i *Installer) deepen(repo v.Repo, ver string) error {
+	if !isShallow(repo) {
+		return nil
+	}
+
+	if missingRevision(repo, ver) {
+		if err := i.fetchRevision(repo, ver); err == nil {
+			return nil
+		}
+		return i.unshallow(repo)
+	}
+	if commitID.MatchString(ver) || repo.IsReference(ver) {
+		return nil
+	}
+	return i.unshallow(repo)
+}
@@ -0,0 +1,87 @@
+package repo
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Ownercz/glide/cfg"
+)
+
+func TestShallowCloneArgs(t *testing.T) {
+	args := shallowCloneArgs("https://example.com/a.git", "/cache/src/a")
+	expected := []string{"clone", "--recursive", "--depth", "1", "--no-single-branch", "https://example.com/a.git", "/cache/src/a"}
+	if !reflect.DeepEqual(args, expected) {
+		t.Errorf("Expected %v, got %v", expected, args)
+	}
+}
+
+func TestShallow(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir, err := ioutil.TempDir("", "glide-shallow")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	remote := filepath.Join(dir, "remote")
+	first := newTestRemote(t, remote)
+	git := func(args ...string) string { return testGit(t, remote, args...) }
+	git("commit", "-q", "--allow-empty", "-m", "second")
+	second := git("rev-parse", "HEAD")
+	git("tag", "v1.0.0")
+	git("commit", "-q", "--allow-empty", "-m", "third")
+
+	newDep := func(ref string) *cfg.Dependency {
+		// The depth is ignored for local paths so a file URL is used.
+		return &cfg.Dependency{Name: "github.com/example/shallow", Repository: "file://" + remote, VcsType: "git", Reference: ref}
+	}
+	i := NewInstaller()
+	i.Home = filepath.Join(dir, "home")
+	i.Shallow = true
+	dep := newDep(first)
+	if err := i.VcsGet(dep); err != nil {
+		t.Fatalf("Unexpected error cloning: %s", err)
+	}
+
+	key, err := i.cacheKey(dep)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := dep.GetRepo(filepath.Join(i.Home, "cache", "src", key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	count := func() string {
+		out, err := exec.Command("git", "-C", repo.LocalPath(), "rev-list", "--count", "--all").Output()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	if !isShallow(repo) || count() != "2" {
+		t.Fatalf("Expected a clone with only the newest commit and tag, got %s commits", count())
+	}
+
+	if err := i.VcsVersion(dep); err != nil {
+		t.Fatalf("Unexpected error setting the version to an older commit: %s", err)
+	}
+	if dep.Pin != first {
+		t.Errorf("Expected the older commit to be checked out, got %s", dep.Pin)
+	}
+
+	dep = newDep("^1.0.0")
+	if err := i.VcsVersion(dep); err != nil {
+		t.Fatalf("Unexpected error setting the version to a range: %s", err)
+	}
+	if isShallow(repo) || count() != "3" || dep.Pin != second {
+		t.Errorf("Expected the full history to be fetched for a range, got %s commits at %s", count(), dep.Pin)
+	}
+}
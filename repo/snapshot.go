@@ -0,0 +1,65 @@
+package repo
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Ownercz/glide/msg"
+	v "github.com/Ownercz/vcs"
+)
+
+// ParseSnapshot parses a snapshot time. Either a date, such as 2017-06-01,
+// or an RFC 3339 time is accepted. Dates are the start of the day in UTC.
+func ParseSnapshot(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("Invalid snapshot time %q, expected a date like 2017-06-01 or an RFC 3339 time", s)
+	}
+	return t, nil
+}
+
+// hasSnapshot returns if versions are resolved as of a point in time.
+func hasSnapshot(i *Installer) bool {
+	return i != nil && !i.Snapshot.IsZero()
+}
+
+// snapshotCommit returns the newest commit on a branch made before the
+// snapshot time. Only Git is supported.
+func snapshotCommit(repo v.Repo, branch string, at time.Time, i *Installer) (string, error) {
+	g, ok := repo.(*v.GitRepo)
+	if !ok {
+		return "", fmt.Errorf("Snapshots are not supported for %s repositories", repo.Vcs())
+	}
+	if err := i.unshallow(repo); err != nil {
+		return "", err
+	}
+
+	out, err := repo.RunFromDir("git", "rev-list", "-1", "--before="+at.Format(time.RFC3339), g.RemoteLocation+"/"+branch)
+	if err != nil {
+		return "", fmt.Errorf("Unable to list the commits on %s: %s", branch, out)
+	}
+	c := strings.TrimSpace(string(out))
+	if c == "" {
+		return "", fmt.Errorf("There are no commits on %s before %s", branch, at.Format(time.RFC3339))
+	}
+	return c, nil
+}
+
+// beforeSnapshot returns if a reference was committed before the snapshot
+// time. Without a snapshot every reference is.
+func beforeSnapshot(repo v.Repo, ref string, i *Installer) bool {
+	if !hasSnapshot(i) {
+		return true
+	}
+
+	ci, err := repo.CommitInfo(ref)
+	if err != nil {
+		msg.Debug("Unable to read the date of %s in %s: %s", ref, repo.Remote(), err)
+		return false
+	}
+	return !ci.Date.After(i.Snapshot)
+}
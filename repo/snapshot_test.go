@@ -0,0 +1,88 @@
+package repo
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	v "github.com/Ownercz/vcs"
+)
+
+func TestParseSnapshot(t *testing.T) {
+	tests := map[string]time.Time{
+		"2017-06-01":                time.Date(2017, 6, 1, 0, 0, 0, 0, time.UTC),
+		"2017-06-01T15:04:05Z":      time.Date(2017, 6, 1, 15, 4, 5, 0, time.UTC),
+		"2017-06-01T15:04:05+02:00": time.Date(2017, 6, 1, 13, 4, 5, 0, time.UTC),
+	}
+	for in, expect := range tests {
+		s, err := ParseSnapshot(in)
+		if err != nil {
+			t.Errorf("Unexpected error parsing %s: %s", in, err)
+		} else if !s.Equal(expect) {
+			t.Errorf("Expected %s to be %s, got %s", in, expect, s)
+		}
+	}
+
+	if _, err := ParseSnapshot("June 2017"); err == nil {
+		t.Error("Expected an error parsing an unknown format")
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir, err := ioutil.TempDir("", "glide-snapshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// A commit tagged v1.0.0 in 2016 and another tagged v1.1.0 in 2018.
+	remote := filepath.Join(dir, "remote")
+	testGit(t, "", "init", "-q", remote)
+	commits := []string{}
+	for _, c := range []struct{ tag, date string }{{"v1.0.0", "2016-01-01T00:00:00Z"}, {"v1.1.0", "2018-01-01T00:00:00Z"}} {
+		for _, args := range [][]string{{"commit", "-q", "--allow-empty", "-m", c.tag}, {"tag", c.tag}} {
+			cmd := exec.Command("git", append([]string{"-C", remote, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+			cmd.Env = append(os.Environ(), "GIT_COMMITTER_DATE="+c.date, "GIT_AUTHOR_DATE="+c.date)
+			if out, err := cmd.CombinedOutput(); err != nil {
+				t.Fatalf("Unable to setup the test repo: %s", out)
+			}
+		}
+		commits = append(commits, testGit(t, remote, "rev-parse", "HEAD"))
+	}
+	branch := testGit(t, remote, "rev-parse", "--abbrev-ref", "HEAD")
+
+	repo, err := v.NewGitRepo(remote, filepath.Join(dir, "local"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Get(); err != nil {
+		t.Fatal(err)
+	}
+
+	i := NewInstaller()
+	i.Snapshot = time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	c, err := snapshotCommit(repo, branch, i.Snapshot, i)
+	if err != nil {
+		t.Fatalf("Unexpected error finding the snapshot commit: %s", err)
+	}
+	if c != commits[0] {
+		t.Errorf("Expected the commit from 2016 %s, got %s", commits[0], c)
+	}
+	if _, err := snapshotCommit(repo, branch, time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC), i); err == nil {
+		t.Error("Expected an error when there are no commits before the snapshot")
+	}
+
+	if !beforeSnapshot(repo, "v1.0.0", i) || beforeSnapshot(repo, "v1.1.0", i) {
+		t.Error("Expected only v1.0.0 to be before the snapshot")
+	}
+	if !beforeSnapshot(repo, "v1.1.0", NewInstaller()) {
+		t.Error("Expected every version to match without a snapshot")
+	}
+}
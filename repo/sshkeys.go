@@ -0,0 +1,155 @@
+package repo
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/Ownercz/glide/msg"
+	"github.com/Ownercz/glide/util"
+	"github.com/mitchellh/go-homedir"
+)
+
+// SSHKeysFile is the name of the file in the glide home directory the SSH
+// keys used to fetch dependencies are read from. Each line holds a pattern
+// and the path of a private key, e.g.
+// gitlab.example.com/group/project ~/.ssh/project_deploy_key
+// Blank lines and lines starting with # are skipped.
+const SSHKeysFile = "ssh-keys"
+
+// SSHKey is the private key used for the SSH remotes matching a pattern.
+type SSHKey struct {
+	Pattern, Path string
+}
+
+// SSHKeys are the private keys used to fetch dependencies over SSH with git.
+// The first one with a pattern matching a remote is used.
+type SSHKeys []SSHKey
+
+// ReadSSHKeys reads the SSH keys in the SSHKeysFile of a glide home
+// directory. None are returned when there is no file.
+func ReadSSHKeys(home string) (SSHKeys, error) {
+	p := filepath.Join(home, SSHKeysFile)
+	b, err := ioutil.ReadFile(p)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	keys, err := ParseSSHKeys(string(b))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", p, err)
+	}
+	return keys, nil
+}
+
+// ParseSSHKeys parses SSH keys in the format of the SSHKeysFile. A pattern is
+// matched with path.Match against the host of a remote, or the host followed
+// by the path of the repository for a key used with one repository. A key
+// path starting with ~/ is relative to the home directory of the user.
+func ParseSSHKeys(s string) (SSHKeys, error) {
+	var keys SSHKeys
+	for n, l := range strings.Split(s, "\n") {
+		l = strings.TrimSpace(l)
+		if l == "" || strings.HasPrefix(l, "#") {
+			continue
+		}
+
+		fields := strings.Fields(l)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("Line %d: expected a pattern and the path of a key", n+1)
+		}
+		if _, err := path.Match(fields[0], ""); err != nil {
+			return nil, fmt.Errorf("Line %d: invalid pattern %q", n+1, fields[0])
+		}
+		p, err := homedir.Expand(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("Line %d: %s", n+1, err)
+		}
+		keys = append(keys, SSHKey{Pattern: strings.ToLower(fields[0]), Path: p})
+	}
+	return keys, nil
+}
+
+// keyFor returns the path of the key for a remote. It is empty when the
+// remote is not fetched over SSH or no pattern matches it.
+func (k SSHKeys) keyFor(remote string) string {
+	host, repo, ok := sshRemote(remote)
+	if !ok {
+		return ""
+	}
+
+	for _, key := range k {
+		if ok, _ := path.Match(key.Pattern, host); ok {
+			return key.Path
+		}
+		if ok, _ := path.Match(key.Pattern, host+"/"+repo); ok {
+			return key.Path
+		}
+	}
+	return ""
+}
+
+// sshRemote returns the host and repository path of a remote fetched over
+// SSH, either as an ssh:// URL or in the SCP-like syntax. The path has no
+// leading slash or .git suffix.
+func sshRemote(remote string) (string, string, bool) {
+	var host, repo string
+	if m := util.ScpSyntaxRe.FindStringSubmatch(remote); m != nil {
+		host, repo = m[2], m[3]
+	} else if u, err := url.Parse(remote); err == nil && (u.Scheme == "ssh" || u.Scheme == "git+ssh") {
+		host, repo = urlHost(u), u.Path
+	} else {
+		return "", "", false
+	}
+
+	repo = strings.TrimSuffix(strings.Trim(repo, "/"), ".git")
+	return strings.ToLower(host), strings.ToLower(repo), true
+}
+
+// urlHost returns the host of a URL without the port.
+func urlHost(u *url.URL) string {
+	if h, _, err := net.SplitHostPort(u.Host); err == nil {
+		return h
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(u.Host, "["), "]")
+}
+
+// sshCommand returns the GIT_SSH_COMMAND using a private key and only that
+// key, rather than those offered by an agent. An ssh command already set in
+// the environment is kept with the key added to it.
+func sshCommand(key string) string {
+	cmd := os.Getenv("GIT_SSH_COMMAND")
+	if cmd == "" {
+		cmd = "ssh"
+	}
+	return cmd + " -i " + shellQuote(key) + " -o IdentitiesOnly=yes"
+}
+
+// shellQuote quotes a string for a POSIX shell, which git runs the
+// GIT_SSH_COMMAND with.
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+// sshEnv returns the environment added to git run for a remote so it uses the
+// SSH key for it. It is only set on the git processes fetching the remote so
+// the environment of glide and the git configuration of the user are left
+// alone.
+func (i *Installer) sshEnv(remote string) []string {
+	if i == nil {
+		return nil
+	}
+	key := i.SSHKeys.keyFor(remote)
+	if key == "" {
+		return nil
+	}
+	msg.Debug("Using the SSH key %s for %s", key, remote)
+	return []string{"GIT_SSH_COMMAND=" + sshCommand(key)}
+}
@@ -0,0 +1,139 @@
+package repo
+
+import (
+	"io/ioutil"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseSSHKeys(t *testing.T) {
+	keys, err := ParseSSHKeys(`
+# Deploy keys
+gitlab.example.com/group/app /keys/app
+*.example.com   /keys/example
+`)
+	if err != nil {
+		t.Fatalf("Unexpected error parsing SSH keys: %s", err)
+	}
+	if len(keys) != 2 || keys[0] != (SSHKey{"gitlab.example.com/group/app", "/keys/app"}) || keys[1] != (SSHKey{"*.example.com", "/keys/example"}) {
+		t.Errorf("Unexpected SSH keys %v", keys)
+	}
+
+	for _, s := range []string{"gitlab.example.com", "a b c", "[ /keys/a"} {
+		if _, err := ParseSSHKeys(s); err == nil {
+			t.Errorf("Expected an error parsing %q", s)
+		}
+	}
+}
+
+func TestReadSSHKeys(t *testing.T) {
+	home, err := ioutil.TempDir("", "glide-sshkeys")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(home)
+
+	if keys, err := ReadSSHKeys(home); err != nil || len(keys) != 0 {
+		t.Errorf("Expected no SSH keys without a file, got %v %v", keys, err)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(home, SSHKeysFile), []byte("gitlab.example.com ~/.ssh/deploy\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	keys, err := ReadSSHKeys(home)
+	if err != nil {
+		t.Fatalf("Unexpected error reading SSH keys: %s", err)
+	}
+	if len(keys) != 1 || !filepath.IsAbs(keys[0].Path) || !strings.HasSuffix(keys[0].Path, filepath.Join(".ssh", "deploy")) {
+		t.Errorf("Expected the key path to be in the home directory, got %v", keys)
+	}
+}
+
+func TestSSHKeyFor(t *testing.T) {
+	keys := SSHKeys{
+		{Pattern: "gitlab.example.com/group/app", Path: "/keys/app"},
+		{Pattern: "*.example.com", Path: "/keys/example"},
+	}
+	for remote, expected := range map[string]string{
+		"git@gitlab.example.com:group/app.git":      "/keys/app",
+		"ssh://git@GitLab.example.com/group/app":    "/keys/app",
+		"git@gitlab.example.com:group/other.git":    "/keys/example",
+		"ssh://git@git.example.com:2222/group/lib":  "/keys/example",
+		"https://gitlab.example.com/group/app.git":  "",
+		"git@github.com:example/app.git":            "",
+		"/tmp/gitlab.example.com/group/app":         "",
+		"git+ssh://git@gitlab.example.com/group/ap": "/keys/example",
+	} {
+		if k := keys.keyFor(remote); k != expected {
+			t.Errorf("Expected the key %q for %s, got %q", expected, remote, k)
+		}
+	}
+}
+
+func TestSSHEnv(t *testing.T) {
+	defer os.Setenv("GIT_SSH_COMMAND", os.Getenv("GIT_SSH_COMMAND"))
+	os.Unsetenv("GIT_SSH_COMMAND")
+
+	i := NewInstaller()
+	i.SSHKeys = SSHKeys{{Pattern: "gitlab.example.com", Path: "/keys/it's"}}
+	env := i.sshEnv("git@gitlab.example.com:group/app.git")
+	expected := `GIT_SSH_COMMAND=ssh -i '/keys/it'\''s' -o IdentitiesOnly=yes`
+	if len(env) != 1 || env[0] != expected {
+		t.Errorf("Expected %q, got %v", expected, env)
+	}
+	if env := i.sshEnv("https://gitlab.example.com/group/app.git"); env != nil {
+		t.Errorf("Expected no environment over HTTPS, got %v", env)
+	}
+
+	os.Setenv("GIT_SSH_COMMAND", "ssh -v")
+	if env := i.sshEnv("git@gitlab.example.com:group/app.git"); len(env) != 1 || !strings.HasPrefix(env[0], "GIT_SSH_COMMAND=ssh -v -i ") {
+		t.Errorf("Expected the ssh command in the environment to be kept, got %v", env)
+	}
+}
+
+func TestRunGitEnv(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	defer os.Setenv("GIT_SSH_COMMAND", os.Getenv("GIT_SSH_COMMAND"))
+	os.Unsetenv("GIT_SSH_COMMAND")
+
+	dir, err := ioutil.TempDir("", "glide-sshkeys")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	newTestRemote(t, dir)
+	testGit(t, dir, "config", "alias.sshcmd", "!echo \"$GIT_SSH_COMMAND\"")
+
+	i := NewInstaller()
+	out, err := i.runGitEnv(dir, []string{"GIT_SSH_COMMAND=ssh -i /keys/app"}, "sshcmd")
+	if err != nil || strings.TrimSpace(string(out)) != "ssh -i /keys/app" {
+		t.Errorf("Expected git to be run with the ssh command, got %q %v", out, err)
+	}
+	if c := os.Getenv("GIT_SSH_COMMAND"); c != "" {
+		t.Errorf("Expected the environment of glide to be left alone, got %q", c)
+	}
+}
+
+func TestURLHost(t *testing.T) {
+	tests := map[string]string{
+		"ssh://git@example.com/group/app":      "example.com",
+		"ssh://git@example.com:2222/group/app": "example.com",
+		"https://[::1]:8443/group/app":         "::1",
+		"https://[::1]/group/app":              "::1",
+	}
+	for remote, expected := range tests {
+		u, err := url.Parse(remote)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if h := urlHost(u); h != expected {
+			t.Errorf("Expected the host of %s to be %s, got %s", remote, expected, h)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package repo
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/Ownercz/glide/msg"
+	gpath "github.com/Ownercz/glide/path"
+	v "github.com/Ownercz/vcs"
+)
+
+// storeDir returns where the exported files of a repository at a revision
+// are kept in the store. The revision identifies the content so projects
+// using the same one share it.
+func (i *Installer) storeDir(key, rev string) string {
+	return filepath.Join(i.cacheLocation(), "store", key, rev)
+}
+
+// exportFromStore exports a checkout to a directory through the store. The
+// checkout is exported to the store the first time its revision is used.
+// After that the files in the store are copied into the directory, or linked
+// with StoreLinks. Checkouts
+// with changes, or that are symlinks, don't match their revision so they are
+// exported directly. So are those with LFS content that was not fetched.
+func (i *Installer) exportFromStore(repo v.Repo, key, dest string) error {
+	if isSymlink(repo.LocalPath()) || repo.IsDirty() || (usesLFS(repo) && !i.FetchLFS) {
+		msg.Debug("%s does not match a revision. Not using the store", repo.LocalPath())
+		return repo.ExportDir(dest)
+	}
+	rev, err := repo.Version()
+	if err != nil {
+		return err
+	}
+
+	sd := i.storeDir(key, rev)
+	if _, err := os.Stat(sd); os.IsNotExist(err) {
+		if err := os.MkdirAll(filepath.Dir(sd), 0755); err != nil {
+			return err
+		}
+
+		// The export is moved into place once complete so a partial one is
+		// never used by another process.
+		tmp, err := ioutil.TempDir(filepath.Dir(sd), rev+".tmp")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(tmp)
+		if err := repo.ExportDir(filepath.Join(tmp, "tree")); err != nil {
+			return err
+		}
+		if err := os.Rename(filepath.Join(tmp, "tree"), sd); err != nil {
+			// Another process may have stored the same revision first.
+			if _, serr := os.Stat(sd); serr != nil {
+				return err
+			}
+		}
+	}
+
+	return linkTree(sd, dest, i.StoreLinks)
+}
+
+// linkTree populates a directory with the files under another. They are
+// copied unless link is set, in which case hard links to them are created.
+// Where a link cannot be created, such as across file systems or on those
+// without hard links, the file is copied instead. Symlinks are recreated as
+// symlinks.
+func linkTree(src, dest string, link bool) error {
+	return filepath.Walk(src, func(p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dest, rel)
+
+		switch {
+		case fi.IsDir():
+			return os.MkdirAll(target, 0755)
+		case fi.Mode()&os.ModeSymlink != 0:
+			l, err := os.Readlink(p)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(l, target)
+		case !link:
+			return gpath.CopyFile(p, target)
+		}
+		if err := os.Link(p, target); err != nil {
+			msg.Debug("Unable to link %s, copying it: %s", p, err)
+			return gpath.CopyFile(p, target)
+		}
+		return nil
+	})
+}
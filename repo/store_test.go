@@ -0,0 +1,95 @@
+package repo
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	v "github.com/Ownercz/vcs"
+)
+
+func TestExportFromStore(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir, err := ioutil.TempDir("", "glide-store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	remote := filepath.Join(dir, "remote")
+	newTestRemote(t, remote, "a.go", "package a\n")
+	if err := os.Symlink("a.go", filepath.Join(remote, "b.go")); err != nil {
+		t.Fatal(err)
+	}
+	testGit(t, remote, "add", "b.go")
+	testGit(t, remote, "commit", "-q", "-m", "link")
+
+	repo, err := v.NewGitRepo(remote, filepath.Join(dir, "checkout"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Get(); err != nil {
+		t.Fatal(err)
+	}
+	rev, err := repo.Version()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	i := NewInstaller()
+	i.Home = filepath.Join(dir, "home")
+	first, second, copied := filepath.Join(dir, "one", "a"), filepath.Join(dir, "two", "a"), filepath.Join(dir, "three", "a")
+	if err := i.exportFromStore(repo, "example-key", copied); err != nil {
+		t.Fatalf("Unexpected error exporting to %s: %s", copied, err)
+	}
+
+	i.StoreLinks = true
+	for _, dest := range []string{first, second} {
+		if err := i.exportFromStore(repo, "example-key", dest); err != nil {
+			t.Fatalf("Unexpected error exporting to %s: %s", dest, err)
+		}
+		b, err := ioutil.ReadFile(filepath.Join(dest, "a.go"))
+		if err != nil || string(b) != "package a\n" {
+			t.Errorf("Expected a.go to be exported to %s", dest)
+		}
+	}
+
+	stored := filepath.Join(i.storeDir("example-key", rev), "a.go")
+	sfi, err := os.Stat(stored)
+	if err != nil {
+		t.Fatalf("Expected the revision to be in the store: %s", err)
+	}
+	fi1, err := os.Stat(filepath.Join(first, "a.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fi2, err := os.Stat(filepath.Join(second, "a.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !os.SameFile(sfi, fi1) || !os.SameFile(sfi, fi2) {
+		t.Error("Expected the exported files to be linked to the store")
+	}
+
+	// By default the files are copied so editing them leaves the store alone.
+	fi3, err := os.Stat(filepath.Join(copied, "a.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if os.SameFile(sfi, fi3) {
+		t.Error("Expected the exported files to be copied from the store")
+	}
+	for _, dest := range []string{first, copied} {
+		if l, err := os.Readlink(filepath.Join(dest, "b.go")); err != nil || l != "a.go" {
+			t.Errorf("Expected the symlink to be kept in %s, got %q (%v)", dest, l, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(first, ".git")); err == nil {
+		t.Error("Expected VCS metadata to not be exported")
+	}
+}
@@ -0,0 +1,94 @@
+package repo
+
+import (
+	"fmt"
+
+	"github.com/Ownercz/glide/cfg"
+	"github.com/Ownercz/glide/dependency"
+	"github.com/Ownercz/glide/msg"
+	"github.com/Ownercz/glide/util"
+)
+
+// Subtree resolves the dependencies of a single package in isolation and
+// returns them, including the dependency the package is part of, with their
+// versions set. The rest of the project is not resolved.
+//
+// The config is used as it would be for a full run. Its ignores apply and the
+// versions set in it are used. The config itself is not changed. Nothing is
+// exported to the vendor directory.
+func (i *Installer) Subtree(conf *cfg.Config, pkg string) ([]*cfg.Dependency, error) {
+	root, sub := util.NormalizeName(pkg)
+	if root == conf.Name {
+		return nil, fmt.Errorf("%s is part of the project rather than a dependency", pkg)
+	}
+	if conf.HasIgnore(pkg) {
+		return nil, fmt.Errorf("%s is ignored", pkg)
+	}
+	if sub == "" {
+		sub = "."
+	}
+
+	scoped := conf.Clone()
+	dep := scoped.Imports.Get(root)
+	if dep == nil {
+		dep = scoped.DevImports.Get(root)
+	}
+	if dep == nil {
+		dep = &cfg.Dependency{Name: root}
+		scoped.Imports = append(scoped.Imports, dep)
+	}
+
+	ic := newImportCache()
+	roots := &rootCache{}
+	m := &MissingPackageHandler{
+		Config:    scoped,
+		Use:       ic,
+		installer: i,
+		roots:     roots,
+	}
+	v := &VersionHandler{
+		Use:       ic,
+		Imported:  make(map[string]bool),
+		Conflicts: make(map[string]bool),
+		Config:    scoped,
+		Strategy:  i.ConflictStrategy,
+		installer: i,
+		roots:     roots,
+	}
+
+	res, err := dependency.NewResolver(i.basePath())
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create a resolver: %s", err)
+	}
+	res.Config = scoped
+	res.Handler = m
+	res.VersionHandler = v
+	res.ResolveAllFiles = i.ResolveAllFiles
+	res.VendorName = i.VendorName
+	res.StrictSubpackages = i.StrictSubpackages
+	i.setPlatform(res)
+
+	// The dependency is fetched and set to its version before it is scanned.
+	if err := ConcurrentUpdate([]*cfg.Dependency{dep}, i, scoped); err != nil {
+		return nil, err
+	}
+	if err := v.SetVersion(root, false); err != nil {
+		return nil, err
+	}
+
+	msg.Info("Resolving the imports of %s", pkg)
+	pkgs, err := allPackages([]*cfg.Dependency{{Name: root, Subpackages: []string{sub}}}, res, false)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to resolve %s: %s", pkg, err)
+	}
+	if err := v.conflictError(); err != nil {
+		return nil, err
+	}
+
+	scopeToPackages(scoped, append([]string{root}, pkgs...))
+	if err := i.SetReference(scoped); err != nil {
+		return nil, err
+	}
+
+	return append(scoped.Imports, scoped.DevImports...), nil
+}
@@ -0,0 +1,61 @@
+package repo
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/Ownercz/glide/cfg"
+)
+
+func TestSubtree(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir, err := ioutil.TempDir("", "glide-subtree")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// The sub dependency imports other. Unused is not imported by either.
+	sources := map[string]string{
+		"sub":    "package sub\n\nimport _ \"github.com/example/other\"\n",
+		"other":  "package other\n",
+		"unused": "package unused\n",
+	}
+	conf := &cfg.Config{Name: "example.com/app"}
+	for name, src := range sources {
+		remote := filepath.Join(dir, "remotes", name)
+		newTestRemote(t, remote, name+".go", src)
+		conf.Imports = append(conf.Imports, &cfg.Dependency{Name: "github.com/example/" + name, Repository: remote, VcsType: "git"})
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "app", "vendor"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	i := NewInstaller()
+	i.Base = filepath.Join(dir, "app")
+	i.Home = filepath.Join(dir, "home")
+	deps, err := i.Subtree(conf, "github.com/example/sub")
+	if err != nil {
+		t.Fatalf("Unexpected error resolving the subtree: %s", err)
+	}
+
+	found := make(map[string]bool)
+	for _, d := range deps {
+		found[d.Name] = true
+		if d.Pin == "" {
+			t.Errorf("Expected a version to be set for %s", d.Name)
+		}
+	}
+	if len(deps) != 2 || !found["github.com/example/sub"] || !found["github.com/example/other"] {
+		t.Errorf("Expected sub and other in the subtree, got %v", found)
+	}
+	if len(conf.Imports) != 3 || conf.Imports[0].Pin != "" {
+		t.Error("Expected the config not to be changed")
+	}
+}
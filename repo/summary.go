@@ -0,0 +1,74 @@
+package repo
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Ownercz/glide/msg"
+)
+
+// updateSummary counts how updating each dependency in ConcurrentUpdate
+// went so a single line can be logged once all of them are done. This is a
+// concurrency safe implementation and its zero value is ready to use.
+type updateSummary struct {
+	sync.Mutex
+
+	updated, skipped int
+	failed           []string
+}
+
+// add records the outcome of updating a dependency.
+func (s *updateSummary) add(name string, err error) {
+	s.Lock()
+	defer s.Unlock()
+	if err != nil {
+		s.failed = append(s.failed, name)
+	} else {
+		s.updated++
+	}
+}
+
+// skip records dependencies that were not updated, such as ignored ones.
+func (s *updateSummary) skip(n int) {
+	s.Lock()
+	s.skipped += n
+	s.Unlock()
+}
+
+// String returns the summary, naming the dependencies that failed.
+func (s *updateSummary) String() string {
+	s.Lock()
+	defer s.Unlock()
+
+	l := fmt.Sprintf("Updated %d dependencies", s.updated)
+	if s.updated == 1 {
+		l = "Updated 1 dependency"
+	}
+	if s.skipped > 0 {
+		l += fmt.Sprintf(", %d skipped", s.skipped)
+	}
+	if len(s.failed) > 0 {
+		failed := append([]string(nil), s.failed...)
+		sort.Strings(failed)
+		l += fmt.Sprintf(", %d failed: %s", len(failed), strings.Join(failed, ", "))
+	}
+	return l
+}
+
+// log logs the summary, as a warning when any dependency failed. Nothing is
+// logged when there were no dependencies.
+func (s *updateSummary) log() {
+	s.Lock()
+	failed, total := len(s.failed), s.updated+s.skipped+len(s.failed)
+	s.Unlock()
+
+	switch {
+	case total == 0:
+	case failed > 0:
+		msg.Warn("%s", s)
+	default:
+		msg.Info("%s", s)
+	}
+}
@@ -0,0 +1,37 @@
+package repo
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestUpdateSummary(t *testing.T) {
+	s := &updateSummary{}
+	var wg sync.WaitGroup
+	for ii := 0; ii < 50; ii++ {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			var err error
+			if n%25 == 3 {
+				err = errors.New("unable to fetch")
+			}
+			s.add(fmt.Sprintf("example.com/dep%d", n), err)
+		}(ii)
+	}
+	wg.Wait()
+	s.skip(3)
+
+	expected := "Updated 48 dependencies, 3 skipped, 2 failed: example.com/dep28, example.com/dep3"
+	if s.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, s.String())
+	}
+
+	s = &updateSummary{}
+	s.add("example.com/a", nil)
+	if s.String() != "Updated 1 dependency" {
+		t.Errorf("Expected only the updated count without skips or failures, got %q", s.String())
+	}
+}
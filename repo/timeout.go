@@ -0,0 +1,118 @@
+package repo
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/Ownercz/glide/msg"
+	v "github.com/Ownercz/vcs"
+)
+
+// runGit runs git in a directory. With OpTimeout the process is killed once
+// it runs longer and an error saying it timed out is returned, which counts
+// as a transient failure for retries.
+func (i *Installer) runGit(dir string, args ...string) ([]byte, error) {
+	return i.runGitEnv(dir, nil, args...)
+}
+
+// runRemoteGit runs git like runGit for a command reaching a remote, with the
+// environment and options from gitEnv.
+func (i *Installer) runRemoteGit(dir, remote string, args ...string) ([]byte, error) {
+	env, opts := i.gitEnv(remote)
+	return i.runGitEnv(dir, env, append(opts, args...)...)
+}
+
+// runGitEnv runs git like runGit with variables added to its environment.
+// With OpTimeout git is started in its own process group and the whole group
+// is killed so the processes it started, such as a remote helper, are stopped
+// too rather than holding on to its output.
+func (i *Installer) runGitEnv(dir string, env []string, args ...string) ([]byte, error) {
+	c := exec.Command("git", args...)
+	c.Dir = dir
+	if len(env) > 0 {
+		c.Env = append(os.Environ(), env...)
+	}
+	if i == nil || i.OpTimeout <= 0 {
+		return c.CombinedOutput()
+	}
+
+	var out bytes.Buffer
+	c.Stdout = &out
+	c.Stderr = &out
+	setProcessGroup(c)
+	if err := c.Start(); err != nil {
+		return nil, err
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- c.Wait() }()
+	select {
+	case err := <-done:
+		return out.Bytes(), err
+	case <-time.After(i.OpTimeout):
+		killProcessGroup(c)
+		<-done
+		err := fmt.Errorf("git %s timed out after %s", gitCommand(args), i.OpTimeout)
+		msg.Warn("%s in %s", err, dir)
+		return out.Bytes(), err
+	}
+}
+
+// gitCommand returns the git command in its arguments, skipping the -c options
+// before it.
+func gitCommand(args []string) string {
+	for n := 0; n < len(args); n++ {
+		if args[n] == "-c" {
+			n++
+			continue
+		}
+		return args[n]
+	}
+	return ""
+}
+
+// update fetches the updates of a repository in the cache. When the Installer
+// has an OpTimeout, or gitEnv for the remote, a git repository is updated by
+// running the same commands as the vcs package with runGit so a hung one is
+// stopped and the key and credentials are used. There is no way to stop the other VCS so
+// they are updated without a timeout.
+func (i *Installer) update(repo v.Repo) error {
+	g, ok := repo.(*v.GitRepo)
+	if !ok {
+		return repo.Update()
+	}
+	env, opts := i.gitEnv(g.Remote())
+	if i == nil || (i.OpTimeout <= 0 && env == nil && opts == nil) {
+		return repo.Update()
+	}
+
+	dir := g.LocalPath()
+	git := func(args ...string) ([]byte, error) {
+		return i.runGitEnv(dir, env, append(opts, args...)...)
+	}
+	if out, err := git("fetch", "--tags", g.RemoteLocation); err != nil {
+		return v.NewRemoteError("Unable to update repository", err, string(out))
+	}
+
+	// A detached head, such as a commit being checked out, has no branch to
+	// pull.
+	if _, err := git("symbolic-ref", "-q", "HEAD"); err == nil {
+		if out, err := git("pull"); err != nil {
+			return v.NewRemoteError("Unable to update repository", err, string(out))
+		}
+	}
+
+	for _, args := range [][]string{
+		{"submodule", "update", "--init", "--recursive"},
+		{"clean", "-x", "-d", "-f", "-f"},
+		{"submodule", "foreach", "--recursive", "git clean -x -d -f -f"},
+	} {
+		if out, err := git(args...); err != nil {
+			return v.NewLocalError("Unable to update the submodules of the repository", err, string(out))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package repo
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunGitTimeout(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir, err := ioutil.TempDir("", "glide-timeout")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	newTestRemote(t, dir)
+	testGit(t, dir, "config", "alias.hang", "!sleep 30")
+
+	i := NewInstaller()
+	i.OpTimeout = 100 * time.Millisecond
+	start := time.Now()
+	_, err = i.runGit(dir, "hang")
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("Expected the command to time out, got %v", err)
+	}
+	if d := time.Since(start); d > 10*time.Second {
+		t.Errorf("Expected the command to be stopped, it took %s", d)
+	}
+	if !transientError(err) {
+		t.Error("Expected a timeout to be retried")
+	}
+
+	i.OpTimeout = 0
+	if out, err := i.runGit(dir, "rev-parse", "--git-dir"); err != nil || strings.TrimSpace(string(out)) != ".git" {
+		t.Errorf("Expected a command to run without a timeout, got %s %v", out, err)
+	}
+}
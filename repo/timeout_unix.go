@@ -0,0 +1,21 @@
+// +build !windows
+
+package repo
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup starts a command in a process group of its own.
+func setProcessGroup(c *exec.Cmd) {
+	c.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// killProcessGroup kills a command started with setProcessGroup along with
+// every process it started.
+func killProcessGroup(c *exec.Cmd) {
+	if c.Process != nil {
+		syscall.Kill(-c.Process.Pid, syscall.SIGKILL)
+	}
+}
@@ -0,0 +1,16 @@
+// +build windows
+
+package repo
+
+import "os/exec"
+
+// setProcessGroup does nothing on Windows where a command is killed on its
+// own.
+func setProcessGroup(c *exec.Cmd) {}
+
+// killProcessGroup kills a command. The processes it started are not.
+func killProcessGroup(c *exec.Cmd) {
+	if c.Process != nil {
+		c.Process.Kill()
+	}
+}
@@ -0,0 +1,98 @@
+package repo
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Ownercz/glide/cfg"
+	"github.com/Ownercz/glide/dependency"
+	"github.com/Ownercz/glide/msg"
+	"github.com/Ownercz/glide/util"
+)
+
+// importGraph returns the import graph of dependencies, mapping each root
+// package to those it imports. The graph recorded by the resolver during
+// Update is used when there is one. Otherwise the packages of each
+// dependency in use, the root package and its subpackages, are scanned in
+// the cache.
+func (i *Installer) importGraph(deps []*cfg.Dependency) map[string][]string {
+	if i.graph != nil {
+		return i.graph
+	}
+
+	g := make(map[string][]string, len(deps))
+	for _, dep := range deps {
+		key, err := i.cacheKey(dep)
+		if err != nil {
+			continue
+		}
+		dir := filepath.Join(i.cacheLocation(), "src", key)
+
+		found := make(map[string]bool)
+		for _, sp := range append([]string{"."}, dep.Subpackages...) {
+			imps, _, err := dependency.IterativeScan(filepath.Join(dir, filepath.FromSlash(sp)))
+			if err != nil {
+				msg.Debug("Unable to scan %s/%s for imports: %s", dep.Name, sp, err)
+				continue
+			}
+			for _, imp := range imps {
+				root, _ := util.NormalizeName(imp)
+				if root != dep.Name && !found[root] {
+					found[root] = true
+					g[dep.Name] = append(g[dep.Name], root)
+				}
+			}
+		}
+		sort.Strings(g[dep.Name])
+	}
+
+	return g
+}
+
+// topoLevels orders dependencies by the import graph. Each level holds the
+// dependencies that only import those in earlier levels, in the order given.
+// Dependencies in an import cycle can't be ordered so they are put in a final
+// level together.
+func topoLevels(deps []*cfg.Dependency, graph map[string][]string) [][]*cfg.Dependency {
+	pending := make(map[string]bool, len(deps))
+	for _, dep := range deps {
+		pending[dep.Name] = true
+	}
+
+	levels := [][]*cfg.Dependency{}
+	remaining := deps
+	for len(remaining) > 0 {
+		level, next := []*cfg.Dependency{}, []*cfg.Dependency{}
+		for _, dep := range remaining {
+			ready := true
+			for _, imp := range graph[dep.Name] {
+				if pending[imp] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				level = append(level, dep)
+			} else {
+				next = append(next, dep)
+			}
+		}
+
+		if len(level) == 0 {
+			names := make([]string, len(next))
+			for ii, dep := range next {
+				names[ii] = dep.Name
+			}
+			msg.Warn("Import cycle between %s. They are not ordered", strings.Join(names, ", "))
+			return append(levels, next)
+		}
+		for _, dep := range level {
+			delete(pending, dep.Name)
+		}
+		levels = append(levels, level)
+		remaining = next
+	}
+
+	return levels
+}
@@ -0,0 +1,89 @@
+package repo
+
+import (
+	"path/filepath"
+	"regexp"
+
+	"github.com/Ownercz/glide/cfg"
+	"github.com/Ownercz/semver"
+)
+
+// The reasons a dependency is not pinned to a concrete version.
+const (
+	// UnpinnedNone is a dependency without a version in the config or a
+	// revision in the lock file.
+	UnpinnedNone = "none"
+
+	// UnpinnedLock is a dependency without a version in the config that is
+	// only held in place by the lock file. Updating moves it.
+	UnpinnedLock = "lock"
+
+	// UnpinnedBranch is a dependency whose version is a branch.
+	UnpinnedBranch = "branch"
+)
+
+// commitID matches references that look like a commit id.
+var commitID = regexp.MustCompile(`^[0-9a-f]{7,40}$`)
+
+// UnpinnedDependency is a dependency whose version can change without its
+// config changing.
+type UnpinnedDependency struct {
+	Name      string
+	Reference string
+	Reason    string
+}
+
+// Unpinned returns the dependencies that are not pinned to a concrete
+// version, in the order given. The lock file can be nil. Whether a reference
+// is a branch is checked in the cache. A dependency not in the cache has its
+// reference taken as a branch unless it looks like a commit id or semantic
+// version. Semantic version ranges are not listed.
+func (i *Installer) Unpinned(deps cfg.Dependencies, lock *cfg.Lockfile) []UnpinnedDependency {
+	locked := make(map[string]bool)
+	if lock != nil {
+		for _, l := range append(lock.Imports, lock.DevImports...) {
+			locked[l.Name] = l.Version != ""
+		}
+	}
+
+	unpinned := []UnpinnedDependency{}
+	for _, dep := range deps {
+		u := UnpinnedDependency{Name: dep.Name, Reference: dep.Reference}
+		switch {
+		case isFloating(dep) && locked[dep.Name]:
+			u.Reason = UnpinnedLock
+		case isFloating(dep):
+			u.Reason = UnpinnedNone
+		case i.referenceIsBranch(dep):
+			u.Reason = UnpinnedBranch
+		default:
+			continue
+		}
+		unpinned = append(unpinned, u)
+	}
+
+	return unpinned
+}
+
+// referenceIsBranch returns if the reference of a dependency is a branch,
+// using the copy in the cache when there is one.
+func (i *Installer) referenceIsBranch(dep *cfg.Dependency) bool {
+	key, err := i.cacheKey(dep)
+	if err == nil {
+		repo, err := dep.GetRepo(filepath.Join(i.cacheLocation(), "src", key))
+		if err == nil && repo.CheckLocal() {
+			ib, err := isBranch(dep.Reference, repo)
+			if err == nil {
+				return ib
+			}
+		}
+	}
+
+	if commitID.MatchString(dep.Reference) {
+		return false
+	}
+	if _, err := semver.NewConstraint(dep.Reference); err == nil {
+		return false
+	}
+	return true
+}
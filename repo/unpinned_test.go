@@ -0,0 +1,84 @@
+package repo
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/Ownercz/glide/cfg"
+)
+
+func TestUnpinned(t *testing.T) {
+	dir, err := ioutil.TempDir("", "glide-unpinned")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	i := NewInstaller()
+	i.Home = filepath.Join(dir, "home")
+
+	deps := cfg.Dependencies{
+		{Name: "github.com/example/none"},
+		{Name: "github.com/example/locked"},
+		{Name: "github.com/example/branch", Reference: "master"},
+		{Name: "github.com/example/commit", Reference: "3f4c3bea144e112a69bbe5d8d01c1b09a544253f"},
+		{Name: "github.com/example/semver", Reference: "^1.2.0"},
+	}
+	lock := &cfg.Lockfile{Imports: cfg.Locks{
+		{Name: "github.com/example/locked", Version: "3f4c3bea144e112a69bbe5d8d01c1b09a544253f"},
+	}}
+
+	expect := []UnpinnedDependency{
+		{Name: "github.com/example/none", Reason: UnpinnedNone},
+		{Name: "github.com/example/locked", Reason: UnpinnedLock},
+		{Name: "github.com/example/branch", Reference: "master", Reason: UnpinnedBranch},
+	}
+	u := i.Unpinned(deps, lock)
+	if len(u) != len(expect) {
+		t.Fatalf("Expected %d unpinned dependencies, got %v", len(expect), u)
+	}
+	for ii, e := range expect {
+		if u[ii] != e {
+			t.Errorf("Expected %+v, got %+v", e, u[ii])
+		}
+	}
+
+	if u := i.Unpinned(deps[1:2], nil); len(u) != 1 || u[0].Reason != UnpinnedNone {
+		t.Errorf("Expected a dependency without a lock file to have no version, got %v", u)
+	}
+}
+
+func TestUnpinnedCache(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir, err := ioutil.TempDir("", "glide-unpinned")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	remote := filepath.Join(dir, "remote")
+	newTestRemote(t, remote)
+	testGit(t, remote, "branch", "release")
+	testGit(t, remote, "tag", "stable")
+
+	i := NewInstaller()
+	i.Home = filepath.Join(dir, "home")
+	dep := &cfg.Dependency{Name: "github.com/example/cached", Repository: remote, VcsType: "git"}
+	if err := i.VcsGet(dep); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without the cache both would be taken as branches.
+	tag := &cfg.Dependency{Name: dep.Name, Repository: remote, VcsType: "git", Reference: "stable"}
+	branch := &cfg.Dependency{Name: dep.Name, Repository: remote, VcsType: "git", Reference: "release"}
+	u := i.Unpinned(cfg.Dependencies{tag, branch}, nil)
+	if len(u) != 1 || u[0].Reference != "release" || u[0].Reason != UnpinnedBranch {
+		t.Errorf("Expected only the branch to be unpinned, got %v", u)
+	}
+}
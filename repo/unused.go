@@ -0,0 +1,162 @@
+package repo
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Ownercz/glide/cfg"
+	"github.com/Ownercz/glide/msg"
+)
+
+// The policies for imports in the config that the project does not import.
+const (
+	// UnusedWarn lists the unused imports but still fetches them.
+	UnusedWarn = "warn"
+
+	// UnusedSkip lists the unused imports and leaves them out so they are not
+	// fetched, exported or written to the lock file.
+	UnusedSkip = "skip"
+)
+
+// checkUnused finds the imports in the config whose packages are not among
+// those resolved and applies the Unused policy to them. Without a policy
+// nothing is done as some projects list tools that are not imported.
+func (i *Installer) checkUnused(conf *cfg.Config, used map[string]bool) {
+	if i.Unused != UnusedWarn && i.Unused != UnusedSkip {
+		return
+	}
+
+	kept := make(cfg.Dependencies, 0, len(conf.Imports))
+	for _, dep := range conf.Imports {
+		if used[dep.Name] || conf.HasIgnore(dep.Name) {
+			kept = append(kept, dep)
+			continue
+		}
+
+		i.unused = append(i.unused, dep.Name)
+		if i.Unused == UnusedSkip {
+			msg.Debug("%s is not imported. Skipping it", dep.Name)
+			continue
+		}
+		kept = append(kept, dep)
+	}
+	conf.Imports = kept
+}
+
+// UnusedImports returns the imports in the config found to not be imported by the
+// project while updating. They are only checked when Unused is set.
+func (i *Installer) UnusedImports() []string {
+	u := make([]string, len(i.unused))
+	copy(u, i.unused)
+	return u
+}
+
+// LogUnused displays the imports in the config that are not imported by the
+// project, if any.
+func (i *Installer) LogUnused() {
+	if len(i.unused) == 0 {
+		return
+	}
+
+	if i.Unused == UnusedSkip {
+		msg.Warn("%d imports in the config are not imported by the project and were skipped: %s", len(i.unused), strings.Join(i.unused, ", "))
+	} else {
+		msg.Warn("%d imports in the config are not imported by the project: %s", len(i.unused), strings.Join(i.unused, ", "))
+	}
+}
+
+// exportDeps returns the dependencies Export puts in the vendor directory.
+// These are the imports plus, when ResolveTest is set, the test imports that
+// are neither ignored, quarantined nor for another platform. The test imports
+// Install skipped are left out, see keptDeps.
+func (i *Installer) exportDeps(conf *cfg.Config) []*cfg.Dependency {
+	deps := []*cfg.Dependency{}
+	for _, dep := range i.vendorScope(conf) {
+		if !i.skippedDev[dep.Name] {
+			deps = append(deps, dep)
+		}
+	}
+	return deps
+}
+
+// keptDeps returns the test imports Install skipped that Export would
+// otherwise put in the vendor directory. Their packages already there are
+// kept rather than deleted as installing the test imports brings them back.
+func (i *Installer) keptDeps(conf *cfg.Config) []*cfg.Dependency {
+	deps := []*cfg.Dependency{}
+	for _, dep := range i.vendorScope(conf) {
+		if i.skippedDev[dep.Name] {
+			deps = append(deps, dep)
+		}
+	}
+	return deps
+}
+
+// vendorScope returns the imports plus, when ResolveTest is set, the test
+// imports that are neither ignored, quarantined nor for another platform.
+func (i *Installer) vendorScope(conf *cfg.Config) []*cfg.Dependency {
+	scope := conf.Imports
+	if i.ResolveTest {
+		scope = append(append(cfg.Dependencies{}, conf.Imports...), conf.DevImports...)
+	}
+
+	deps := []*cfg.Dependency{}
+	for _, dep := range scope {
+		if !conf.HasIgnore(dep.Name) && !i.isQuarantined(dep.Name) && !filterArchOs(dep, i) {
+			deps = append(deps, dep)
+		}
+	}
+	return deps
+}
+
+// UnusedVendored returns the packages in the vendor directory that are not
+// part of a dependency Export puts there or keeps. They are deleted when the vendor
+// directory is replaced. The packages are sorted paths relative to the vendor
+// directory. Nothing is changed.
+func (i *Installer) UnusedVendored(conf *cfg.Config) ([]string, error) {
+	vendor := i.VendorPath()
+	unused := []string{}
+	if _, err := os.Stat(vendor); os.IsNotExist(err) {
+		return unused, nil
+	}
+
+	kept := make(map[string]bool)
+	for _, dep := range i.vendorScope(conf) {
+		dir, err := i.vendorDir(vendor, dep.Name)
+		if err != nil {
+			return nil, err
+		}
+		kept[dir] = true
+	}
+
+	found := make(map[string]bool)
+	err := filepath.Walk(vendor, func(p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fi.IsDir() && kept[p] {
+			return filepath.SkipDir
+		}
+		if fi.IsDir() || !strings.HasSuffix(p, ".go") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(vendor, filepath.Dir(p))
+		if err != nil || rel == "." {
+			return err
+		}
+		if pkg := filepath.ToSlash(rel); !found[pkg] {
+			found[pkg] = true
+			unused = append(unused, pkg)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(unused)
+	return unused, nil
+}
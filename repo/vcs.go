@@ -8,53 +8,88 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
-	"runtime"
 	"sort"
 	"strings"
+	"time"
 
 	cp "github.com/Ownercz/glide/cache"
 	"github.com/Ownercz/glide/cfg"
 	"github.com/Ownercz/glide/msg"
 	gpath "github.com/Ownercz/glide/path"
+	"github.com/Ownercz/glide/util"
 	"github.com/Ownercz/semver"
 	v "github.com/Ownercz/vcs"
 )
 
 // VcsUpdate updates to a particular checkout based on the VCS setting.
+//
+// It uses the default settings of an Installer other than force, and
+// updated to track the dependencies already updated. See Installer.VcsUpdate.
 func VcsUpdate(dep *cfg.Dependency, force bool, updated *UpdateTracker) error {
+	i := NewInstaller()
+	i.Force = force
+	if updated != nil {
+		i.Updated = updated
+	}
+	return i.VcsUpdate(dep)
+}
+
+// VcsUpdate updates to a particular checkout based on the VCS setting.
+//
+// The Installer supplies the options (such as Force) used while fetching.
+// A nil Installer uses the default settings.
+func (i *Installer) VcsUpdate(dep *cfg.Dependency) (err error) {
+	if i == nil {
+		i = NewInstaller()
+	}
+	defer func() { err = i.authError(dep.Name, err) }()
 
 	// If the dependency has already been pinned we can skip it. This is a
 	// faster path so we don't need to resolve it again.
 	if dep.Pin != "" {
 		msg.Debug("Dependency %s has already been pinned. Fetching updates skipped", dep.Name)
+		i.countMetric(func(m *Metrics) { m.Skipped++ })
+		return nil
+	}
+
+	if i.inOverlay(dep.Name) || i.fromOverlay(dep.Name) {
+		msg.Debug("%s is used from a local copy. Fetching skipped", dep.Name)
+		i.countMetric(func(m *Metrics) { m.Skipped++ })
 		return nil
 	}
 
-	if updated.Check(dep.Name) {
+	if i.Updated.Check(dep.Name) {
 		msg.Debug("%s was already updated, skipping", dep.Name)
+		i.countMetric(func(m *Metrics) { m.Skipped++ })
 		return nil
 	}
-	updated.Add(dep.Name)
+	i.Updated.Add(dep.Name)
 
-	if filterArchOs(dep) {
-		msg.Info("%s is not used for %s/%s.\n", dep.Name, runtime.GOOS, runtime.GOARCH)
+	if filterArchOs(dep, i) {
+		goos, goarch := i.platform()
+		msg.Info("%s is not used for %s/%s.\n", dep.Name, goos, goarch)
+		i.countMetric(func(m *Metrics) { m.OtherPlatform++ })
 		return nil
 	}
 
-	key, err := cp.Key(dep.Remote())
+	key, err := i.cacheKey(dep)
 	if err != nil {
-		msg.Die("Cache key generation error: %s", err)
+		return fmt.Errorf("Cache key generation error: %s", err)
 	}
-	location := cp.Location()
+	location := i.cacheLocation()
 	dest := filepath.Join(location, "src", key)
 
 	// If destination doesn't exist we need to perform an initial checkout.
 	if _, err := os.Stat(dest); os.IsNotExist(err) {
 		msg.Info("--> Fetching %s", dep.Name)
-		if err = VcsGet(dep); err != nil {
+		if err = i.VcsGet(dep); err != nil {
 			msg.Warn("Unable to checkout %s\n", dep.Name)
 			return err
 		}
+		i.markFetched(key)
+	} else if isSymlink(dest) && !i.Force {
+		msg.Debug("%s is a symlink to a local checkout. Skipping update", dest)
+		i.countMetric(func(m *Metrics) { m.Skipped++ })
 	} else {
 		// At this point we have a directory for the package.
 		msg.Info("--> Fetching updates for %s", dep.Name)
@@ -73,7 +108,7 @@ func VcsUpdate(dep *cfg.Dependency, force bool, updated *UpdateTracker) error {
 			if err != nil {
 				return err
 			}
-			if err = VcsGet(dep); err != nil {
+			if err = i.VcsGet(dep); err != nil {
 				msg.Warn("Unable to checkout %s\n", dep.Name)
 				return err
 			}
@@ -85,7 +120,7 @@ func VcsUpdate(dep *cfg.Dependency, force bool, updated *UpdateTracker) error {
 			// location can be removed and replaced with the new one.
 			// Warning, any changes in the old location will be deleted.
 			// TODO: Put dirty checking in on the existing local checkout.
-			if (err == v.ErrWrongVCS || err == v.ErrWrongRemote) && force == true {
+			if (err == v.ErrWrongVCS || err == v.ErrWrongRemote) && i.Force {
 				newRemote := dep.Remote()
 
 				msg.Warn("Replacing %s with contents from %s\n", dep.Name, newRemote)
@@ -93,7 +128,7 @@ func VcsUpdate(dep *cfg.Dependency, force bool, updated *UpdateTracker) error {
 				if rerr != nil {
 					return rerr
 				}
-				if err = VcsGet(dep); err != nil {
+				if err = i.VcsGet(dep); err != nil {
 					msg.Warn("Unable to checkout %s\n", dep.Name)
 					return err
 				}
@@ -103,15 +138,16 @@ func VcsUpdate(dep *cfg.Dependency, force bool, updated *UpdateTracker) error {
 					return err
 				}
 			} else if err != nil {
-				return err
+				return cacheVcsError(dep, dest, err)
 			} else if repo.IsDirty() {
 				return fmt.Errorf("%s contains uncommitted changes. Skipping update", dep.Name)
 			}
 
 			ver := dep.Reference
-			if ver == "" {
-				ver = defaultBranch(repo)
+			if isFloating(dep) {
+				ver = floatingBranch(repo, i)
 			}
+			missing := missingRevision(repo, ver)
 			// Check if the current version is a tag or commit id. If it is
 			// and that version is already checked out we can skip updating
 			// which is faster than going out to the Internet to perform
@@ -131,22 +167,68 @@ func VcsUpdate(dep *cfg.Dependency, force bool, updated *UpdateTracker) error {
 				// performing an update.
 				if version == ver && !ib {
 					msg.Debug("%s is already set to version %s. Skipping update", dep.Name, dep.Reference)
+					i.countMetric(func(m *Metrics) { m.Skipped++ })
+					return nil
+				}
+
+				// With a cache TTL a tag or commit already in the cache
+				// never changes so it does not need to be fetched again.
+				if ttl := cacheTTL(dep, i); ttl > 0 && !ib && !isFloating(dep) && hasReference(repo, ver) {
+					msg.Debug("%s %s is in the cache. Skipping update", dep.Name, ver)
+					i.countMetric(func(m *Metrics) { m.Skipped++ })
+					return nil
+				}
+			}
+
+			// A pinned commit missing from the cache was likely made after
+			// it was last fetched. Fetching only that commit is quicker
+			// than fetching all updates and also finds commits no longer
+			// on a branch.
+			if missing && i.MissingRevision != MissingRevisionRefresh {
+				err := i.fetchRevision(repo, ver)
+				if err == nil && !missingRevision(repo, ver) {
+					msg.Debug("Fetched the missing revision %s of %s", ver, dep.Name)
+					i.countMetric(func(m *Metrics) { m.Updated++ })
 					return nil
 				}
+				msg.Debug("Unable to fetch the revision %s of %s alone, fetching all updates: %v", ver, dep.Name, err)
+			}
+
+			if ttl := cacheTTL(dep, i); ttl > 0 && !missing && i.fetchedWithin(key, ttl) {
+				msg.Debug("%s was fetched within %s. Skipping update", dep.Name, ttl)
+				i.countMetric(func(m *Metrics) { m.Skipped++ })
+				return nil
 			}
 
-			if err := repo.Update(); err != nil {
+			if err := i.retry(dep.Name, func() error { return i.update(repo) }); err != nil {
 				msg.Warn("Download failed.\n")
 				return err
 			}
+			i.markFetched(key)
+			i.countMetric(func(m *Metrics) { m.Updated++ })
 		}
 	}
 
 	return nil
 }
 
-// VcsVersion set the VCS version for a checkout.
+// VcsVersion set the VCS version for a checkout using the default settings of
+// an Installer. See Installer.VcsVersion.
 func VcsVersion(dep *cfg.Dependency) error {
+	return NewInstaller().VcsVersion(dep)
+}
+
+// VcsVersion set the VCS version for a checkout.
+//
+// When the dependency has no reference the Installer's PreferredBranch is
+// checked out if the repository has it, or with LatestTag its highest
+// semantic version tag. With the Installer's Snapshot the versions are those
+// at that time. A nil Installer uses the default settings.
+func (i *Installer) VcsVersion(dep *cfg.Dependency) error {
+	if i == nil {
+		i = NewInstaller()
+	}
+	defer i.promptLock()()
 
 	// If the dependency has already been pinned we can skip it. This is a
 	// faster path so we don't need to resolve it again.
@@ -154,21 +236,48 @@ func VcsVersion(dep *cfg.Dependency) error {
 		msg.Debug("Dependency %s has already been pinned. Setting version skipped", dep.Name)
 		return nil
 	}
+	if i.inOverlay(dep.Name) {
+		msg.Debug("%s is used from a local copy. Setting version skipped", dep.Name)
+		return nil
+	}
 
-	key, err := cp.Key(dep.Remote())
+	key, err := i.cacheKey(dep)
 	if err != nil {
-		msg.Die("Cache key generation error: %s", err)
+		return fmt.Errorf("Cache key generation error: %s", err)
 	}
-	location := cp.Location()
+	location := i.cacheLocation()
 	cwd := filepath.Join(location, "src", key)
 
-	// If there is no reference configured there is nothing to set.
-	if dep.Reference == "" {
+	// If there is no reference configured, or it is latest, the newest commit
+	// on the branch being followed is used.
+	if isFloating(dep) {
 		// Before exiting update the pinned version
 		repo, err := dep.GetRepo(cwd)
 		if err != nil {
 			return err
 		}
+		if useLatestTag(dep, i) {
+			tag, err := latestTag(dep, repo, i)
+			if err != nil {
+				return err
+			}
+			if tag != "" {
+				msg.Info("--> Setting version for %s to its latest tag %s.\n", dep.Name, tag)
+				if err := repo.UpdateVersion(tag); err != nil {
+					return err
+				}
+				dep.Pin, err = repo.Version()
+				return err
+			}
+			msg.Warn("%s has no semantic version tags, using the newest commit instead", dep.Name)
+		}
+		if err := checkoutFloating(dep, repo, i); err != nil {
+			// The current checkout would not match the snapshot.
+			if hasSnapshot(i) {
+				return err
+			}
+			msg.Warn("Unable to check out the newest commit for %s, using the current checkout: %s", dep.Name, err)
+		}
 		dep.Pin, err = repo.Version()
 		if err != nil {
 			return err
@@ -193,11 +302,36 @@ func VcsVersion(dep *cfg.Dependency) error {
 	}
 
 	ver := dep.Reference
+	if err := i.deepen(repo, ver); err != nil {
+		return err
+	}
+	if missingReference(repo, ver) {
+		msg.Err("%s does not have the version %s. It may have been deleted or force pushed over", dep.Name, ver)
+		if !i.Force {
+			return fmt.Errorf("Version %s of %s does not exist", ver, dep.Name)
+		}
+		msg.Warn("Using the newest commit of %s instead as the install is forced", dep.Name)
+		if err := checkoutFloating(dep, repo, i); err != nil {
+			return err
+		}
+		dep.Pin, err = repo.Version()
+		return err
+	}
 	// References in Git can begin with a ^ which is similar to semver.
 	// If there is a ^ prefix we assume it's a semver constraint rather than
 	// part of the git/VCS commit id.
 	if repo.IsReference(ver) && !strings.HasPrefix(ver, "^") {
-		msg.Info("--> Setting version for %s to %s.\n", dep.Name, ver)
+		// A branch moves so it is resolved to the commit it was at.
+		if ib, err := isBranch(ver, repo); hasSnapshot(i) && err == nil && ib {
+			c, err := snapshotCommit(repo, ver, i.Snapshot, i)
+			if err != nil {
+				return err
+			}
+			msg.Info("--> Setting version for %s to %s as of %s (%s).\n", dep.Name, ver, i.Snapshot.Format(time.RFC3339), c)
+			ver = c
+		} else {
+			msg.Info("--> Setting version for %s to %s.\n", dep.Name, ver)
+		}
 	} else {
 
 		// Create the constraint first to make sure it's valid before
@@ -220,22 +354,33 @@ func VcsVersion(dep *cfg.Dependency) error {
 		// Convert and filter the list to semver.Version instances
 		semvers := getSemVers(refs)
 
+		// Without any versions to choose from the constraint can't mean
+		// anything so the newest commit is used.
+		if len(semvers) == 0 {
+			msg.Warn("%s has no semantic version tags to match %s against, using the newest commit instead", dep.Name, ver)
+			if err := checkoutFloating(dep, repo, i); err != nil {
+				return err
+			}
+			dep.Pin, err = repo.Version()
+			return err
+		}
+
 		// Sort semver list
 		sort.Sort(sort.Reverse(semver.Collection(semvers)))
+		pre := includePrerelease(dep, i)
 		found := false
 		for _, v := range semvers {
-			if constraint.Check(v) {
+			if checkConstraint(ver, constraint, v, pre) && beforeSnapshot(repo, v.Original(), i) {
 				found = true
 				// If the constrint passes get the original reference
 				ver = v.Original()
 				break
 			}
 		}
-		if found {
-			msg.Info("--> Detected semantic version. Setting version for %s to %s", dep.Name, ver)
-		} else {
-			msg.Warn("--> Unable to find semantic version for constraint %s %s", dep.Name, ver)
+		if !found {
+			return fmt.Errorf("No version of %s matches the constraint %s, the highest is %s", dep.Name, ver, semvers[0].Original())
 		}
+		msg.Info("--> Detected semantic version. Setting version for %s to %s", dep.Name, ver)
 	}
 	if err := repo.UpdateVersion(ver); err != nil {
 		return err
@@ -248,34 +393,204 @@ func VcsVersion(dep *cfg.Dependency) error {
 	return nil
 }
 
+// missingReference returns if a version is a commit, branch or tag the
+// repository does not have. Semantic version constraints, including single
+// versions, are matched against the tags later so they are not checked.
+func missingReference(repo v.Repo, ver string) bool {
+	if !commitID.MatchString(ver) {
+		if _, err := semver.NewConstraint(ver); err == nil {
+			return false
+		}
+	}
+	return !hasReference(repo, ver)
+}
+
+// hasReference returns if the repository has a commit, branch or tag. A
+// commit id is looked up with missingRevision as Git accepts any full commit
+// id as a reference.
+func hasReference(repo v.Repo, ver string) bool {
+	if commitID.MatchString(ver) {
+		return !missingRevision(repo, ver)
+	}
+	return repo.IsReference(ver)
+}
+
+// useLatestTag returns if a dependency without a version is resolved to its
+// highest semantic version tag. The setting on the dependency wins over the
+// Installer's. A reference of latest always follows the branch.
+func useLatestTag(dep *cfg.Dependency, i *Installer) bool {
+	if dep.Reference != "" {
+		return false
+	}
+
+	switch dep.Float {
+	case cfg.FloatTag:
+		return true
+	case cfg.FloatBranch:
+		return false
+	}
+
+	return i != nil && i.LatestTag
+}
+
+// latestTag returns the highest semantic version tag of a repository that
+// is a candidate for a dependency. It returns an empty string when there is
+// none.
+func latestTag(dep *cfg.Dependency, repo v.Repo, i *Installer) (string, error) {
+	if err := i.unshallow(repo); err != nil {
+		return "", err
+	}
+	tags, err := repo.Tags()
+	if err != nil {
+		return "", err
+	}
+
+	semvers := getSemVers(tags)
+	sort.Sort(sort.Reverse(semver.Collection(semvers)))
+	pre := includePrerelease(dep, i)
+	for _, sv := range semvers {
+		if sv.Prerelease() != "" && !pre {
+			continue
+		}
+		if beforeSnapshot(repo, sv.Original(), i) {
+			return sv.Original(), nil
+		}
+	}
+
+	return "", nil
+}
+
+// includePrerelease returns if pre-release tags are candidates for a semantic
+// version range. The setting on the dependency wins over the Installer's.
+func includePrerelease(dep *cfg.Dependency, i *Installer) bool {
+	switch dep.Prerelease {
+	case cfg.PrereleaseInclude:
+		return true
+	case cfg.PrereleaseExclude:
+		return false
+	}
+
+	return i != nil && i.IncludePrerelease
+}
+
+// checkConstraint checks a version against a constraint, c parsed from ver.
+// The semver package never matches a pre-release against a range without
+// one. When pre-releases are included they are checked using the release
+// they belong to. A pre-release comes before its release so it only matches
+// when the range does not start at that release, so v1.3.0-rc1 matches
+// ^1.2.0 but not ^1.3.0 or >=1.3.0.
+func checkConstraint(ver string, c *semver.Constraints, v *semver.Version, pre bool) bool {
+	if c.Check(v) {
+		return true
+	}
+	if !pre || v.Prerelease() == "" {
+		return false
+	}
+
+	rel, err := v.SetPrerelease("")
+	if err != nil {
+		return false
+	}
+	for _, or := range strings.Split(ver, "||") {
+		oc, err := semver.NewConstraint(or)
+		if err == nil && oc.Check(&rel) && !startsAt(or, &rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// startsAt returns if a range, without any ||, includes a version as its
+// lowest one.
+func startsAt(rng string, v *semver.Version) bool {
+	if i := strings.Index(rng, " - "); i != -1 {
+		rng = rng[:i]
+	}
+	for _, c := range strings.Split(rng, ",") {
+		c = strings.TrimSpace(c)
+		if strings.HasPrefix(c, "!") || strings.HasPrefix(c, "<") || strings.HasPrefix(c, "=<") || (strings.HasPrefix(c, ">") && !strings.HasPrefix(c, ">=")) {
+			continue
+		}
+		c = strings.TrimSpace(strings.TrimLeft(c, "=>~^"))
+		for _, x := range []string{".x", ".X", ".*"} {
+			for strings.HasSuffix(c, x) {
+				c = strings.TrimSuffix(c, x)
+			}
+		}
+		low, err := semver.NewVersion(c)
+		if err == nil && low.Prerelease() == "" && low.Major() == v.Major() && low.Minor() == v.Minor() && low.Patch() == v.Patch() {
+			return true
+		}
+	}
+	return false
+}
+
+// VcsGet figures out how to fetch a dependency, and then gets it, using the
+// default settings of an Installer. See Installer.VcsGet.
+func VcsGet(dep *cfg.Dependency) error {
+	return NewInstaller().VcsGet(dep)
+}
+
 // VcsGet figures out how to fetch a dependency, and then gets it.
 //
-// VcsGet installs into the cache.
-func VcsGet(dep *cfg.Dependency) error {
+// VcsGet installs into the cache. When the dependency declares a custom
+// checkout command it is used for the initial fetch in place of the VCS. A
+// nil Installer uses the default settings.
+func (i *Installer) VcsGet(dep *cfg.Dependency) (err error) {
+	if i == nil {
+		i = NewInstaller()
+	}
+	defer func() { err = i.authError(dep.Name, err) }()
+	defer i.promptLock()()
 
-	key, err := cp.Key(dep.Remote())
+	key, err := i.cacheKey(dep)
 	if err != nil {
-		msg.Die("Cache key generation error: %s", err)
+		return fmt.Errorf("Cache key generation error: %s", err)
+	}
+	location := i.cacheLocation()
+	if err := i.cacheError(); err != nil {
+		return err
 	}
-	location := cp.Location()
 	d := filepath.Join(location, "src", key)
 
 	repo, err := dep.GetRepo(d)
 	if err != nil {
-		return err
+		return cacheVcsError(dep, d, err)
 	}
 	// If the directory does not exist this is a first cache.
 	if _, err = os.Stat(d); os.IsNotExist(err) {
 		msg.Debug("Adding %s to the cache for the first time", dep.Name)
-		err = repo.Get()
+		if dep.Checkout != "" {
+			err = customCheckout(dep, d, i)
+		} else if err = i.retry(dep.Name, func() error {
+			// A failed attempt can leave a partial checkout behind.
+			if err := os.RemoveAll(d); err != nil {
+				return err
+			}
+			return i.clone(repo)
+		}); err != nil && len(dep.Fallbacks) > 0 {
+			// A failed fetch can leave a partial checkout behind.
+			if rerr := os.RemoveAll(d); rerr != nil {
+				return rerr
+			}
+			repo, err = i.getFromFallbacks(dep, filepath.Join(location, "src"), err)
+		}
 		if err != nil {
-			return err
+			return remoteVcsError(dep, err)
 		}
+		size := dirSize(d)
+		i.countMetric(func(m *Metrics) {
+			m.Cloned++
+			m.Bytes += size
+		})
 		branch := findCurrentBranch(repo)
 		if branch != "" {
 			msg.Debug("Saving default branch for %s", repo.Remote())
 			c := cp.RepoInfo{DefaultBranch: branch}
-			err = cp.SaveRepoData(key, c)
+			// The data is for the repository so it is shared by the
+			// major versions using it.
+			rkey, _ := i.repoKey(repo.Remote())
+			err = cp.SaveRepoDataAt(i.cacheLocation(), rkey, c)
 			if err == cp.ErrCacheDisabled {
 				msg.Debug("Unable to cache default branch because caching is disabled")
 			} else if err != nil {
@@ -284,24 +599,30 @@ func VcsGet(dep *cfg.Dependency) error {
 		}
 	} else {
 		msg.Debug("Updating %s in the cache", dep.Name)
-		err = repo.Update()
+		err = i.retry(dep.Name, func() error { return i.update(repo) })
+		if err != nil && len(dep.Fallbacks) > 0 {
+			// The existing checkout is left in place for a later run.
+			_, err = i.getFromFallbacks(dep, filepath.Join(location, "src"), err)
+		}
 		if err != nil {
 			return err
 		}
+		i.countMetric(func(m *Metrics) { m.Updated++ })
 	}
 
 	return nil
 }
 
 // filterArchOs indicates a dependency should be filtered out because it is
-// the wrong GOOS or GOARCH.
+// the wrong GOOS or GOARCH for the platform of the Installer.
 //
 // FIXME: Should this be moved to the dependency package?
-func filterArchOs(dep *cfg.Dependency) bool {
+func filterArchOs(dep *cfg.Dependency, i *Installer) bool {
+	goos, goarch := i.platform()
 	found := false
 	if len(dep.Arch) > 0 {
 		for _, a := range dep.Arch {
-			if a == runtime.GOARCH {
+			if a == goarch {
 				found = true
 			}
 		}
@@ -314,7 +635,7 @@ func filterArchOs(dep *cfg.Dependency) bool {
 	found = false
 	if len(dep.Os) > 0 {
 		for _, o := range dep.Os {
-			if o == runtime.GOOS {
+			if o == goos {
 				found = true
 			}
 		}
@@ -327,6 +648,67 @@ func filterArchOs(dep *cfg.Dependency) bool {
 	return false
 }
 
+// preferredBranch returns the Installer's PreferredBranch when the repo has a
+// branch by that name. Otherwise an empty string is returned.
+func preferredBranch(repo v.Repo, i *Installer) string {
+	if i == nil || i.PreferredBranch == "" {
+		return ""
+	}
+
+	ib, err := isBranch(i.PreferredBranch, repo)
+	if err != nil || !ib {
+		msg.Debug("Preferred branch %s not found for %s. Using the default branch", i.PreferredBranch, repo.Remote())
+		return ""
+	}
+
+	return i.PreferredBranch
+}
+
+// floatingBranch returns the branch to follow for a dependency without a
+// reference. This is the preferred branch when available or the default one.
+func floatingBranch(repo v.Repo, i *Installer) string {
+	if pb := preferredBranch(repo, i); pb != "" {
+		return pb
+	}
+
+	return defaultBranch(repo, i)
+}
+
+// latestReference is a version asking for the newest commit on the default,
+// or preferred, branch. It is recorded as a commit id in the lock file.
+const latestReference = "latest"
+
+// isFloating returns if a dependency follows a branch rather than a version.
+func isFloating(dep *cfg.Dependency) bool {
+	return dep.Reference == "" || dep.Reference == latestReference
+}
+
+// checkoutFloating checks out the newest commit of the branch followed by a
+// dependency without a version. With Git the remote tracking branch is used
+// as the local branch may be behind the last fetch.
+func checkoutFloating(dep *cfg.Dependency, repo v.Repo, i *Installer) error {
+	b := floatingBranch(repo, i)
+	if b == "" {
+		return nil
+	}
+
+	ver := b
+	if g, ok := repo.(*v.GitRepo); ok {
+		ver = g.RemoteLocation + "/" + b
+	}
+	if hasSnapshot(i) {
+		c, err := snapshotCommit(repo, b, i.Snapshot, i)
+		if err != nil {
+			return err
+		}
+		msg.Info("--> Setting version for %s to the newest on %s as of %s.\n", dep.Name, b, i.Snapshot.Format(time.RFC3339))
+		return repo.UpdateVersion(c)
+	}
+	msg.Info("--> Setting version for %s to the newest on %s.\n", dep.Name, b)
+
+	return repo.UpdateVersion(ver)
+}
+
 // isBranch returns true if the given string is a branch in VCS.
 func isBranch(branch string, repo v.Repo) (bool, error) {
 	branches, err := repo.Branches()
@@ -344,7 +726,7 @@ func isBranch(branch string, repo v.Repo) (bool, error) {
 // defaultBranch tries to ascertain the default branch for the given repo.
 // Some repos will have multiple branches in them (e.g. Git) while others
 // (e.g. Svn) will not.
-func defaultBranch(repo v.Repo) string {
+func defaultBranch(repo v.Repo, i *Installer) string {
 
 	// Svn and Bzr use different locations (paths or entire locations)
 	// for branches so we won't have a default branch.
@@ -353,10 +735,10 @@ func defaultBranch(repo v.Repo) string {
 	}
 
 	// Check the cache for a value.
-	key, kerr := cp.Key(repo.Remote())
+	key, kerr := i.repoKey(repo.Remote())
 	var d cp.RepoInfo
 	if kerr == nil {
-		d, err := cp.RepoData(key)
+		d, err := cp.RepoDataAt(i.cacheLocation(), key)
 		if err == nil {
 			if d.DefaultBranch != "" {
 				return d.DefaultBranch
@@ -404,7 +786,7 @@ func defaultBranch(repo v.Repo) string {
 		db := gh["default_branch"].(string)
 		if kerr == nil {
 			d.DefaultBranch = db
-			err := cp.SaveRepoData(key, d)
+			err := cp.SaveRepoDataAt(i.cacheLocation(), key, d)
 			if err == cp.ErrCacheDisabled {
 				msg.Debug("Unable to cache default branch because caching is disabled")
 			} else if err != nil {
@@ -438,7 +820,7 @@ func defaultBranch(repo v.Repo) string {
 		db := bb["name"].(string)
 		if kerr == nil {
 			d.DefaultBranch = db
-			err := cp.SaveRepoData(key, d)
+			err := cp.SaveRepoDataAt(i.cacheLocation(), key, d)
 			if err == cp.ErrCacheDisabled {
 				msg.Debug("Unable to cache default branch because caching is disabled")
 			} else if err != nil {
@@ -492,3 +874,33 @@ NextVar:
 	}
 	return out
 }
+
+// repoKey returns the key of a repository in the cache, from its canonical
+// location with CanonicalRepos.
+func (i *Installer) repoKey(remote string) (string, error) {
+	if i != nil && i.CanonicalRepos {
+		return cp.CanonicalKey(remote), nil
+	}
+	return cp.Key(remote)
+}
+
+// sameRepository returns if two dependencies use the same repository,
+// following CanonicalRepos.
+func (i *Installer) sameRepository(a, b *cfg.Dependency) bool {
+	return cfg.SameRepository(a, b, i != nil && i.CanonicalRepos)
+}
+
+// cacheKey returns the key for the location of a dependency in the cache. The
+// major versions of a Go module, such as github.com/example/lib/v2, share a
+// repository but each has its own location so they can be checked out at
+// different versions.
+func (i *Installer) cacheKey(dep *cfg.Dependency) (string, error) {
+	key, err := i.repoKey(dep.Remote())
+	if err != nil {
+		return "", err
+	}
+	if _, major := util.SplitMajorVersion(dep.Name); major != "" {
+		key += "-" + major
+	}
+	return key, nil
+}
@@ -0,0 +1,313 @@
+package repo
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Ownercz/glide/cfg"
+	"github.com/Ownercz/semver"
+	v "github.com/Ownercz/vcs"
+)
+
+func TestCheckConstraintPrerelease(t *testing.T) {
+	tests := []struct {
+		constraint, version string
+		pre, match          bool
+	}{
+		{"^1.2.0", "v1.3.0-rc1", false, false},
+		{"^1.2.0", "v1.3.0-rc1", true, true},
+		{"^1.2.0", "v2.0.0-rc1", true, false},
+		{"^1.3.0", "v1.3.0-rc1", true, false},
+		{">=1.3.0", "v1.3.0-rc1", true, false},
+		{"=> 1.3.0", "v1.3.0-rc1", true, false},
+		{"~1.3.0", "v1.3.0-rc1", true, false},
+		{"~1.3.0", "v1.3.1-rc1", true, true},
+		{"1.3.x", "v1.3.0-rc1", true, false},
+		{"1.3.0", "v1.3.0-rc1", true, false},
+		{">1.2.0", "v1.2.1-rc1", true, true},
+		{">=1.2.0, <1.3.0", "v1.3.0-rc1", true, false},
+		{"<=1.3.0", "v1.3.0-rc1", true, true},
+		{"1.3.0 - 1.4.0", "v1.3.0-rc1", true, false},
+		{"1.2.0 - 1.4.0", "v1.4.0-rc1", true, true},
+		{"^1.3.0 || ^1.0.0", "v1.3.0-rc1", true, true},
+		{"^1.3.0 || ^2.0.0", "v1.3.0-rc1", true, false},
+		{"^1.3.0-beta", "v1.3.0-rc1", false, true},
+	}
+	for _, tt := range tests {
+		c, err := semver.NewConstraint(tt.constraint)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m := checkConstraint(tt.constraint, c, semver.MustParse(tt.version), tt.pre); m != tt.match {
+			t.Errorf("Expected %s matching %s with pre-releases %t to be %t", tt.version, tt.constraint, tt.pre, tt.match)
+		}
+	}
+}
+
+func TestIncludePrerelease(t *testing.T) {
+	i := &Installer{IncludePrerelease: true}
+	dep := &cfg.Dependency{Name: "example.com/foo/bar"}
+
+	if !includePrerelease(dep, i) {
+		t.Error("Expected the installer setting to be used")
+	}
+	dep.Prerelease = cfg.PrereleaseExclude
+	if includePrerelease(dep, i) {
+		t.Error("Expected the dependency setting to override the installer")
+	}
+	dep.Prerelease = cfg.PrereleaseInclude
+	if !includePrerelease(dep, nil) {
+		t.Error("Expected the dependency setting to include pre-releases")
+	}
+}
+
+func TestCheckoutFloating(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir, err := ioutil.TempDir("", "glide-floating")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	remote := filepath.Join(dir, "remote")
+	newTestRemote(t, remote)
+
+	repo, err := v.NewGitRepo(remote, filepath.Join(dir, "local"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Get(); err != nil {
+		t.Fatal(err)
+	}
+	first, _ := repo.Version()
+	if err := repo.UpdateVersion(first); err != nil {
+		t.Fatal(err)
+	}
+
+	// Move the remote ahead while the local copy is on a detached head.
+	testGit(t, remote, "commit", "-q", "--allow-empty", "-m", "commit")
+	if err := repo.Update(); err != nil {
+		t.Fatal(err)
+	}
+	branch := testGit(t, remote, "symbolic-ref", "--short", "HEAD")
+	head := testGit(t, remote, "rev-parse", "HEAD")
+
+	dep := &cfg.Dependency{Name: "example.com/foo", Reference: latestReference}
+	if !isFloating(dep) {
+		t.Error("Expected latest to follow a branch")
+	}
+	if err := checkoutFloating(dep, repo, &Installer{PreferredBranch: branch}); err != nil {
+		t.Fatalf("Unexpected error checking out %s: %s", branch, err)
+	}
+	if ver, _ := repo.Version(); ver != head || ver == first {
+		t.Errorf("Expected the newest commit %s, got %s", head, ver)
+	}
+}
+
+func TestLatestTag(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir, err := ioutil.TempDir("", "glide-latesttag")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	remote := filepath.Join(dir, "remote")
+	newTestRemote(t, remote)
+	for _, tag := range []string{"v1.0.0", "v1.1.0", "v1.2.0-rc1", "not-a-version"} {
+		testGit(t, remote, "tag", tag)
+		testGit(t, remote, "commit", "-q", "--allow-empty", "-m", "commit")
+	}
+
+	repo, err := v.NewGitRepo(remote, filepath.Join(dir, "local"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Get(); err != nil {
+		t.Fatal(err)
+	}
+
+	i := &Installer{LatestTag: true}
+	dep := &cfg.Dependency{Name: "example.com/foo"}
+	if !useLatestTag(dep, i) {
+		t.Error("Expected the installer setting to be used")
+	}
+	dep.Float = cfg.FloatBranch
+	if useLatestTag(dep, i) {
+		t.Error("Expected the dependency setting to override the installer")
+	}
+	dep.Float = cfg.FloatTag
+	if !useLatestTag(dep, nil) {
+		t.Error("Expected the dependency setting to use the latest tag")
+	}
+	if useLatestTag(&cfg.Dependency{Name: "example.com/foo", Reference: latestReference}, i) {
+		t.Error("Expected latest to follow a branch")
+	}
+
+	if tag, err := latestTag(dep, repo, i); err != nil || tag != "v1.1.0" {
+		t.Errorf("Expected the highest release v1.1.0, got %q (%v)", tag, err)
+	}
+	dep.Prerelease = cfg.PrereleaseInclude
+	if tag, err := latestTag(dep, repo, i); err != nil || tag != "v1.2.0-rc1" {
+		t.Errorf("Expected the pre-release v1.2.0-rc1, got %q (%v)", tag, err)
+	}
+}
+
+func TestVcsVersionConstraint(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir, err := ioutil.TempDir("", "glide-constraint")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	setup := func(name string, tags ...string) string {
+		remote := filepath.Join(dir, "remotes", name)
+		newTestRemote(t, remote)
+		for _, tag := range tags {
+			testGit(t, remote, "tag", tag)
+			testGit(t, remote, "commit", "-q", "--allow-empty", "-m", "commit")
+		}
+		return remote
+	}
+	rev := func(remote, ref string) string {
+		return testGit(t, remote, "rev-parse", ref+"^{commit}")
+	}
+	tagged := setup("tagged", "v1.0.0", "v1.1.0", "v2.0.0")
+	untagged := setup("untagged")
+
+	i := NewInstaller()
+	i.Home = filepath.Join(dir, "home")
+	version := func(remote, ref string) (*cfg.Dependency, error) {
+		dep := &cfg.Dependency{Name: "example.com/" + filepath.Base(remote), Repository: remote, VcsType: "git", Reference: ref}
+		if err := i.VcsGet(dep); err != nil {
+			t.Fatal(err)
+		}
+		return dep, i.VcsVersion(dep)
+	}
+
+	dep, err := version(tagged, "^1.0.0")
+	if err != nil || dep.Pin != rev(tagged, "v1.1.0") {
+		t.Errorf("Expected the highest matching tag v1.1.0, got %s (%v)", dep.Pin, err)
+	}
+
+	_, err = version(tagged, "^3.0.0")
+	if err == nil || !strings.Contains(err.Error(), "the highest is v2.0.0") {
+		t.Errorf("Expected an error when no version matches, got %v", err)
+	}
+
+	dep, err = version(untagged, "^1.0.0")
+	if err != nil || dep.Pin != rev(untagged, "HEAD") {
+		t.Errorf("Expected the newest commit without tags, got %s (%v)", dep.Pin, err)
+	}
+}
+
+func TestVcsVersionMissingReference(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir, err := ioutil.TempDir("", "glide-missing-ref")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	remote := filepath.Join(dir, "remote")
+	git := func(args ...string) string { return testGit(t, remote, args...) }
+	first := newTestRemote(t, remote)
+	git("commit", "-q", "--allow-empty", "-m", "second")
+	head := git("rev-parse", "HEAD")
+
+	i := NewInstaller()
+	i.Home = filepath.Join(dir, "home")
+	version := func(ref string) (*cfg.Dependency, error) {
+		dep := &cfg.Dependency{Name: "example.com/missing", Repository: remote, VcsType: "git", Reference: ref}
+		if err := i.VcsGet(dep); err != nil {
+			t.Fatal(err)
+		}
+		return dep, i.VcsVersion(dep)
+	}
+
+	dep, err := version(first[:10])
+	if err != nil || dep.Pin != first {
+		t.Errorf("Expected the existing commit to be checked out, got %s (%v)", dep.Pin, err)
+	}
+
+	for _, ref := range []string{"release-2016", "0123456789abcdef0123456789abcdef01234567"} {
+		if _, err := version(ref); err == nil || !strings.Contains(err.Error(), "does not exist") {
+			t.Errorf("Expected an error for the missing version %s, got %v", ref, err)
+		}
+	}
+
+	i.Force = true
+	dep, err = version("release-2016")
+	if err != nil || dep.Pin != head {
+		t.Errorf("Expected the newest commit for a missing tag when forced, got %s (%v)", dep.Pin, err)
+	}
+}
+
+func TestCacheKeyMajorVersion(t *testing.T) {
+	i := NewInstaller()
+	v1, err := i.cacheKey(&cfg.Dependency{Name: "github.com/example/lib"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	v2, err := i.cacheKey(&cfg.Dependency{Name: "github.com/example/lib/v2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v2 != v1+"-v2" {
+		t.Errorf("Expected the major version to have its own location in the cache, got %s and %s", v1, v2)
+	}
+}
+
+func TestVcsGetCacheUnavailable(t *testing.T) {
+	f, err := ioutil.TempFile("", "glide-home")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+
+	// A file in place of the home directory stops the cache from being
+	// created, which is returned rather than exiting.
+	i := NewInstaller()
+	i.Home = f.Name()
+	err = i.VcsGet(&cfg.Dependency{Name: "github.com/example/lib"})
+	if err == nil || !strings.Contains(err.Error(), "Cache directory unavailable") {
+		t.Errorf("Expected an error for the unavailable cache, got %v", err)
+	}
+}
+
+func TestVcsDefaultInstaller(t *testing.T) {
+	// Pinned dependencies are skipped before anything else is done.
+	dep := &cfg.Dependency{Name: "github.com/example/lib", Pin: "1111111111111111111111111111111111111111"}
+
+	var i *Installer
+	if err := i.VcsUpdate(dep); err != nil {
+		t.Errorf("Unexpected error updating with a nil Installer: %s", err)
+	}
+	if err := i.VcsVersion(dep); err != nil {
+		t.Errorf("Unexpected error setting the version with a nil Installer: %s", err)
+	}
+	if err := VcsUpdate(dep, false, nil); err != nil {
+		t.Errorf("Unexpected error updating with the default Installer: %s", err)
+	}
+	if err := VcsVersion(dep); err != nil {
+		t.Errorf("Unexpected error setting the version with the default Installer: %s", err)
+	}
+}
@@ -0,0 +1,88 @@
+package repo
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/Ownercz/glide/cfg"
+	v "github.com/Ownercz/vcs"
+)
+
+// declaredVcs returns the VCS type set for a dependency, with vcs in the
+// glide.yaml file or a mirror, or an empty string when it is detected.
+func declaredVcs(dep *cfg.Dependency) string {
+	t := dep.Vcs()
+	if t == "None" {
+		return ""
+	}
+	return t
+}
+
+// cacheVcsError explains an error getting the repository of a dependency in
+// its cache location when the location holds another VCS than the one set for
+// the dependency.
+func cacheVcsError(dep *cfg.Dependency, dest string, err error) error {
+	want := declaredVcs(dep)
+	if err != v.ErrWrongVCS || want == "" {
+		return err
+	}
+	got, derr := v.DetectVcsFromFS(dest)
+	if derr != nil {
+		return err
+	}
+	return fmt.Errorf("The cache of %s in %s is a %s repository but its vcs is set to %s. Use --force to replace it", dep.Name, dest, got, want)
+}
+
+// remoteVcsError explains an error fetching a dependency for the first time
+// with the VCS set for it when its remote looks like another VCS. The type
+// set is always the one used, the detection only makes for a clearer error.
+func remoteVcsError(dep *cfg.Dependency, err error) error {
+	want := declaredVcs(dep)
+	if err == nil || want == "" || dep.Checkout != "" {
+		return err
+	}
+
+	got := remoteVcs(dep.Remote())
+	if got == "" || string(got) == want {
+		return err
+	}
+	return fmt.Errorf("Unable to fetch %s with %s, the vcs set for it, while %s looks like a %s repository: %s", dep.Name, want, dep.Remote(), got, err)
+}
+
+// remoteVcs returns the VCS type a remote looks like, or an empty one when it
+// can't be told. The scheme and extension are checked first, as the vcs
+// package does for hosts it doesn't know, so the VCS doesn't need to be
+// installed. Otherwise the vcs package detects it, which can contact the host.
+func remoteVcs(remote string) v.Type {
+	if u, err := url.Parse(remote); err == nil {
+		switch u.Scheme {
+		case "git", "git+ssh":
+			return v.Git
+		case "bzr+ssh":
+			return v.Bzr
+		case "svn+ssh":
+			return v.Svn
+		}
+	}
+	switch ext := path.Ext(strings.TrimSuffix(remote, "/")); ext {
+	case ".git", ".hg", ".svn", ".bzr":
+		return v.Type(ext[1:])
+	}
+
+	// Detecting the type needs a location with nothing in it.
+	tmp, err := ioutil.TempDir("", "glide-vcs")
+	if err != nil {
+		return ""
+	}
+	defer os.RemoveAll(tmp)
+	repo, err := v.NewRepo(remote, filepath.Join(tmp, "repo"))
+	if err != nil {
+		return ""
+	}
+	return repo.Vcs()
+}
@@ -0,0 +1,86 @@
+package repo
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Ownercz/glide/cfg"
+	v "github.com/Ownercz/vcs"
+)
+
+func TestVcsGetDeclaredType(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir, err := ioutil.TempDir("", "glide-vcstype")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	remote := filepath.Join(dir, "remote")
+	newTestRemote(t, remote, "lib.go", "package lib\n")
+
+	// An internal host serving git at URLs that look like Mercurial ones. Git
+	// is pointed at the local repository and a missing one in their place.
+	for k, val := range map[string]string{
+		"GIT_CONFIG_COUNT":   "2",
+		"GIT_CONFIG_KEY_0":   "url." + remote + ".insteadOf",
+		"GIT_CONFIG_VALUE_0": "https://hg.example.com/lib.hg",
+		"GIT_CONFIG_KEY_1":   "url." + filepath.Join(dir, "missing") + "/.insteadOf",
+		"GIT_CONFIG_VALUE_1": "https://hg.example.com/",
+	} {
+		defer os.Setenv(k, os.Getenv(k))
+		os.Setenv(k, val)
+	}
+
+	i := NewInstaller()
+	i.Home = filepath.Join(dir, "home")
+	dep := &cfg.Dependency{Name: "example.com/lib", Repository: "https://hg.example.com/lib.hg", VcsType: "git"}
+	key, err := i.cacheKey(dep)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dest := filepath.Join(i.cacheLocation(), "src", key)
+	auto := dep.Clone()
+	auto.VcsType = ""
+	if r, err := auto.GetRepo(dest); err == nil && r.Vcs() == v.Git {
+		t.Fatal("Expected the remote to be detected as another VCS")
+	}
+
+	if err := i.VcsGet(dep); err != nil {
+		t.Fatalf("Unexpected error fetching with the declared VCS: %s", err)
+	}
+	if _, err := os.Stat(filepath.Join(dest, "lib.go")); err != nil {
+		t.Errorf("Expected the dependency to be cloned with git: %s", err)
+	}
+	if err := i.VcsUpdate(dep); err != nil {
+		t.Errorf("Unexpected error updating with the declared VCS: %s", err)
+	}
+
+	// A remote that isn't a git repository and looks like another VCS.
+	other := &cfg.Dependency{Name: "example.com/other", Repository: "https://hg.example.com/other.hg", VcsType: "git"}
+	err = i.VcsGet(other)
+	if err == nil || !strings.Contains(err.Error(), "looks like a hg repository") {
+		t.Errorf("Expected an error saying the remote looks like hg, got %v", err)
+	}
+
+	// A cache location holding another VCS is not detected over the type set.
+	hg := &cfg.Dependency{Name: "example.com/cached", Repository: "https://hg.example.com/cached.hg", VcsType: "git"}
+	if key, err = i.cacheKey(hg); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(i.cacheLocation(), "src", key, ".hg"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range []func(*cfg.Dependency) error{i.VcsGet, i.VcsUpdate} {
+		if err := f(hg); err == nil || !strings.Contains(err.Error(), "is a hg repository but its vcs is set to git") {
+			t.Errorf("Expected an error saying the cache is hg, got %v", err)
+		}
+	}
+}
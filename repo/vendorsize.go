@@ -0,0 +1,111 @@
+package repo
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Ownercz/glide/cfg"
+)
+
+// maxSizeContributors is the number of dependencies listed when the vendor
+// directory exceeds MaxVendorSize.
+const maxSizeContributors = 5
+
+// sizeUnits are the suffixes accepted by ParseSize.
+var sizeUnits = []struct {
+	suffix string
+	size   int64
+}{
+	{"GB", 1 << 30},
+	{"MB", 1 << 20},
+	{"KB", 1 << 10},
+	{"G", 1 << 30},
+	{"M", 1 << 20},
+	{"K", 1 << 10},
+	{"B", 1},
+}
+
+// ParseSize parses a size in bytes with an optional unit, such as 500K, 20MB
+// or 1G. Units are powers of 1024.
+func ParseSize(s string) (int64, error) {
+	str := strings.ToUpper(strings.TrimSpace(s))
+	unit := int64(1)
+	for _, u := range sizeUnits {
+		if strings.HasSuffix(str, u.suffix) {
+			str = strings.TrimSpace(strings.TrimSuffix(str, u.suffix))
+			unit = u.size
+			break
+		}
+	}
+
+	n, err := strconv.ParseInt(str, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("Invalid size %q", s)
+	}
+	return n * unit, nil
+}
+
+// vendorSize is the size of a single dependency in the vendor directory.
+type vendorSize struct {
+	name string
+	size int64
+}
+
+// vendorSizes is a slice of sizes sorted with the largest first.
+type vendorSizes []vendorSize
+
+// Len returns the length of the sizes. This is needed for sorting with
+// the sort package.
+func (s vendorSizes) Len() int {
+	return len(s)
+}
+
+// Less is needed for the sort interface. The larger size comes first.
+func (s vendorSizes) Less(i, j int) bool {
+	return s[i].size > s[j].size
+}
+
+// Swap is needed for the sort interface. It swaps the position of two
+// sizes.
+func (s vendorSizes) Swap(i, j int) {
+	s[i], s[j] = s[j], s[i]
+}
+
+// checkVendorSize measures the dependencies exported to a vendor directory
+// and returns an error listing the largest of them when their total exceeds
+// MaxVendorSize. Export writes the files without VCS metadata so it is not
+// counted.
+func (i *Installer) checkVendorSize(vendor string, deps []*cfg.Dependency) error {
+	if i.MaxVendorSize <= 0 {
+		return nil
+	}
+
+	var total int64
+	sizes := make(vendorSizes, 0, len(deps))
+	for _, dep := range deps {
+		dir, err := i.vendorDir(vendor, dep.Name)
+		if err != nil {
+			return err
+		}
+		s := dirSize(dir)
+		total += s
+		sizes = append(sizes, vendorSize{name: dep.Name, size: s})
+	}
+
+	if total <= i.MaxVendorSize {
+		return nil
+	}
+
+	sort.Stable(sizes)
+	if len(sizes) > maxSizeContributors {
+		sizes = sizes[:maxSizeContributors]
+	}
+	largest := make([]string, len(sizes))
+	for ii, s := range sizes {
+		largest[ii] = fmt.Sprintf("%s (%d bytes)", s.name, s.size)
+	}
+	return fmt.Errorf("The vendor directory would be %d bytes, exceeding the limit of %d bytes. The largest dependencies are:\n  %s",
+		total, i.MaxVendorSize, strings.Join(largest, "\n  "))
+}
@@ -0,0 +1,82 @@
+package repo
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Ownercz/glide/cfg"
+)
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		in     string
+		expect int64
+	}{
+		{"100", 100},
+		{"2K", 2048},
+		{"3mb", 3 << 20},
+		{"1 G", 1 << 30},
+		{"10B", 10},
+	}
+	for _, tt := range tests {
+		s, err := ParseSize(tt.in)
+		if err != nil {
+			t.Errorf("Unexpected error parsing %s: %s", tt.in, err)
+		} else if s != tt.expect {
+			t.Errorf("Expected %s to be %d bytes, got %d", tt.in, tt.expect, s)
+		}
+	}
+
+	for _, in := range []string{"", "MB", "-1K", "1.5M", "ten"} {
+		if _, err := ParseSize(in); err == nil {
+			t.Errorf("Expected an error parsing %q", in)
+		}
+	}
+}
+
+func TestCheckVendorSize(t *testing.T) {
+	dir, err := ioutil.TempDir("", "glide-vendorsize")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	sizes := map[string]int{
+		"github.com/example/small": 10,
+		"github.com/example/large": 1000,
+	}
+	deps := []*cfg.Dependency{}
+	for name, size := range sizes {
+		d := filepath.Join(dir, name)
+		if err := os.MkdirAll(d, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(d, "a.go"), make([]byte, size), 0644); err != nil {
+			t.Fatal(err)
+		}
+		deps = append(deps, &cfg.Dependency{Name: name})
+	}
+
+	i := NewInstaller()
+	if err := i.checkVendorSize(dir, deps); err != nil {
+		t.Errorf("Expected no limit by default, got %s", err)
+	}
+
+	i.MaxVendorSize = 1010
+	if err := i.checkVendorSize(dir, deps); err != nil {
+		t.Errorf("Expected a vendor directory at the limit to pass, got %s", err)
+	}
+
+	i.MaxVendorSize = 500
+	err = i.checkVendorSize(dir, deps)
+	if err == nil {
+		t.Fatal("Expected an error when over the limit")
+	}
+	l, s := strings.Index(err.Error(), "example/large (1000 bytes)"), strings.Index(err.Error(), "example/small (10 bytes)")
+	if l == -1 || s == -1 || l > s {
+		t.Errorf("Expected the error to list the largest dependencies first, got %s", err)
+	}
+}
@@ -0,0 +1,160 @@
+package repo
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/Ownercz/glide/cfg"
+	"github.com/Ownercz/glide/msg"
+	"github.com/Ownercz/semver"
+	v "github.com/Ownercz/vcs"
+)
+
+// VerifyReferences checks that the version of every dependency in the config
+// can be found in its remote repository. Nothing is checked out. Dependencies
+// without a version are skipped. Test dependencies are checked when
+// ResolveTest is set.
+//
+// An error listing each reference that could not be found is returned.
+func (i *Installer) VerifyReferences(conf *cfg.Config) error {
+	deps := []*cfg.Dependency{}
+	for _, dep := range conf.Imports {
+		if dep.Reference != "" && !conf.HasIgnore(dep.Name) {
+			deps = append(deps, dep)
+		}
+	}
+	if i.ResolveTest {
+		for _, dep := range conf.DevImports {
+			if dep.Reference != "" && !conf.HasIgnore(dep.Name) {
+				deps = append(deps, dep)
+			}
+		}
+	}
+
+	msg.Info("Verifying the versions of %d dependencies...", len(deps))
+	pool := i.newPool()
+	defer pool.close()
+	for _, dep := range deps {
+		dep := dep
+		pool.run(func() {
+			if err := i.verifyReference(dep, conf); err != nil {
+				msg.Err(err.Error())
+				pool.fail(err)
+			} else {
+				msg.Debug("Found version %s for %s", dep.Reference, dep.Name)
+			}
+		})
+	}
+
+	return pool.wait()
+}
+
+// verifyReference probes the remote of a dependency for its reference.
+func (i *Installer) verifyReference(dep *cfg.Dependency, conf *cfg.Config) error {
+	if err := i.discover(dep, conf); err != nil {
+		return fmt.Errorf("Discovery failed for %s: %s", dep.Name, err)
+	}
+
+	key, err := i.cacheKey(dep)
+	if err != nil {
+		return fmt.Errorf("Cache key generation error: %s", err)
+	}
+
+	// The cache location is only used to detect the VCS when it can't be
+	// determined from the remote.
+	repo, err := dep.GetRepo(filepath.Join(i.cacheLocation(), "src", key))
+	if err != nil {
+		return fmt.Errorf("Unable to verify %s: %s", dep.Name, err)
+	}
+
+	found, err := remoteHasReference(repo, dep, i)
+	if err != nil {
+		return fmt.Errorf("Unable to verify %s: %s", dep.Name, err)
+	}
+	if !found {
+		return fmt.Errorf("Version %s of %s not found in %s", dep.Reference, dep.Name, repo.Remote())
+	}
+
+	return nil
+}
+
+// remoteHasReference returns if the reference of a dependency exists in the
+// remote repository. For Git the branches and tags are listed so semantic
+// version ranges can be checked as well. For Mercurial and Bazaar the remote
+// is asked for the reference directly.
+func remoteHasReference(repo v.Repo, dep *cfg.Dependency, i *Installer) (bool, error) {
+	ref := dep.Reference
+	switch repo.Vcs() {
+	case v.Git:
+		out, err := i.runRemoteGit("", repo.Remote(), "ls-remote", "--heads", "--tags", repo.Remote())
+		if err != nil {
+			return false, fmt.Errorf("Unable to list remote references: %s", strings.TrimSpace(string(out)))
+		}
+
+		names := []string{}
+		for _, line := range strings.Split(string(out), "\n") {
+			f := strings.Fields(line)
+			if len(f) != 2 {
+				continue
+			}
+			name := strings.TrimSuffix(f[1], "^{}")
+			name = strings.TrimPrefix(name, "refs/heads/")
+			name = strings.TrimPrefix(name, "refs/tags/")
+			if name == ref || f[0] == ref {
+				return true, nil
+			}
+			names = append(names, name)
+		}
+
+		// A commit that is not the tip of a branch or tag can only be found
+		// in a local copy. The cache is used when present.
+		if repo.CheckLocal() && repo.IsReference(ref) {
+			return true, nil
+		}
+
+		constraint, err := semver.NewConstraint(ref)
+		if err != nil {
+			return false, nil
+		}
+		pre := includePrerelease(dep, i)
+		for _, sv := range getSemVers(names) {
+			if checkConstraint(ref, constraint, sv, pre) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case v.Hg:
+		out, err := exec.Command("hg", "identify", "-r", ref, repo.Remote()).CombinedOutput()
+		return remoteRevision(v.Hg, out, err)
+	case v.Bzr:
+		out, err := exec.Command("bzr", "revision-info", "-d", repo.Remote(), "-r", ref).CombinedOutput()
+		return remoteRevision(v.Bzr, out, err)
+	}
+
+	return false, fmt.Errorf("Verifying versions is not supported for %s", repo.Vcs())
+}
+
+// remoteRevision reads the result of asking Mercurial or Bazaar for a
+// revision upstream. A revision they report as unknown is not found. Any
+// other failure, such as the remote being unreachable, is returned.
+func remoteRevision(t v.Type, out []byte, err error) (bool, error) {
+	if err == nil {
+		return true, nil
+	}
+
+	var missing []string
+	switch t {
+	case v.Hg:
+		missing = []string{"unknown revision"}
+	case v.Bzr:
+		missing = []string{"does not exist in branch", "No such tag"}
+	}
+	for _, m := range missing {
+		if strings.Contains(string(out), m) {
+			return false, nil
+		}
+	}
+	return false, fmt.Errorf("Unable to look up the revision: %s: %s", err, strings.TrimSpace(string(out)))
+}
@@ -0,0 +1,77 @@
+package repo
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/Ownercz/glide/cfg"
+	v "github.com/Ownercz/vcs"
+)
+
+func TestRemoteHasReference(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir, err := ioutil.TempDir("", "glide-verify")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	remote := filepath.Join(dir, "remote")
+	newTestRemote(t, remote)
+	testGit(t, remote, "tag", "v1.2.3")
+
+	repo, err := v.NewGitRepo(remote, filepath.Join(dir, "local"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]bool{
+		"v1.2.3": true,
+		"^1.2.0": true,
+		"v1.2.4": false,
+		"^2.0.0": false,
+	}
+	for ref, expected := range tests {
+		found, err := remoteHasReference(repo, &cfg.Dependency{Name: "example.com/foo", Reference: ref}, nil)
+		if err != nil {
+			t.Errorf("Unexpected error verifying %s: %s", ref, err)
+		}
+		if found != expected {
+			t.Errorf("Expected %s found to be %t", ref, expected)
+		}
+	}
+}
+
+func TestRemoteRevision(t *testing.T) {
+	exit := errors.New("exit status 255")
+	tests := []struct {
+		vcs   v.Type
+		out   string
+		err   error
+		found bool
+		fails bool
+	}{
+		{v.Hg, "8a3e2b1c9f0d tip\n", nil, true, false},
+		{v.Hg, "abort: unknown revision 'v9.9.9'!\n", exit, false, false},
+		{v.Hg, "abort: error: Connection refused\n", exit, false, true},
+		{v.Bzr, "bzr: ERROR: Requested revision: '42' does not exist in branch: https://example.com/foo/\n", exit, false, false},
+		{v.Bzr, "bzr: ERROR: No such tag: v9.9.9\n", exit, false, false},
+		{v.Bzr, "bzr: ERROR: Connection error: Couldn't resolve host 'example.com'\n", exit, false, true},
+	}
+	for _, tt := range tests {
+		found, err := remoteRevision(tt.vcs, []byte(tt.out), tt.err)
+		if found != tt.found {
+			t.Errorf("Expected found to be %t for %q", tt.found, tt.out)
+		}
+		if (err != nil) != tt.fails {
+			t.Errorf("Unexpected error result for %q: %v", tt.out, err)
+		}
+	}
+}
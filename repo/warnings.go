@@ -0,0 +1,51 @@
+package repo
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/Ownercz/glide/cfg"
+)
+
+// warningList collects the warnings recorded for the lock file. This is a
+// concurrency safe implementation and its zero value is ready to use.
+type warningList struct {
+	sync.Mutex
+
+	warnings []string
+	seen     map[string]bool
+}
+
+// recordWarning keeps a warning for the lock file metadata when
+// RecordWarnings is set. A warning repeated is kept once. It is safe to call
+// with a nil Installer.
+func (i *Installer) recordWarning(ft string, v ...interface{}) {
+	if i == nil || !i.RecordWarnings {
+		return
+	}
+
+	m := fmt.Sprintf(ft, v...)
+	i.warnings.Lock()
+	defer i.warnings.Unlock()
+	if i.warnings.seen == nil {
+		i.warnings.seen = make(map[string]bool)
+	}
+	if !i.warnings.seen[m] {
+		i.warnings.seen[m] = true
+		i.warnings.warnings = append(i.warnings.warnings, m)
+	}
+}
+
+// LockMetadata returns the metadata to record in the lock file. It is nil
+// unless RecordWarnings is set and there were warnings.
+func (i *Installer) LockMetadata() *cfg.LockMetadata {
+	i.warnings.Lock()
+	defer i.warnings.Unlock()
+	if len(i.warnings.warnings) == 0 {
+		return nil
+	}
+
+	return &cfg.LockMetadata{
+		Warnings: append([]string(nil), i.warnings.warnings...),
+	}
+}
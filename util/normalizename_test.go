@@ -40,6 +40,21 @@ func TestNormalizeName(t *testing.T) {
 			root:  "net",
 			extra: "",
 		},
+		{
+			input: "github.com/Ownercz/cookoo/v2/web",
+			root:  "github.com/Ownercz/cookoo/v2",
+			extra: "web",
+		},
+		{
+			input: "github.com/Ownercz/cookoo/v10",
+			root:  "github.com/Ownercz/cookoo/v10",
+			extra: "",
+		},
+		{
+			input: "github.com/Ownercz/cookoo/v1/web",
+			root:  "github.com/Ownercz/cookoo",
+			extra: "v1/web",
+		},
 	}
 	remotePackageCache["otherurl/example/root"] = "otherurl/example/root"
 
@@ -53,3 +68,23 @@ func TestNormalizeName(t *testing.T) {
 		}
 	}
 }
+
+func TestSplitMajorVersion(t *testing.T) {
+	tests := []struct {
+		input, name, major string
+	}{
+		{"github.com/Ownercz/cookoo/v2", "github.com/Ownercz/cookoo", "v2"},
+		{"github.com/Ownercz/cookoo", "github.com/Ownercz/cookoo", ""},
+		{"github.com/Ownercz/v2", "github.com/Ownercz/v2", ""},
+		{"go.uber.org/zap/v3", "go.uber.org/zap", "v3"},
+		{"github.com/Ownercz/cookoo/v0", "github.com/Ownercz/cookoo/v0", ""},
+		{"gopkg.in/yaml.v2", "gopkg.in/yaml.v2", ""},
+		{"v2", "v2", ""},
+	}
+	for _, test := range tests {
+		name, major := SplitMajorVersion(test.input)
+		if name != test.name || major != test.major {
+			t.Errorf("%s: Expected '%s' and '%s', got '%s' and '%s'", test.input, test.name, test.major, name, major)
+		}
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"path/filepath"
 	"regexp"
 	"strings"
+	"sync"
 
 	"github.com/Ownercz/vcs"
 )
@@ -22,6 +23,10 @@ import (
 // other needs arise it may need to be re-written.
 var ResolveCurrent = false
 
+// ScpSyntaxRe matches the SCP-like addresses used to access repos over SSH,
+// e.g. git@github.com:user/repo. The submatches are the user, host and path.
+var ScpSyntaxRe = regexp.MustCompile(`^([a-zA-Z0-9_]+)@([a-zA-Z0-9._-]+):(.*)$`)
+
 // goRoot caches the GOROOT variable for build contexts. If $GOROOT is not set in
 // the user's environment, then the context's root path is 'go env GOROOT'.
 var goRoot string
@@ -43,15 +48,71 @@ func init() {
 	}
 }
 
+// SetHTTPProxy sets the proxy for the HTTP requests Glide makes itself, such
+// as those for go-import meta tags to find where a package is and to the APIs
+// of hosts. It replaces the one from the HTTP_PROXY, HTTPS_PROXY and NO_PROXY
+// environment variables, which are used when it is empty. The VCS commands
+// run by Glide are separate processes so they keep their own configuration.
+// It is meant to be set at startup, before any requests are made.
+func SetHTTPProxy(proxy string) error {
+	t, ok := http.DefaultTransport.(*http.Transport)
+	if !ok {
+		return fmt.Errorf("Unable to set the HTTP proxy on a %T", http.DefaultTransport)
+	}
+	if proxy == "" {
+		t.Proxy = http.ProxyFromEnvironment
+		return nil
+	}
+
+	u, err := url.Parse(proxy)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return fmt.Errorf("Invalid HTTP proxy %q: it must be a URL such as http://proxy.example.com:3128", proxy)
+	}
+	t.Proxy = http.ProxyURL(u)
+	return nil
+}
+
 func toSlash(v string) string {
 	return strings.Replace(v, "\\", "/", -1)
 }
 
+// CanonicalRepo returns the canonical form of a repository location so the
+// different ways of referring to the same repository are equal. The scheme
+// and user are dropped, the host is lowercased, and a trailing / or .git is
+// removed. For example, git@GitHub.com:Ownercz/vcs.git and
+// https://github.com/Ownercz/vcs both become github.com/Ownercz/vcs.
+func CanonicalRepo(repo string) string {
+	var host, pth string
+	if m := ScpSyntaxRe.FindStringSubmatch(repo); m != nil {
+		host, pth = m[2], m[3]
+	} else if u, err := url.Parse(repo); err == nil && u.Host != "" {
+		host, pth = u.Host, u.Path
+	} else {
+		pth = repo
+	}
+
+	pth = strings.TrimSuffix(strings.TrimRight(toSlash(pth), "/"), ".git")
+	if host == "" {
+		return pth
+	}
+
+	return strings.ToLower(host) + "/" + strings.TrimLeft(pth, "/")
+}
+
+// majorVersionRe matches the element of an import path Go modules use for
+// major versions from 2 on, such as v2.
+var majorVersionRe = regexp.MustCompile(`^v([2-9]|[1-9][0-9]+)$`)
+
 // GetRootFromPackage retrives the top level package from a name.
 //
 // From a package name find the root repo. For example,
 // the package github.com/Ownercz/cookoo/io has a root repo
 // at github.com/Ownercz/cookoo
+//
+// A major version following the repo, as Go modules use, is part of the
+// root. The root of github.com/Ownercz/cookoo/v2/io is
+// github.com/Ownercz/cookoo/v2 so it is a separate dependency from
+// github.com/Ownercz/cookoo.
 func GetRootFromPackage(pkg string) string {
 	pkg = toSlash(pkg)
 	for _, v := range vcsList {
@@ -61,15 +122,67 @@ func GetRootFromPackage(pkg string) string {
 		}
 
 		if m[1] != "" {
-			return m[1]
+			return withMajorVersion(m[1], pkg)
 		}
 	}
 
 	// There are cases where a package uses the special go get magic for
 	// redirects. If we've not discovered the location already try that.
-	pkg = getRootFromGoGet(pkg)
+	root := getRootFromGoGet(pkg)
+
+	return withMajorVersion(root, pkg)
+}
 
-	return pkg
+// withMajorVersion adds the major version following a root in a package to
+// the root. gopkg.in has its own way of versioning so it is left alone.
+func withMajorVersion(root, pkg string) string {
+	if strings.HasPrefix(root, "gopkg.in/") || !strings.HasPrefix(pkg, root+"/") {
+		return root
+	}
+	e := strings.SplitN(strings.TrimPrefix(pkg, root+"/"), "/", 2)[0]
+	if majorVersionRe.MatchString(e) {
+		return root + "/" + e
+	}
+	return root
+}
+
+// SplitMajorVersion splits the major version off the end of a root package.
+// For github.com/Ownercz/cookoo/v2 it returns github.com/Ownercz/cookoo, which
+// is where the repository is, and v2. When there is no major version the root
+// is returned along with an empty string.
+func SplitMajorVersion(root string) (string, string) {
+	root = toSlash(root)
+	i := strings.LastIndex(root, "/")
+	if i == -1 || strings.HasPrefix(root, "gopkg.in/") || !majorVersionRe.MatchString(root[i+1:]) {
+		return root, ""
+	}
+
+	// A repository can be named like a major version, such as
+	// github.com/example/v2.
+	for _, v := range vcsList {
+		if m := v.regex.FindStringSubmatch(root); m != nil && m[1] == root {
+			return root, ""
+		}
+	}
+	return root[:i], root[i+1:]
+}
+
+// VendorPath returns the location of a package within a vendor directory.
+// name maps the import path to a relative, / separated, directory. When it is
+// nil the directory is the import path. It is an error for the directory to
+// be outside of the vendor directory.
+func VendorPath(vendor, importPath string, name func(string) string) (string, error) {
+	n := importPath
+	if name != nil {
+		n = name(importPath)
+	}
+
+	n = filepath.Clean(filepath.FromSlash(n))
+	if n == "." || filepath.IsAbs(n) || n == ".." || strings.HasPrefix(n, ".."+string(os.PathSeparator)) {
+		return "", fmt.Errorf("Invalid vendor directory %q for %s", n, importPath)
+	}
+
+	return filepath.Join(vendor, n), nil
 }
 
 // Pages like https://golang.org/x/net provide an html document with
@@ -118,11 +231,16 @@ func getRootFromGoGet(pkg string) string {
 	return nu
 }
 
-// The caching is not concurrency safe but should be made to be that way.
 // This implementation is far too much of a hack... rewrite needed.
 var remotePackageCache = make(map[string]string)
 
+// remotePackageLock guards remotePackageCache as configuration can be
+// imported concurrently.
+var remotePackageLock sync.RWMutex
+
 func checkRemotePackageCache(pkg string) (string, bool) {
+	remotePackageLock.RLock()
+	defer remotePackageLock.RUnlock()
 	for k, v := range remotePackageCache {
 		if pkg == k || strings.HasPrefix(pkg, k+"/") {
 			return v, true
@@ -133,7 +251,9 @@ func checkRemotePackageCache(pkg string) (string, bool) {
 }
 
 func addToRemotePackageCache(pkg, v string) {
+	remotePackageLock.Lock()
 	remotePackageCache[pkg] = v
+	remotePackageLock.Unlock()
 }
 
 func parseImportFromBody(ur *url.URL, r io.ReadCloser) (u string, err error) {
@@ -1,10 +1,14 @@
 package util
 
-import "testing"
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
 
 func TestGetRootFromPackage(t *testing.T) {
 	urlList := map[string]string{
-		"github.com/Ownercz/VCSTestRepo":                       "github.com/Ownercz/VCSTestRepo",
+		"github.com/Ownercz/VCSTestRepo":                           "github.com/Ownercz/VCSTestRepo",
 		"bitbucket.org/mattfarina/testhgrepo":                      "bitbucket.org/mattfarina/testhgrepo",
 		"launchpad.net/govcstestbzrrepo/trunk":                     "launchpad.net/govcstestbzrrepo/trunk",
 		"launchpad.net/~mattfarina/+junk/mygovcstestbzrrepo":       "launchpad.net/~mattfarina/+junk/mygovcstestbzrrepo",
@@ -36,3 +40,54 @@ func TestGetRootFromPackage(t *testing.T) {
 		}
 	}
 }
+
+func TestCanonicalRepo(t *testing.T) {
+	tests := map[string]string{
+		"https://github.com/foo/bar":     "github.com/foo/bar",
+		"https://GitHub.com/foo/bar.git": "github.com/foo/bar",
+		"git@github.com:foo/bar.git":     "github.com/foo/bar",
+		"ssh://git@github.com/foo/bar/":  "github.com/foo/bar",
+		"git://github.com:123/foo/bar":   "github.com:123/foo/bar",
+		"github.com/foo/bar":             "github.com/foo/bar",
+	}
+
+	for k, v := range tests {
+		if c := CanonicalRepo(k); c != v {
+			t.Errorf("Expected canonical repo %s for %s but got %s", v, k, c)
+		}
+	}
+}
+
+func TestSetHTTPProxy(t *testing.T) {
+	hosts := make(chan string, 1)
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Requests for https pages come as a CONNECT to the host.
+		select {
+		case hosts <- r.Host:
+		default:
+		}
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer proxy.Close()
+
+	if err := SetHTTPProxy(proxy.URL); err != nil {
+		t.Fatal(err)
+	}
+	defer SetHTTPProxy("")
+
+	if r := getRootFromGoGet("proxied.example.com/foo/bar"); r != "proxied.example.com/foo/bar" {
+		t.Errorf("Expected the package when the proxy fails, got %s", r)
+	}
+	select {
+	case h := <-hosts:
+		if h != "proxied.example.com:443" {
+			t.Errorf("Expected a request for proxied.example.com through the proxy, got %s", h)
+		}
+	default:
+		t.Error("Expected the request to go through the proxy")
+	}
+
+	if err := SetHTTPProxy("proxy.example.com"); err == nil {
+		t.Error("Expected an error for a proxy that isn't a URL")
+	}
+}